@@ -15,6 +15,43 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/ai/quota": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Reports the authenticated user's per-user limits (0 = unlimited) and current usage: runs still pending or running, runs started today, and tokens used this month. Days and months are UTC calendar windows.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ai"
+                ],
+                "summary": "Get the user's AI summarization quota",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.QuotaResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/ai/summaries": {
             "get": {
                 "security": [
@@ -151,14 +188,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/ai/summarize-repo": {
+        "/ai/summaries/{id}/ask": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Enqueues a Hatchet workflow that clones the repository, summarises individual files via the configured LLM provider (OpenRouter), and produces a repo-level summary.",
+                "description": "Answers a question about a completed run owned by the authenticated user. The run's file and directory summaries are ranked against the question (BM25), the best matches go to the LLM together with the overview and the last few questions, and the answer cites the paths it relies on. The question and answer are stored with the run. Answers count against the monthly token budget. Returns 404 for missing rows AND cross-user questions, 409 when the run hasn't completed.",
                 "consumes": [
                     "application/json"
                 ],
@@ -168,23 +205,30 @@ const docTemplate = `{
                 "tags": [
                     "ai"
                 ],
-                "summary": "Trigger a repository summarization workflow",
+                "summary": "Ask a question about a repository summary",
                 "parameters": [
                     {
-                        "description": "Repo URL to summarize",
+                        "type": "integer",
+                        "description": "Summary ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Question",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/aiworkflows_interfaces_http.SummarizeRepoRequest"
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.AskRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "202": {
-                        "description": "Accepted",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/aiworkflows_interfaces_http.SummarizeRepoResponse"
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.AnswerDTO"
                         }
                     },
                     "400": {
@@ -199,6 +243,24 @@ const docTemplate = `{
                             "$ref": "#/definitions/aiworkflows_interfaces_http.ErrorResponse"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Monthly token budget exhausted; see Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.ErrorResponse"
+                        }
+                    },
                     "503": {
                         "description": "Service Unavailable",
                         "schema": {
@@ -208,20 +270,25 @@ const docTemplate = `{
                 }
             }
         },
-        "/export/download/{id}": {
-            "get": {
-                "description": "Downloads the exported file by download ID",
+        "/ai/summaries/{id}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Stops a pending or running run owned by the authenticated user: the run is marked cancelled and its workflow run is cancelled in Hatchet. Files not yet summarised are skipped. Returns 404 for missing rows AND cross-user cancels, 409 when the run has already finished.",
                 "produces": [
-                    "application/octet-stream"
+                    "application/json"
                 ],
                 "tags": [
-                    "export"
+                    "ai"
                 ],
-                "summary": "Download a completed export",
+                "summary": "Cancel a running repository summarization",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Download ID",
+                        "type": "integer",
+                        "description": "Summary ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -231,163 +298,178 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "file"
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.RepoSummaryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.ErrorResponse"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/exports_interfaces_http.ErrorResponse"
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/export/start": {
-            "post": {
-                "description": "Starts a background job to export user data",
-                "consumes": [
-                    "application/json"
+        "/ai/summaries/{id}/questions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Returns up to 50 of the most recent questions asked about a run owned by the authenticated user, with their answers, oldest first. Cross-user reads return 404.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "export"
+                    "ai"
                 ],
-                "summary": "Start a data export job",
+                "summary": "List the questions asked about a repository summary",
                 "parameters": [
                     {
-                        "description": "Export parameters",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/exports_interfaces_http.StartExportRequest"
-                        }
+                        "type": "integer",
+                        "description": "Summary ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/exports_interfaces_http.StartExportResponse"
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.AnswerListResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/exports_interfaces_http.ErrorResponse"
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/exports_interfaces_http.ErrorResponse"
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.ErrorResponse"
                         }
-                    }
-                }
-            }
-        },
-        "/hello": {
-            "get": {
-                "description": "Returns a hello message, no auth required",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "public"
-                ],
-                "summary": "Public hello endpoint",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/auth_interfaces_http.MessageResponse"
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/me": {
-            "get": {
+        "/ai/summaries/{id}/retry": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get the currently authenticated user's information",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Starts a new attempt for a failed run owned by the authenticated user. The attempt is linked to the failed run and reuses the file summaries it already produced, so only the missing files and the repo-level overview call the LLM again. Returns 404 for missing rows AND cross-user retries, 409 when the run did not fail.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "ai"
+                ],
+                "summary": "Retry a failed repository summarization",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Summary ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get current user",
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "202": {
+                        "description": "Accepted",
                         "schema": {
-                            "$ref": "#/definitions/auth_interfaces_http.UserResponse"
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.SummarizeRepoResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.ErrorResponse"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/auth_interfaces_http.ErrorResponse"
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.ErrorResponse"
                         }
-                    }
-                }
-            }
-        },
-        "/protected/hello": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Returns a personalized hello message for authenticated users",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "protected"
-                ],
-                "summary": "Protected hello endpoint",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/auth_interfaces_http.MessageResponse"
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.ErrorResponse"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
-                            "$ref": "#/definitions/auth_interfaces_http.ErrorResponse"
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Per-user quota exhausted; see Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/stats": {
-            "get": {
+        "/ai/summarize-repo": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get statistics for the authenticated user",
+                "description": "Enqueues a Hatchet workflow that clones the repository, summarises individual files via the configured LLM provider (OpenRouter), and produces a repo-level summary. An optional ref picks the branch, tag or commit (default branch otherwise); optional include/exclude patterns and a subdirectory narrow the files considered; a .summaryignore at the repository root is honoured too. With incremental set, the run builds on the caller's latest completed run of the same repository and only re-summarizes files added or modified since.",
                 "consumes": [
                     "application/json"
                 ],
@@ -395,7 +477,242 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "ai"
+                ],
+                "summary": "Trigger a repository summarization workflow",
+                "parameters": [
+                    {
+                        "description": "Repo URL to summarize",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.SummarizeRepoRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.SummarizeRepoResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Per-user quota exhausted; see Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/export/download/{id}": {
+            "get": {
+                "description": "Downloads the exported file by download ID",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "export"
+                ],
+                "summary": "Download a completed export",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Download ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/exports_interfaces_http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/export/start": {
+            "post": {
+                "description": "Starts a background job to export user data",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "export"
+                ],
+                "summary": "Start a data export job",
+                "parameters": [
+                    {
+                        "description": "Export parameters",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/exports_interfaces_http.StartExportRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/exports_interfaces_http.StartExportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/exports_interfaces_http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/exports_interfaces_http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/hello": {
+            "get": {
+                "description": "Returns a hello message, no auth required",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "public"
+                ],
+                "summary": "Public hello endpoint",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/auth_interfaces_http.MessageResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the currently authenticated user's information",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get current user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/auth_interfaces_http.UserResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/auth_interfaces_http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/protected/hello": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns a personalized hello message for authenticated users",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "protected"
+                ],
+                "summary": "Protected hello endpoint",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/auth_interfaces_http.MessageResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/auth_interfaces_http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stats": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get statistics for the authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
                 ],
                 "summary": "Get user statistics",
                 "responses": {
@@ -783,23 +1100,407 @@ const docTemplate = `{
         }
     },
     "definitions": {
-        "aiworkflows_interfaces_http.ErrorResponse": {
+        "aiworkflows_interfaces_http.AnswerDTO": {
             "type": "object",
             "properties": {
-                "error": {
+                "answer": {
                     "type": "string",
-                    "example": "invalid repo url"
+                    "example": "Sessions are validated in the auth middleware [backend/internal/platform/middleware/auth.go]."
+                },
+                "citations": {
+                    "description": "Citations are the paths the answer cites, in order of first\nmention; directories end in a slash.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "backend/internal/platform/middleware/auth.go"
+                    ]
+                },
+                "createdAt": {
+                    "type": "string",
+                    "example": "2026-05-01T12:00:00Z"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "model": {
+                    "type": "string",
+                    "example": "openai/gpt-oss-120b"
+                },
+                "question": {
+                    "type": "string",
+                    "example": "Where is authentication handled?"
+                },
+                "sources": {
+                    "description": "Sources are the summaries the answer was grounded on, best match\nfirst.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "backend/internal/platform/middleware/auth.go",
+                        "backend/internal/auth/"
+                    ]
+                },
+                "usage": {
+                    "$ref": "#/definitions/aiworkflows_interfaces_http.TokenUsageDTO"
                 }
             }
         },
-        "aiworkflows_interfaces_http.FileSummaryDTO": {
+        "aiworkflows_interfaces_http.AnswerListResponse": {
             "type": "object",
             "properties": {
-                "filename": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/aiworkflows_interfaces_http.AnswerDTO"
+                    }
+                }
+            }
+        },
+        "aiworkflows_interfaces_http.AskRequest": {
+            "type": "object",
+            "properties": {
+                "question": {
+                    "type": "string",
+                    "example": "Where is authentication handled?"
+                }
+            }
+        },
+        "aiworkflows_interfaces_http.AttemptDTO": {
+            "type": "object",
+            "properties": {
+                "attempt": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "completedAt": {
+                    "type": "string"
+                },
+                "failReason": {
+                    "type": "string"
+                },
+                "fileCount": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "startedAt": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "aiworkflows_interfaces_http.ChangelogDTO": {
+            "type": "object",
+            "properties": {
+                "added": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "backend/internal/stats/cache.go"
+                    ]
+                },
+                "baseCommit": {
+                    "type": "string",
+                    "example": "9fceb02d0ae598e95dc970b74767f19372d61af8"
+                },
+                "baseId": {
+                    "type": "integer",
+                    "example": 41
+                },
+                "modified": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "backend/internal/stats/handler.go"
+                    ]
+                },
+                "removed": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "backend/internal/stats/legacy.go"
+                    ]
+                },
+                "unchanged": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "backend/cmd/server/main.go"
+                    ]
+                }
+            }
+        },
+        "aiworkflows_interfaces_http.DirectorySummaryDTO": {
+            "type": "object",
+            "properties": {
+                "children": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/aiworkflows_interfaces_http.DirectorySummaryDTO"
+                    }
+                },
+                "files": {
+                    "description": "Files are the summarized files directly in the directory.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "backend/go.mod"
+                    ]
+                },
+                "path": {
+                    "type": "string",
+                    "example": "backend/internal"
+                },
+                "summary": {
+                    "type": "string"
+                }
+            }
+        },
+        "aiworkflows_interfaces_http.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "invalid repo url"
+                }
+            }
+        },
+        "aiworkflows_interfaces_http.FileFilterDTO": {
+            "type": "object",
+            "properties": {
+                "exclude": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "**/*_gen.go"
+                    ]
+                },
+                "include": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "*.go"
+                    ]
+                },
+                "subdir": {
+                    "type": "string",
+                    "example": "backend"
+                },
+                "summaryIgnore": {
+                    "description": "SummaryIgnore are the rules read from the repository's\n.summaryignore; known once the traverse step has run.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "docs/"
+                    ]
+                }
+            }
+        },
+        "aiworkflows_interfaces_http.FileInsightsDTO": {
+            "type": "object",
+            "properties": {
+                "externalDependencies": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "github.com/gorilla/mux"
+                    ]
+                },
+                "keySymbols": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "Handler",
+                        "NewHandler"
+                    ]
+                },
+                "purpose": {
+                    "type": "string",
+                    "example": "HTTP handlers for the AI workflows"
+                },
+                "risks": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "no request body size limit"
+                    ]
+                }
+            }
+        },
+        "aiworkflows_interfaces_http.FileSelectionDTO": {
+            "type": "object",
+            "properties": {
+                "files": {
+                    "description": "Files is in selection order, best candidate first.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/aiworkflows_interfaces_http.SelectedFileDTO"
+                    }
+                },
+                "skippedSecrets": {
+                    "description": "SkippedSecrets are files left out because they hold secrets\n(.env files, private keys, credential files).",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "config/secrets.yaml"
+                    ]
+                },
+                "skippedTooLarge": {
+                    "description": "SkippedTooLarge are files left out for being over the per-file\nsize ceiling (AI_MAX_FILE_BYTES).",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "testdata/fixtures.json"
+                    ]
+                },
+                "strategy": {
+                    "description": "Strategy is \"ranked\" or \"alphabetical\".",
+                    "type": "string",
+                    "example": "ranked"
+                }
+            }
+        },
+        "aiworkflows_interfaces_http.FileSummaryDTO": {
+            "type": "object",
+            "properties": {
+                "cached": {
+                    "description": "Cached is true when the summary was served from the summary cache.",
+                    "type": "boolean"
+                },
+                "chunks": {
+                    "description": "Chunks is how many chunks the file was summarized in because it\nwas too large for one prompt; absent when it fit.",
+                    "type": "integer",
+                    "example": 4
+                },
+                "filename": {
                     "type": "string"
                 },
+                "insights": {
+                    "description": "Insights is the structured breakdown of the file; absent for\nsummaries generated in free-text mode.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.FileInsightsDTO"
+                        }
+                    ]
+                },
+                "model": {
+                    "description": "Model is the LLM model that wrote the summary; with a provider\nfallback chain it may not be the first one configured. Absent for\ncached summaries.",
+                    "type": "string",
+                    "example": "openai/gpt-oss-120b"
+                },
+                "redactions": {
+                    "description": "Redactions is how many secrets were redacted from the file before\nit was sent to the LLM; absent when none.",
+                    "type": "integer",
+                    "example": 2
+                },
                 "summary": {
                     "type": "string"
+                },
+                "usage": {
+                    "description": "Usage is what generating this summary cost; absent for cached\nand reused summaries.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.TokenUsageDTO"
+                        }
+                    ]
+                }
+            }
+        },
+        "aiworkflows_interfaces_http.QuotaLimitsDTO": {
+            "type": "object",
+            "properties": {
+                "maxConcurrentRuns": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "maxRunsPerDay": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "monthlyTokenBudget": {
+                    "type": "integer",
+                    "example": 1000000
+                }
+            }
+        },
+        "aiworkflows_interfaces_http.QuotaResponse": {
+            "type": "object",
+            "properties": {
+                "dayResetsAt": {
+                    "type": "string",
+                    "example": "2026-05-02T00:00:00Z"
+                },
+                "limits": {
+                    "$ref": "#/definitions/aiworkflows_interfaces_http.QuotaLimitsDTO"
+                },
+                "monthResetsAt": {
+                    "type": "string",
+                    "example": "2026-06-01T00:00:00Z"
+                },
+                "usage": {
+                    "$ref": "#/definitions/aiworkflows_interfaces_http.QuotaUsageDTO"
+                }
+            }
+        },
+        "aiworkflows_interfaces_http.QuotaUsageDTO": {
+            "type": "object",
+            "properties": {
+                "activeRuns": {
+                    "type": "integer"
+                },
+                "runsToday": {
+                    "type": "integer"
+                },
+                "tokensThisMonth": {
+                    "type": "integer"
+                }
+            }
+        },
+        "aiworkflows_interfaces_http.RedactionsDTO": {
+            "type": "object",
+            "properties": {
+                "byRule": {
+                    "description": "ByRule maps the rule that matched (aws-access-key, private-key,\nenv-assignment, …) to how often it did.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 3
                 }
             }
         },
@@ -840,9 +1541,53 @@ const docTemplate = `{
         "aiworkflows_interfaces_http.RepoSummaryResponse": {
             "type": "object",
             "properties": {
+                "attempts": {
+                    "description": "Attempts is the run's retry chain, oldest first, including the\nrun itself. Only returned by GET /ai/summaries/{id}.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/aiworkflows_interfaces_http.AttemptDTO"
+                    }
+                },
+                "baseId": {
+                    "description": "BaseID is the run an incremental run builds on; absent for a full\nrun.",
+                    "type": "integer",
+                    "example": 41
+                },
+                "cacheHits": {
+                    "description": "CacheHits and CacheMisses split Files by whether the summary was\nserved from the summary cache or generated by the LLM.",
+                    "type": "integer"
+                },
+                "cacheMisses": {
+                    "type": "integer"
+                },
+                "changelog": {
+                    "description": "Changelog is how the files compare to the base run. Absent for\nfull runs and until the summarize-files step has run.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.ChangelogDTO"
+                        }
+                    ]
+                },
+                "commitSha": {
+                    "description": "CommitSHA and CommitTime identify the commit that was actually\nsummarized; known once the clone step has run.",
+                    "type": "string",
+                    "example": "9fceb02d0ae598e95dc970b74767f19372d61af8"
+                },
+                "commitTime": {
+                    "type": "string",
+                    "example": "2026-03-01T12:00:00Z"
+                },
                 "completedAt": {
                     "type": "string"
                 },
+                "directories": {
+                    "description": "Directories is the architecture outline: the root of the tree of\ndirectory summaries. Absent until the directory step has run.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.DirectorySummaryDTO"
+                        }
+                    ]
+                },
                 "failReason": {
                     "type": "string"
                 },
@@ -852,12 +1597,52 @@ const docTemplate = `{
                         "$ref": "#/definitions/aiworkflows_interfaces_http.FileSummaryDTO"
                     }
                 },
+                "filter": {
+                    "description": "Filter is the effective file filter: what the request asked for\nplus the repository's .summaryignore rules. Absent when neither\nrestricts anything.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.FileFilterDTO"
+                        }
+                    ]
+                },
                 "id": {
                     "type": "integer"
                 },
+                "promptVersions": {
+                    "description": "PromptVersions maps prompt name (file-summary, aggregate) to the\ntemplate version the run used. Absent for runs that haven't\nstarted or predate prompt versioning.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "redactions": {
+                    "description": "Redactions counts the secrets kept from the LLM. Absent when\nnothing was redacted.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.RedactionsDTO"
+                        }
+                    ]
+                },
+                "ref": {
+                    "description": "Ref is the branch, tag or commit the run asked for; absent for\nthe default branch.",
+                    "type": "string",
+                    "example": "main"
+                },
                 "repoUrl": {
                     "type": "string"
                 },
+                "retryOf": {
+                    "description": "RetryOf is the failed run this one retried; 0 for a first attempt.",
+                    "type": "integer"
+                },
+                "selection": {
+                    "description": "Selection is how the summarized files were chosen. Absent until\nthe traverse step has run.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.FileSelectionDTO"
+                        }
+                    ]
+                },
                 "startedAt": {
                     "type": "string"
                 },
@@ -873,21 +1658,81 @@ const docTemplate = `{
                 },
                 "summary": {
                     "type": "string"
+                },
+                "usage": {
+                    "description": "Usage totals the run's LLM calls: generated file summaries plus\nthe repo-level overview.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/aiworkflows_interfaces_http.TokenUsageDTO"
+                        }
+                    ]
+                }
+            }
+        },
+        "aiworkflows_interfaces_http.SelectedFileDTO": {
+            "type": "object",
+            "properties": {
+                "filename": {
+                    "type": "string",
+                    "example": "cmd/server/main.go"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "entry point; imported by 3 files"
                 }
             }
         },
         "aiworkflows_interfaces_http.SummarizeRepoRequest": {
             "type": "object",
             "properties": {
+                "exclude": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "**/*_gen.go"
+                    ]
+                },
+                "include": {
+                    "description": "Include and Exclude are optional .gitignore-style patterns matched\nagainst repo-relative paths. Include replaces the default file\ntypes; Exclude always wins.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "*.go",
+                        "*.proto"
+                    ]
+                },
+                "incremental": {
+                    "description": "Incremental builds on the latest completed run of the same\nrepository: only files added or modified since are summarized\nagain. Without such a run it is a full run.",
+                    "type": "boolean"
+                },
+                "ref": {
+                    "description": "Ref is the branch, tag or full commit SHA to summarize; the\ndefault branch when empty.",
+                    "type": "string",
+                    "example": "main"
+                },
                 "repoUrl": {
                     "type": "string",
                     "example": "https://github.com/owner/repo"
+                },
+                "subdir": {
+                    "description": "Subdir restricts the run to one directory of the repository.",
+                    "type": "string",
+                    "example": "backend"
                 }
             }
         },
         "aiworkflows_interfaces_http.SummarizeRepoResponse": {
             "type": "object",
             "properties": {
+                "baseId": {
+                    "description": "BaseID is the run an incremental request builds on; absent for a\nfull run.",
+                    "type": "integer",
+                    "example": 41
+                },
                 "runId": {
                     "type": "string",
                     "example": "a1b2c3d4-..."
@@ -902,6 +1747,23 @@ const docTemplate = `{
                 }
             }
         },
+        "aiworkflows_interfaces_http.TokenUsageDTO": {
+            "type": "object",
+            "properties": {
+                "completionTokens": {
+                    "type": "integer"
+                },
+                "costUsd": {
+                    "type": "number"
+                },
+                "promptTokens": {
+                    "type": "integer"
+                },
+                "totalTokens": {
+                    "type": "integer"
+                }
+            }
+        },
         "auth_interfaces_http.ErrorResponse": {
             "type": "object",
             "properties": {
@@ -0,0 +1,165 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
+	shared "github.com/atilladeniz/next-go-pg/backend/internal/shared/domain"
+)
+
+// MaxSources is how many summaries a question is answered from.
+const MaxSources = 6
+
+// historyTurns is how many earlier answers go into the prompt, enough
+// for a follow-up ("and where is it tested?") to make sense.
+const historyTurns = 3
+
+// AnswerHistoryMax caps the history GET /ai/summaries/{id}/questions
+// returns.
+const AnswerHistoryMax = 50
+
+// AskQuestion answers a question about a completed run from its
+// summaries: the best-matching file and directory summaries are
+// retrieved, the LLM answers from them citing paths, and the answer is
+// stored with the run. Returns ErrNotFound for missing rows AND
+// cross-user questions, ErrNotCompleted unless the run completed, and
+// wraps ai.ErrInvalidQuestion for unusable questions. Answers spend
+// tokens from the monthly budget, so Quota applies to them too.
+type AskQuestion struct {
+	Store   Store
+	Answers AnswerStore
+	LLM     LLMClient
+	Prompts QuestionPrompter
+	Quota   *Quota
+}
+
+type AskQuestionInput struct {
+	UserID    shared.UserID
+	SummaryID uint
+	Question  string
+}
+
+func (uc AskQuestion) Execute(ctx context.Context, in AskQuestionInput) (*ai.Answer, error) {
+	question, err := ai.NewQuestion(in.Question)
+	if err != nil {
+		return nil, err
+	}
+	agg, err := uc.Store.GetByID(ctx, in.SummaryID)
+	if err != nil {
+		return nil, err
+	}
+	if agg.UserID != in.UserID {
+		return nil, ErrNotFound
+	}
+	if agg.Status != ai.StatusCompleted {
+		return nil, ErrNotCompleted
+	}
+	if err := uc.Quota.CheckTokens(ctx, in.UserID); err != nil {
+		return nil, err
+	}
+	history, err := uc.Answers.ListAnswers(ctx, agg.ID, historyTurns)
+	if err != nil {
+		return nil, fmt.Errorf("load answers: %w", err)
+	}
+
+	sources := retrieve(agg, question, MaxSources)
+	prompt, version, err := uc.Prompts.QuestionPrompt(QuestionPrompt{
+		SummaryID: agg.ID,
+		Question:  question,
+		Overview:  agg.Summary,
+		Sources:   sources,
+		History:   history,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("render question prompt: %w", err)
+	}
+	completion, err := uc.LLM.Generate(ctx, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("llm generate: %w", err)
+	}
+
+	text := strings.TrimSpace(completion.Text)
+	answer := &ai.Answer{
+		SummaryID:     agg.ID,
+		UserID:        in.UserID,
+		Question:      question,
+		Text:          text,
+		Sources:       make([]string, 0, len(sources)),
+		Citations:     citedSources(text, sources),
+		Model:         completion.Model,
+		PromptVersion: version,
+		Usage:         completion.Usage,
+	}
+	for _, s := range sources {
+		answer.Sources = append(answer.Sources, s.Path)
+	}
+	if err := uc.Answers.AddAnswer(ctx, answer); err != nil {
+		return nil, fmt.Errorf("store answer: %w", err)
+	}
+	return answer, nil
+}
+
+// citedSources returns the paths of sources the answer cites as
+// [path], in order of first mention. Brackets may hold several paths
+// separated by commas or semicolons; a directory may be cited with or
+// without its trailing slash. Citations of anything that wasn't a
+// source are dropped — the model can't ground an answer on a file it
+// never saw.
+func citedSources(text string, sources []Source) []string {
+	byKey := make(map[string]string, len(sources))
+	for _, s := range sources {
+		byKey[citationKey(s.Path)] = s.Path
+	}
+	seen := map[string]bool{}
+	out := []string{}
+	for {
+		open := strings.IndexByte(text, '[')
+		if open < 0 {
+			return out
+		}
+		end := strings.IndexByte(text[open:], ']')
+		if end < 0 {
+			return out
+		}
+		inner := text[open+1 : open+end]
+		text = text[open+end+1:]
+		for _, ref := range strings.FieldsFunc(inner, func(r rune) bool { return r == ',' || r == ';' }) {
+			path, ok := byKey[citationKey(ref)]
+			if ok && !seen[path] {
+				seen[path] = true
+				out = append(out, path)
+			}
+		}
+	}
+}
+
+// citationKey normalises a cited path for lookup.
+func citationKey(ref string) string {
+	return strings.Trim(strings.TrimSpace(ref), "`/")
+}
+
+// GetAnswerHistory lists the questions asked about a run owned by the
+// caller, oldest first. Returns ErrNotFound for missing rows AND
+// cross-user reads.
+type GetAnswerHistory struct {
+	Store   Store
+	Answers AnswerStore
+}
+
+type GetAnswerHistoryInput struct {
+	UserID    shared.UserID
+	SummaryID uint
+}
+
+func (uc GetAnswerHistory) Execute(ctx context.Context, in GetAnswerHistoryInput) ([]ai.Answer, error) {
+	agg, err := uc.Store.GetByID(ctx, in.SummaryID)
+	if err != nil {
+		return nil, err
+	}
+	if agg.UserID != in.UserID {
+		return nil, ErrNotFound
+	}
+	return uc.Answers.ListAnswers(ctx, agg.ID, AnswerHistoryMax)
+}
@@ -0,0 +1,235 @@
+package application_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	aiapp "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/application"
+	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
+	shared "github.com/atilladeniz/next-go-pg/backend/internal/shared/domain"
+)
+
+// fakeAnswers is an in-memory AnswerStore.
+type fakeAnswers struct {
+	rows []ai.Answer
+}
+
+func (a *fakeAnswers) AddAnswer(_ context.Context, ans *ai.Answer) error {
+	ans.ID = uint(len(a.rows) + 1)
+	ans.CreatedAt = time.Now()
+	a.rows = append(a.rows, *ans)
+	return nil
+}
+
+func (a *fakeAnswers) ListAnswers(_ context.Context, summaryID uint, limit int) ([]ai.Answer, error) {
+	var out []ai.Answer
+	for _, r := range a.rows {
+		if r.SummaryID == summaryID {
+			out = append(out, r)
+		}
+	}
+	if len(out) > limit {
+		out = out[len(out)-limit:]
+	}
+	return out, nil
+}
+
+// fakePrompter records what it was asked to render.
+type fakePrompter struct {
+	last aiapp.QuestionPrompt
+}
+
+func (p *fakePrompter) QuestionPrompt(q aiapp.QuestionPrompt) (string, string, error) {
+	p.last = q
+	return "PROMPT " + q.Question, "v1", nil
+}
+
+// fakeLLM answers every prompt with text.
+type fakeLLM struct {
+	text  string
+	calls int
+}
+
+func (l *fakeLLM) Generate(context.Context, string) (aiapp.Completion, error) {
+	l.calls++
+	return aiapp.Completion{Text: l.text, Model: "test-model", Usage: ai.TokenUsage{PromptTokens: 100, CompletionTokens: 20}}, nil
+}
+
+func (l *fakeLLM) Stream(ctx context.Context, prompt string, onChunk func(string)) (aiapp.Completion, error) {
+	c, err := l.Generate(ctx, prompt)
+	onChunk(c.Text)
+	return c, err
+}
+
+func newCompletedSummary(t *testing.T, store *fakeStore, owner shared.UserID) *ai.RepoSummary {
+	t.Helper()
+	agg := newRunningSummary(t, store, owner, "run-1")
+	files := map[string]string{
+		"backend/internal/platform/middleware/auth.go": "Validates session cookies and bearer tokens and puts the user on the request context.",
+		"backend/internal/auth/handler.go":             "HTTP handlers for login and logout backed by Better Auth.",
+		"backend/internal/stats/handler.go":            "Serves the dashboard statistics endpoint.",
+		"frontend/src/app/page.tsx":                    "Landing page of the web app.",
+	}
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	slices.Sort(paths)
+	for _, p := range paths {
+		fs, err := ai.NewFileSummary(p, files[p])
+		if err != nil {
+			t.Fatalf("NewFileSummary: %v", err)
+		}
+		if err := agg.AppendFileSummary(fs, len(files)); err != nil {
+			t.Fatalf("AppendFileSummary: %v", err)
+		}
+	}
+	tree := ai.NewDirectoryTree(paths)
+	for i := range tree.Children {
+		tree.Children[i].Summary = "Everything under " + tree.Children[i].Path + "."
+	}
+	agg.RecordDirectories(tree)
+	if err := agg.MarkCompleted("A Go backend with a Next.js frontend.", time.Now()); err != nil {
+		t.Fatalf("MarkCompleted: %v", err)
+	}
+	agg.PullEvents()
+	return agg
+}
+
+func TestAskQuestion_RetrievesAndCites(t *testing.T) {
+	t.Parallel()
+	store := newFakeStore()
+	owner := uid(t, "user-1")
+	agg := newCompletedSummary(t, store, owner)
+	answers := &fakeAnswers{}
+	prompter := &fakePrompter{}
+	llm := &fakeLLM{text: "Sessions are checked in [backend/internal/platform/middleware/auth.go]; login lives in [backend/internal/auth/handler.go, made/up.go]. See also [backend/internal/platform/middleware/auth.go]."}
+	uc := aiapp.AskQuestion{Store: store, Answers: answers, LLM: llm, Prompts: prompter}
+
+	got, err := uc.Execute(context.Background(), aiapp.AskQuestionInput{UserID: owner, SummaryID: agg.ID, Question: "  Where is authentication handled? "})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	if prompter.last.Question != "Where is authentication handled?" || prompter.last.Overview != agg.Summary {
+		t.Errorf("prompt = %+v", prompter.last)
+	}
+	if len(prompter.last.Sources) == 0 || len(prompter.last.Sources) > aiapp.MaxSources {
+		t.Fatalf("sources = %+v", prompter.last.Sources)
+	}
+	// "authentication" is matched through the "auth" paths; the stats
+	// handler only shares "handled", the frontend page nothing.
+	var paths []string
+	for _, s := range prompter.last.Sources {
+		paths = append(paths, s.Path)
+	}
+	if len(paths) < 2 || !slices.Contains(paths[:2], "backend/internal/auth/handler.go") || !slices.Contains(paths[:2], "backend/internal/platform/middleware/auth.go") {
+		t.Errorf("sources = %v, want the auth files first", paths)
+	}
+	if slices.Contains(paths, "frontend/src/app/page.tsx") {
+		t.Errorf("sources = %v, want no frontend page", paths)
+	}
+
+	wantCitations := []string{"backend/internal/platform/middleware/auth.go", "backend/internal/auth/handler.go"}
+	if !slices.Equal(got.Citations, wantCitations) {
+		t.Errorf("Citations = %v, want %v", got.Citations, wantCitations)
+	}
+	if got.ID == 0 || got.Model != "test-model" || got.PromptVersion != "v1" || got.Usage.TotalTokens() != 120 {
+		t.Errorf("answer = %+v", got)
+	}
+	if len(answers.rows) != 1 {
+		t.Fatalf("stored answers = %d, want 1", len(answers.rows))
+	}
+
+	// A follow-up sees the earlier exchange.
+	if _, err := uc.Execute(context.Background(), aiapp.AskQuestionInput{UserID: owner, SummaryID: agg.ID, Question: "And where is it tested?"}); err != nil {
+		t.Fatalf("Execute follow-up: %v", err)
+	}
+	if len(prompter.last.History) != 1 || prompter.last.History[0].Question != "Where is authentication handled?" {
+		t.Errorf("History = %+v", prompter.last.History)
+	}
+}
+
+func TestAskQuestion_NoMatchFallsBackToOutline(t *testing.T) {
+	t.Parallel()
+	store := newFakeStore()
+	owner := uid(t, "user-1")
+	agg := newCompletedSummary(t, store, owner)
+	prompter := &fakePrompter{}
+	uc := aiapp.AskQuestion{Store: store, Answers: &fakeAnswers{}, LLM: &fakeLLM{text: "No idea."}, Prompts: prompter}
+
+	got, err := uc.Execute(context.Background(), aiapp.AskQuestionInput{UserID: owner, SummaryID: agg.ID, Question: "Kubernetes?"})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	var paths []string
+	for _, s := range prompter.last.Sources {
+		paths = append(paths, s.Path)
+	}
+	if want := []string{"backend/internal/", "frontend/src/app/"}; !slices.Equal(paths, want) {
+		t.Errorf("sources = %v, want the top-level outline %v", paths, want)
+	}
+	if len(got.Citations) != 0 {
+		t.Errorf("Citations = %v, want none", got.Citations)
+	}
+}
+
+func TestAskQuestion_Rejections(t *testing.T) {
+	t.Parallel()
+	store := newFakeStore()
+	owner := uid(t, "user-1")
+	done := newCompletedSummary(t, store, owner)
+	running := newRunningSummary(t, store, owner, "run-2")
+	llm := &fakeLLM{text: "x"}
+	quota := &aiapp.Quota{Store: store, Limits: aiapp.QuotaLimits{MonthlyTokenBudget: 1000, MaxConcurrentRuns: 1}}
+	uc := aiapp.AskQuestion{Store: store, Answers: &fakeAnswers{}, LLM: llm, Prompts: &fakePrompter{}, Quota: quota}
+
+	cases := []struct {
+		name string
+		in   aiapp.AskQuestionInput
+		want error
+	}{
+		{"empty question", aiapp.AskQuestionInput{UserID: owner, SummaryID: done.ID, Question: "   "}, ai.ErrInvalidQuestion},
+		{"other user", aiapp.AskQuestionInput{UserID: uid(t, "user-2"), SummaryID: done.ID, Question: "Where?"}, aiapp.ErrNotFound},
+		{"missing run", aiapp.AskQuestionInput{UserID: owner, SummaryID: 99, Question: "Where?"}, aiapp.ErrNotFound},
+		{"running run", aiapp.AskQuestionInput{UserID: owner, SummaryID: running.ID, Question: "Where?"}, aiapp.ErrNotCompleted},
+	}
+	for _, tc := range cases {
+		if _, err := uc.Execute(context.Background(), tc.in); !errors.Is(err, tc.want) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+	if llm.calls != 0 {
+		t.Errorf("LLM calls = %d, want 0", llm.calls)
+	}
+
+	// An active run doesn't block questions; the token budget does.
+	store.usage = aiapp.UserUsage{ActiveRuns: 1}
+	if _, err := uc.Execute(context.Background(), aiapp.AskQuestionInput{UserID: owner, SummaryID: done.ID, Question: "Where?"}); err != nil {
+		t.Fatalf("Execute under run limit: %v", err)
+	}
+	store.usage = aiapp.UserUsage{TokensThisMonth: 1000}
+	if _, err := uc.Execute(context.Background(), aiapp.AskQuestionInput{UserID: owner, SummaryID: done.ID, Question: "Where?"}); !errors.Is(err, aiapp.ErrQuotaExceeded) {
+		t.Errorf("err = %v, want ErrQuotaExceeded", err)
+	}
+}
+
+func TestGetAnswerHistory_Ownership(t *testing.T) {
+	t.Parallel()
+	store := newFakeStore()
+	owner := uid(t, "user-1")
+	agg := newCompletedSummary(t, store, owner)
+	answers := &fakeAnswers{rows: []ai.Answer{{ID: 1, SummaryID: agg.ID, Question: "Q?", Text: "A."}}}
+	uc := aiapp.GetAnswerHistory{Store: store, Answers: answers}
+
+	got, err := uc.Execute(context.Background(), aiapp.GetAnswerHistoryInput{UserID: owner, SummaryID: agg.ID})
+	if err != nil || len(got) != 1 {
+		t.Fatalf("Execute = %v, %v; want one answer", got, err)
+	}
+	if _, err := uc.Execute(context.Background(), aiapp.GetAnswerHistoryInput{UserID: uid(t, "user-2"), SummaryID: agg.ID}); !errors.Is(err, aiapp.ErrNotFound) {
+		t.Errorf("cross-user err = %v, want ErrNotFound", err)
+	}
+}
@@ -7,6 +7,7 @@ package application
 import (
 	"context"
 	"errors"
+	"time"
 
 	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
 	shared "github.com/atilladeniz/next-go-pg/backend/internal/shared/domain"
@@ -18,21 +19,88 @@ import (
 // avoids leaking existence of other users' runs.
 var ErrNotFound = errors.New("repo summary not found")
 
+// ErrAlreadyTerminal is returned when an operation needs a run that is
+// still pending or running but it has already completed, failed, or
+// been cancelled. The HTTP layer maps it to 409 Conflict.
+var ErrAlreadyTerminal = errors.New("repo summary already finished")
+
+// ErrNotRetryable is returned when a retry is requested for a run that
+// did not fail. The HTTP layer maps it to 409 Conflict.
+var ErrNotRetryable = errors.New("only failed repo summaries can be retried")
+
+// ErrNotCompleted is returned when a question is asked about a run that
+// hasn't completed: there is nothing to answer from yet. The HTTP layer
+// maps it to 409 Conflict.
+var ErrNotCompleted = errors.New("repo summary not completed")
+
+// ErrStatusChanged is returned by Store.Transition and
+// Store.AppendFiles when the row is no longer in the status the write
+// expects: another writer, most often a cancel, changed it after the
+// caller loaded the aggregate.
+var ErrStatusChanged = errors.New("repo summary status changed")
+
 // Store persists and retrieves RepoSummary aggregates. The contract is:
 //   - Create assigns a non-zero ID on success.
-//   - Save MUST mutate fields in place rather than replacing *agg, so
-//     the aggregate's pending domain events survive persistence.
 //   - GetByID returns ErrNotFound when the row does not exist.
+//   - Nothing writes the whole row back. A run's steps and a cancel
+//     update the same row concurrently, so every write below touches
+//     only the columns it is about, and lifecycle changes only apply
+//     while the row is still in the status they start from.
 type Store interface {
 	Create(ctx context.Context, agg *ai.RepoSummary) error
-	Save(ctx context.Context, agg *ai.RepoSummary) error
 	GetByID(ctx context.Context, id uint) (*ai.RepoSummary, error)
+	// Transition persists agg's lifecycle change — its status and the
+	// fields that change sets — if the row's status is one of from, and
+	// returns ErrStatusChanged otherwise.
+	Transition(ctx context.Context, agg *ai.RepoSummary, from ...ai.Status) error
+	// AppendFiles adds file summaries, and their usage to the run's
+	// totals, to a running run. Returns ErrStatusChanged when the run
+	// isn't running.
+	AppendFiles(ctx context.Context, id uint, files []ai.FileSummary) error
+	// AddUsage adds LLM usage not tied to a file summary to the totals.
+	AddUsage(ctx context.Context, id uint, u ai.TokenUsage) error
+	// Complete persists agg's completion and adds u to its usage totals
+	// in one write, if the row is still running, and returns
+	// ErrStatusChanged otherwise.
+	Complete(ctx context.Context, agg *ai.RepoSummary, u ai.TokenUsage) error
+	// AttachRun records the engine's run ID.
+	AttachRun(ctx context.Context, id uint, runID string) error
+	// RecordDirectories records the run's directory outline.
+	RecordDirectories(ctx context.Context, id uint, tree ai.DirectorySummary) error
+	// RecordStepDuration sets one step's duration, keeping the others.
+	RecordStepDuration(ctx context.Context, id uint, step string, ms int64) error
+	// RecordChangelog records how the run differs from its base run.
+	RecordChangelog(ctx context.Context, id uint, c ai.Changelog) error
+	// RecordCommit records the commit the run's clone checked out.
+	RecordCommit(ctx context.Context, id uint, sha string, at time.Time) error
+	// RecordSelection records which files the run picked, and why.
+	RecordSelection(ctx context.Context, id uint, sel ai.FileSelection) error
 	ListByUserID(ctx context.Context, userID shared.UserID, limit int) ([]*ai.RepoSummary, error)
 	// Delete removes the row owned by userID. Returns ErrNotFound when
 	// the row is missing OR when it belongs to another user — the same
 	// error surface as GetByID, so the HTTP layer maps both to 404 and
 	// never leaks cross-user existence.
 	Delete(ctx context.Context, userID shared.UserID, id uint) error
+	// ListAttempts returns the retry chain whose first attempt is
+	// originalID, oldest first. Callers check ownership.
+	ListAttempts(ctx context.Context, originalID uint) ([]*ai.RepoSummary, error)
+	// LatestCompleted returns the user's most recent completed run of
+	// url, or ErrNotFound when there is none.
+	LatestCompleted(ctx context.Context, userID shared.UserID, url ai.RepoURL) (*ai.RepoSummary, error)
+	// UserUsage reports the user's runs still pending or running, runs
+	// created since dayStart, and tokens used since monthStart by runs
+	// and by answered questions.
+	UserUsage(ctx context.Context, userID shared.UserID, dayStart, monthStart time.Time) (UserUsage, error)
+}
+
+// AnswerStore persists the questions asked about a RepoSummary and
+// their answers. Callers check that the user owns the summary.
+type AnswerStore interface {
+	// AddAnswer inserts a and assigns its ID and CreatedAt.
+	AddAnswer(ctx context.Context, a *ai.Answer) error
+	// ListAnswers returns the summary's limit most recent answers,
+	// oldest first.
+	ListAnswers(ctx context.Context, summaryID uint, limit int) ([]ai.Answer, error)
 }
 
 // HatchetEnqueuer hides the Hatchet SDK from the application and HTTP
@@ -40,6 +108,10 @@ type Store interface {
 // infrastructure-layer adapter.
 type HatchetEnqueuer interface {
 	EnqueueSummarizeRepo(ctx context.Context, in EnqueueSummarizeRepoInput) (runID string, err error)
+	// CancelRun asks the engine to stop a run it returned from
+	// EnqueueSummarizeRepo. Cancelling a run that already finished is
+	// not an error.
+	CancelRun(ctx context.Context, runID string) error
 }
 
 // EnqueueSummarizeRepoInput is the payload the engine adapter forwards
@@ -49,25 +121,129 @@ type EnqueueSummarizeRepoInput struct {
 	SummaryID uint
 	UserID    shared.UserID
 	RepoURL   ai.RepoURL
+	Filter    ai.FileFilter
+	Ref       ai.GitRef
 }
 
 // LLMClient is the LLM-runtime abstraction. Current implementation
 // talks to OpenRouter; the port stays generic so swapping in another
 // provider (local model, different gateway) is a one-line wire change.
 type LLMClient interface {
-	Generate(ctx context.Context, prompt string) (string, error)
+	Generate(ctx context.Context, prompt string) (Completion, error)
+	// Stream is Generate with the answer delivered incrementally:
+	// onChunk receives each piece of text as the provider emits it, on
+	// the calling goroutine and in order. The returned Completion's
+	// Text is exactly the concatenation of the chunks.
+	Stream(ctx context.Context, prompt string, onChunk func(chunk string)) (Completion, error)
+}
+
+// Completion is one LLM answer plus what it cost. Model is the model
+// that actually answered, which a routing gateway may pick per call;
+// adapters fall back to the configured model when the provider
+// doesn't say. Usage fields the provider doesn't report stay zero.
+type Completion struct {
+	Text  string
+	Model string
+	Usage ai.TokenUsage
+}
+
+// QuestionPrompter renders the prompt that answers a question about a
+// summary and reports the template version it rendered.
+type QuestionPrompter interface {
+	QuestionPrompt(p QuestionPrompt) (prompt, version string, err error)
+}
+
+// QuestionPrompt is what a question is answered from: the run's
+// overview, the retrieved Sources, and the most recent earlier
+// questions about the same run for follow-ups.
+type QuestionPrompt struct {
+	SummaryID uint
+	Question  string
+	Overview  string
+	Sources   []Source
+	History   []ai.Answer
 }
 
-// RepoCloner produces a local working copy of a public Git repository.
-// Callers MUST invoke Cleanup when done with the path, even on error.
+// Source is one retrieved passage: the summary of a file, or of a
+// directory when Path ends in a slash.
+type Source struct {
+	Path string
+	Text string
+}
+
+// SummaryCacheKey identifies a per-file summary by what produced it
+// rather than by path: the file's git blob hash, the prompt template
+// version, and the model. Changing any of the three is a miss.
+type SummaryCacheKey struct {
+	BlobHash      string
+	PromptVersion string
+	Model         string
+}
+
+// SummaryCache is the persistent, content-addressed store of per-file
+// summaries shared by all runs. Get reports a miss as ok=false with a
+// nil error; errors mean the backing store itself failed.
+type SummaryCache interface {
+	Get(ctx context.Context, key SummaryCacheKey) (summary string, ok bool, err error)
+	Put(ctx context.Context, key SummaryCacheKey, summary string) error
+}
+
+// SecretScanner keeps credentials in a repository from reaching the
+// LLM. SecretStore reports files that exist to hold secrets — .env
+// files, private keys, credential files — which are not summarized at
+// all. Redact replaces every credential it finds in content with a
+// placeholder naming the rule that matched, and counts the matches per
+// rule; the secrets themselves are never returned. Version changes
+// with the rules, so summaries cached from content redacted under
+// other rules aren't reused.
+type SecretScanner interface {
+	SecretStore(path string) bool
+	Redact(content string) (redacted string, counts map[string]int)
+	Version() string
+}
+
+// RepoCloner produces a local working copy of a public Git repository
+// at ref (empty: the default branch). Callers MUST invoke Cleanup when
+// done with the path, even on error. A ref the remote doesn't have is
+// reported as ErrRefNotFound, a host the clone-target policy forbids
+// as ErrTargetNotAllowed, a repository over one of the clone limits as
+// ErrCloneLimit.
 type RepoCloner interface {
-	Clone(ctx context.Context, url ai.RepoURL) (ClonedRepo, error)
+	Clone(ctx context.Context, url ai.RepoURL, ref ai.GitRef) (ClonedRepo, error)
 }
 
-// ClonedRepo is the result of a successful RepoCloner.Clone.
+// ErrRefNotFound is returned by RepoCloner when the requested branch,
+// tag or commit doesn't exist in the remote. Retrying can't fix it.
+var ErrRefNotFound = errors.New("git ref not found")
+
+// ErrTargetNotAllowed is returned by RepoCloner when the repository's
+// host — or a host it redirects to — is denied, not on the allowlist,
+// or resolves to a loopback, private, link-local or otherwise internal
+// address. Retrying can't fix it.
+var ErrTargetNotAllowed = errors.New("clone target not allowed")
+
+// ErrCloneLimit is returned by RepoCloner when a clone transfers too
+// many bytes or objects, would check out too much, or takes too long.
+// The error message names the limit that was hit. The repository won't
+// have shrunk by the next attempt, so retrying can't fix it.
+var ErrCloneLimit = errors.New("clone limit exceeded")
+
+// ClonedRepo is the result of a successful RepoCloner.Clone. Commit
+// and CommitTime identify the checked-out commit.
 type ClonedRepo struct {
-	Path    string
-	Cleanup func() error
+	Path       string
+	Cleanup    func() error
+	Commit     string
+	CommitTime time.Time
+}
+
+// RepoHistory reads the commit history of a working copy produced by
+// RepoCloner. It only feeds file ranking, so adapters may return
+// whatever history the clone happens to have.
+type RepoHistory interface {
+	// RecentChanges maps repo-relative, slash-separated paths to the
+	// time of the newest available commit that touched them.
+	RecentChanges(ctx context.Context, path string) (map[string]time.Time, error)
 }
 
 // ProgressPublisher dispatches workflow progress to the frontend.
@@ -94,6 +270,7 @@ const (
 	StepClone          StepName = "clone"
 	StepTraverse       StepName = "traverse"
 	StepSummarizeFiles StepName = "summarize_files"
+	StepSummarizeDirs  StepName = "summarize_dirs"
 	StepAggregate      StepName = "aggregate"
 	StepStore          StepName = "store"
 )
@@ -105,7 +282,8 @@ const (
 	StepStateStarted   StepState = "started"
 	StepStateCompleted StepState = "completed"
 	StepStateFailed    StepState = "failed"
-	StepStateProgress  StepState = "progress" // per-file ticks within summarize_files
+	StepStateProgress  StepState = "progress"  // per-file ticks within summarize_files
+	StepStateStreaming StepState = "streaming" // overview text as it arrives within aggregate
 )
 
 // StepProgress is the payload published on a step transition. Use the
@@ -120,4 +298,5 @@ type StepProgress struct {
 	FileCount  int    // total files Traverse selected
 	Filename   string // last completed filename
 	Reason     string // populated only when State=failed
+	Delta      string // overview text since the last event, for aggregate state=streaming
 }
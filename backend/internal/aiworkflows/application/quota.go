@@ -0,0 +1,136 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	shared "github.com/atilladeniz/next-go-pg/backend/internal/shared/domain"
+)
+
+// ErrQuotaExceeded matches every *QuotaExceededError via errors.Is, for
+// callers that only need to know a limit was hit.
+var ErrQuotaExceeded = errors.New("ai quota exceeded")
+
+// QuotaLimit names the limit a request ran into.
+type QuotaLimit string
+
+const (
+	QuotaConcurrentRuns QuotaLimit = "concurrent_runs"
+	QuotaRunsPerDay     QuotaLimit = "runs_per_day"
+	QuotaMonthlyTokens  QuotaLimit = "monthly_tokens"
+)
+
+// concurrentRetryAfter is the hint given when the concurrency limit is
+// hit. Unlike the calendar windows there is no known reset time; a run
+// typically finishes within a minute or two.
+const concurrentRetryAfter = time.Minute
+
+// QuotaExceededError is returned when starting a run would break one of
+// the user's limits. RetryAfter is when trying again can succeed: the
+// next UTC day or month for the calendar limits, a fixed hint for the
+// concurrency limit.
+type QuotaExceededError struct {
+	Limit      QuotaLimit
+	RetryAfter time.Duration
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("ai quota exceeded: %s", e.Limit)
+}
+
+func (e *QuotaExceededError) Is(target error) bool { return target == ErrQuotaExceeded }
+
+// QuotaLimits are the per-user limits on summarization. Zero disables a
+// limit. Days and months are UTC calendar windows.
+type QuotaLimits struct {
+	MaxConcurrentRuns  int
+	MaxRunsPerDay      int
+	MonthlyTokenBudget int
+}
+
+// UserUsage is what a user has consumed against their limits. Runs and
+// tokens count towards the window the run was created in.
+type UserUsage struct {
+	ActiveRuns      int
+	RunsToday       int
+	TokensThisMonth int
+}
+
+// QuotaStatus is the report served by GET /ai/quota.
+type QuotaStatus struct {
+	Limits     QuotaLimits
+	Usage      UserUsage
+	DayReset   time.Time
+	MonthReset time.Time
+}
+
+// Quota enforces QuotaLimits. A nil *Quota allows everything, so use
+// cases treat it as optional. The check and the subsequent insert are
+// not atomic: two requests racing past the check can exceed a limit by
+// one run, which the HTTP rate limiter keeps rare enough to accept.
+type Quota struct {
+	Store  Store
+	Limits QuotaLimits
+}
+
+// windows returns the start of the current UTC day and month, and when
+// each ends.
+func windows(now time.Time) (dayStart, dayEnd, monthStart, monthEnd time.Time) {
+	now = now.UTC()
+	dayStart = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return dayStart, dayStart.AddDate(0, 0, 1), monthStart, monthStart.AddDate(0, 1, 0)
+}
+
+// Status reports the user's usage against their limits.
+func (q *Quota) Status(ctx context.Context, userID shared.UserID) (QuotaStatus, error) {
+	dayStart, dayEnd, monthStart, monthEnd := windows(nowFn())
+	usage, err := q.Store.UserUsage(ctx, userID, dayStart, monthStart)
+	if err != nil {
+		return QuotaStatus{}, fmt.Errorf("load usage: %w", err)
+	}
+	return QuotaStatus{Limits: q.Limits, Usage: usage, DayReset: dayEnd, MonthReset: monthEnd}, nil
+}
+
+// Check returns a *QuotaExceededError when the user may not start
+// another run right now.
+func (q *Quota) Check(ctx context.Context, userID shared.UserID) error {
+	if q == nil {
+		return nil
+	}
+	now := nowFn()
+	st, err := q.Status(ctx, userID)
+	if err != nil {
+		return err
+	}
+	// Longest wait first, so Retry-After never points at a reset that
+	// still leaves the user blocked.
+	l, u := st.Limits, st.Usage
+	switch {
+	case l.MonthlyTokenBudget > 0 && u.TokensThisMonth >= l.MonthlyTokenBudget:
+		return &QuotaExceededError{Limit: QuotaMonthlyTokens, RetryAfter: st.MonthReset.Sub(now)}
+	case l.MaxRunsPerDay > 0 && u.RunsToday >= l.MaxRunsPerDay:
+		return &QuotaExceededError{Limit: QuotaRunsPerDay, RetryAfter: st.DayReset.Sub(now)}
+	case l.MaxConcurrentRuns > 0 && u.ActiveRuns >= l.MaxConcurrentRuns:
+		return &QuotaExceededError{Limit: QuotaConcurrentRuns, RetryAfter: concurrentRetryAfter}
+	}
+	return nil
+}
+
+// CheckTokens is Check for work that spends tokens without starting a
+// run, such as answering a question: only the monthly budget applies.
+func (q *Quota) CheckTokens(ctx context.Context, userID shared.UserID) error {
+	if q == nil || q.Limits.MonthlyTokenBudget <= 0 {
+		return nil
+	}
+	st, err := q.Status(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if st.Usage.TokensThisMonth >= st.Limits.MonthlyTokenBudget {
+		return &QuotaExceededError{Limit: QuotaMonthlyTokens, RetryAfter: st.MonthReset.Sub(nowFn())}
+	}
+	return nil
+}
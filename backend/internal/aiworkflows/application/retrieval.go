@@ -0,0 +1,238 @@
+package application
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
+)
+
+// BM25 parameters: the usual defaults. k1 caps how much repeating a
+// term helps, b how much long summaries are penalised.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// minPrefixTerm is the shortest term that also matches longer words it
+// starts, so a question about "auth" finds "authentication" and one
+// about "authentication" finds the auth/ package.
+const minPrefixTerm = 4
+
+// passage is one searchable unit of a run: a file or directory summary
+// and the terms it is matched on.
+type passage struct {
+	source Source
+	terms  map[string]int
+	length int
+}
+
+// retrieve ranks the run's file and directory summaries against
+// question with BM25 and returns the best k, best first. A run holds at
+// most a few dozen summaries, so they are scored in memory rather than
+// indexed. When nothing matches, the top-level directories stand in:
+// the outline is the broadest context the run has.
+func retrieve(agg *ai.RepoSummary, question string, k int) []Source {
+	passages := summaryPassages(agg)
+	query := uniqueTerms(question)
+	if len(passages) == 0 || len(query) == 0 {
+		return outlineSources(agg, k)
+	}
+
+	avgLen := 0.0
+	for _, p := range passages {
+		avgLen += float64(p.length)
+	}
+	avgLen /= float64(len(passages))
+
+	type scored struct {
+		index int
+		score float64
+	}
+	scores := make([]scored, len(passages))
+	for i := range scores {
+		scores[i].index = i
+	}
+	n := float64(len(passages))
+	for _, q := range query {
+		tfs := make([]int, len(passages))
+		df := 0
+		for i, p := range passages {
+			if tfs[i] = p.frequency(q); tfs[i] > 0 {
+				df++
+			}
+		}
+		if df == 0 {
+			continue
+		}
+		idf := math.Log(1 + (n-float64(df)+0.5)/(float64(df)+0.5))
+		for i, p := range passages {
+			tf := float64(tfs[i])
+			if tf == 0 {
+				continue
+			}
+			norm := bm25K1 * (1 - bm25B + bm25B*float64(p.length)/avgLen)
+			scores[i].score += idf * tf * (bm25K1 + 1) / (tf + norm)
+		}
+	}
+	sort.SliceStable(scores, func(i, j int) bool { return scores[i].score > scores[j].score })
+
+	var out []Source
+	for _, s := range scores {
+		if s.score <= 0 || len(out) == k {
+			break
+		}
+		out = append(out, passages[s.index].source)
+	}
+	if len(out) == 0 {
+		return outlineSources(agg, k)
+	}
+	return out
+}
+
+// summaryPassages turns the run's summaries into passages. A file's
+// path counts twice, so a question naming a package or file finds it
+// even if its summary doesn't repeat the name; insights add their
+// symbols and dependencies.
+func summaryPassages(agg *ai.RepoSummary) []passage {
+	var out []passage
+	add := func(path, text, extra string) {
+		if strings.TrimSpace(text) == "" {
+			return
+		}
+		terms := map[string]int{}
+		length := 0
+		for _, part := range []string{path, path, text, extra} {
+			for _, t := range tokenize(part) {
+				terms[t]++
+				length++
+			}
+		}
+		out = append(out, passage{source: Source{Path: path, Text: text}, terms: terms, length: length})
+	}
+	for _, f := range agg.Files {
+		i := f.Insights()
+		extra := strings.Join(append(append([]string{i.Purpose}, i.KeySymbols...), i.ExternalDependencies...), " ")
+		add(f.Filename(), f.Summary(), extra)
+	}
+	var walk func(d ai.DirectorySummary)
+	walk = func(d ai.DirectorySummary) {
+		for _, c := range d.Children {
+			add(c.Path+"/", c.Summary, "")
+			walk(c)
+		}
+	}
+	walk(agg.Directories)
+	return out
+}
+
+// outlineSources returns up to k top-level directory summaries.
+func outlineSources(agg *ai.RepoSummary, k int) []Source {
+	var out []Source
+	for _, c := range agg.Directories.Children {
+		if c.Summary != "" && len(out) < k {
+			out = append(out, Source{Path: c.Path + "/", Text: c.Summary})
+		}
+	}
+	return out
+}
+
+// frequency counts q in the passage, including terms that q starts or
+// that start q, when the shorter of the two is a meaningful prefix.
+func (p passage) frequency(q string) int {
+	n := 0
+	for t, c := range p.terms {
+		if t == q || prefixMatch(t, q) || prefixMatch(q, t) {
+			n += c
+		}
+	}
+	return n
+}
+
+// prefixMatch reports whether the longer term long starts with prefix.
+func prefixMatch(long, prefix string) bool {
+	return len(prefix) >= minPrefixTerm && len(long) > len(prefix) && strings.HasPrefix(long, prefix)
+}
+
+// tokenize splits s into lower-cased, stemmed terms. Words break at
+// anything that isn't a letter or digit and at camelCase humps, so
+// "AuthHandler" and "auth_handler.go" both yield "auth" and "handl";
+// stop words and single characters are dropped.
+func tokenize(s string) []string {
+	var (
+		out  []string
+		word []rune
+	)
+	flush := func() {
+		if len(word) > 1 {
+			if w := strings.ToLower(string(word)); !stopWords[w] {
+				out = append(out, stem(w))
+			}
+		}
+		word = word[:0]
+	}
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		// A hump: lower followed by upper, or the last capital of an
+		// acronym followed by lower ("HTTPServer" → "HTTP", "Server").
+		if unicode.IsUpper(r) && len(word) > 0 {
+			prev := word[len(word)-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush()
+			}
+		}
+		word = append(word, r)
+	}
+	flush()
+	return out
+}
+
+// uniqueTerms is tokenize without repeats, in order of first use.
+func uniqueTerms(s string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, t := range tokenize(s) {
+		if !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// stem strips one common English suffix so "handled", "handler" and
+// "handlers" meet. Crude, but both sides of the match go through it.
+func stem(w string) string {
+	for _, suffix := range []string{"ing", "ers", "ed", "er", "es", "s"} {
+		if len(w) > len(suffix)+2 && strings.HasSuffix(w, suffix) {
+			return strings.TrimSuffix(w, suffix)
+		}
+	}
+	return w
+}
+
+// stopWords are question words too common to rank by, including the
+// verbs questions about code are phrased with ("where is X handled?"),
+// which would otherwise pull in every handler. Only these exact forms
+// are dropped, so "handler" in a summary still counts. German ones are
+// included because the UI is German and so are many questions.
+var stopWords = map[string]bool{
+	"defined": true, "done": true, "handle": true, "handled": true, "happen": true,
+	"happens": true, "implemented": true, "located": true, "used": true,
+	"an": true, "and": true, "are": true, "as": true, "at": true, "be": true,
+	"by": true, "can": true, "do": true, "does": true, "for": true, "from": true,
+	"how": true, "in": true, "is": true, "it": true, "its": true, "of": true,
+	"on": true, "or": true, "the": true, "this": true, "that": true, "to": true,
+	"was": true, "what": true, "when": true, "where": true, "which": true,
+	"who": true, "why": true, "with": true,
+	"das": true, "der": true, "die": true, "ein": true, "eine": true, "ist": true,
+	"im": true, "mit": true, "und": true, "von": true, "wie": true, "wird": true,
+	"wo": true, "passiert": true, "behandelt": true, "implementiert": true,
+}
@@ -2,6 +2,7 @@ package application
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
@@ -13,24 +14,42 @@ import (
 // created BEFORE enqueue so the workflow's first step can load it by
 // SummaryID. If enqueue fails, the use case marks the aggregate as
 // failed so the row does not linger in `pending` forever.
+//
+// Quota, when set, is checked before anything is persisted; a user over
+// a limit gets a *QuotaExceededError and no row.
+//
+// An incremental request builds on the user's latest completed run of
+// the same repository: the workflow carries over the summaries of
+// files that haven't changed since. Without such a run it is a full
+// run.
 type SummarizeRepo struct {
 	Store    Store
 	Enqueuer HatchetEnqueuer
+	Quota    *Quota
 }
 
 // SummarizeRepoInput is the wire-level request. The use case is
-// responsible for validating RepoURL — handlers MUST NOT pre-validate.
+// responsible for validating RepoURL, Ref and the filter — handlers
+// MUST NOT pre-validate. Ref, Include, Exclude and Subdir are optional;
+// see ai.GitRef and ai.FileFilter.
 type SummarizeRepoInput struct {
-	UserID  shared.UserID
-	RepoURL string
+	UserID      shared.UserID
+	RepoURL     string
+	Ref         string
+	Include     []string
+	Exclude     []string
+	Subdir      string
+	Incremental bool
 }
 
 // SummarizeRepoOutput is returned to the HTTP layer; the RunID is the
 // Hatchet workflow run ID and the SummaryID is the aggregate ID used
-// for subsequent reads.
+// for subsequent reads. BaseID is the run an incremental request
+// builds on; 0 for a full run.
 type SummarizeRepoOutput struct {
 	SummaryID uint
 	RunID     string
+	BaseID    uint
 }
 
 func (uc SummarizeRepo) Execute(ctx context.Context, in SummarizeRepoInput) (SummarizeRepoOutput, error) {
@@ -38,27 +57,67 @@ func (uc SummarizeRepo) Execute(ctx context.Context, in SummarizeRepoInput) (Sum
 	if err != nil {
 		return SummarizeRepoOutput{}, fmt.Errorf("invalid repo url: %w", err)
 	}
+	ref, err := ai.NewGitRef(in.Ref)
+	if err != nil {
+		return SummarizeRepoOutput{}, fmt.Errorf("invalid ref: %w", err)
+	}
+	filter, err := ai.NewFileFilter(in.Include, in.Exclude, in.Subdir)
+	if err != nil {
+		return SummarizeRepoOutput{}, fmt.Errorf("invalid file filter: %w", err)
+	}
+	if err := uc.Quota.Check(ctx, in.UserID); err != nil {
+		return SummarizeRepoOutput{}, err
+	}
 	agg := ai.NewRepoSummary(in.UserID, url)
+	agg.Filter = filter
+	agg.Ref = ref
+	if in.Incremental {
+		base, err := uc.Store.LatestCompleted(ctx, in.UserID, url)
+		switch {
+		case err == nil:
+			agg.BaseID = base.ID
+		case !errors.Is(err, ErrNotFound):
+			return SummarizeRepoOutput{}, fmt.Errorf("find base run: %w", err)
+		}
+	}
 	if err := uc.Store.Create(ctx, agg); err != nil {
 		return SummarizeRepoOutput{}, fmt.Errorf("store create: %w", err)
 	}
 
-	runID, err := uc.Enqueuer.EnqueueSummarizeRepo(ctx, EnqueueSummarizeRepoInput{
+	runID, err := enqueueRun(ctx, uc.Store, uc.Enqueuer, agg)
+	if err != nil {
+		return SummarizeRepoOutput{}, err
+	}
+	return SummarizeRepoOutput{SummaryID: agg.ID, RunID: runID, BaseID: agg.BaseID}, nil
+}
+
+// enqueueRun starts the workflow for a freshly created aggregate. If
+// enqueue fails the aggregate is marked failed so the row does not sit
+// in `pending`; on success the engine's run ID is recorded on it.
+func enqueueRun(ctx context.Context, store Store, enqueuer HatchetEnqueuer, agg *ai.RepoSummary) (string, error) {
+	runID, err := enqueuer.EnqueueSummarizeRepo(ctx, EnqueueSummarizeRepoInput{
 		SummaryID: agg.ID,
 		UserID:    agg.UserID,
 		RepoURL:   agg.RepoURL,
+		Filter:    agg.Filter,
+		Ref:       agg.Ref,
 	})
 	if err != nil {
 		// Best-effort: mark the row failed so it doesn't sit in `pending`.
-		// We deliberately ignore Save errors here — the original error is
-		// more useful to the caller.
+		// We deliberately ignore store errors here — the original error
+		// is more useful to the caller.
 		if markErr := agg.MarkFailed("workflow enqueue failed: "+err.Error(), nowFn()); markErr == nil {
-			_ = uc.Store.Save(ctx, agg)
+			_ = store.Transition(ctx, agg, ai.StatusPending)
 		}
-		return SummarizeRepoOutput{}, fmt.Errorf("enqueue workflow: %w", err)
+		return "", fmt.Errorf("enqueue workflow: %w", err)
 	}
 
-	return SummarizeRepoOutput{SummaryID: agg.ID, RunID: runID}, nil
+	// Remember the engine handle so CancelSummary can stop the run.
+	// Best-effort: the run is already enqueued, and without the ID a
+	// cancel still lands through the status check in every step.
+	agg.AttachRun(runID)
+	_ = store.AttachRun(ctx, agg.ID, runID)
+	return runID, nil
 }
 
 // ListUserSummaries returns the requesting user's recent summary runs,
@@ -116,3 +175,123 @@ type DeleteUserSummaryInput struct {
 func (uc DeleteUserSummary) Execute(ctx context.Context, in DeleteUserSummaryInput) error {
 	return uc.Store.Delete(ctx, in.UserID, in.SummaryID)
 }
+
+// CancelSummary stops a pending or running run owned by the caller.
+// The aggregate is marked cancelled BEFORE the engine is asked to stop
+// the run: the persisted status is what in-flight steps check before
+// calling the LLM, so the run winds down even if the engine call is
+// lost. Returns ErrNotFound for missing rows AND cross-user cancels,
+// and ErrAlreadyTerminal when the run has already finished.
+type CancelSummary struct {
+	Store    Store
+	Enqueuer HatchetEnqueuer
+	Progress ProgressPublisher // optional; nil skips the SSE notification
+}
+
+type CancelSummaryInput struct {
+	UserID    shared.UserID
+	SummaryID uint
+}
+
+func (uc CancelSummary) Execute(ctx context.Context, in CancelSummaryInput) (*ai.RepoSummary, error) {
+	agg, err := uc.Store.GetByID(ctx, in.SummaryID)
+	if err != nil {
+		return nil, err
+	}
+	if agg.UserID != in.UserID {
+		return nil, ErrNotFound
+	}
+	if agg.Status.IsTerminal() {
+		return nil, ErrAlreadyTerminal
+	}
+	if err := agg.MarkCancelled(nowFn()); err != nil {
+		return nil, fmt.Errorf("mark cancelled: %w", err)
+	}
+	events := agg.PullEvents()
+	// Conditional: the run may have finished since it was loaded.
+	err = uc.Store.Transition(ctx, agg, ai.StatusPending, ai.StatusRunning)
+	if errors.Is(err, ErrStatusChanged) {
+		return nil, ErrAlreadyTerminal
+	}
+	if err != nil {
+		return nil, fmt.Errorf("store transition: %w", err)
+	}
+	if uc.Progress != nil {
+		_ = uc.Progress.Publish(ctx, events...)
+	}
+
+	if agg.RunID != "" {
+		if err := uc.Enqueuer.CancelRun(ctx, agg.RunID); err != nil {
+			return agg, fmt.Errorf("cancel workflow run: %w", err)
+		}
+	}
+	return agg, nil
+}
+
+// RetrySummary starts a new attempt for a failed run owned by the
+// caller. The attempt is a fresh aggregate linked to the failed one;
+// the workflow reuses the file summaries that attempt already produced,
+// so only the missing files and the overview go back to the LLM.
+// Returns ErrNotFound for missing rows AND cross-user retries, and
+// ErrNotRetryable unless the run failed. A retry is a new run, so it
+// counts against Quota like any other.
+type RetrySummary struct {
+	Store    Store
+	Enqueuer HatchetEnqueuer
+	Quota    *Quota
+}
+
+type RetrySummaryInput struct {
+	UserID    shared.UserID
+	SummaryID uint
+}
+
+func (uc RetrySummary) Execute(ctx context.Context, in RetrySummaryInput) (SummarizeRepoOutput, error) {
+	prev, err := uc.Store.GetByID(ctx, in.SummaryID)
+	if err != nil {
+		return SummarizeRepoOutput{}, err
+	}
+	if prev.UserID != in.UserID {
+		return SummarizeRepoOutput{}, ErrNotFound
+	}
+	if prev.Status != ai.StatusFailed {
+		return SummarizeRepoOutput{}, ErrNotRetryable
+	}
+	agg, err := ai.NewRetryAttempt(prev)
+	if err != nil {
+		return SummarizeRepoOutput{}, err
+	}
+	if err := uc.Quota.Check(ctx, in.UserID); err != nil {
+		return SummarizeRepoOutput{}, err
+	}
+	if err := uc.Store.Create(ctx, agg); err != nil {
+		return SummarizeRepoOutput{}, fmt.Errorf("store create: %w", err)
+	}
+	runID, err := enqueueRun(ctx, uc.Store, uc.Enqueuer, agg)
+	if err != nil {
+		return SummarizeRepoOutput{}, err
+	}
+	return SummarizeRepoOutput{SummaryID: agg.ID, RunID: runID, BaseID: agg.BaseID}, nil
+}
+
+// GetAttemptHistory lists every attempt in the retry chain of a run the
+// caller has already loaded through GetRepoSummary, oldest first.
+type GetAttemptHistory struct {
+	Store Store
+}
+
+func (uc GetAttemptHistory) Execute(ctx context.Context, agg *ai.RepoSummary) ([]*ai.RepoSummary, error) {
+	attempts, err := uc.Store.ListAttempts(ctx, agg.ChainID())
+	if err != nil {
+		return nil, err
+	}
+	// Attempts are created from their predecessor, so they always share
+	// its owner; filter anyway rather than trust the link column.
+	out := attempts[:0]
+	for _, a := range attempts {
+		if a.UserID == agg.UserID {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
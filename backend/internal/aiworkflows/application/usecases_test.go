@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	aiapp "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/application"
 	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
@@ -12,13 +13,16 @@ import (
 
 // fakeStore is an in-memory Store implementation for unit tests.
 type fakeStore struct {
-	rows        map[uint]*ai.RepoSummary
-	nextID      uint
-	createErr   error
-	getErr      error
-	saveErr     error
-	createCalls int
-	saveCalls   int
+	rows      map[uint]*ai.RepoSummary
+	nextID    uint
+	createErr error
+	getErr    error
+	// transitionErr fails Transition, as the store does when the row's
+	// status changed after it was loaded.
+	transitionErr   error
+	createCalls     int
+	transitionCalls int
+	usage           aiapp.UserUsage
 }
 
 func newFakeStore() *fakeStore {
@@ -36,15 +40,83 @@ func (s *fakeStore) Create(_ context.Context, agg *ai.RepoSummary) error {
 	return nil
 }
 
-func (s *fakeStore) Save(_ context.Context, agg *ai.RepoSummary) error {
-	s.saveCalls++
-	if s.saveErr != nil {
-		return s.saveErr
+func (s *fakeStore) AttachRun(_ context.Context, id uint, runID string) error {
+	if row, ok := s.rows[id]; ok {
+		row.AttachRun(runID)
 	}
+	return nil
+}
+
+// Transition doesn't check from: the rows share their pointers with
+// the callers, which have already applied the change.
+func (s *fakeStore) Transition(_ context.Context, agg *ai.RepoSummary, _ ...ai.Status) error {
+	s.transitionCalls++
+	if s.transitionErr != nil {
+		return s.transitionErr
+	}
+	s.rows[agg.ID] = agg
+	return nil
+}
+
+func (s *fakeStore) AppendFiles(_ context.Context, id uint, files []ai.FileSummary) error {
+	row, ok := s.rows[id]
+	if !ok || row.Status != ai.StatusRunning {
+		return aiapp.ErrStatusChanged
+	}
+	for _, fs := range files {
+		_ = row.AppendFileSummary(fs, 0)
+	}
+	row.PullEvents()
+	return nil
+}
+
+func (s *fakeStore) AddUsage(_ context.Context, id uint, u ai.TokenUsage) error {
+	if row, ok := s.rows[id]; ok {
+		row.RecordUsage(u)
+	}
+	return nil
+}
+
+func (s *fakeStore) Complete(_ context.Context, agg *ai.RepoSummary, _ ai.TokenUsage) error {
 	s.rows[agg.ID] = agg
 	return nil
 }
 
+func (s *fakeStore) RecordChangelog(_ context.Context, id uint, c ai.Changelog) error {
+	if row, ok := s.rows[id]; ok {
+		row.RecordChangelog(c)
+	}
+	return nil
+}
+
+func (s *fakeStore) RecordCommit(_ context.Context, id uint, sha string, at time.Time) error {
+	if row, ok := s.rows[id]; ok {
+		row.RecordCommit(sha, at)
+	}
+	return nil
+}
+
+func (s *fakeStore) RecordDirectories(_ context.Context, id uint, tree ai.DirectorySummary) error {
+	if row, ok := s.rows[id]; ok {
+		row.RecordDirectories(tree)
+	}
+	return nil
+}
+
+func (s *fakeStore) RecordSelection(_ context.Context, id uint, sel ai.FileSelection) error {
+	if row, ok := s.rows[id]; ok {
+		row.RecordSelection(sel)
+	}
+	return nil
+}
+
+func (s *fakeStore) RecordStepDuration(_ context.Context, id uint, step string, ms int64) error {
+	if row, ok := s.rows[id]; ok {
+		row.RecordStepDuration(step, ms)
+	}
+	return nil
+}
+
 func (s *fakeStore) GetByID(_ context.Context, id uint) (*ai.RepoSummary, error) {
 	if s.getErr != nil {
 		return nil, s.getErr
@@ -78,12 +150,37 @@ func (s *fakeStore) Delete(_ context.Context, userID shared.UserID, id uint) err
 	return nil
 }
 
+func (s *fakeStore) ListAttempts(_ context.Context, originalID uint) ([]*ai.RepoSummary, error) {
+	var out []*ai.RepoSummary
+	for id := uint(1); id < s.nextID; id++ {
+		if row, ok := s.rows[id]; ok && (row.ID == originalID || row.OriginalID == originalID) {
+			out = append(out, row)
+		}
+	}
+	return out, nil
+}
+
+func (s *fakeStore) LatestCompleted(_ context.Context, userID shared.UserID, url ai.RepoURL) (*ai.RepoSummary, error) {
+	for id := s.nextID - 1; id > 0; id-- {
+		if row, ok := s.rows[id]; ok && row.UserID == userID && row.RepoURL == url && row.Status == ai.StatusCompleted {
+			return row, nil
+		}
+	}
+	return nil, aiapp.ErrNotFound
+}
+
+func (s *fakeStore) UserUsage(context.Context, shared.UserID, time.Time, time.Time) (aiapp.UserUsage, error) {
+	return s.usage, nil
+}
+
 // fakeEnqueuer is a stub HatchetEnqueuer.
 type fakeEnqueuer struct {
-	runID string
-	err   error
-	calls int
-	last  aiapp.EnqueueSummarizeRepoInput
+	runID     string
+	err       error
+	calls     int
+	last      aiapp.EnqueueSummarizeRepoInput
+	cancelErr error
+	cancelled []string
 }
 
 func (e *fakeEnqueuer) EnqueueSummarizeRepo(_ context.Context, in aiapp.EnqueueSummarizeRepoInput) (string, error) {
@@ -92,6 +189,23 @@ func (e *fakeEnqueuer) EnqueueSummarizeRepo(_ context.Context, in aiapp.EnqueueS
 	return e.runID, e.err
 }
 
+func (e *fakeEnqueuer) CancelRun(_ context.Context, runID string) error {
+	e.cancelled = append(e.cancelled, runID)
+	return e.cancelErr
+}
+
+// fakeProgress records published domain events.
+type fakeProgress struct {
+	events []shared.DomainEvent
+}
+
+func (p *fakeProgress) Publish(_ context.Context, events ...shared.DomainEvent) error {
+	p.events = append(p.events, events...)
+	return nil
+}
+
+func (p *fakeProgress) PublishStep(context.Context, aiapp.StepProgress) {}
+
 func uid(t *testing.T, s string) shared.UserID {
 	t.Helper()
 	u, err := shared.NewUserID(s)
@@ -132,6 +246,9 @@ func TestSummarizeRepo_HappyPath(t *testing.T) {
 	if enq.last.RepoURL != "https://github.com/owner/repo" {
 		t.Errorf("Enqueue RepoURL = %q", enq.last.RepoURL)
 	}
+	if got := store.rows[out.SummaryID].RunID; got != "run-123" {
+		t.Errorf("persisted RunID = %q, want run-123", got)
+	}
 }
 
 func TestSummarizeRepo_InvalidURL(t *testing.T) {
@@ -164,6 +281,70 @@ func TestSummarizeRepo_InvalidURL(t *testing.T) {
 	}
 }
 
+func TestSummarizeRepo_FileFilter(t *testing.T) {
+	t.Parallel()
+	store := newFakeStore()
+	enq := &fakeEnqueuer{runID: "run-1"}
+	uc := aiapp.SummarizeRepo{Store: store, Enqueuer: enq}
+	out, err := uc.Execute(context.Background(), aiapp.SummarizeRepoInput{
+		UserID:  uid(t, "user-1"),
+		RepoURL: "https://github.com/owner/repo",
+		Exclude: []string{"**/*_gen.go"},
+		Subdir:  "./backend/",
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if enq.last.Filter.Subdir != "backend" || len(enq.last.Filter.Exclude) != 1 {
+		t.Errorf("enqueued filter = %+v", enq.last.Filter)
+	}
+	if got := store.rows[out.SummaryID].Filter.Subdir; got != "backend" {
+		t.Errorf("persisted subdir = %q", got)
+	}
+
+	_, err = uc.Execute(context.Background(), aiapp.SummarizeRepoInput{
+		UserID:  uid(t, "user-1"),
+		RepoURL: "https://github.com/owner/repo",
+		Subdir:  "../outside",
+	})
+	if err == nil || store.createCalls != 1 {
+		t.Errorf("escaping subdir: err = %v, creates = %d; want an error and no new row", err, store.createCalls)
+	}
+}
+
+func TestSummarizeRepo_Incremental(t *testing.T) {
+	t.Parallel()
+	store := newFakeStore()
+	owner := uid(t, "user-1")
+	uc := aiapp.SummarizeRepo{Store: store, Enqueuer: &fakeEnqueuer{runID: "run-1"}}
+	in := aiapp.SummarizeRepoInput{UserID: owner, RepoURL: "https://github.com/owner/repo", Incremental: true}
+
+	// Nothing to build on yet: a full run.
+	out, err := uc.Execute(context.Background(), in)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if out.BaseID != 0 || store.rows[out.SummaryID].BaseID != 0 {
+		t.Errorf("BaseID = %d, want a full run", out.BaseID)
+	}
+
+	done := newCompletedSummary(t, store, owner)
+	newRunningSummary(t, store, owner, "run-still-going")
+	newCompletedSummary(t, store, uid(t, "user-2"))
+	out, err = uc.Execute(context.Background(), in)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if out.BaseID != done.ID || store.rows[out.SummaryID].BaseID != done.ID {
+		t.Errorf("BaseID = %d, want the user's completed run %d", out.BaseID, done.ID)
+	}
+
+	in.Incremental = false
+	if out, _ = uc.Execute(context.Background(), in); out.BaseID != 0 {
+		t.Errorf("BaseID = %d without incremental, want 0", out.BaseID)
+	}
+}
+
 func TestSummarizeRepo_StoreCreateError(t *testing.T) {
 	t.Parallel()
 	store := newFakeStore()
@@ -196,12 +377,12 @@ func TestSummarizeRepo_EnqueueErrorMarksFailed(t *testing.T) {
 	if err == nil {
 		t.Fatalf("expected error")
 	}
-	// One row was created and then Save was called to mark it failed.
+	// One row was created and then marked failed.
 	if len(store.rows) != 1 {
 		t.Errorf("expected 1 row in store, got %d", len(store.rows))
 	}
-	if store.saveCalls != 1 {
-		t.Errorf("Save calls = %d, want 1 (mark-failed path)", store.saveCalls)
+	if store.transitionCalls != 1 {
+		t.Errorf("Transition calls = %d, want 1 (mark-failed path)", store.transitionCalls)
 	}
 	for _, row := range store.rows {
 		if row.Status != ai.StatusFailed {
@@ -262,3 +443,341 @@ func TestGetRepoSummary_MissingReturns404(t *testing.T) {
 		t.Errorf("err = %v, want ErrNotFound", err)
 	}
 }
+
+func newRunningSummary(t *testing.T, store *fakeStore, owner shared.UserID, runID string) *ai.RepoSummary {
+	t.Helper()
+	url, _ := ai.NewRepoURL("https://github.com/owner/repo")
+	agg := ai.NewRepoSummary(owner, url)
+	_ = store.Create(context.Background(), agg)
+	agg.AttachRun(runID)
+	if err := agg.MarkStarted(time.Now()); err != nil {
+		t.Fatalf("MarkStarted: %v", err)
+	}
+	agg.PullEvents()
+	return agg
+}
+
+func TestCancelSummary_HappyPath(t *testing.T) {
+	t.Parallel()
+	store := newFakeStore()
+	enq := &fakeEnqueuer{}
+	progress := &fakeProgress{}
+	owner := uid(t, "user-1")
+	agg := newRunningSummary(t, store, owner, "run-123")
+
+	uc := aiapp.CancelSummary{Store: store, Enqueuer: enq, Progress: progress}
+	got, err := uc.Execute(context.Background(), aiapp.CancelSummaryInput{UserID: owner, SummaryID: agg.ID})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if got.Status != ai.StatusCancelled {
+		t.Errorf("status = %s, want cancelled", got.Status)
+	}
+	if store.transitionCalls != 1 {
+		t.Errorf("Transition calls = %d, want 1", store.transitionCalls)
+	}
+	if len(enq.cancelled) != 1 || enq.cancelled[0] != "run-123" {
+		t.Errorf("CancelRun calls = %v, want [run-123]", enq.cancelled)
+	}
+	if len(progress.events) != 1 {
+		t.Fatalf("published %d events, want 1", len(progress.events))
+	}
+	if _, ok := progress.events[0].(ai.SummaryCancelled); !ok {
+		t.Errorf("published %T, want SummaryCancelled", progress.events[0])
+	}
+}
+
+func TestCancelSummary_OwnershipMismatchReturns404(t *testing.T) {
+	t.Parallel()
+	store := newFakeStore()
+	enq := &fakeEnqueuer{}
+	agg := newRunningSummary(t, store, uid(t, "user-1"), "run-123")
+
+	uc := aiapp.CancelSummary{Store: store, Enqueuer: enq}
+	_, err := uc.Execute(context.Background(), aiapp.CancelSummaryInput{UserID: uid(t, "other"), SummaryID: agg.ID})
+	if !errors.Is(err, aiapp.ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound", err)
+	}
+	if agg.Status != ai.StatusRunning || len(enq.cancelled) != 0 {
+		t.Errorf("cross-user cancel must not touch the run")
+	}
+}
+
+func TestCancelSummary_TerminalRunConflicts(t *testing.T) {
+	t.Parallel()
+	store := newFakeStore()
+	enq := &fakeEnqueuer{}
+	owner := uid(t, "user-1")
+	agg := newRunningSummary(t, store, owner, "run-123")
+	_ = agg.MarkCompleted("done", time.Now())
+
+	uc := aiapp.CancelSummary{Store: store, Enqueuer: enq}
+	_, err := uc.Execute(context.Background(), aiapp.CancelSummaryInput{UserID: owner, SummaryID: agg.ID})
+	if !errors.Is(err, aiapp.ErrAlreadyTerminal) {
+		t.Errorf("err = %v, want ErrAlreadyTerminal", err)
+	}
+	if len(enq.cancelled) != 0 {
+		t.Errorf("CancelRun should not be called for a finished run")
+	}
+}
+
+func TestCancelSummary_RunFinishedMeanwhileConflicts(t *testing.T) {
+	t.Parallel()
+	store := newFakeStore()
+	store.transitionErr = aiapp.ErrStatusChanged
+	enq := &fakeEnqueuer{}
+	owner := uid(t, "user-1")
+	agg := newRunningSummary(t, store, owner, "run-123")
+
+	uc := aiapp.CancelSummary{Store: store, Enqueuer: enq}
+	_, err := uc.Execute(context.Background(), aiapp.CancelSummaryInput{UserID: owner, SummaryID: agg.ID})
+	if !errors.Is(err, aiapp.ErrAlreadyTerminal) {
+		t.Errorf("err = %v, want ErrAlreadyTerminal", err)
+	}
+	if len(enq.cancelled) != 0 {
+		t.Errorf("CancelRun should not be called for a finished run")
+	}
+}
+
+func TestCancelSummary_EngineErrorStillCancelsRow(t *testing.T) {
+	t.Parallel()
+	store := newFakeStore()
+	enq := &fakeEnqueuer{cancelErr: errors.New("hatchet unavailable")}
+	owner := uid(t, "user-1")
+	agg := newRunningSummary(t, store, owner, "run-123")
+
+	uc := aiapp.CancelSummary{Store: store, Enqueuer: enq}
+	got, err := uc.Execute(context.Background(), aiapp.CancelSummaryInput{UserID: owner, SummaryID: agg.ID})
+	if err == nil {
+		t.Fatalf("expected engine error to surface")
+	}
+	if got == nil || got.Status != ai.StatusCancelled {
+		t.Errorf("row must be cancelled even when the engine call fails, got %+v", got)
+	}
+}
+
+func TestCancelSummary_WithoutRunIDSkipsEngine(t *testing.T) {
+	t.Parallel()
+	store := newFakeStore()
+	enq := &fakeEnqueuer{}
+	owner := uid(t, "user-1")
+	agg := newRunningSummary(t, store, owner, "")
+
+	uc := aiapp.CancelSummary{Store: store, Enqueuer: enq}
+	if _, err := uc.Execute(context.Background(), aiapp.CancelSummaryInput{UserID: owner, SummaryID: agg.ID}); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if len(enq.cancelled) != 0 {
+		t.Errorf("CancelRun called without a run ID: %v", enq.cancelled)
+	}
+}
+
+func newFailedSummary(t *testing.T, store *fakeStore, owner shared.UserID) *ai.RepoSummary {
+	t.Helper()
+	agg := newRunningSummary(t, store, owner, "run-1")
+	if err := agg.MarkFailed("workflow failure", time.Now()); err != nil {
+		t.Fatalf("MarkFailed: %v", err)
+	}
+	agg.PullEvents()
+	return agg
+}
+
+func TestRetrySummary_HappyPath(t *testing.T) {
+	t.Parallel()
+	store := newFakeStore()
+	enq := &fakeEnqueuer{runID: "run-2"}
+	owner := uid(t, "user-1")
+	failed := newFailedSummary(t, store, owner)
+
+	uc := aiapp.RetrySummary{Store: store, Enqueuer: enq}
+	out, err := uc.Execute(context.Background(), aiapp.RetrySummaryInput{UserID: owner, SummaryID: failed.ID})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if out.SummaryID == failed.ID || out.RunID != "run-2" {
+		t.Errorf("out = %+v, want a new attempt on run-2", out)
+	}
+	next := store.rows[out.SummaryID]
+	if next.Status != ai.StatusPending || next.RetryOf != failed.ID || next.OriginalID != failed.ID {
+		t.Errorf("attempt = %+v, want pending and linked to %d", next, failed.ID)
+	}
+	if next.RunID != "run-2" {
+		t.Errorf("RunID = %q, want run-2", next.RunID)
+	}
+	if enq.last.SummaryID != out.SummaryID {
+		t.Errorf("enqueued summary %d, want %d", enq.last.SummaryID, out.SummaryID)
+	}
+	if failed.Status != ai.StatusFailed {
+		t.Errorf("original status = %s, want it left failed", failed.Status)
+	}
+}
+
+func TestRetrySummary_OnlyFailedRuns(t *testing.T) {
+	t.Parallel()
+	store := newFakeStore()
+	enq := &fakeEnqueuer{}
+	owner := uid(t, "user-1")
+	running := newRunningSummary(t, store, owner, "run-1")
+
+	uc := aiapp.RetrySummary{Store: store, Enqueuer: enq}
+	_, err := uc.Execute(context.Background(), aiapp.RetrySummaryInput{UserID: owner, SummaryID: running.ID})
+	if !errors.Is(err, aiapp.ErrNotRetryable) {
+		t.Errorf("err = %v, want ErrNotRetryable", err)
+	}
+	if enq.calls != 0 || store.createCalls != 1 {
+		t.Errorf("enqueue calls = %d, create calls = %d; want no new attempt", enq.calls, store.createCalls)
+	}
+}
+
+func TestRetrySummary_OwnershipMismatchReturns404(t *testing.T) {
+	t.Parallel()
+	store := newFakeStore()
+	failed := newFailedSummary(t, store, uid(t, "user-1"))
+
+	uc := aiapp.RetrySummary{Store: store, Enqueuer: &fakeEnqueuer{}}
+	_, err := uc.Execute(context.Background(), aiapp.RetrySummaryInput{UserID: uid(t, "user-2"), SummaryID: failed.ID})
+	if !errors.Is(err, aiapp.ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRetrySummary_EnqueueErrorMarksAttemptFailed(t *testing.T) {
+	t.Parallel()
+	store := newFakeStore()
+	enq := &fakeEnqueuer{err: errors.New("engine down")}
+	owner := uid(t, "user-1")
+	failed := newFailedSummary(t, store, owner)
+
+	uc := aiapp.RetrySummary{Store: store, Enqueuer: enq}
+	if _, err := uc.Execute(context.Background(), aiapp.RetrySummaryInput{UserID: owner, SummaryID: failed.ID}); err == nil {
+		t.Fatalf("expected enqueue error")
+	}
+	attempt := store.rows[failed.ID+1]
+	if attempt == nil || attempt.Status != ai.StatusFailed {
+		t.Errorf("attempt = %+v, want it marked failed", attempt)
+	}
+}
+
+func TestGetAttemptHistory_ReturnsWholeChain(t *testing.T) {
+	t.Parallel()
+	store := newFakeStore()
+	enq := &fakeEnqueuer{runID: "run-2"}
+	owner := uid(t, "user-1")
+	first := newFailedSummary(t, store, owner)
+	_ = newFailedSummary(t, store, owner) // unrelated run
+
+	out, err := aiapp.RetrySummary{Store: store, Enqueuer: enq}.Execute(context.Background(), aiapp.RetrySummaryInput{UserID: owner, SummaryID: first.ID})
+	if err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+
+	// The history is the same whichever attempt it is asked for.
+	for _, id := range []uint{first.ID, out.SummaryID} {
+		history, err := aiapp.GetAttemptHistory{Store: store}.Execute(context.Background(), store.rows[id])
+		if err != nil {
+			t.Fatalf("Execute: %v", err)
+		}
+		if len(history) != 2 || history[0].ID != first.ID || history[1].ID != out.SummaryID {
+			t.Errorf("history for %d = %v, want [%d %d]", id, history, first.ID, out.SummaryID)
+		}
+	}
+}
+
+func TestQuota_Check(t *testing.T) {
+	t.Parallel()
+	limits := aiapp.QuotaLimits{MaxConcurrentRuns: 2, MaxRunsPerDay: 5, MonthlyTokenBudget: 1000}
+	cases := []struct {
+		name      string
+		usage     aiapp.UserUsage
+		wantLimit aiapp.QuotaLimit
+		maxWait   time.Duration
+	}{
+		{"under every limit", aiapp.UserUsage{ActiveRuns: 1, RunsToday: 4, TokensThisMonth: 999}, "", 0},
+		{"too many running", aiapp.UserUsage{ActiveRuns: 2}, aiapp.QuotaConcurrentRuns, time.Minute},
+		{"daily runs used up", aiapp.UserUsage{RunsToday: 5}, aiapp.QuotaRunsPerDay, 24 * time.Hour},
+		{"token budget spent", aiapp.UserUsage{TokensThisMonth: 1000}, aiapp.QuotaMonthlyTokens, 31 * 24 * time.Hour},
+		// The budget outlasts the daily reset, so it is what gets reported.
+		{"budget and daily both hit", aiapp.UserUsage{RunsToday: 5, TokensThisMonth: 1200}, aiapp.QuotaMonthlyTokens, 31 * 24 * time.Hour},
+	}
+	for _, tc := range cases {
+		store := newFakeStore()
+		store.usage = tc.usage
+		q := &aiapp.Quota{Store: store, Limits: limits}
+
+		err := q.Check(context.Background(), uid(t, "user-1"))
+		if tc.wantLimit == "" {
+			if err != nil {
+				t.Errorf("%s: err = %v, want nil", tc.name, err)
+			}
+			continue
+		}
+		var qe *aiapp.QuotaExceededError
+		if !errors.As(err, &qe) || !errors.Is(err, aiapp.ErrQuotaExceeded) {
+			t.Errorf("%s: err = %v, want *QuotaExceededError", tc.name, err)
+			continue
+		}
+		if qe.Limit != tc.wantLimit {
+			t.Errorf("%s: limit = %s, want %s", tc.name, qe.Limit, tc.wantLimit)
+		}
+		if qe.RetryAfter <= 0 || qe.RetryAfter > tc.maxWait {
+			t.Errorf("%s: RetryAfter = %s, want within (0, %s]", tc.name, qe.RetryAfter, tc.maxWait)
+		}
+	}
+}
+
+func TestQuota_ZeroLimitsAreUnlimited(t *testing.T) {
+	t.Parallel()
+	store := newFakeStore()
+	store.usage = aiapp.UserUsage{ActiveRuns: 100, RunsToday: 100, TokensThisMonth: 1 << 30}
+	q := &aiapp.Quota{Store: store}
+	if err := q.Check(context.Background(), uid(t, "user-1")); err != nil {
+		t.Errorf("err = %v, want nil with no limits configured", err)
+	}
+}
+
+func TestSummarizeRepo_QuotaExceededCreatesNoRun(t *testing.T) {
+	t.Parallel()
+	store := newFakeStore()
+	store.usage = aiapp.UserUsage{RunsToday: 3}
+	enq := &fakeEnqueuer{runID: "run-1"}
+	uc := aiapp.SummarizeRepo{
+		Store:    store,
+		Enqueuer: enq,
+		Quota:    &aiapp.Quota{Store: store, Limits: aiapp.QuotaLimits{MaxRunsPerDay: 3}},
+	}
+
+	_, err := uc.Execute(context.Background(), aiapp.SummarizeRepoInput{UserID: uid(t, "user-1"), RepoURL: "https://github.com/owner/repo"})
+	if !errors.Is(err, aiapp.ErrQuotaExceeded) {
+		t.Fatalf("err = %v, want ErrQuotaExceeded", err)
+	}
+	if store.createCalls != 0 || enq.calls != 0 {
+		t.Errorf("create calls = %d, enqueue calls = %d; want none", store.createCalls, enq.calls)
+	}
+}
+
+func TestSummarizeRepo_Ref(t *testing.T) {
+	t.Parallel()
+	store := newFakeStore()
+	enq := &fakeEnqueuer{runID: "run-1"}
+	uc := aiapp.SummarizeRepo{Store: store, Enqueuer: enq}
+	out, err := uc.Execute(context.Background(), aiapp.SummarizeRepoInput{
+		UserID:  uid(t, "user-1"),
+		RepoURL: "https://github.com/owner/repo",
+		Ref:     "release/1.2",
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if enq.last.Ref != "release/1.2" || store.rows[out.SummaryID].Ref != "release/1.2" {
+		t.Errorf("enqueued ref = %q, persisted ref = %q", enq.last.Ref, store.rows[out.SummaryID].Ref)
+	}
+
+	_, err = uc.Execute(context.Background(), aiapp.SummarizeRepoInput{
+		UserID:  uid(t, "user-1"),
+		RepoURL: "https://github.com/owner/repo",
+		Ref:     "--upload-pack=evil",
+	})
+	if err == nil || store.createCalls != 1 {
+		t.Errorf("option-like ref: err = %v, creates = %d; want an error and no new row", err, store.createCalls)
+	}
+}
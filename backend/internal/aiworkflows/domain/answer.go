@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	shared "github.com/atilladeniz/next-go-pg/backend/internal/shared/domain"
+)
+
+// MaxQuestionLength bounds a question in characters. Questions are
+// matched against summaries and sent to the LLM verbatim; anything
+// longer is a document, not a question.
+const MaxQuestionLength = 500
+
+// ErrInvalidQuestion is returned by NewQuestion for empty or overlong
+// questions.
+var ErrInvalidQuestion = errors.New("invalid question")
+
+// NewQuestion trims q and checks it is a question we can ask.
+func NewQuestion(q string) (string, error) {
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return "", fmt.Errorf("%w: empty", ErrInvalidQuestion)
+	}
+	if utf8.RuneCountInString(q) > MaxQuestionLength {
+		return "", fmt.Errorf("%w: longer than %d characters", ErrInvalidQuestion, MaxQuestionLength)
+	}
+	return q, nil
+}
+
+// Answer is one question asked about a completed RepoSummary and the
+// answer it got. Sources are the file and directory paths (directories
+// with a trailing slash) the answer was grounded on, best match first;
+// Citations are the ones the answer actually cites, in order of first
+// mention. Answers are immutable once stored.
+type Answer struct {
+	ID            uint
+	SummaryID     uint
+	UserID        shared.UserID
+	Question      string
+	Text          string
+	Sources       []string
+	Citations     []string
+	Model         string
+	PromptVersion string
+	Usage         TokenUsage
+	CreatedAt     time.Time
+}
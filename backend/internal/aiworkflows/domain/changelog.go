@@ -0,0 +1,54 @@
+package domain
+
+// Changelog is what changed between an incremental run and the
+// completed run it builds on. Paths are repo-relative; Added, Modified
+// and Unchanged are in selection order, Removed in the base run's
+// order. Only Added and Modified files are sent to the LLM again.
+type Changelog struct {
+	// BaseID and BaseCommit identify the run compared against.
+	BaseID     uint
+	BaseCommit string
+	Added      []string
+	Modified   []string
+	// Removed are files the base run summarized that this run didn't —
+	// deleted, or no longer selected.
+	Removed   []string
+	Unchanged []string
+}
+
+// IsZero reports whether no comparison was made: a full run.
+func (c Changelog) IsZero() bool { return c.BaseID == 0 }
+
+// NewChangelog compares the files a run selected against the ones base
+// summarized. blobs maps each selected file to the git blob hash of its
+// content at commit. A file counts as unchanged when base summarized
+// the same blob, or — for base rows from before blob hashes were
+// recorded — when both runs looked at the same commit. Anything else
+// base had is modified.
+func NewChangelog(base *RepoSummary, commit string, files []string, blobs map[string]string) Changelog {
+	c := Changelog{BaseID: base.ID, BaseCommit: base.CommitSHA}
+	sameCommit := commit != "" && commit == base.CommitSHA
+	before := make(map[string]FileSummary, len(base.Files))
+	for _, f := range base.Files {
+		before[f.Filename()] = f
+	}
+	selected := make(map[string]bool, len(files))
+	for _, name := range files {
+		selected[name] = true
+		prev, ok := before[name]
+		switch {
+		case !ok:
+			c.Added = append(c.Added, name)
+		case sameCommit || (prev.BlobHash() != "" && prev.BlobHash() == blobs[name]):
+			c.Unchanged = append(c.Unchanged, name)
+		default:
+			c.Modified = append(c.Modified, name)
+		}
+	}
+	for _, f := range base.Files {
+		if !selected[f.Filename()] {
+			c.Removed = append(c.Removed, f.Filename())
+		}
+	}
+	return c
+}
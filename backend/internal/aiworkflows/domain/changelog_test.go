@@ -0,0 +1,49 @@
+package domain_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
+)
+
+func TestNewChangelog(t *testing.T) {
+	t.Parallel()
+	base := ai.NewRepoSummary(mustUserID(t), mustRepoURL(t, "https://github.com/owner/repo"))
+	base.ID = 3
+	base.CommitSHA = strings.Repeat("a", 40)
+	base.Files = []ai.FileSummary{
+		mustFileSummary(t, "main.go", "Entry point.").WithBlobHash("h-main"),
+		mustFileSummary(t, "handler.go", "Handlers.").WithBlobHash("h-handler"),
+		mustFileSummary(t, "legacy.go", "Old code.").WithBlobHash("h-legacy"),
+		// Written before blob hashes were recorded.
+		mustFileSummary(t, "util.go", "Helpers."),
+	}
+	files := []string{"main.go", "handler.go", "util.go", "new.go"}
+	blobs := map[string]string{"main.go": "h-main", "handler.go": "h-handler-2", "util.go": "h-util", "new.go": "h-new"}
+
+	c := ai.NewChangelog(base, strings.Repeat("b", 40), files, blobs)
+	if c.BaseID != 3 || c.BaseCommit != base.CommitSHA || c.IsZero() {
+		t.Errorf("base = (%d, %q)", c.BaseID, c.BaseCommit)
+	}
+	check := func(name string, got, want []string) {
+		t.Helper()
+		if !slices.Equal(got, want) {
+			t.Errorf("%s = %v, want %v", name, got, want)
+		}
+	}
+	check("Added", c.Added, []string{"new.go"})
+	check("Modified", c.Modified, []string{"handler.go", "util.go"})
+	check("Unchanged", c.Unchanged, []string{"main.go"})
+	check("Removed", c.Removed, []string{"legacy.go"})
+
+	// On the same commit nothing base had can have changed, hash or not.
+	same := ai.NewChangelog(base, base.CommitSHA, files, blobs)
+	check("Unchanged (same commit)", same.Unchanged, []string{"main.go", "handler.go", "util.go"})
+	check("Modified (same commit)", same.Modified, nil)
+
+	if !(ai.Changelog{}).IsZero() {
+		t.Errorf("zero changelog should report IsZero")
+	}
+}
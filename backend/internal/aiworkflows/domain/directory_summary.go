@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// DirectorySummary is one node of a run's architecture outline: a
+// directory summarised from the summaries of the files directly in it
+// and of its subdirectories. Path is repo-relative and slash-separated.
+// The root node has Path "" and no Summary of its own — the run's
+// overview plays that part.
+type DirectorySummary struct {
+	Path     string
+	Summary  string
+	Files    []string
+	Children []DirectorySummary
+}
+
+// NewDirectoryTree lays out the directories of files (repo-relative,
+// slash-separated) as an unsummarised tree. A directory holding nothing
+// but one subdirectory is folded into it, so `cmd/` → `cmd/app/` shows
+// as the single node `cmd/app`. Files and children are sorted by path.
+func NewDirectoryTree(files []string) DirectorySummary {
+	root := &dirBuilder{children: map[string]*dirBuilder{}}
+	for _, f := range files {
+		node := root
+		dir := path.Dir(f)
+		if dir != "." {
+			for _, seg := range strings.Split(dir, "/") {
+				child, ok := node.children[seg]
+				if !ok {
+					child = &dirBuilder{children: map[string]*dirBuilder{}}
+					node.children[seg] = child
+				}
+				node = child
+			}
+		}
+		node.files = append(node.files, f)
+	}
+	return root.build("", true)
+}
+
+type dirBuilder struct {
+	files    []string
+	children map[string]*dirBuilder
+}
+
+func (b *dirBuilder) build(p string, isRoot bool) DirectorySummary {
+	if !isRoot && len(b.files) == 0 && len(b.children) == 1 {
+		for seg, only := range b.children {
+			return only.build(path.Join(p, seg), false)
+		}
+	}
+	node := DirectorySummary{Path: p, Files: append([]string(nil), b.files...)}
+	sort.Strings(node.Files)
+	segs := make([]string, 0, len(b.children))
+	for seg := range b.children {
+		segs = append(segs, seg)
+	}
+	sort.Strings(segs)
+	for _, seg := range segs {
+		node.Children = append(node.Children, b.children[seg].build(path.Join(p, seg), false))
+	}
+	return node
+}
+
+// IsZero reports whether the outline is empty, as for runs that
+// predate directory summaries or haven't reached that step.
+func (d DirectorySummary) IsZero() bool {
+	return d.Path == "" && d.Summary == "" && len(d.Files) == 0 && len(d.Children) == 0
+}
+
+// Count is the number of directories below d, d itself excluded.
+func (d DirectorySummary) Count() int {
+	n := len(d.Children)
+	for _, c := range d.Children {
+		n += c.Count()
+	}
+	return n
+}
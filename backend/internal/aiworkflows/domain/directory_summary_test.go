@@ -0,0 +1,44 @@
+package domain_test
+
+import (
+	"slices"
+	"testing"
+
+	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
+)
+
+func TestNewDirectoryTree(t *testing.T) {
+	tree := ai.NewDirectoryTree([]string{
+		"README.md",
+		"backend/go.mod",
+		"backend/internal/api/handler.go",
+		"backend/internal/api/routes.go",
+		"backend/cmd/server/main.go",
+		"frontend/src/app/page.tsx",
+	})
+
+	if tree.Path != "" || !slices.Equal(tree.Files, []string{"README.md"}) {
+		t.Fatalf("root = %+v", tree)
+	}
+	if len(tree.Children) != 2 || tree.Children[0].Path != "backend" || tree.Children[1].Path != "frontend/src/app" {
+		t.Fatalf("top level = %+v, want backend and the folded frontend/src/app", tree.Children)
+	}
+	backend := tree.Children[0]
+	var paths []string
+	for _, c := range backend.Children {
+		paths = append(paths, c.Path)
+	}
+	// cmd/ and internal/ hold a single subdirectory each and fold.
+	if !slices.Equal(paths, []string{"backend/cmd/server", "backend/internal/api"}) {
+		t.Errorf("backend children = %q", paths)
+	}
+	if !slices.Equal(backend.Files, []string{"backend/go.mod"}) {
+		t.Errorf("backend files = %q", backend.Files)
+	}
+	if n := tree.Count(); n != 4 {
+		t.Errorf("Count = %d, want 4", n)
+	}
+	if !ai.NewDirectoryTree(nil).IsZero() {
+		t.Error("tree of no files should be zero")
+	}
+}
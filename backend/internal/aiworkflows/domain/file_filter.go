@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Limits on a FileFilter, so a request can't make the traverse step
+// match every path against thousands of patterns.
+const (
+	maxFilterPatterns  = 32
+	maxFilterPatternLn = 200
+)
+
+// FileFilter narrows which files of the repository a run considers.
+// Include and Exclude are .gitignore-style patterns matched against
+// repo-relative paths: `*.go` matches at any depth, `backend/**/*.sql`
+// is anchored at the root, a trailing `/` matches directories only.
+// A non-empty Include replaces the built-in extension list; Exclude
+// always wins. Subdir restricts the walk to one directory. The zero
+// value is no restriction.
+type FileFilter struct {
+	Include []string
+	Exclude []string
+	Subdir  string
+}
+
+// NewFileFilter validates and normalises a user-supplied filter. Blank
+// patterns are dropped; Subdir is cleaned to a relative slash path
+// ("./backend/" becomes "backend", "." becomes "").
+func NewFileFilter(include, exclude []string, subdir string) (FileFilter, error) {
+	var f FileFilter
+	var err error
+	if f.Include, err = cleanPatterns("include", include); err != nil {
+		return FileFilter{}, err
+	}
+	if f.Exclude, err = cleanPatterns("exclude", exclude); err != nil {
+		return FileFilter{}, err
+	}
+	if f.Subdir, err = cleanSubdir(subdir); err != nil {
+		return FileFilter{}, err
+	}
+	return f, nil
+}
+
+// IsZero reports whether the filter restricts nothing.
+func (f FileFilter) IsZero() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0 && f.Subdir == ""
+}
+
+func cleanPatterns(field string, raw []string) ([]string, error) {
+	var out []string
+	for _, p := range raw {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if len(p) > maxFilterPatternLn {
+			return nil, fmt.Errorf("%s pattern longer than %d characters", field, maxFilterPatternLn)
+		}
+		if strings.ContainsAny(p, "\x00\\") {
+			return nil, fmt.Errorf("%s pattern %q contains forbidden characters", field, p)
+		}
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("%s pattern %q is malformed", field, p)
+		}
+		out = append(out, p)
+	}
+	if len(out) > maxFilterPatterns {
+		return nil, fmt.Errorf("at most %d %s patterns allowed", maxFilterPatterns, field)
+	}
+	return out, nil
+}
+
+func cleanSubdir(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", nil
+	}
+	if strings.ContainsAny(raw, "\x00\\") {
+		return "", errors.New("subdirectory contains forbidden characters")
+	}
+	if strings.HasPrefix(raw, "/") {
+		return "", errors.New("subdirectory must be relative to the repository root")
+	}
+	clean := path.Clean(raw)
+	if clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", errors.New("subdirectory must stay inside the repository")
+	}
+	if clean == "." {
+		return "", nil
+	}
+	return clean, nil
+}
@@ -0,0 +1,41 @@
+package domain_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
+)
+
+func TestNewFileFilter_Normalises(t *testing.T) {
+	f, err := ai.NewFileFilter([]string{" *.go ", ""}, []string{"**/*_gen.go"}, "./backend/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(f.Include, []string{"*.go"}) || f.Subdir != "backend" {
+		t.Errorf("filter = %+v", f)
+	}
+	if f, _ := ai.NewFileFilter(nil, []string{" "}, "."); !f.IsZero() {
+		t.Errorf("blank input should be the zero filter, got %+v", f)
+	}
+}
+
+func TestNewFileFilter_Rejects(t *testing.T) {
+	cases := map[string]struct {
+		include []string
+		subdir  string
+	}{
+		"escaping subdir":  {subdir: "../etc"},
+		"absolute subdir":  {subdir: "/etc"},
+		"malformed glob":   {include: []string{"[a-"}},
+		"backslash":        {include: []string{`src\*.go`}},
+		"overlong pattern": {include: []string{strings.Repeat("a", 201)}},
+		"too many":         {include: slices.Repeat([]string{"*.go"}, 33)},
+	}
+	for name, c := range cases {
+		if _, err := ai.NewFileFilter(c.include, nil, c.subdir); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package domain
+
+// FileInsights is the structured side of a per-file summary: what the
+// file is for, the symbols worth knowing, what it depends on outside
+// the repository, and what could go wrong around it. All fields may be
+// empty; summaries produced in free-text mode carry none.
+type FileInsights struct {
+	Purpose              string
+	KeySymbols           []string
+	ExternalDependencies []string
+	Risks                []string
+}
+
+// IsZero reports whether no insight was recorded.
+func (i FileInsights) IsZero() bool {
+	return i.Purpose == "" && len(i.KeySymbols) == 0 &&
+		len(i.ExternalDependencies) == 0 && len(i.Risks) == 0
+}
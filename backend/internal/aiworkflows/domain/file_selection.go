@@ -0,0 +1,32 @@
+package domain
+
+// FileSelection records which files a run chose to summarise and why.
+// Strategy names the selection algorithm; Files is in selection order,
+// best candidate first. IgnorePatterns are the rules the repository's
+// own .summaryignore added to the run's FileFilter. SkippedSecrets are
+// the files left out as secret stores (.env files, private keys); only
+// their paths are recorded. SkippedTooLarge are the files left out for
+// being over the per-file size ceiling.
+type FileSelection struct {
+	Strategy        string
+	Files           []SelectedFile
+	IgnorePatterns  []string
+	SkippedSecrets  []string
+	SkippedTooLarge []string
+}
+
+// SelectedFile is one chosen path with a short human-readable reason,
+// e.g. "entry point; imported by 4 files".
+type SelectedFile struct {
+	Path   string
+	Reason string
+}
+
+// Paths lists the selected paths in selection order.
+func (s FileSelection) Paths() []string {
+	out := make([]string, len(s.Files))
+	for i, f := range s.Files {
+		out[i] = f.Path
+	}
+	return out
+}
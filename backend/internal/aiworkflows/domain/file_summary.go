@@ -8,8 +8,15 @@ import (
 // FileSummary is an immutable value-object pairing a repository file
 // path with the LLM-produced summary text for that file.
 type FileSummary struct {
-	filename string
-	summary  string
+	filename   string
+	summary    string
+	cached     bool
+	usage      TokenUsage
+	model      string
+	insights   FileInsights
+	chunks     int
+	blobHash   string
+	redactions map[string]int
 }
 
 // NewFileSummary constructs a FileSummary. An empty filename is rejected;
@@ -24,3 +31,89 @@ func NewFileSummary(filename, summary string) (FileSummary, error) {
 
 func (f FileSummary) Filename() string { return f.filename }
 func (f FileSummary) Summary() string  { return f.summary }
+
+// Cached reports whether the summary came from the content-addressed
+// summary cache rather than a fresh LLM call.
+func (f FileSummary) Cached() bool { return f.cached }
+
+// AsCached returns a copy of f flagged as served from the cache.
+func (f FileSummary) AsCached() FileSummary {
+	f.cached = true
+	return f
+}
+
+// Usage is what the LLM call that produced the summary consumed. Zero
+// for cached summaries, which cost nothing.
+func (f FileSummary) Usage() TokenUsage { return f.usage }
+
+// WithUsage returns a copy of f carrying the LLM usage that produced it.
+func (f FileSummary) WithUsage(u TokenUsage) FileSummary {
+	f.usage = u
+	return f
+}
+
+// Model names the LLM model that wrote the summary — with a provider
+// fallback chain not necessarily the first one configured. Empty for
+// cached summaries and for rows written before it was recorded.
+func (f FileSummary) Model() string { return f.model }
+
+// WithModel returns a copy of f recording the model that wrote it.
+func (f FileSummary) WithModel(model string) FileSummary {
+	f.model = model
+	return f
+}
+
+// Insights is the structured summary of the file; zero when the run
+// asked for free text only.
+func (f FileSummary) Insights() FileInsights { return f.insights }
+
+// WithInsights returns a copy of f carrying structured insights.
+func (f FileSummary) WithInsights(i FileInsights) FileSummary {
+	f.insights = i
+	return f
+}
+
+// Chunks is the number of chunks the file was summarized in when it was
+// too large for one prompt; 0 when it fit.
+func (f FileSummary) Chunks() int { return f.chunks }
+
+// WithChunks returns a copy of f recording that it was summarized in n
+// chunks. n below 2 means the file fit one prompt and records nothing.
+func (f FileSummary) WithChunks(n int) FileSummary {
+	if n < 2 {
+		n = 0
+	}
+	f.chunks = n
+	return f
+}
+
+// BlobHash is the git blob ID of the content that was summarized, which
+// lets a later run tell whether the file has changed since. Empty for
+// rows written before it was recorded.
+func (f FileSummary) BlobHash() string { return f.blobHash }
+
+// WithBlobHash returns a copy of f recording the blob it summarizes.
+func (f FileSummary) WithBlobHash(hash string) FileSummary {
+	f.blobHash = hash
+	return f
+}
+
+// Redactions counts, per rule, the credentials replaced in the file's
+// content before it was sent to the LLM. Nil when there were none.
+func (f FileSummary) Redactions() map[string]int { return f.redactions }
+
+// WithRedactions returns a copy of f recording the redaction counts;
+// zero counts are dropped.
+func (f FileSummary) WithRedactions(counts map[string]int) FileSummary {
+	f.redactions = nil
+	for rule, n := range counts {
+		if n <= 0 {
+			continue
+		}
+		if f.redactions == nil {
+			f.redactions = make(map[string]int)
+		}
+		f.redactions[rule] = n
+	}
+	return f
+}
@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// GitRef is a validated branch, tag or commit to summarise. The empty
+// value means the remote's default branch. Constructed via NewGitRef so
+// a ref can never be mistaken for a command-line option or escape the
+// refs namespace.
+type GitRef string
+
+const maxGitRefLen = 200
+
+// NewGitRef validates a user-supplied ref. Branch and tag names follow
+// a strict subset of `git check-ref-format`: letters, digits and
+// `._/-`, no `..`, no empty or dot-led path components, no `.lock`
+// suffix, and no leading `-`. A commit must be given as its full
+// 40-character SHA and is normalised to lower case; abbreviated SHAs
+// would be ambiguous with branch names and can't be fetched directly.
+func NewGitRef(raw string) (GitRef, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", nil
+	}
+	if isFullSHA(raw) {
+		return GitRef(strings.ToLower(raw)), nil
+	}
+	if len(raw) > maxGitRefLen {
+		return "", fmt.Errorf("git ref longer than %d characters", maxGitRefLen)
+	}
+	for _, c := range raw {
+		if !isRefChar(c) {
+			return "", fmt.Errorf("git ref contains forbidden character %q", c)
+		}
+	}
+	if strings.HasPrefix(raw, "-") {
+		return "", errors.New("git ref must not start with '-'")
+	}
+	if strings.Contains(raw, "..") || strings.HasSuffix(raw, ".lock") {
+		return "", errors.New("git ref must not contain '..' or end in '.lock'")
+	}
+	for _, part := range strings.Split(raw, "/") {
+		if part == "" || strings.HasPrefix(part, ".") || strings.HasSuffix(part, ".") {
+			return "", errors.New("git ref has an empty or dot-led path component")
+		}
+	}
+	return GitRef(raw), nil
+}
+
+// IsCommit reports whether the ref names a commit rather than a branch
+// or tag.
+func (r GitRef) IsCommit() bool { return isFullSHA(string(r)) }
+
+func (r GitRef) String() string { return string(r) }
+
+func isFullSHA(s string) bool {
+	if len(s) != 40 {
+		return false
+	}
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+func isRefChar(c rune) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '.' || c == '_' || c == '/' || c == '-'
+}
@@ -0,0 +1,56 @@
+package domain_test
+
+import (
+	"strings"
+	"testing"
+
+	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
+)
+
+func TestNewGitRef_Valid(t *testing.T) {
+	cases := map[string]string{
+		"":                       "",
+		" main ":                 "main",
+		"release/v1.2.3":         "release/v1.2.3",
+		"refs/tags/v2.0.0":       "refs/tags/v2.0.0",
+		"feature_x-y":            "feature_x-y",
+		strings.Repeat("AB", 20): strings.Repeat("ab", 20),
+	}
+	for raw, want := range cases {
+		got, err := ai.NewGitRef(raw)
+		if err != nil {
+			t.Errorf("NewGitRef(%q): %v", raw, err)
+			continue
+		}
+		if got.String() != want {
+			t.Errorf("NewGitRef(%q) = %q, want %q", raw, got, want)
+		}
+	}
+	if ref, _ := ai.NewGitRef(strings.Repeat("a", 40)); !ref.IsCommit() {
+		t.Error("a full SHA should be a commit")
+	}
+	if ref, _ := ai.NewGitRef("deadbeef"); ref.IsCommit() {
+		t.Error("an abbreviated SHA is treated as a branch or tag name")
+	}
+}
+
+func TestNewGitRef_Rejects(t *testing.T) {
+	for _, raw := range []string{
+		"--upload-pack=evil",
+		"main;rm -rf /",
+		"a..b",
+		"feature/",
+		"/main",
+		"a//b",
+		".hidden",
+		"x/.y",
+		"branch.lock",
+		"HEAD@{1}",
+		"with space",
+		strings.Repeat("a", 201),
+	} {
+		if _, err := ai.NewGitRef(raw); err == nil {
+			t.Errorf("NewGitRef(%q) accepted", raw)
+		}
+	}
+}
@@ -14,9 +14,33 @@ import (
 type RepoSummary struct {
 	shared.AggregateBase
 
-	ID          uint
-	UserID      shared.UserID
-	RepoURL     RepoURL
+	ID      uint
+	UserID  shared.UserID
+	RepoURL RepoURL
+	// RunID is the workflow engine's handle for this run, recorded
+	// right after enqueue so the run can be cancelled later. Empty for
+	// rows enqueued before it was tracked or whose enqueue failed.
+	RunID string
+	// OriginalID is the first attempt of a retry chain; 0 when this row
+	// is itself a first attempt. RetryOf is the failed attempt this one
+	// was started from, whose successful file summaries it reuses.
+	OriginalID uint
+	RetryOf    uint
+	// Filter is what the user asked to restrict the run to; a retry
+	// inherits it.
+	Filter FileFilter
+	// BaseID is the completed run an incremental run builds on: files
+	// unchanged since it are carried over instead of summarized again.
+	// 0 for a full run. Changelog is the comparison, recorded by the
+	// summarize-files step.
+	BaseID    uint
+	Changelog Changelog
+	// Ref is the branch, tag or commit the user asked for; empty means
+	// the default branch. CommitSHA and CommitTime are what it resolved
+	// to when the clone step ran — the exact tree that was summarised.
+	Ref         GitRef
+	CommitSHA   string
+	CommitTime  time.Time
 	Status      Status
 	Files       []FileSummary
 	Summary     string
@@ -29,6 +53,22 @@ type RepoSummary struct {
 	// steps. Persisted as JSONB so a page reload shows the exact same
 	// timings the live SSE stream produced.
 	StepDurations map[string]int64
+	// Usage totals every LLM call the run made: the per-file summaries
+	// it generated (not the cached or reused ones) plus the overview.
+	Usage TokenUsage
+	// PromptVersions maps prompt name → the template version the run
+	// renders, fixed when the run starts.
+	PromptVersions map[string]string
+	// RedactionVersion is the version of the secret-redaction rules the
+	// run's file contents go through, fixed when the run starts. Empty
+	// when nothing is redacted.
+	RedactionVersion string
+	// Selection is what the traverse step picked and why. Zero until
+	// the traverse step has run.
+	Selection FileSelection
+	// Directories is the architecture outline built bottom-up from the
+	// file summaries. Zero until the directory step has run.
+	Directories DirectorySummary
 }
 
 var _ shared.AggregateRoot = (*RepoSummary)(nil)
@@ -45,6 +85,74 @@ func NewRepoSummary(userID shared.UserID, repoURL RepoURL) *RepoSummary {
 	}
 }
 
+// NewRetryAttempt is the factory for a retry of a failed run. The new
+// attempt starts pending with no files of its own; the workflow pulls
+// reusable file summaries from prev when it gets to the fan-out.
+func NewRetryAttempt(prev *RepoSummary) (*RepoSummary, error) {
+	if prev.Status != StatusFailed {
+		return nil, fmt.Errorf("cannot retry: status is %s, want failed", prev.Status)
+	}
+	next := NewRepoSummary(prev.UserID, prev.RepoURL)
+	next.OriginalID = prev.ChainID()
+	next.RetryOf = prev.ID
+	next.Filter = prev.Filter
+	next.BaseID = prev.BaseID
+	// Pin the retry to the commit the failed attempt cloned, so the
+	// file summaries it reuses describe the same tree even if the
+	// branch has moved since.
+	next.Ref = prev.Ref
+	if prev.CommitSHA != "" {
+		next.Ref = GitRef(prev.CommitSHA)
+	}
+	return next, nil
+}
+
+// ChainID identifies the retry chain the run belongs to: the ID of its
+// first attempt.
+func (r *RepoSummary) ChainID() uint {
+	if r.OriginalID != 0 {
+		return r.OriginalID
+	}
+	return r.ID
+}
+
+// HasFile reports whether a summary for filename is already recorded.
+func (r *RepoSummary) HasFile(filename string) bool {
+	for _, f := range r.Files {
+		if f.Filename() == filename {
+			return true
+		}
+	}
+	return false
+}
+
+// CacheHits counts the file summaries that were served from the
+// summary cache.
+func (r *RepoSummary) CacheHits() int {
+	n := 0
+	for _, f := range r.Files {
+		if f.Cached() {
+			n++
+		}
+	}
+	return n
+}
+
+// Redactions adds up, per rule, the credentials redacted from the
+// files the run summarized. Nil when there were none.
+func (r *RepoSummary) Redactions() map[string]int {
+	var out map[string]int
+	for _, f := range r.Files {
+		for rule, n := range f.Redactions() {
+			if out == nil {
+				out = make(map[string]int)
+			}
+			out[rule] += n
+		}
+	}
+	return out
+}
+
 // RecordStepDuration stores how long a completed step took. Idempotent
 // — re-recording the same step (after a retry) overwrites. Persistence
 // adapters serialise the map to JSONB so refresh keeps the timings.
@@ -55,6 +163,54 @@ func (r *RepoSummary) RecordStepDuration(step string, durationMs int64) {
 	r.StepDurations[step] = durationMs
 }
 
+// UsePrompts fixes the prompt template versions for the run. Only the
+// first call counts: a retried start step must not switch prompts
+// halfway through a run.
+func (r *RepoSummary) UsePrompts(versions map[string]string) {
+	if len(r.PromptVersions) > 0 {
+		return
+	}
+	r.PromptVersions = versions
+}
+
+// UseRedaction fixes the redaction rules' version for the run. Set
+// alongside the prompts, before the first file is read.
+func (r *RepoSummary) UseRedaction(version string) {
+	r.RedactionVersion = version
+}
+
+// RecordSelection stores the traverse step's file selection. A
+// re-run of the step overwrites it: the selection is a pure function
+// of the working copy, so a retry picks the same files.
+func (r *RepoSummary) RecordSelection(sel FileSelection) {
+	r.Selection = sel
+}
+
+// RecordDirectories stores the directory step's outline. A re-run of
+// the step overwrites it.
+func (r *RepoSummary) RecordDirectories(tree DirectorySummary) {
+	r.Directories = tree
+}
+
+// RecordCommit stores the commit the run's ref resolved to. A re-run of
+// the clone step overwrites it.
+func (r *RepoSummary) RecordCommit(sha string, at time.Time) {
+	r.CommitSHA = sha
+	r.CommitTime = at
+}
+
+// RecordChangelog stores how the run's files compare to its base run.
+// A re-run of the step overwrites it.
+func (r *RepoSummary) RecordChangelog(c Changelog) {
+	r.Changelog = c
+}
+
+// AttachRun records the workflow engine's run ID. No event — the
+// engine handle is an infrastructure detail, not a lifecycle change.
+func (r *RepoSummary) AttachRun(runID string) {
+	r.RunID = runID
+}
+
 // MarkStarted transitions pending → running and records SummaryStarted.
 func (r *RepoSummary) MarkStarted(at time.Time) error {
 	if r.Status != StatusPending {
@@ -71,15 +227,17 @@ func (r *RepoSummary) MarkStarted(at time.Time) error {
 	return nil
 }
 
-// AppendFileSummary records one per-file summary and emits a
-// FileSummarized event. totalFiles is the count discovered by Traverse,
-// known to the workflow but not to the aggregate; passing it through
-// keeps the event self-contained for SSE consumers downstream.
+// AppendFileSummary records one per-file summary, adds its LLM usage to
+// the run's total, and emits a FileSummarized event. totalFiles is the
+// count discovered by Traverse, known to the workflow but not to the
+// aggregate; passing it through keeps the event self-contained for SSE
+// consumers downstream.
 func (r *RepoSummary) AppendFileSummary(fs FileSummary, totalFiles int) error {
 	if r.Status != StatusRunning {
 		return fmt.Errorf("cannot append file summary: status is %s, want running", r.Status)
 	}
 	r.Files = append(r.Files, fs)
+	r.Usage = r.Usage.Add(fs.Usage())
 	r.Record(FileSummarized{
 		SummaryID: r.ID,
 		UserID:    r.UserID,
@@ -90,6 +248,13 @@ func (r *RepoSummary) AppendFileSummary(fs FileSummary, totalFiles int) error {
 	return nil
 }
 
+// RecordUsage adds LLM usage that isn't tied to a file summary, such
+// as the repo-level overview, to the run's total. No event — usage is
+// bookkeeping, not a lifecycle change.
+func (r *RepoSummary) RecordUsage(u TokenUsage) {
+	r.Usage = r.Usage.Add(u)
+}
+
 // MarkCompleted transitions running → completed, stores the repo-level
 // summary text, and records SummaryCompleted.
 func (r *RepoSummary) MarkCompleted(summary string, at time.Time) error {
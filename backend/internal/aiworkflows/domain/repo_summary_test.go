@@ -263,6 +263,109 @@ func TestRepoSummary_Cancel(t *testing.T) {
 	}
 }
 
+func TestNewRetryAttempt(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	first := ai.NewRepoSummary(mustUserID(t), mustRepoURL(t, "https://github.com/owner/repo"))
+	first.ID = 7
+	first.Filter = ai.FileFilter{Subdir: "backend"}
+	first.Ref = "main"
+	first.BaseID = 4
+	first.RecordCommit(strings.Repeat("c", 40), now)
+	if _, err := ai.NewRetryAttempt(first); err == nil {
+		t.Fatalf("retrying a pending run should fail")
+	}
+	_ = first.MarkStarted(now)
+	_ = first.MarkFailed("boom", now)
+
+	second, err := ai.NewRetryAttempt(first)
+	if err != nil {
+		t.Fatalf("NewRetryAttempt: %v", err)
+	}
+	if second.Status != ai.StatusPending || second.UserID != first.UserID || second.RepoURL != first.RepoURL {
+		t.Errorf("retry = %+v, want pending copy of owner and url", second)
+	}
+	if second.RetryOf != 7 || second.OriginalID != 7 {
+		t.Errorf("links = (retryOf %d, original %d), want (7, 7)", second.RetryOf, second.OriginalID)
+	}
+	if second.Filter.Subdir != "backend" {
+		t.Errorf("retry filter = %+v, want the original run's", second.Filter)
+	}
+	if second.BaseID != 4 {
+		t.Errorf("retry base = %d, want the original run's", second.BaseID)
+	}
+	if second.Ref != ai.GitRef(strings.Repeat("c", 40)) || second.CommitSHA != "" {
+		t.Errorf("retry ref = %q (commit %q), want pinned to the cloned commit", second.Ref, second.CommitSHA)
+	}
+	if len(second.PullEvents()) != 0 {
+		t.Errorf("a fresh attempt should record no events")
+	}
+
+	// A retry of a retry stays in the first attempt's chain.
+	second.ID = 9
+	_ = second.MarkStarted(now)
+	_ = second.MarkFailed("boom again", now)
+	third, err := ai.NewRetryAttempt(second)
+	if err != nil {
+		t.Fatalf("NewRetryAttempt: %v", err)
+	}
+	if third.RetryOf != 9 || third.OriginalID != 7 || third.ChainID() != 7 {
+		t.Errorf("links = (retryOf %d, original %d), want (9, 7)", third.RetryOf, third.OriginalID)
+	}
+}
+
+func TestRepoSummary_CacheHits(t *testing.T) {
+	t.Parallel()
+	r := ai.NewRepoSummary(mustUserID(t), mustRepoURL(t, "https://github.com/owner/repo"))
+	_ = r.MarkStarted(time.Now())
+	hit := mustFileSummary(t, "a.go", "A").AsCached()
+	if !hit.Cached() || hit.Filename() != "a.go" || hit.Summary() != "A" {
+		t.Fatalf("AsCached = %+v", hit)
+	}
+	_ = r.AppendFileSummary(hit, 2)
+	_ = r.AppendFileSummary(mustFileSummary(t, "b.go", "B"), 2)
+	if got := r.CacheHits(); got != 1 {
+		t.Errorf("CacheHits = %d, want 1", got)
+	}
+}
+
+func TestRepoSummary_Redactions(t *testing.T) {
+	t.Parallel()
+	r := ai.NewRepoSummary(mustUserID(t), mustRepoURL(t, "https://github.com/owner/repo"))
+	_ = r.MarkStarted(time.Now())
+	if r.Redactions() != nil {
+		t.Errorf("Redactions on an empty run = %v, want nil", r.Redactions())
+	}
+	a := mustFileSummary(t, "a.go", "A").WithRedactions(map[string]int{"aws-access-key": 1, "jwt": 0})
+	if _, ok := a.Redactions()["jwt"]; ok || a.Redactions()["aws-access-key"] != 1 {
+		t.Errorf("WithRedactions = %v, want zero counts dropped", a.Redactions())
+	}
+	_ = r.AppendFileSummary(a, 3)
+	_ = r.AppendFileSummary(mustFileSummary(t, "b.go", "B").WithRedactions(map[string]int{"aws-access-key": 2, "private-key": 1}), 3)
+	_ = r.AppendFileSummary(mustFileSummary(t, "c.go", "C"), 3)
+	got := r.Redactions()
+	if len(got) != 2 || got["aws-access-key"] != 3 || got["private-key"] != 1 {
+		t.Errorf("Redactions = %v", got)
+	}
+}
+
+func TestRepoSummary_UsageTotals(t *testing.T) {
+	t.Parallel()
+	r := ai.NewRepoSummary(mustUserID(t), mustRepoURL(t, "https://github.com/owner/repo"))
+	_ = r.MarkStarted(time.Now())
+	_ = r.AppendFileSummary(mustFileSummary(t, "a.go", "A").WithUsage(ai.TokenUsage{PromptTokens: 100, CompletionTokens: 20, CostUSD: 0.01}), 2)
+	_ = r.AppendFileSummary(mustFileSummary(t, "b.go", "B").AsCached(), 2)
+	r.RecordUsage(ai.TokenUsage{PromptTokens: 50, CompletionTokens: 40})
+
+	want := ai.TokenUsage{PromptTokens: 150, CompletionTokens: 60, CostUSD: 0.01}
+	if r.Usage != want {
+		t.Errorf("Usage = %+v, want %+v", r.Usage, want)
+	}
+	if r.Usage.TotalTokens() != 210 {
+		t.Errorf("TotalTokens = %d, want 210", r.Usage.TotalTokens())
+	}
+}
+
 func TestRepoSummary_EventNames(t *testing.T) {
 	t.Parallel()
 	type named interface{ EventName() string }
@@ -286,3 +389,13 @@ func TestRepoSummary_EventNames(t *testing.T) {
 		}
 	}
 }
+
+func TestRepoSummary_UsePromptsOnlyOnce(t *testing.T) {
+	t.Parallel()
+	s := ai.NewRepoSummary(mustUserID(t), mustRepoURL(t, "https://github.com/owner/repo"))
+	s.UsePrompts(map[string]string{"file-summary": "v2", "aggregate": "v1"})
+	s.UsePrompts(map[string]string{"file-summary": "v3", "aggregate": "v3"})
+	if s.PromptVersions["file-summary"] != "v2" || s.PromptVersions["aggregate"] != "v1" {
+		t.Errorf("PromptVersions = %v, want the first selection kept", s.PromptVersions)
+	}
+}
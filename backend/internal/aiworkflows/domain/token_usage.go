@@ -0,0 +1,25 @@
+package domain
+
+// TokenUsage is what one or more LLM calls consumed. CostUSD is the
+// provider-reported price and stays zero when the provider doesn't
+// report one — it is never estimated from token counts.
+type TokenUsage struct {
+	PromptTokens     int
+	CompletionTokens int
+	CostUSD          float64
+}
+
+// Add returns the sum of u and o.
+func (u TokenUsage) Add(o TokenUsage) TokenUsage {
+	return TokenUsage{
+		PromptTokens:     u.PromptTokens + o.PromptTokens,
+		CompletionTokens: u.CompletionTokens + o.CompletionTokens,
+		CostUSD:          u.CostUSD + o.CostUSD,
+	}
+}
+
+// TotalTokens is prompt plus completion tokens.
+func (u TokenUsage) TotalTokens() int { return u.PromptTokens + u.CompletionTokens }
+
+// IsZero reports whether no usage was recorded.
+func (u TokenUsage) IsZero() bool { return u == TokenUsage{} }
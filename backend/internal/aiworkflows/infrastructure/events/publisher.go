@@ -16,12 +16,14 @@ import (
 // Broadcaster is the minimal upstream this publisher needs. The platform
 // SSE broker satisfies it without knowing about aiworkflows.
 type Broadcaster interface {
-	Broadcast(eventName, payload string)
+	SendToUser(userID, eventName, payload string)
 }
 
 // Publisher dispatches aiworkflows domain events to the broadcaster.
 // All events flow on a single SSE event name `ai-progress` so the
-// frontend has one stream to listen on regardless of step.
+// frontend has one stream to listen on regardless of step. Every event
+// is addressed to the run's owner — payloads carry filenames and
+// failure reasons that must not reach other users' browsers.
 type Publisher struct {
 	broadcaster Broadcaster
 }
@@ -37,7 +39,8 @@ func NewPublisher(broadcaster Broadcaster) *Publisher {
 //   - kind=lifecycle: started/completed/failed/cancelled from the
 //     RepoSummary aggregate's domain events
 //   - kind=step: step-level transitions emitted directly by the
-//     workflow (clone/traverse/.../store with started/completed/failed/progress)
+//     workflow (clone/traverse/.../store with started/completed/failed/progress,
+//     plus streaming chunks of the overview during aggregate)
 type progressPayload struct {
 	Kind       string `json:"kind"`
 	SummaryID  uint   `json:"summaryId"`
@@ -50,6 +53,7 @@ type progressPayload struct {
 	FileIndex  int    `json:"fileIndex,omitempty"`
 	FileCount  int    `json:"fileCount,omitempty"`
 	Reason     string `json:"reason,omitempty"`
+	Delta      string `json:"delta,omitempty"` // aggregate state=streaming: overview text to append
 }
 
 const sseEventName = "ai-progress"
@@ -72,12 +76,13 @@ func (p *Publisher) PublishStep(_ context.Context, step aiapp.StepProgress) {
 		FileIndex:  step.FileIndex,
 		FileCount:  step.FileCount,
 		Reason:     step.Reason,
+		Delta:      step.Delta,
 	}
 	raw, err := json.Marshal(payload)
 	if err != nil {
 		return
 	}
-	p.broadcaster.Broadcast(sseEventName, string(raw))
+	p.broadcaster.SendToUser(payload.UserID, sseEventName, string(raw))
 }
 
 // Publish routes each domain event to the broadcast topic. Unknown
@@ -95,7 +100,7 @@ func (p *Publisher) Publish(_ context.Context, events ...shared.DomainEvent) err
 		if err != nil {
 			continue
 		}
-		p.broadcaster.Broadcast(sseEventName, string(raw))
+		p.broadcaster.SendToUser(payload.UserID, sseEventName, string(raw))
 		incrementTerminalCounter(ev)
 	}
 	return nil
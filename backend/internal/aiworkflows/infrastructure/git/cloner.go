@@ -10,38 +10,98 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
+	"strings"
+	"time"
 
 	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
 
 	aiapp "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/application"
 	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
 )
 
-// Cloner is the RepoCloner adapter. MaxBytes caps the total unpacked
-// repository size to defend against malicious or pathologically large
-// repos. SingleBranch keeps the clone shallow (HEAD of default branch
-// only) so the per-run disk footprint stays small.
+// Cloner is the RepoCloner adapter. The limits defend against
+// malicious or pathologically large repos and are enforced while the
+// clone streams, not after it has landed on disk: MaxTransferBytes and
+// MaxObjects as the pack arrives, MaxBytes before anything is checked
+// out, Timeout throughout. SingleBranch and Depth keep the clone
+// shallow (the last few commits of the requested ref) so the per-run
+// disk footprint stays small while file ranking still sees what
+// changed recently. Policy restricts the hosts clones may reach; nil
+// allows any.
 type Cloner struct {
-	BaseDir  string // parent directory for the working copies, e.g. os.TempDir()
-	MaxBytes int64  // total unpacked size cap; 0 = no cap
+	BaseDir          string        // parent directory for the working copies, e.g. os.TempDir()
+	MaxBytes         int64         // total size of the checked-out files; 0 = no cap
+	MaxTransferBytes int64         // bytes received over http(s); 0 = no cap
+	MaxObjects       int64         // objects in a fetched pack; 0 = no cap
+	Timeout          time.Duration // wall clock for the whole clone; 0 = none
+	Depth            int           // commits of history to fetch; 0 = 1
+	Policy           *TargetPolicy
 }
 
-var _ aiapp.RepoCloner = (*Cloner)(nil)
+var (
+	_ aiapp.RepoCloner  = (*Cloner)(nil)
+	_ aiapp.RepoHistory = (*Cloner)(nil)
+)
+
+// DefaultDepth is the history NewCloner fetches: enough for "recently
+// changed" to mean something, little enough to stay a shallow clone.
+const DefaultDepth = 20
+
+// Defaults for the limits NewCloner sets. A pack compresses well below
+// the files it holds, so the transfer cap is simply maxBytes.
+const (
+	DefaultMaxObjects = 100_000
+	DefaultTimeout    = 5 * time.Minute
+)
 
 // NewCloner constructs a Cloner with sensible defaults.
 func NewCloner(baseDir string, maxBytes int64) *Cloner {
 	if baseDir == "" {
 		baseDir = os.TempDir()
 	}
-	return &Cloner{BaseDir: baseDir, MaxBytes: maxBytes}
+	return &Cloner{
+		BaseDir:          baseDir,
+		MaxBytes:         maxBytes,
+		MaxTransferBytes: maxBytes,
+		MaxObjects:       DefaultMaxObjects,
+		Timeout:          DefaultTimeout,
+		Depth:            DefaultDepth,
+	}
 }
 
-// Clone performs a shallow clone of url into a freshly-created temp dir
-// under BaseDir. The returned ClonedRepo.Cleanup removes the directory.
-// Caller MUST invoke Cleanup even on error — we honour the contract by
-// only returning Cleanup-bearing values on success.
-func (c *Cloner) Clone(ctx context.Context, url ai.RepoURL) (aiapp.ClonedRepo, error) {
+// Clone performs a shallow clone of url at ref into a freshly-created
+// temp dir under BaseDir. Branches and tags are told apart by asking
+// the remote first (`git ls-remote`), so an unknown ref fails fast with
+// ErrRefNotFound; a commit SHA is fetched directly. A target the
+// Policy forbids fails with ErrTargetNotAllowed before anything is
+// fetched, or as soon as a redirect or connection reaches it. Going
+// over a limit aborts the clone with an ErrCloneLimit naming it.
+// Symlinks that resolve outside the working copy are removed from it.
+// The returned ClonedRepo.Cleanup removes the directory. Caller MUST
+// invoke Cleanup even on error — we honour the contract by only
+// returning Cleanup-bearing values on success.
+func (c *Cloner) Clone(ctx context.Context, url ai.RepoURL, ref ai.GitRef) (aiapp.ClonedRepo, error) {
+	ctx, stop := context.WithCancelCause(ctx)
+	defer stop(nil)
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, c.Timeout,
+			fmt.Errorf("%w: took longer than %s", aiapp.ErrCloneLimit, c.Timeout))
+		defer cancel()
+	}
+	if c.Policy != nil {
+		if err := c.Policy.CheckURL(ctx, url); err != nil {
+			return aiapp.ClonedRepo{}, fmt.Errorf("clone %s: %w", url.String(), limitCause(ctx, err))
+		}
+		ctx = withPolicy(ctx, c.Policy)
+	}
+	installGuardedTransport()
+	ctx = withBudget(ctx, &transferBudget{maxBytes: c.MaxTransferBytes, maxObjects: c.MaxObjects, stop: stop})
+
 	dir, err := os.MkdirTemp(c.BaseDir, "repo-summary-*")
 	if err != nil {
 		return aiapp.ClonedRepo{}, fmt.Errorf("mkdir temp: %w", err)
@@ -49,46 +109,261 @@ func (c *Cloner) Clone(ctx context.Context, url ai.RepoURL) (aiapp.ClonedRepo, e
 
 	cleanup := func() error { return os.RemoveAll(dir) }
 
-	_, err = gogit.PlainCloneContext(ctx, dir, false, &gogit.CloneOptions{
-		URL:               url.String(),
-		Depth:             1,
-		SingleBranch:      true,
-		ShallowSubmodules: true,
-		Progress:          io.Discard,
-	})
+	var repo *gogit.Repository
+	if ref.IsCommit() {
+		repo, err = c.fetchCommit(ctx, dir, url, ref)
+	} else {
+		opts := &gogit.CloneOptions{
+			URL:               url.String(),
+			Depth:             max(c.Depth, 1),
+			SingleBranch:      true,
+			NoCheckout:        true,
+			ShallowSubmodules: true,
+			Progress:          io.Discard,
+		}
+		if ref != "" {
+			opts.ReferenceName, err = resolveRefName(ctx, url, ref)
+		}
+		if err == nil {
+			repo, err = gogit.PlainCloneContext(ctx, dir, false, opts)
+		}
+		if err == nil {
+			err = c.checkoutHead(ctx, repo)
+		}
+	}
+	if err == nil {
+		err = dropEscapingSymlinks(dir)
+	}
+	if err != nil {
+		_ = cleanup()
+		return aiapp.ClonedRepo{}, fmt.Errorf("clone %s: %w", url.String(), limitCause(ctx, err))
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		_ = cleanup()
+		return aiapp.ClonedRepo{}, fmt.Errorf("resolve HEAD: %w", err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
 	if err != nil {
 		_ = cleanup()
-		return aiapp.ClonedRepo{}, fmt.Errorf("clone %s: %w", url.String(), err)
+		return aiapp.ClonedRepo{}, fmt.Errorf("read HEAD commit: %w", err)
 	}
+	return aiapp.ClonedRepo{
+		Path:       dir,
+		Cleanup:    cleanup,
+		Commit:     head.Hash().String(),
+		CommitTime: commit.Committer.When.UTC(),
+	}, nil
+}
+
+// limitCause prefers the limit that cancelled ctx over err, which is
+// then only its symptom ("context canceled", a failed read).
+func limitCause(ctx context.Context, err error) error {
+	if cause := context.Cause(ctx); errors.Is(cause, aiapp.ErrCloneLimit) {
+		return cause
+	}
+	return err
+}
+
+// checkoutHead checks out the branch or tag the clone's HEAD points at.
+func (c *Cloner) checkoutHead(ctx context.Context, repo *gogit.Repository) error {
+	head, err := repo.Head()
+	if err != nil {
+		return fmt.Errorf("resolve HEAD: %w", err)
+	}
+	opts := &gogit.CheckoutOptions{Hash: head.Hash()}
+	if head.Name().IsBranch() {
+		opts = &gogit.CheckoutOptions{Branch: head.Name()}
+	}
+	return c.checkout(ctx, repo, head.Hash(), opts)
+}
 
+// checkout writes commit's files to the working tree once their total
+// size is known to fit MaxBytes — measured from the objects already
+// fetched, so an oversized tree is never written.
+func (c *Cloner) checkout(ctx context.Context, repo *gogit.Repository, hash plumbing.Hash, opts *gogit.CheckoutOptions) error {
 	if c.MaxBytes > 0 {
-		size, err := dirSize(dir)
+		commit, err := repo.CommitObject(hash)
 		if err != nil {
-			_ = cleanup()
-			return aiapp.ClonedRepo{}, fmt.Errorf("measure clone: %w", err)
+			return fmt.Errorf("read commit: %w", err)
+		}
+		size, err := treeSize(ctx, commit)
+		if err != nil {
+			return fmt.Errorf("measure tree: %w", err)
 		}
 		if size > c.MaxBytes {
-			_ = cleanup()
-			return aiapp.ClonedRepo{}, fmt.Errorf("clone size %d bytes exceeds limit %d", size, c.MaxBytes)
+			return fmt.Errorf("%w: checkout of %d bytes exceeds %d", aiapp.ErrCloneLimit, size, c.MaxBytes)
 		}
 	}
-
-	return aiapp.ClonedRepo{Path: dir, Cleanup: cleanup}, nil
+	wt, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	return wt.Checkout(opts)
 }
 
-func dirSize(root string) (int64, error) {
+// treeSize adds up the sizes of the files in commit's tree.
+func treeSize(ctx context.Context, commit *object.Commit) (int64, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return 0, err
+	}
 	var total int64
-	err := filepath.Walk(root, func(_ string, info os.FileInfo, err error) error {
+	err = tree.Files().ForEach(func(f *object.File) error {
+		total += f.Size
+		return ctx.Err()
+	})
+	return total, err
+}
+
+// resolveRefName finds ref among the remote's branches and tags, in
+// that order. A fully-qualified `refs/heads/...` or `refs/tags/...`
+// name is matched as is.
+func resolveRefName(ctx context.Context, url ai.RepoURL, ref ai.GitRef) (plumbing.ReferenceName, error) {
+	refs, err := listRemote(ctx, url)
+	if err != nil {
+		return "", fmt.Errorf("list refs: %w", err)
+	}
+	advertised := make(map[plumbing.ReferenceName]bool, len(refs))
+	for _, r := range refs {
+		advertised[r.Name()] = true
+	}
+	for _, name := range []plumbing.ReferenceName{
+		plumbing.ReferenceName(ref),
+		plumbing.NewBranchReferenceName(ref.String()),
+		plumbing.NewTagReferenceName(ref.String()),
+	} {
+		if (name.IsBranch() || name.IsTag()) && advertised[name] {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", aiapp.ErrRefNotFound, ref)
+}
+
+// fetchCommit fetches a single commit (plus Depth-1 ancestors) by SHA
+// and checks it out detached. The remote has to allow fetching
+// unadvertised commits; the major forges do for reachable ones.
+func (c *Cloner) fetchCommit(ctx context.Context, dir string, url ai.RepoURL, ref ai.GitRef) (*gogit.Repository, error) {
+	repo, err := gogit.PlainInit(dir, false)
+	if err != nil {
+		return nil, err
+	}
+	remote, err := repo.CreateRemote(&config.RemoteConfig{
+		Name: gogit.DefaultRemoteName,
+		URLs: []string{url.String()},
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = remote.FetchContext(ctx, &gogit.FetchOptions{
+		RefSpecs: []config.RefSpec{config.RefSpec(ref.String() + ":refs/heads/summarized")},
+		Depth:    max(c.Depth, 1),
+		Tags:     gogit.NoTags,
+		Progress: io.Discard,
+	})
+	if err != nil {
+		if isMissingCommit(err) || remoteReachable(ctx, url) {
+			return nil, fmt.Errorf("%w: %s", aiapp.ErrRefNotFound, ref)
+		}
+		return nil, err
+	}
+	hash := plumbing.NewHash(ref.String())
+	if err := c.checkout(ctx, repo, hash, &gogit.CheckoutOptions{Hash: hash}); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// isMissingCommit recognises a remote refusing (or not having) the
+// commit we asked for. Servers word it differently, and go-git passes
+// their message through as text — when it gets one at all: some
+// transports just see the server hang up, which is why fetchCommit
+// falls back to remoteReachable.
+func isMissingCommit(err error) bool {
+	if errors.Is(err, plumbing.ErrObjectNotFound) || errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "not our ref") || strings.Contains(msg, "couldn't find remote ref")
+}
+
+// remoteReachable reports whether the remote answers a ref listing. A
+// SHA fetch that fails against a reachable remote is a missing commit,
+// not a network problem worth retrying.
+func remoteReachable(ctx context.Context, url ai.RepoURL) bool {
+	_, err := listRemote(ctx, url)
+	return err == nil
+}
+
+// listRemote is `git ls-remote url`.
+func listRemote(ctx context.Context, url ai.RepoURL) ([]*plumbing.Reference, error) {
+	remote := gogit.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: gogit.DefaultRemoteName,
+		URLs: []string{url.String()},
+	})
+	return remote.ListContext(ctx, &gogit.ListOptions{})
+}
+
+// RecentChanges walks the history the clone fetched, newest first, and
+// records for each file still in the tree when the newest commit that
+// touched it was made. The shallow boundary commit has no parent to
+// diff against and contributes nothing, so a depth-1 clone yields an
+// empty map.
+func (c *Cloner) RecentChanges(ctx context.Context, path string) (map[string]time.Time, error) {
+	repo, err := gogit.PlainOpen(path)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("resolve HEAD: %w", err)
+	}
+	iter, err := repo.Log(&gogit.LogOptions{From: head.Hash(), Order: gogit.LogOrderCommitterTime})
+	if err != nil {
+		return nil, fmt.Errorf("log: %w", err)
+	}
+	defer iter.Close()
+
+	out := make(map[string]time.Time)
+	err = iter.ForEach(func(commit *object.Commit) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if commit.NumParents() == 0 {
+			return nil
+		}
+		parent, err := commit.Parent(0)
+		if err != nil {
+			// Beyond the shallow boundary.
+			return nil
+		}
+		from, err := parent.Tree()
+		if err != nil {
+			return nil
+		}
+		to, err := commit.Tree()
 		if err != nil {
-			if errors.Is(err, os.ErrNotExist) {
-				return nil
-			}
 			return err
 		}
-		if !info.IsDir() {
-			total += info.Size()
+		changes, err := object.DiffTreeWithOptions(ctx, from, to, nil)
+		if err != nil {
+			return err
+		}
+		when := commit.Committer.When
+		for _, ch := range changes {
+			name := ch.To.Name
+			if name == "" {
+				continue // deleted
+			}
+			if prev, ok := out[name]; !ok || when.After(prev) {
+				out[name] = when
+			}
 		}
 		return nil
 	})
-	return total, err
+	if err != nil && !errors.Is(err, plumbing.ErrObjectNotFound) {
+		return nil, fmt.Errorf("walk history: %w", err)
+	}
+	return out, nil
 }
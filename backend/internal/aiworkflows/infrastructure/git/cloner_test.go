@@ -0,0 +1,152 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	aiapp "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/application"
+	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
+)
+
+func TestRecentChanges(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := gogit.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	commit := func(day int, files map[string]string, remove ...string) {
+		t.Helper()
+		for name, body := range files {
+			full := filepath.Join(dir, name)
+			if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(full, []byte(body), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := wt.Add(name); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, name := range remove {
+			if _, err := wt.Remove(name); err != nil {
+				t.Fatal(err)
+			}
+		}
+		sig := &object.Signature{Name: "t", Email: "t@example.com", When: base.AddDate(0, 0, day)}
+		if _, err := wt.Commit("c", &gogit.CommitOptions{Author: sig, Committer: sig}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	commit(0, map[string]string{"README.md": "a", "old.go": "a", "gone.go": "a"})
+	commit(1, map[string]string{"pkg/x.go": "a", "old.go": "b"})
+	commit(2, map[string]string{"pkg/x.go": "b"}, "gone.go")
+
+	got, err := (&Cloner{}).RecentChanges(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]time.Time{
+		"pkg/x.go": base.AddDate(0, 0, 2),
+		"old.go":   base.AddDate(0, 0, 1),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v (the root commit and deletions don't count)", got, want)
+	}
+	for name, when := range want {
+		if !got[name].Equal(when) {
+			t.Errorf("%s changed at %v, want %v", name, got[name], when)
+		}
+	}
+}
+
+func TestCloneRef(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary needed for the file transport")
+	}
+	src := t.TempDir()
+	repo, err := gogit.PlainInit(src, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit := func(day int, body string) plumbing.Hash {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(src, "a.txt"), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := wt.Add("a.txt"); err != nil {
+			t.Fatal(err)
+		}
+		sig := &object.Signature{Name: "t", Email: "t@example.com", When: time.Date(2026, 1, day, 0, 0, 0, 0, time.UTC)}
+		h, err := wt.Commit(body, &gogit.CommitOptions{Author: sig, Committer: sig})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	first := commit(1, "first")
+	if _, err := repo.CreateTag("v1", first, nil); err != nil {
+		t.Fatal(err)
+	}
+	head := commit(2, "second")
+	// Fetching by SHA needs the server's consent, as on the real forges.
+	cfg, err := repo.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Raw.Section("uploadpack").SetOption("allowAnySHA1InWant", "true")
+	if err := repo.SetConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewCloner(t.TempDir(), 0)
+	cases := map[ai.GitRef]plumbing.Hash{
+		"":                        head,
+		"master":                  head,
+		"refs/heads/master":       head,
+		"v1":                      first,
+		ai.GitRef(first.String()): first,
+	}
+	for ref, want := range cases {
+		cloned, err := c.Clone(context.Background(), ai.RepoURL(src), ref)
+		if err != nil {
+			t.Fatalf("ref %q: %v", ref, err)
+		}
+		if cloned.Commit != want.String() {
+			t.Errorf("ref %q: commit %s, want %s", ref, cloned.Commit, want)
+		}
+		if body, err := os.ReadFile(filepath.Join(cloned.Path, "a.txt")); err != nil || len(body) == 0 {
+			t.Errorf("ref %q: a.txt not checked out: %q, %v", ref, body, err)
+		}
+		if ref == "v1" && !cloned.CommitTime.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("commit time = %v", cloned.CommitTime)
+		}
+		_ = cloned.Cleanup()
+	}
+
+	for _, ref := range []ai.GitRef{"nope", ai.GitRef(strings.Repeat("ab", 20))} {
+		_, err := c.Clone(context.Background(), ai.RepoURL(src), ref)
+		if !errors.Is(err, aiapp.ErrRefNotFound) {
+			t.Errorf("ref %q: err = %v, want ErrRefNotFound", ref, err)
+		}
+	}
+}
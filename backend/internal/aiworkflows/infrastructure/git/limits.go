@@ -0,0 +1,206 @@
+package git
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	aiapp "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/application"
+)
+
+// transferBudget meters the HTTP responses of one clone. The first
+// limit hit cancels the clone's context with an ErrCloneLimit cause, so
+// go-git stops however it happens to wrap the failed read.
+type transferBudget struct {
+	maxBytes   int64 // 0 = no cap
+	maxObjects int64 // 0 = no cap
+	stop       context.CancelCauseFunc
+
+	mu       sync.Mutex
+	received int64
+}
+
+func (b *transferBudget) exceed(err error) error {
+	b.stop(err)
+	return err
+}
+
+// add counts n more bytes received, and the objects of a pack once its
+// header has been seen.
+func (b *transferBudget) add(n int, objects int64) error {
+	b.mu.Lock()
+	b.received += int64(n)
+	received := b.received
+	b.mu.Unlock()
+	if b.maxBytes > 0 && received > b.maxBytes {
+		return b.exceed(fmt.Errorf("%w: transferred more than %d bytes", aiapp.ErrCloneLimit, b.maxBytes))
+	}
+	if b.maxObjects > 0 && objects > b.maxObjects {
+		return b.exceed(fmt.Errorf("%w: pack has %d objects, limit is %d", aiapp.ErrCloneLimit, objects, b.maxObjects))
+	}
+	return nil
+}
+
+type budgetKey struct{}
+
+func withBudget(ctx context.Context, b *transferBudget) context.Context {
+	return context.WithValue(ctx, budgetKey{}, b)
+}
+
+func budgetFrom(ctx context.Context) *transferBudget {
+	b, _ := ctx.Value(budgetKey{}).(*transferBudget)
+	return b
+}
+
+// meteredTransport counts each response body against the budget in
+// the request's context while go-git reads it; requests without one
+// pass through.
+type meteredTransport struct {
+	base http.RoundTripper
+}
+
+func (t meteredTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if b := budgetFrom(req.Context()); b != nil {
+		res.Body = &meteredBody{ReadCloser: res.Body, budget: b}
+	}
+	return res, nil
+}
+
+type meteredBody struct {
+	io.ReadCloser
+	budget *transferBudget
+	pack   packSniffer
+}
+
+func (m *meteredBody) Read(p []byte) (int, error) {
+	n, err := m.ReadCloser.Read(p)
+	if n > 0 {
+		if lerr := m.budget.add(n, m.pack.feed(p[:n])); lerr != nil {
+			return n, lerr
+		}
+	}
+	return n, err
+}
+
+// packSniffer finds the object count in the header of the packfile an
+// upload-pack response carries, so an oversized pack is refused before
+// its objects are downloaded. The pack follows the negotiation's
+// pkt-lines ("NAK", "shallow <sha>"), either raw or — with side-band —
+// spread over pkt-lines whose first byte is band 1. Its 12-byte header
+// is "PACK", a version and the number of objects, big-endian.
+type packSniffer struct {
+	done    bool
+	objects int64
+	raw     bool   // past the pkt-lines, in a raw pack
+	size    []byte // the pkt-line length being read
+	left    int    // payload bytes left in the current pkt-line
+	start   bool   // the next payload byte is the first
+	band    byte
+	header  []byte
+}
+
+const packHeaderLen = 12
+
+// feed scans the next bytes of the response and returns the pack's
+// object count once known, else 0. Responses that turn out not to
+// follow the format are left alone.
+func (s *packSniffer) feed(p []byte) int64 {
+	for len(p) > 0 && !s.done {
+		switch {
+		case s.raw:
+			p = s.collect(p)
+		case s.left > 0:
+			take := min(s.left, len(p))
+			chunk := p[:take]
+			p, s.left = p[take:], s.left-take
+			if s.start {
+				s.band, chunk, s.start = chunk[0], chunk[1:], false
+			}
+			if s.band == 1 {
+				s.collect(chunk)
+			}
+		default:
+			take := min(4-len(s.size), len(p))
+			s.size, p = append(s.size, p[:take]...), p[take:]
+			if len(s.size) < 4 {
+				continue
+			}
+			if string(s.size) == "PACK" {
+				s.raw = true
+				s.header = append(s.header, s.size...)
+				continue
+			}
+			n, err := strconv.ParseUint(string(s.size), 16, 16)
+			s.size = s.size[:0]
+			switch {
+			case err != nil || n == 3:
+				s.done = true
+			case n > 4:
+				s.left, s.start = int(n)-4, true
+			}
+			// 0000-0002 are flush, delimiter and response-end packets,
+			// 0004 an empty line.
+		}
+	}
+	return s.objects
+}
+
+// collect appends to the pack header and returns what's left of p.
+func (s *packSniffer) collect(p []byte) []byte {
+	take := min(packHeaderLen-len(s.header), len(p))
+	s.header, p = append(s.header, p[:take]...), p[take:]
+	if len(s.header) == packHeaderLen {
+		s.done = true
+		if string(s.header[:4]) == "PACK" {
+			s.objects = int64(binary.BigEndian.Uint32(s.header[8:]))
+		}
+	}
+	return p
+}
+
+// dropEscapingSymlinks removes the symlinks in the working copy at root
+// that resolve outside it — absolute, through "..", or via another
+// link — or to nothing, so no later reader can be led out of the
+// checkout, say to /etc or the worker's environment. Links that stay
+// inside are kept.
+func dropEscapingSymlinks(root string) error {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if d.Type()&fs.ModeSymlink == 0 {
+			return nil
+		}
+		if target, err := filepath.EvalSymlinks(p); err == nil && Within(realRoot, target) {
+			return nil
+		}
+		return os.Remove(p)
+	})
+}
+
+// Within reports whether path is root or below it; both must already be
+// resolved (filepath.EvalSymlinks). The workflow steps use it too, to
+// keep what they read inside the checkout.
+func Within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,147 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	aiapp "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/application"
+	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
+)
+
+func TestPackSniffer(t *testing.T) {
+	header := "PACK\x00\x00\x00\x02\x00\x00\x01\x2c" // version 2, 300 objects
+	cases := map[string]struct {
+		stream string
+		want   int64
+	}{
+		"side-band, header split over pkt-lines": {
+			stream: "0008NAK\n" + "0035shallow 0123456789012345678901234567890123456789\n" +
+				"0007\x01PA" + "0016\x02Counting objects\n" + "0013\x01" + header[2:] + "xxxx",
+			want: 300,
+		},
+		"raw pack": {stream: "0008NAK\n" + header + "objects...", want: 300},
+		"ref advertisement": {
+			stream: "001e# service=git-upload-pack\n0000" + "003d0123456789012345678901234567890123456789 refs/heads/main\n0000",
+			want:   0,
+		},
+		"not pkt-lines": {stream: "<html>PACK</html>", want: 0},
+	}
+	for name, tc := range cases {
+		// Whole, and a byte at a time as a slow connection delivers it.
+		var whole, bytewise packSniffer
+		got := whole.feed([]byte(tc.stream))
+		var trickled int64
+		for i := range len(tc.stream) {
+			trickled = bytewise.feed([]byte{tc.stream[i]})
+		}
+		if got != tc.want || trickled != tc.want {
+			t.Errorf("%s: objects = %d (whole), %d (bytewise), want %d", name, got, trickled, tc.want)
+		}
+	}
+}
+
+// serveRepo serves a repository with a few files, an escaping symlink
+// and an internal one over smart HTTP through git-http-backend.
+func serveRepo(t *testing.T) ai.RepoURL {
+	t.Helper()
+	git, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("git binary needed for git-http-backend")
+	}
+	execPath, err := exec.Command(git, "--exec-path").Output()
+	if err != nil {
+		t.Skip("git --exec-path: ", err)
+	}
+	root := t.TempDir()
+	src := filepath.Join(root, "repo")
+	repo, err := gogit.PlainInit(src, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range map[string]string{"main.go": "package main\n", "lib/util.go": "package lib\n", "README.md": strings.Repeat("docs ", 100)} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(src, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(src, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("/etc/passwd", filepath.Join(src, "passwd.go")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("../main.go", filepath.Join(src, "lib", "main.go")); err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.AddGlob("."); err != nil {
+		t.Fatal(err)
+	}
+	sig := &object.Signature{Name: "t", Email: "t@example.com", When: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	if _, err := wt.Commit("init", &gogit.CommitOptions{Author: sig, Committer: sig}); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(&cgi.Handler{
+		Path: filepath.Join(strings.TrimSpace(string(execPath)), "git-http-backend"),
+		Env:  []string{"GIT_PROJECT_ROOT=" + root, "GIT_HTTP_EXPORT_ALL=1"},
+	})
+	t.Cleanup(srv.Close)
+	return ai.RepoURL(srv.URL + "/repo/.git")
+}
+
+func TestCloneLimits(t *testing.T) {
+	url := serveRepo(t)
+
+	c := NewCloner(t.TempDir(), 1<<20)
+	cloned, err := c.Clone(context.Background(), url, "")
+	if err != nil {
+		t.Fatalf("Clone: %v", err)
+	}
+	defer func() { _ = cloned.Cleanup() }()
+	if _, err := os.Stat(filepath.Join(cloned.Path, "main.go")); err != nil {
+		t.Errorf("main.go not checked out: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(cloned.Path, "passwd.go")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("escaping symlink kept: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cloned.Path, "lib", "main.go")); err != nil {
+		t.Errorf("internal symlink dropped: %v", err)
+	}
+
+	cases := []struct {
+		name  string
+		limit func(*Cloner)
+		want  string
+	}{
+		{"objects", func(c *Cloner) { c.MaxObjects = 2 }, "objects, limit is 2"},
+		{"transfer", func(c *Cloner) { c.MaxTransferBytes = 200 }, "transferred more than 200 bytes"},
+		{"checkout", func(c *Cloner) { c.MaxBytes = 100 }, "exceeds 100"},
+		{"timeout", func(c *Cloner) { c.Timeout = time.Nanosecond }, "took longer than 1ns"},
+	}
+	for _, tc := range cases {
+		base := t.TempDir()
+		c := NewCloner(base, 1<<20)
+		tc.limit(c)
+		_, err := c.Clone(context.Background(), url, "")
+		if !errors.Is(err, aiapp.ErrCloneLimit) || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: err = %v, want ErrCloneLimit naming %q", tc.name, err, tc.want)
+		}
+		if left, _ := os.ReadDir(base); len(left) != 0 {
+			t.Errorf("%s: working copy left behind", tc.name)
+		}
+	}
+}
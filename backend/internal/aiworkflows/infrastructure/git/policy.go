@@ -0,0 +1,220 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+
+	aiapp "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/application"
+	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
+)
+
+// TargetPolicy decides which hosts the cloner may fetch from, so a
+// user-supplied repo URL can't turn the worker into a probe of the
+// network it runs in. Host patterns are exact host names or
+// "*.example.com" for any subdomain; matching ignores case and port.
+//
+// The policy is checked on the URL before cloning, on every redirect,
+// and — because DNS can answer differently the second time — on the
+// address each connection is actually made to.
+type TargetPolicy struct {
+	// AllowHosts, when non-empty, is the only hosts clones may use.
+	AllowHosts []string
+	// DenyHosts are refused even if allowed.
+	DenyHosts []string
+	// AllowPrivate lets hosts resolve to loopback, private, link-local
+	// and other internal addresses — for a self-hosted forge on the
+	// same network, best combined with AllowHosts.
+	AllowPrivate bool
+}
+
+// CheckHost applies the allow- and denylists to host.
+func (p *TargetPolicy) CheckHost(host string) error {
+	host = normalizeHost(host)
+	if host == "" {
+		return fmt.Errorf("%w: no host", aiapp.ErrTargetNotAllowed)
+	}
+	if matchHost(p.DenyHosts, host) {
+		return fmt.Errorf("%w: host %s is denied", aiapp.ErrTargetNotAllowed, host)
+	}
+	if len(p.AllowHosts) > 0 && !matchHost(p.AllowHosts, host) {
+		return fmt.Errorf("%w: host %s is not on the allowlist", aiapp.ErrTargetNotAllowed, host)
+	}
+	return nil
+}
+
+// CheckAddr rejects internal addresses unless AllowPrivate is set.
+func (p *TargetPolicy) CheckAddr(addr netip.Addr) error {
+	if p.AllowPrivate {
+		return nil
+	}
+	if kind := internalKind(addr); kind != "" {
+		return fmt.Errorf("%w: %s is a %s address", aiapp.ErrTargetNotAllowed, addr, kind)
+	}
+	return nil
+}
+
+// CheckURL is the pre-flight check: the scheme, the host lists, and
+// every address the host currently resolves to.
+func (p *TargetPolicy) CheckURL(ctx context.Context, raw ai.RepoURL) error {
+	u, err := url.Parse(raw.String())
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("%w: only http(s) URLs can be cloned", aiapp.ErrTargetNotAllowed)
+	}
+	if err := p.CheckHost(u.Hostname()); err != nil {
+		return err
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", u.Hostname(), err)
+	}
+	for _, addr := range addrs {
+		if err := p.CheckAddr(addr); err != nil {
+			return fmt.Errorf("host %s: %w", u.Hostname(), err)
+		}
+	}
+	return nil
+}
+
+// extraInternal are the internal ranges netip has no predicate for.
+var extraInternal = []struct {
+	prefix netip.Prefix
+	kind   string
+}{
+	{netip.MustParsePrefix("0.0.0.0/8"), "this-network"},
+	// Carrier-grade NAT; some clouds put metadata services here.
+	{netip.MustParsePrefix("100.64.0.0/10"), "shared"},
+	{netip.MustParsePrefix("192.0.0.0/24"), "protocol-assignment"},
+	{netip.MustParsePrefix("198.18.0.0/15"), "benchmarking"},
+	{netip.MustParsePrefix("240.0.0.0/4"), "reserved"},
+}
+
+// internalKind names the kind of internal address addr is, or returns
+// "" for a public one. Link-local covers 169.254.169.254, the metadata
+// endpoint of the major clouds; private covers fd00:ec2::254.
+func internalKind(addr netip.Addr) string {
+	addr = addr.Unmap()
+	switch {
+	case !addr.IsValid():
+		return "invalid"
+	case addr.IsLoopback():
+		return "loopback"
+	case addr.IsUnspecified():
+		return "unspecified"
+	case addr.IsLinkLocalUnicast(), addr.IsLinkLocalMulticast():
+		return "link-local"
+	case addr.IsPrivate():
+		return "private"
+	case addr.IsMulticast(), addr.IsInterfaceLocalMulticast():
+		return "multicast"
+	}
+	for _, r := range extraInternal {
+		if r.prefix.Contains(addr) {
+			return r.kind
+		}
+	}
+	return ""
+}
+
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
+
+// matchHost reports whether host matches one of patterns.
+func matchHost(patterns []string, host string) bool {
+	for _, p := range patterns {
+		p = normalizeHost(p)
+		if suffix, ok := strings.CutPrefix(p, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+			continue
+		}
+		if p == host {
+			return true
+		}
+	}
+	return false
+}
+
+type policyKey struct{}
+
+// withPolicy hands p to the guarded transport for the requests made
+// under ctx.
+func withPolicy(ctx context.Context, p *TargetPolicy) context.Context {
+	return context.WithValue(ctx, policyKey{}, p)
+}
+
+func policyFrom(ctx context.Context) *TargetPolicy {
+	p, _ := ctx.Value(policyKey{}).(*TargetPolicy)
+	return p
+}
+
+var installOnce sync.Once
+
+// installGuardedTransport replaces go-git's http(s) transport with one
+// built on guardedClient. go-git keeps transports in a process-wide
+// registry, which is why the policy and the transfer budget travel in
+// the request context rather than in the transport.
+func installGuardedTransport() {
+	installOnce.Do(func() {
+		guarded := githttp.NewClient(guardedClient())
+		client.InstallProtocol("http", guarded)
+		client.InstallProtocol("https", guarded)
+	})
+}
+
+// guardedClient is an HTTP client that enforces the policy carried by
+// each request's context on every connection it dials and every
+// redirect it follows, and meters responses against the context's
+// transfer budget; requests without either are not restricted. Under a
+// policy it connects directly, ignoring HTTP(S)_PROXY: through a proxy
+// the dial check would see the proxy's address, not the target's.
+func guardedClient() *http.Client {
+	dialer := &net.Dialer{
+		ControlContext: func(ctx context.Context, _, address string, _ syscall.RawConn) error {
+			p := policyFrom(ctx)
+			if p == nil {
+				return nil
+			}
+			ap, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("%w: unexpected address %q", aiapp.ErrTargetNotAllowed, address)
+			}
+			return p.CheckAddr(ap.Addr())
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		if policyFrom(req.Context()) != nil {
+			return nil, nil
+		}
+		return http.ProxyFromEnvironment(req)
+	}
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Transport: meteredTransport{base: transport},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			p := policyFrom(req.Context())
+			if p == nil {
+				return nil
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("%w: redirect to %s", aiapp.ErrTargetNotAllowed, req.URL.Scheme)
+			}
+			if err := p.CheckHost(req.URL.Hostname()); err != nil {
+				return fmt.Errorf("redirect: %w", err)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,125 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+
+	aiapp "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/application"
+	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
+)
+
+func TestTargetPolicy_CheckHost(t *testing.T) {
+	p := &TargetPolicy{
+		AllowHosts: []string{"github.com", "*.example.com"},
+		DenyHosts:  []string{"evil.example.com"},
+	}
+	cases := map[string]bool{
+		"github.com":         true,
+		"GitHub.com.":        true,
+		"git.example.com":    true,
+		"a.b.example.com":    true,
+		"example.com":        false, // the wildcard only covers subdomains
+		"evil.example.com":   false,
+		"gitlab.com":         false,
+		"github.com.evil.io": false,
+		"":                   false,
+	}
+	for host, ok := range cases {
+		err := p.CheckHost(host)
+		if ok != (err == nil) {
+			t.Errorf("CheckHost(%q) = %v, want allowed=%v", host, err, ok)
+		}
+		if err != nil && !errors.Is(err, aiapp.ErrTargetNotAllowed) {
+			t.Errorf("CheckHost(%q) = %v, want ErrTargetNotAllowed", host, err)
+		}
+	}
+	if err := (&TargetPolicy{}).CheckHost("anything.dev"); err != nil {
+		t.Errorf("an empty allowlist should allow any host: %v", err)
+	}
+}
+
+func TestTargetPolicy_CheckAddr(t *testing.T) {
+	p := &TargetPolicy{}
+	cases := map[string]bool{
+		"140.82.121.3":     true,
+		"2606:4700::1111":  true,
+		"127.0.0.1":        false,
+		"::1":              false,
+		"10.1.2.3":         false,
+		"172.20.0.5":       false,
+		"192.168.1.1":      false,
+		"169.254.169.254":  false,
+		"100.100.100.200":  false,
+		"0.0.0.0":          false,
+		"fd00:ec2::254":    false,
+		"fe80::1":          false,
+		"::ffff:127.0.0.1": false,
+	}
+	for raw, ok := range cases {
+		err := p.CheckAddr(netip.MustParseAddr(raw))
+		if ok != (err == nil) {
+			t.Errorf("CheckAddr(%s) = %v, want allowed=%v", raw, err, ok)
+		}
+	}
+	if err := (&TargetPolicy{AllowPrivate: true}).CheckAddr(netip.MustParseAddr("10.1.2.3")); err != nil {
+		t.Errorf("AllowPrivate: %v", err)
+	}
+}
+
+func TestCloneRejectsInternalTargets(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Every request is bounced to the same server under another name.
+		target := *r.URL
+		target.Scheme, target.Host = "http", "localhost:"+srvPort(r)
+		http.Redirect(w, r, target.String(), http.StatusFound)
+	}))
+	defer srv.Close()
+	repo := ai.RepoURL(srv.URL + "/owner/repo.git")
+
+	// Pre-flight: the host resolves to loopback.
+	c := NewCloner(t.TempDir(), 0)
+	c.Policy = &TargetPolicy{}
+	if _, err := c.Clone(context.Background(), repo, ""); !errors.Is(err, aiapp.ErrTargetNotAllowed) {
+		t.Errorf("loopback clone: err = %v, want ErrTargetNotAllowed", err)
+	}
+
+	// Redirects are checked too.
+	c.Policy = &TargetPolicy{AllowPrivate: true, DenyHosts: []string{"localhost"}}
+	if _, err := c.Clone(context.Background(), repo, ""); !errors.Is(err, aiapp.ErrTargetNotAllowed) {
+		t.Errorf("redirected clone: err = %v, want ErrTargetNotAllowed", err)
+	}
+
+	// Only http(s) remotes, whatever the value object let through.
+	if _, err := c.Clone(context.Background(), ai.RepoURL(t.TempDir()), ""); !errors.Is(err, aiapp.ErrTargetNotAllowed) {
+		t.Errorf("local path clone: err = %v, want ErrTargetNotAllowed", err)
+	}
+}
+
+func TestGuardedClientChecksDialedAddress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	defer srv.Close()
+
+	// The host passed the lists; the address it connects to doesn't.
+	ctx := withPolicy(context.Background(), &TargetPolicy{})
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if _, err := guardedClient().Do(req); !errors.Is(err, aiapp.ErrTargetNotAllowed) {
+		t.Errorf("err = %v, want ErrTargetNotAllowed", err)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, srv.URL, nil)
+	res, err := guardedClient().Do(req)
+	if err != nil {
+		t.Fatalf("without a policy: %v", err)
+	}
+	_ = res.Body.Close()
+}
+
+func srvPort(r *http.Request) string {
+	u, _ := url.Parse("http://" + r.Host)
+	return u.Port()
+}
@@ -0,0 +1,233 @@
+// Package inprocess is the aiworkflows context's in-memory workflow
+// engine: the summarize-repo DAG run in goroutines of the calling
+// process, with per-step retries and the failure hook the Hatchet
+// workflow has, but no queue and no persistence. Runs die with the
+// process, which is what local runs and integration tests want.
+package inprocess
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	aiapp "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/application"
+	"github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/infrastructure/workflows"
+)
+
+// DefaultRetries are the Hatchet workflow's retries per task. Traverse
+// is deterministic and gets none.
+var DefaultRetries = map[string]int{
+	"clone":                      3,
+	"traverse":                   0,
+	"summarize-files":            3,
+	"summarize-dirs":             3,
+	"aggregate":                  3,
+	"store":                      3,
+	workflows.StandaloneFileTask: 5,
+}
+
+// DefaultFileSlots is how many files are summarized at once, as many as
+// the Hatchet worker has slots.
+const DefaultFileSlots = 10
+
+// Engine is the in-process HatchetEnqueuer. Configure it before the
+// first run; the zero value of each field means its default.
+type Engine struct {
+	Deps workflows.Deps
+	// Retries overrides DefaultRetries per task name.
+	Retries map[string]int
+	// Backoff is the wait before the first retry of a task, doubling for
+	// each further one up to a minute. Zero retries at once.
+	Backoff time.Duration
+	// FileSlots caps the files summarized at once; 0 means
+	// DefaultFileSlots.
+	FileSlots int
+
+	once  sync.Once
+	slots chan struct{}
+	mu    sync.Mutex
+	// runs holds the runs still going; a run leaves it when it finishes.
+	// Its outcome is on the aggregate by then, so nothing else is kept.
+	runs map[string]*run
+	wg   sync.WaitGroup
+	// finished, when set, is told each run's error before the run
+	// leaves runs. Only tests set it, to wait for a run's result.
+	finished func(runID string, err error)
+}
+
+type run struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+var _ aiapp.HatchetEnqueuer = (*Engine)(nil)
+
+// NewEngine constructs an Engine with the default retries.
+func NewEngine(deps workflows.Deps) *Engine {
+	return &Engine{Deps: deps}
+}
+
+func (e *Engine) init() {
+	e.once.Do(func() {
+		n := e.FileSlots
+		if n <= 0 {
+			n = DefaultFileSlots
+		}
+		e.slots = make(chan struct{}, n)
+		e.runs = make(map[string]*run)
+	})
+}
+
+// EnqueueSummarizeRepo starts the run in a goroutine and returns its
+// ID at once. The run outlives ctx; CancelRun and Shutdown stop it.
+func (e *Engine) EnqueueSummarizeRepo(_ context.Context, in aiapp.EnqueueSummarizeRepoInput) (string, error) {
+	e.init()
+	id := uuid.NewString()
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &run{cancel: cancel, done: make(chan struct{})}
+	e.mu.Lock()
+	e.runs[id] = r
+	e.mu.Unlock()
+
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		err := e.execute(ctx, workflows.NewWorkflowInput(in))
+		cancel()
+		if e.finished != nil {
+			e.finished(id, err)
+		}
+		e.mu.Lock()
+		delete(e.runs, id)
+		e.mu.Unlock()
+		close(r.done)
+	}()
+	return id, nil
+}
+
+// CancelRun cancels the run's context. The steps notice through it or
+// through the aggregate's status, as they do under Hatchet. Unknown and
+// finished runs are left alone.
+func (e *Engine) CancelRun(_ context.Context, runID string) error {
+	e.init()
+	e.mu.Lock()
+	r, ok := e.runs[runID]
+	e.mu.Unlock()
+	if ok {
+		r.cancel()
+	}
+	return nil
+}
+
+// Shutdown cancels every run still going and waits for them to stop,
+// or for ctx to end.
+func (e *Engine) Shutdown(ctx context.Context) error {
+	e.init()
+	e.mu.Lock()
+	for _, r := range e.runs {
+		r.cancel()
+	}
+	e.mu.Unlock()
+	stopped := make(chan struct{})
+	go func() {
+		e.wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// execute runs the DAG: clone → traverse → summarize-files →
+// summarize-dirs → aggregate → store. Any step that fails for good
+// goes through HandleFailure, the Hatchet workflow's OnFailure hook.
+func (e *Engine) execute(ctx context.Context, in workflows.WorkflowInput) (err error) {
+	defer func() {
+		if err != nil {
+			e.Deps.HandleFailure(ctx, in, "workflow failure")
+		}
+	}()
+	d := e.Deps
+	clone, err := retry(ctx, e, "clone", func(ctx context.Context) (workflows.CloneOutput, error) {
+		return d.CloneStep(ctx, in)
+	})
+	if err != nil {
+		return err
+	}
+	traverse, err := retry(ctx, e, "traverse", func(ctx context.Context) (workflows.TraverseOutput, error) {
+		return d.TraverseStep(ctx, in, clone.Path)
+	})
+	if err != nil {
+		return err
+	}
+	summaries, err := retry(ctx, e, "summarize-files", func(ctx context.Context) (workflows.SummarizeFilesOutput, error) {
+		return d.SummarizeFilesStep(ctx, in, traverse, e.runFile)
+	})
+	if err != nil {
+		return err
+	}
+	dirs, err := retry(ctx, e, "summarize-dirs", func(ctx context.Context) (workflows.DirectoriesOutput, error) {
+		return d.SummarizeDirsStep(ctx, in, traverse, summaries)
+	})
+	if err != nil {
+		return err
+	}
+	aggregate, err := retry(ctx, e, "aggregate", func(ctx context.Context) (workflows.AggregateOutput, error) {
+		return d.AggregateStep(ctx, in, traverse, summaries, dirs)
+	})
+	if err != nil {
+		return err
+	}
+	_, err = retry(ctx, e, "store", func(ctx context.Context) (workflows.StoreOutput, error) {
+		return d.StoreStep(ctx, in, traverse, aggregate)
+	})
+	return err
+}
+
+// runFile is the summarize-files fan-out's FileRunner: one child task
+// per file, retried on its own, at most FileSlots at a time.
+func (e *Engine) runFile(ctx context.Context, in workflows.SummarizeFileInput) (workflows.SummarizeFileOutput, error) {
+	select {
+	case e.slots <- struct{}{}:
+	case <-ctx.Done():
+		return workflows.SummarizeFileOutput{}, ctx.Err()
+	}
+	defer func() { <-e.slots }()
+	return retry(ctx, e, workflows.StandaloneFileTask, func(ctx context.Context) (workflows.SummarizeFileOutput, error) {
+		return e.Deps.SummarizeFileStep(ctx, in)
+	})
+}
+
+func (e *Engine) retries(task string) int {
+	if n, ok := e.Retries[task]; ok {
+		return n
+	}
+	return DefaultRetries[task]
+}
+
+// retry runs fn until it succeeds, fails non-retryably, or has used up
+// the task's retries.
+func retry[T any](ctx context.Context, e *Engine, task string, fn func(context.Context) (T, error)) (T, error) {
+	wait := e.Backoff
+	for attempt := 0; ; attempt++ {
+		out, err := fn(ctx)
+		if err == nil || workflows.IsPermanent(err) || attempt >= e.retries(task) {
+			if err != nil {
+				err = fmt.Errorf("%s: %w", task, err)
+			}
+			return out, err
+		}
+		select {
+		case <-ctx.Done():
+			return out, fmt.Errorf("%s: %w", task, err)
+		case <-time.After(wait):
+		}
+		wait = min(2*wait, time.Minute)
+	}
+}
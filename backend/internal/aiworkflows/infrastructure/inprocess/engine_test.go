@@ -0,0 +1,546 @@
+package inprocess
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	aiapp "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/application"
+	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
+	aigit "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/infrastructure/git"
+	"github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/infrastructure/llm"
+	"github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/infrastructure/secrets"
+	"github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/infrastructure/workflows"
+	shared "github.com/atilladeniz/next-go-pg/backend/internal/shared/domain"
+)
+
+// memStore is an in-memory aiapp.Store that, like the database, hands
+// every caller its own copy: the engine's steps run concurrently.
+type memStore struct {
+	mu     sync.Mutex
+	rows   map[uint]ai.RepoSummary
+	nextID uint
+	// afterGet, when set, runs once right after the next GetByID has
+	// taken its copy: a write landing between a caller's load and save.
+	afterGet func()
+}
+
+func newMemStore() *memStore {
+	return &memStore{rows: map[uint]ai.RepoSummary{}, nextID: 1}
+}
+
+func clone(agg ai.RepoSummary) *ai.RepoSummary {
+	agg.Files = slices.Clone(agg.Files)
+	agg.StepDurations = maps.Clone(agg.StepDurations)
+	agg.PromptVersions = maps.Clone(agg.PromptVersions)
+	return &agg
+}
+
+func (s *memStore) Create(_ context.Context, agg *ai.RepoSummary) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	agg.ID = s.nextID
+	agg.CreatedAt = time.Now()
+	s.nextID++
+	s.rows[agg.ID] = *clone(*agg)
+	return nil
+}
+
+// Transition writes only the fields the change sets, and only while
+// the row is in one of from, as the database adapter does.
+func (s *memStore) Transition(_ context.Context, agg *ai.RepoSummary, from ...ai.Status) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	row, ok := s.rows[agg.ID]
+	if !ok || !slices.Contains(from, row.Status) {
+		return aiapp.ErrStatusChanged
+	}
+	row.Status = agg.Status
+	switch agg.Status {
+	case ai.StatusRunning:
+		row.StartedAt, row.PromptVersions = agg.StartedAt, maps.Clone(agg.PromptVersions)
+	case ai.StatusCompleted:
+		row.Summary, row.CompletedAt = agg.Summary, agg.CompletedAt
+	case ai.StatusFailed:
+		row.FailReason, row.CompletedAt = agg.FailReason, agg.CompletedAt
+	case ai.StatusCancelled:
+		row.CompletedAt = agg.CompletedAt
+	}
+	s.rows[agg.ID] = row
+	return nil
+}
+
+func (s *memStore) AppendFiles(_ context.Context, id uint, files []ai.FileSummary) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	row, ok := s.rows[id]
+	if !ok || row.Status != ai.StatusRunning {
+		return aiapp.ErrStatusChanged
+	}
+	r := clone(row)
+	for _, fs := range files {
+		if err := r.AppendFileSummary(fs, 0); err != nil {
+			return err
+		}
+	}
+	r.PullEvents()
+	s.rows[id] = *r
+	return nil
+}
+
+func (s *memStore) Complete(_ context.Context, agg *ai.RepoSummary, u ai.TokenUsage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	row, ok := s.rows[agg.ID]
+	if !ok || row.Status != ai.StatusRunning {
+		return aiapp.ErrStatusChanged
+	}
+	row.Status, row.Summary, row.CompletedAt = agg.Status, agg.Summary, agg.CompletedAt
+	row.Usage = row.Usage.Add(u)
+	s.rows[agg.ID] = row
+	return nil
+}
+
+// update applies fn to a copy of the row and stores it.
+func (s *memStore) update(id uint, fn func(*ai.RepoSummary)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	row, ok := s.rows[id]
+	if !ok {
+		return aiapp.ErrNotFound
+	}
+	r := clone(row)
+	fn(r)
+	s.rows[id] = *r
+	return nil
+}
+
+func (s *memStore) AddUsage(_ context.Context, id uint, u ai.TokenUsage) error {
+	return s.update(id, func(r *ai.RepoSummary) { r.RecordUsage(u) })
+}
+
+func (s *memStore) AttachRun(_ context.Context, id uint, runID string) error {
+	return s.update(id, func(r *ai.RepoSummary) { r.AttachRun(runID) })
+}
+
+func (s *memStore) RecordStepDuration(_ context.Context, id uint, step string, ms int64) error {
+	return s.update(id, func(r *ai.RepoSummary) { r.RecordStepDuration(step, ms) })
+}
+
+func (s *memStore) RecordCommit(_ context.Context, id uint, sha string, at time.Time) error {
+	return s.update(id, func(r *ai.RepoSummary) { r.RecordCommit(sha, at) })
+}
+
+func (s *memStore) RecordSelection(_ context.Context, id uint, sel ai.FileSelection) error {
+	return s.update(id, func(r *ai.RepoSummary) { r.RecordSelection(sel) })
+}
+
+func (s *memStore) RecordChangelog(_ context.Context, id uint, c ai.Changelog) error {
+	return s.update(id, func(r *ai.RepoSummary) { r.RecordChangelog(c) })
+}
+
+func (s *memStore) RecordDirectories(_ context.Context, id uint, tree ai.DirectorySummary) error {
+	return s.update(id, func(r *ai.RepoSummary) { r.RecordDirectories(tree) })
+}
+
+func (s *memStore) GetByID(_ context.Context, id uint) (*ai.RepoSummary, error) {
+	s.mu.Lock()
+	row, ok := s.rows[id]
+	after := s.afterGet
+	s.afterGet = nil
+	s.mu.Unlock()
+	if !ok {
+		return nil, aiapp.ErrNotFound
+	}
+	if after != nil {
+		after()
+	}
+	return clone(row), nil
+}
+
+func (s *memStore) ListByUserID(context.Context, shared.UserID, int) ([]*ai.RepoSummary, error) {
+	return nil, nil
+}
+
+func (s *memStore) Delete(context.Context, shared.UserID, uint) error { return nil }
+
+func (s *memStore) ListAttempts(context.Context, uint) ([]*ai.RepoSummary, error) { return nil, nil }
+
+func (s *memStore) LatestCompleted(context.Context, shared.UserID, ai.RepoURL) (*ai.RepoSummary, error) {
+	return nil, aiapp.ErrNotFound
+}
+
+func (s *memStore) UserUsage(context.Context, shared.UserID, time.Time, time.Time) (aiapp.UserUsage, error) {
+	return aiapp.UserUsage{}, nil
+}
+
+// progressLog records the ai-progress step events.
+type progressLog struct {
+	mu    sync.Mutex
+	steps []string // "step:state"
+}
+
+func (p *progressLog) Publish(context.Context, ...shared.DomainEvent) error { return nil }
+
+func (p *progressLog) PublishStep(_ context.Context, step aiapp.StepProgress) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.steps = append(p.steps, string(step.Step)+":"+string(step.State))
+}
+
+func (p *progressLog) saw(step string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Contains(p.steps, step)
+}
+
+// fixtureFiles is the repository every test summarizes. secrets.toml
+// is a secret store and never selected; config.go carries a credential
+// to redact.
+var fixtureFiles = map[string]string{
+	"main.go":             "package main\n\nfunc main() { run() }\n",
+	"internal/run.go":     "package main\n\nfunc run() {}\n",
+	"internal/config.go":  "package main\n\nconst apiKey = \"" + "Zk3vQ9tLx2Rb7WmN4pHs8YcJ1dGf6KaE" + "\"\n",
+	"README.md":           "# fixture\n\nA tiny CLI.\n",
+	"config/secrets.toml": "db_password = \"hunter2hunter2\"\n",
+}
+
+// serveBareRepo commits files to a repository, clones it bare — the
+// form a forge keeps it in — and serves that over smart HTTP with git's
+// own http-backend, so the real cloner fetches it as it would from a
+// forge.
+func serveBareRepo(t *testing.T, files map[string]string) ai.RepoURL {
+	t.Helper()
+	git, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("git binary needed for git-http-backend")
+	}
+	execPath, err := exec.Command(git, "--exec-path").Output()
+	if err != nil {
+		t.Skip("git --exec-path: ", err)
+	}
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	repo, err := gogit.PlainInit(src, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range files {
+		path := filepath.Join(src, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.AddGlob("."); err != nil {
+		t.Fatal(err)
+	}
+	sig := &object.Signature{Name: "t", Email: "t@example.com", When: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	if _, err := wt.Commit("init", &gogit.CommitOptions{Author: sig, Committer: sig}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gogit.PlainClone(filepath.Join(root, "fixture.git"), true, &gogit.CloneOptions{URL: src}); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(&cgi.Handler{
+		Path: filepath.Join(strings.TrimSpace(string(execPath)), "git-http-backend"),
+		Env:  []string{"GIT_PROJECT_ROOT=" + root, "GIT_HTTP_EXPORT_ALL=1"},
+	})
+	t.Cleanup(srv.Close)
+	return ai.RepoURL(srv.URL + "/fixture.git")
+}
+
+type harness struct {
+	engine   *Engine
+	store    *memStore
+	progress *progressLog
+	url      ai.RepoURL
+
+	mu      sync.Mutex
+	results map[string]error
+}
+
+func newHarness(t *testing.T, model *llm.Stub) *harness {
+	t.Helper()
+	h := &harness{store: newMemStore(), progress: &progressLog{}, url: serveBareRepo(t, fixtureFiles), results: map[string]error{}}
+	cloner := aigit.NewCloner(t.TempDir(), 0)
+	h.engine = NewEngine(workflows.Deps{
+		Cloner:              cloner,
+		LLM:                 model,
+		Store:               h.store,
+		Progress:            h.progress,
+		Model:               "stub",
+		StructuredSummaries: true,
+		MaxFiles:            25,
+		MaxBytes:            64 * 1024,
+		Secrets:             secrets.Scanner{},
+	})
+	h.engine.finished = func(runID string, err error) {
+		h.mu.Lock()
+		h.results[runID] = err
+		h.mu.Unlock()
+	}
+	t.Cleanup(func() { _ = h.engine.Shutdown(context.Background()) })
+	return h
+}
+
+// summarize starts a run through the use case, as the HTTP handler does.
+func (h *harness) summarize(t *testing.T) aiapp.SummarizeRepoOutput {
+	t.Helper()
+	uc := aiapp.SummarizeRepo{Store: h.store, Enqueuer: h.engine}
+	out, err := uc.Execute(context.Background(), aiapp.SummarizeRepoInput{UserID: "user-1", RepoURL: h.url.String()})
+	if err != nil {
+		t.Fatalf("summarize: %v", err)
+	}
+	return out
+}
+
+// wait blocks until the run has finished and returns the error it
+// failed with, if any. A run's result can be waited for once.
+func (h *harness) wait(t *testing.T, runID string) error {
+	t.Helper()
+	h.engine.mu.Lock()
+	r, running := h.engine.runs[runID]
+	h.engine.mu.Unlock()
+	if running {
+		select {
+		case <-r.done:
+		case <-time.After(time.Minute):
+			t.Fatal("run did not finish")
+		}
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	err, ok := h.results[runID]
+	if !ok {
+		t.Fatalf("unknown run %q", runID)
+	}
+	delete(h.results, runID)
+	return err
+}
+
+func (h *harness) run(t *testing.T, id uint) *ai.RepoSummary {
+	t.Helper()
+	agg, err := h.store.GetByID(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return agg
+}
+
+// stubAnswer is the Stub's own answer, for Reply funcs that only
+// change some.
+func stubAnswer(prompt string) (string, error) {
+	c, err := (&llm.Stub{}).Generate(context.Background(), prompt)
+	return c.Text, err
+}
+
+func TestSummarizeRepoEndToEnd(t *testing.T) {
+	h := newHarness(t, &llm.Stub{})
+	out := h.summarize(t)
+	if err := h.wait(t, out.RunID); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	// A finished run leaves nothing behind in the engine.
+	h.engine.mu.Lock()
+	left := len(h.engine.runs)
+	h.engine.mu.Unlock()
+	if left != 0 {
+		t.Errorf("engine still tracks %d entries for a finished run", left)
+	}
+
+	agg := h.run(t, out.SummaryID)
+	if agg.Status != ai.StatusCompleted || agg.Summary != "Stub summary of the repository." {
+		t.Fatalf("run = %s %q (%s)", agg.Status, agg.Summary, agg.FailReason)
+	}
+	if agg.RunID != out.RunID || agg.CommitSHA == "" {
+		t.Errorf("run ID = %q, commit = %q", agg.RunID, agg.CommitSHA)
+	}
+	var names []string
+	for _, f := range agg.Files {
+		names = append(names, f.Filename())
+		if f.Insights().Purpose == "" {
+			t.Errorf("%s: no structured insights", f.Filename())
+		}
+	}
+	slices.Sort(names)
+	if want := []string{"README.md", "internal/config.go", "internal/run.go", "main.go"}; !slices.Equal(names, want) {
+		t.Errorf("summarized %v, want %v", names, want)
+	}
+	if !slices.Equal(agg.Selection.SkippedSecrets, []string{"config/secrets.toml"}) {
+		t.Errorf("skipped secrets = %v, want [config/secrets.toml]", agg.Selection.SkippedSecrets)
+	}
+	if agg.Redactions()["secret-assignment"] != 1 {
+		t.Errorf("redactions = %v", agg.Redactions())
+	}
+	if agg.Usage.PromptTokens == 0 {
+		t.Error("no usage recorded")
+	}
+	for _, step := range []string{"clone:completed", "traverse:completed", "summarize_files:progress", "summarize_files:completed", "aggregate:completed", "store:completed"} {
+		if !h.progress.saw(step) {
+			t.Errorf("no %s event in %v", step, h.progress.steps)
+		}
+	}
+}
+
+func TestFailedFileIsRetried(t *testing.T) {
+	var calls atomic.Int32
+	h := newHarness(t, &llm.Stub{Reply: func(prompt string) (string, error) {
+		if strings.Contains(prompt, "FILENAME: main.go") && calls.Add(1) == 1 {
+			return "", errors.New("upstream 502")
+		}
+		return stubAnswer(prompt)
+	}})
+	out := h.summarize(t)
+	if err := h.wait(t, out.RunID); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if agg := h.run(t, out.SummaryID); agg.Status != ai.StatusCompleted || !agg.HasFile("main.go") {
+		t.Errorf("run = %s with %d files, want completed with main.go", agg.Status, len(agg.Files))
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("main.go prompted %d times, want 2", n)
+	}
+}
+
+func TestRunFailsWhenRetriesRunOut(t *testing.T) {
+	var calls atomic.Int32
+	h := newHarness(t, &llm.Stub{Reply: func(prompt string) (string, error) {
+		if strings.Contains(prompt, "FILENAME: README.md") {
+			calls.Add(1)
+			return "", errors.New("upstream 502")
+		}
+		return stubAnswer(prompt)
+	}})
+	h.engine.Retries = map[string]int{workflows.StandaloneFileTask: 1, "summarize-files": 0}
+	out := h.summarize(t)
+	err := h.wait(t, out.RunID)
+	if err == nil || !strings.Contains(err.Error(), "upstream 502") {
+		t.Fatalf("run error = %v, want the LLM's", err)
+	}
+	agg := h.run(t, out.SummaryID)
+	if agg.Status != ai.StatusFailed || agg.FailReason != "workflow failure" {
+		t.Errorf("run = %s (%q), want failed by the failure hook", agg.Status, agg.FailReason)
+	}
+	// The files that did succeed are kept for a retry to reuse.
+	if !agg.HasFile("main.go") || agg.HasFile("README.md") {
+		t.Errorf("kept %d files", len(agg.Files))
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("README.md prompted %d times, want 2", n)
+	}
+	if !h.progress.saw("summarize_files:failed") || h.progress.saw("aggregate:started") {
+		t.Errorf("steps = %v", h.progress.steps)
+	}
+}
+
+func TestCancelStopsTheRun(t *testing.T) {
+	started := make(chan struct{}, len(fixtureFiles))
+	release := make(chan struct{})
+	h := newHarness(t, &llm.Stub{Reply: func(prompt string) (string, error) {
+		started <- struct{}{}
+		<-release
+		return stubAnswer(prompt)
+	}})
+	out := h.summarize(t)
+	<-started
+
+	cancelUC := aiapp.CancelSummary{Store: h.store, Enqueuer: h.engine}
+	if _, err := cancelUC.Execute(context.Background(), aiapp.CancelSummaryInput{UserID: "user-1", SummaryID: out.SummaryID}); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	close(release)
+	if err := h.wait(t, out.RunID); err == nil {
+		t.Fatal("a cancelled run finished without error")
+	}
+	if agg := h.run(t, out.SummaryID); agg.Status != ai.StatusCancelled {
+		t.Errorf("status = %s, want cancelled", agg.Status)
+	}
+	if h.progress.saw("aggregate:started") || h.progress.saw("store:completed") {
+		t.Errorf("steps after the cancel ran: %v", h.progress.steps)
+	}
+}
+
+func TestCancelBetweenLoadAndSave(t *testing.T) {
+	ctx := context.Background()
+	store := newMemStore()
+	d := workflows.Deps{Store: store, Progress: &progressLog{}}
+	start := func() *ai.RepoSummary {
+		agg := ai.NewRepoSummary("user-1", "https://github.com/acme/tool")
+		if err := store.Create(ctx, agg); err != nil {
+			t.Fatal(err)
+		}
+		if err := agg.MarkStarted(time.Now()); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Transition(ctx, agg, ai.StatusPending); err != nil {
+			t.Fatal(err)
+		}
+		return agg
+	}
+	load := func(id uint) *ai.RepoSummary {
+		agg, err := store.GetByID(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return agg
+	}
+	cancel := func(id uint) func() {
+		return func() {
+			uc := aiapp.CancelSummary{Store: store}
+			if _, err := uc.Execute(ctx, aiapp.CancelSummaryInput{UserID: "user-1", SummaryID: id}); err != nil {
+				t.Errorf("cancel: %v", err)
+			}
+		}
+	}
+
+	// A cancel after the store step loaded the running row wins: the
+	// step must not complete the run over it.
+	agg := start()
+	store.afterGet = cancel(agg.ID)
+	in := workflows.WorkflowInput{SummaryID: agg.ID, UserID: "user-1"}
+	_, err := d.StoreStep(ctx, in, workflows.TraverseOutput{}, workflows.AggregateOutput{Summary: "Done."})
+	if !workflows.IsPermanent(err) {
+		t.Errorf("store step err = %v, want a permanent cancellation", err)
+	}
+	if got := load(agg.ID); got.Status != ai.StatusCancelled || got.Summary != "" {
+		t.Errorf("run = %s %q, want cancelled without a summary", got.Status, got.Summary)
+	}
+
+	// And the other way round: a step's writes landing after the cancel
+	// loaded the row survive it.
+	agg = start()
+	fs, _ := ai.NewFileSummary("main.go", "Entry point.")
+	store.afterGet = func() {
+		if err := store.AppendFiles(ctx, agg.ID, []ai.FileSummary{fs}); err != nil {
+			t.Errorf("append: %v", err)
+		}
+		_ = store.RecordCommit(ctx, agg.ID, "abc123", time.Now())
+	}
+	cancel(agg.ID)()
+	got := load(agg.ID)
+	if got.Status != ai.StatusCancelled || !got.HasFile("main.go") || got.CommitSHA != "abc123" {
+		t.Errorf("run = %s with %d files at %q, want cancelled keeping both writes", got.Status, len(got.Files), got.CommitSHA)
+	}
+}
@@ -0,0 +1,143 @@
+// Package jobs is the aiworkflows context's River-side adapter: the
+// summarize-repo run as a chain of River jobs, one per step, for
+// deployments that would rather not operate a Hatchet engine. The steps
+// themselves are the ones in the workflows package, so both engines
+// write the same rows and publish the same ai-progress events.
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/riverqueue/river"
+
+	"github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/infrastructure/workflows"
+)
+
+// Step names, as the Hatchet workflow calls its tasks.
+const (
+	StepClone          = "clone"
+	StepTraverse       = "traverse"
+	StepSummarizeFiles = "summarize-files"
+	StepSummarizeDirs  = "summarize-dirs"
+	StepAggregate      = "aggregate"
+	StepStore          = "store"
+)
+
+// steps is the run's order and each step's attempts — the Hatchet
+// workflow's retries plus one. Traverse is deterministic and gets none.
+var steps = []struct {
+	name     string
+	attempts int
+}{
+	{StepClone, 4},
+	{StepTraverse, 1},
+	{StepSummarizeFiles, 4},
+	{StepSummarizeDirs, 4},
+	{StepAggregate, 4},
+	{StepStore, 4},
+}
+
+// FileQueue is where the summarize-file jobs run. The summarize-files
+// job waits for them, so they must not compete with it for the default
+// queue's workers.
+const FileQueue = "ai_files"
+
+// runMetadataKey tags every job of a run with the run ID, which is how
+// CancelRun finds them.
+const runMetadataKey = "ai_run_id"
+
+// StepArgs is one step of a summarize-repo run. The outputs of the
+// steps before it ride along, so each job has everything its step needs
+// without the previous job's row. Only RunID and Step make a job
+// unique: a step is enqueued once however often the step before it
+// hands over.
+type StepArgs struct {
+	RunID string                  `json:"runId" river:"unique"`
+	Step  string                  `json:"step" river:"unique"`
+	Input workflows.WorkflowInput `json:"input"`
+
+	Clone     *workflows.CloneOutput          `json:"clone,omitempty"`
+	Traverse  *workflows.TraverseOutput       `json:"traverse,omitempty"`
+	Summaries *workflows.SummarizeFilesOutput `json:"summaries,omitempty"`
+	Dirs      *workflows.DirectoriesOutput    `json:"dirs,omitempty"`
+	Aggregate *workflows.AggregateOutput      `json:"aggregate,omitempty"`
+}
+
+func (StepArgs) Kind() string { return "ai_summarize_repo_step" }
+
+// FileArgs is one file of the summarize-files fan-out.
+type FileArgs struct {
+	RunID string                       `json:"runId"`
+	Input workflows.SummarizeFileInput `json:"input"`
+}
+
+func (FileArgs) Kind() string { return "ai_summarize_file" }
+
+func runMetadata(runID string) []byte {
+	return fmt.Appendf(nil, `{%q:%q}`, runMetadataKey, runID)
+}
+
+func (a StepArgs) insertOpts() *river.InsertOpts {
+	opts := &river.InsertOpts{
+		Metadata:   runMetadata(a.RunID),
+		UniqueOpts: river.UniqueOpts{ByArgs: true},
+	}
+	for _, s := range steps {
+		if s.name == a.Step {
+			opts.MaxAttempts = s.attempts
+		}
+	}
+	return opts
+}
+
+// fileAttempts matches the Hatchet child task's five retries.
+const fileAttempts = 6
+
+func (a FileArgs) insertOpts() *river.InsertOpts {
+	return &river.InsertOpts{
+		Queue:       FileQueue,
+		MaxAttempts: fileAttempts,
+		Metadata:    runMetadata(a.RunID),
+		// A retried summarize-files job finds the children its earlier
+		// attempt enqueued — finished or not — instead of adding more.
+		UniqueOpts: river.UniqueOpts{ByArgs: true},
+	}
+}
+
+// next is the step after a.Step, carrying output — a.Step's JSON
+// output — along with the earlier ones; ok is false after the last
+// step.
+func (a StepArgs) next(output []byte) (next StepArgs, ok bool, err error) {
+	next = a
+	switch a.Step {
+	case StepClone:
+		next.Clone = new(workflows.CloneOutput)
+		err = json.Unmarshal(output, next.Clone)
+	case StepTraverse:
+		next.Traverse = new(workflows.TraverseOutput)
+		err = json.Unmarshal(output, next.Traverse)
+	case StepSummarizeFiles:
+		next.Summaries = new(workflows.SummarizeFilesOutput)
+		err = json.Unmarshal(output, next.Summaries)
+	case StepSummarizeDirs:
+		next.Dirs = new(workflows.DirectoriesOutput)
+		err = json.Unmarshal(output, next.Dirs)
+	case StepAggregate:
+		next.Aggregate = new(workflows.AggregateOutput)
+		err = json.Unmarshal(output, next.Aggregate)
+	case StepStore:
+		return StepArgs{}, false, nil
+	default:
+		return StepArgs{}, false, fmt.Errorf("unknown step %q", a.Step)
+	}
+	if err != nil {
+		return StepArgs{}, false, fmt.Errorf("decode %s output: %w", a.Step, err)
+	}
+	for i, s := range steps[:len(steps)-1] {
+		if s.name == a.Step {
+			next.Step = steps[i+1].name
+		}
+	}
+	return next, true, nil
+}
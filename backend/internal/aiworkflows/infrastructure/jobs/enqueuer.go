@@ -0,0 +1,89 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/riverqueue/river"
+	"github.com/riverqueue/river/rivertype"
+
+	aiapp "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/application"
+	"github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/infrastructure/workflows"
+)
+
+// RiverClient is the subset of *river.Client this adapter needs.
+type RiverClient interface {
+	Insert(ctx context.Context, args river.JobArgs, opts *river.InsertOpts) (*rivertype.JobInsertResult, error)
+	JobGet(ctx context.Context, id int64) (*rivertype.JobRow, error)
+	JobList(ctx context.Context, params *river.JobListParams) (*river.JobListResult, error)
+	JobCancel(ctx context.Context, id int64) (*rivertype.JobRow, error)
+}
+
+// Enqueuer is the River-backed HatchetEnqueuer: the port is named for
+// the engine it was written against, but only asks for runs to be
+// started and cancelled.
+type Enqueuer struct {
+	client RiverClient
+}
+
+var _ aiapp.HatchetEnqueuer = (*Enqueuer)(nil)
+
+func NewEnqueuer(client RiverClient) *Enqueuer {
+	return &Enqueuer{client: client}
+}
+
+// EnqueueSummarizeRepo enqueues the clone step of a new run. The run ID
+// is minted here; every job of the run carries it in its metadata.
+func (e *Enqueuer) EnqueueSummarizeRepo(ctx context.Context, in aiapp.EnqueueSummarizeRepoInput) (string, error) {
+	args := StepArgs{
+		RunID: uuid.NewString(),
+		Step:  StepClone,
+		Input: workflows.NewWorkflowInput(in),
+	}
+	if _, err := e.client.Insert(ctx, args, args.insertOpts()); err != nil {
+		return "", err
+	}
+	return args.RunID, nil
+}
+
+// unfinished are the states a job can still be cancelled in.
+var unfinished = []rivertype.JobState{
+	rivertype.JobStateAvailable,
+	rivertype.JobStatePending,
+	rivertype.JobStateRetryable,
+	rivertype.JobStateRunning,
+	rivertype.JobStateScheduled,
+}
+
+// CancelRun cancels the run's jobs that have not finished yet, the
+// summarize-file children included. A running job has its context
+// cancelled; the steps also check the aggregate's status, so one that
+// is past the point of noticing still stops before the next LLM call.
+func (e *Enqueuer) CancelRun(ctx context.Context, runID string) error {
+	if _, err := uuid.Parse(runID); err != nil {
+		return fmt.Errorf("invalid run id %q: %w", runID, err)
+	}
+	params := river.NewJobListParams().
+		Kinds(StepArgs{}.Kind(), FileArgs{}.Kind()).
+		Metadata(string(runMetadata(runID))).
+		States(unfinished...).
+		First(1000)
+	res, err := e.client.JobList(ctx, params)
+	if err != nil {
+		return err
+	}
+	for _, job := range res.Jobs {
+		if _, err := e.client.JobCancel(ctx, job.ID); err != nil {
+			return fmt.Errorf("cancel job %d: %w", job.ID, err)
+		}
+	}
+	return nil
+}
+
+// Register hooks this context's workers into a River workers registry.
+// The client running them must also serve FileQueue.
+func Register(workers *river.Workers, deps workflows.Deps) {
+	river.AddWorker(workers, NewStepWorker(deps))
+	river.AddWorker(workers, NewFileWorker(deps))
+}
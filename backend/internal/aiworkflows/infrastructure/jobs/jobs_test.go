@@ -0,0 +1,179 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/riverqueue/river"
+	"github.com/riverqueue/river/rivertype"
+
+	aiapp "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/application"
+	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
+	"github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/infrastructure/workflows"
+	shared "github.com/atilladeniz/next-go-pg/backend/internal/shared/domain"
+)
+
+type fakeClient struct {
+	inserted  []river.JobArgs
+	opts      []*river.InsertOpts
+	polls     []*rivertype.JobRow // JobGet answers, in order
+	listed    *river.JobListParams
+	jobs      []*rivertype.JobRow
+	cancelled []int64
+}
+
+func (c *fakeClient) Insert(_ context.Context, args river.JobArgs, opts *river.InsertOpts) (*rivertype.JobInsertResult, error) {
+	c.inserted = append(c.inserted, args)
+	c.opts = append(c.opts, opts)
+	return &rivertype.JobInsertResult{Job: &rivertype.JobRow{ID: int64(len(c.inserted)), State: rivertype.JobStateAvailable}}, nil
+}
+
+func (c *fakeClient) JobGet(_ context.Context, id int64) (*rivertype.JobRow, error) {
+	if len(c.polls) == 0 {
+		return nil, errors.New("no such job")
+	}
+	job := c.polls[0]
+	c.polls = c.polls[1:]
+	return job, nil
+}
+
+func (c *fakeClient) JobList(_ context.Context, params *river.JobListParams) (*river.JobListResult, error) {
+	c.listed = params
+	return &river.JobListResult{Jobs: c.jobs}, nil
+}
+
+func (c *fakeClient) JobCancel(_ context.Context, id int64) (*rivertype.JobRow, error) {
+	c.cancelled = append(c.cancelled, id)
+	return &rivertype.JobRow{ID: id, State: rivertype.JobStateCancelled}, nil
+}
+
+func TestStepArgsCarryOutputsAlong(t *testing.T) {
+	args := StepArgs{RunID: "run", Step: StepClone, Input: workflows.WorkflowInput{SummaryID: 7}}
+	outputs := map[string]any{
+		StepClone:          workflows.CloneOutput{Path: "/tmp/repo"},
+		StepTraverse:       workflows.TraverseOutput{Path: "/tmp/repo", Files: []string{"main.go"}},
+		StepSummarizeFiles: workflows.SummarizeFilesOutput{Summaries: []workflows.SummarizeFileOutput{{Filename: "main.go", Summary: "Entry point."}}},
+		StepSummarizeDirs:  workflows.DirectoriesOutput{Root: workflows.Directory{Summary: "A CLI."}},
+		StepAggregate:      workflows.AggregateOutput{Summary: "Does things."},
+		StepStore:          workflows.StoreOutput{OK: true},
+	}
+	var order []string
+	for {
+		order = append(order, args.Step)
+		if opts := args.insertOpts(); opts.MaxAttempts == 0 || !opts.UniqueOpts.ByArgs {
+			t.Errorf("%s: insert opts = %+v", args.Step, opts)
+		}
+		out, err := json.Marshal(outputs[args.Step])
+		if err != nil {
+			t.Fatal(err)
+		}
+		next, ok, err := args.next(out)
+		if err != nil {
+			t.Fatalf("%s: next: %v", args.Step, err)
+		}
+		if !ok {
+			break
+		}
+		args = next
+	}
+	if got := strings.Join(order, " → "); got != "clone → traverse → summarize-files → summarize-dirs → aggregate → store" {
+		t.Errorf("steps ran as %s", got)
+	}
+	if args.Input.SummaryID != 7 || args.Clone.Path != "/tmp/repo" || args.Traverse.Files[0] != "main.go" ||
+		args.Summaries.Summaries[0].Summary != "Entry point." || args.Dirs.Root.Summary != "A CLI." ||
+		args.Aggregate.Summary != "Does things." {
+		t.Errorf("store step args = %+v, want every earlier output", args)
+	}
+
+	if _, _, err := (StepArgs{Step: "deploy"}).next(nil); err == nil {
+		t.Error("an unknown step must fail")
+	}
+	if _, _, err := (StepArgs{Step: StepClone}).next([]byte("{")); err == nil {
+		t.Error("undecodable output must fail")
+	}
+}
+
+func TestRunRefusesMissingOutputs(t *testing.T) {
+	w := NewStepWorker(workflows.Deps{})
+	for _, step := range []string{StepTraverse, StepSummarizeFiles, StepSummarizeDirs, StepAggregate, StepStore, "deploy"} {
+		_, err := w.run(context.Background(), &fakeClient{}, StepArgs{RunID: "run", Step: step})
+		var cancel *rivertype.JobCancelError
+		if !errors.As(err, &cancel) {
+			t.Errorf("%s without earlier outputs: err = %v, want a cancelled job", step, err)
+		}
+	}
+}
+
+func TestFileRunnerWaitsForTheChild(t *testing.T) {
+	done, err := json.Marshal(workflows.SummarizeFileOutput{Filename: "main.go", Summary: "Entry point."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &fakeClient{polls: []*rivertype.JobRow{
+		{ID: 1, State: rivertype.JobStateRunning},
+		{ID: 1, State: rivertype.JobStateCompleted, Metadata: []byte(`{"ai_run_id":"run","output":` + string(done) + `}`)},
+	}}
+	w := NewStepWorker(workflows.Deps{})
+	w.pollInterval = time.Millisecond
+
+	out, err := w.fileRunner(client, "run")(context.Background(), workflows.SummarizeFileInput{SummaryID: 7, Filename: "main.go"})
+	if err != nil {
+		t.Fatalf("run file: %v", err)
+	}
+	if out.Summary != "Entry point." {
+		t.Errorf("output = %+v", out)
+	}
+	args, ok := client.inserted[0].(FileArgs)
+	if !ok || args.RunID != "run" || args.Input.Filename != "main.go" {
+		t.Errorf("inserted %+v", client.inserted)
+	}
+	if opts := client.opts[0]; opts.Queue != FileQueue || opts.MaxAttempts != fileAttempts {
+		t.Errorf("file job opts = %+v", opts)
+	}
+
+	// A child that gave up fails the file with its last error.
+	client = &fakeClient{polls: []*rivertype.JobRow{{
+		ID:     1,
+		State:  rivertype.JobStateDiscarded,
+		Errors: []rivertype.AttemptError{{Error: "rate limited"}, {Error: "upstream 502"}},
+	}}}
+	_, err = w.fileRunner(client, "run")(context.Background(), workflows.SummarizeFileInput{Filename: "main.go"})
+	if err == nil || !strings.Contains(err.Error(), "upstream 502") {
+		t.Errorf("discarded child: err = %v", err)
+	}
+}
+
+func TestEnqueuerStartsAndCancelsRuns(t *testing.T) {
+	client := &fakeClient{}
+	e := NewEnqueuer(client)
+	runID, err := e.EnqueueSummarizeRepo(context.Background(), aiapp.EnqueueSummarizeRepoInput{
+		SummaryID: 7,
+		UserID:    shared.UserID("user-1"),
+		RepoURL:   ai.RepoURL("https://github.com/acme/tool"),
+	})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	args, ok := client.inserted[0].(StepArgs)
+	if !ok || args.Step != StepClone || args.RunID != runID || args.Input.SummaryID != 7 || args.Input.RepoURL != "https://github.com/acme/tool" {
+		t.Errorf("inserted %+v", client.inserted)
+	}
+	if meta := string(client.opts[0].Metadata); meta != `{"ai_run_id":"`+runID+`"}` {
+		t.Errorf("metadata = %s", meta)
+	}
+
+	client.jobs = []*rivertype.JobRow{{ID: 3}, {ID: 4}}
+	if err := e.CancelRun(context.Background(), runID); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if client.listed == nil || len(client.cancelled) != 2 {
+		t.Errorf("cancelled %v", client.cancelled)
+	}
+	if err := e.CancelRun(context.Background(), "not-a-run"); err == nil {
+		t.Error("a malformed run ID must be rejected")
+	}
+}
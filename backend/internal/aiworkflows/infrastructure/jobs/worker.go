@@ -0,0 +1,211 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/riverqueue/river"
+	"github.com/riverqueue/river/rivertype"
+
+	"github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/infrastructure/workflows"
+)
+
+// clientFrom returns the River client running the job behind ctx. A
+// field rather than a direct call so tests can hand in a fake.
+type clientFrom func(ctx context.Context) (RiverClient, error)
+
+func contextClient(ctx context.Context) (RiverClient, error) {
+	client, err := river.ClientFromContextSafely[pgx.Tx](ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// StepWorker runs one step of a summarize-repo run and enqueues the
+// next. The step's output is recorded on its job row before the hand-
+// off, so when only the hand-off fails the retry resumes from the
+// recorded output instead of running the step again.
+type StepWorker struct {
+	river.WorkerDefaults[StepArgs]
+	deps   workflows.Deps
+	client clientFrom
+	// pollInterval is how often summarize-files checks on its children.
+	pollInterval time.Duration
+}
+
+func NewStepWorker(deps workflows.Deps) *StepWorker {
+	return &StepWorker{deps: deps, client: contextClient, pollInterval: time.Second}
+}
+
+// Timeout is disabled: clone enforces its own, and summarize-files runs
+// as long as its slowest child.
+func (w *StepWorker) Timeout(*river.Job[StepArgs]) time.Duration { return -1 }
+
+func (w *StepWorker) Work(ctx context.Context, job *river.Job[StepArgs]) error {
+	client, err := w.client(ctx)
+	if err != nil {
+		return err
+	}
+	output := job.Output()
+	if output == nil {
+		res, err := w.run(ctx, client, job.Args)
+		if err != nil {
+			return w.fail(job, err)
+		}
+		if output, err = json.Marshal(res); err != nil {
+			return w.fail(job, river.JobCancel(err))
+		}
+		if err := river.RecordOutput(ctx, json.RawMessage(output)); err != nil {
+			return err
+		}
+	}
+	next, ok, err := job.Args.next(output)
+	if err != nil {
+		return w.fail(job, river.JobCancel(err))
+	}
+	if !ok {
+		return nil
+	}
+	if _, err := client.Insert(ctx, next, next.insertOpts()); err != nil {
+		return w.fail(job, fmt.Errorf("enqueue %s: %w", next.Step, err))
+	}
+	return nil
+}
+
+// run dispatches to the step; a missing earlier output means the job
+// was enqueued by hand or by an incompatible version and can't succeed.
+func (w *StepWorker) run(ctx context.Context, client RiverClient, a StepArgs) (any, error) {
+	missing := func(what string) error {
+		return river.JobCancel(fmt.Errorf("%s: no %s output to work from", a.Step, what))
+	}
+	switch a.Step {
+	case StepClone:
+		return w.deps.CloneStep(ctx, a.Input)
+	case StepTraverse:
+		if a.Clone == nil {
+			return nil, missing(StepClone)
+		}
+		return w.deps.TraverseStep(ctx, a.Input, a.Clone.Path)
+	case StepSummarizeFiles:
+		if a.Traverse == nil {
+			return nil, missing(StepTraverse)
+		}
+		return w.deps.SummarizeFilesStep(ctx, a.Input, *a.Traverse, w.fileRunner(client, a.RunID))
+	case StepSummarizeDirs:
+		if a.Traverse == nil || a.Summaries == nil {
+			return nil, missing(StepSummarizeFiles)
+		}
+		return w.deps.SummarizeDirsStep(ctx, a.Input, *a.Traverse, *a.Summaries)
+	case StepAggregate:
+		if a.Traverse == nil || a.Summaries == nil || a.Dirs == nil {
+			return nil, missing(StepSummarizeDirs)
+		}
+		return w.deps.AggregateStep(ctx, a.Input, *a.Traverse, *a.Summaries, *a.Dirs)
+	case StepStore:
+		if a.Traverse == nil || a.Aggregate == nil {
+			return nil, missing(StepAggregate)
+		}
+		return w.deps.StoreStep(ctx, a.Input, *a.Traverse, *a.Aggregate)
+	}
+	return nil, river.JobCancel(fmt.Errorf("unknown step %q", a.Step))
+}
+
+// fail turns a non-retryable step error into a cancelled job and,
+// when no attempt is left, marks the run failed — River's counterpart
+// of the Hatchet workflow's OnFailure hook.
+func (w *StepWorker) fail(job *river.Job[StepArgs], err error) error {
+	var cancel *rivertype.JobCancelError
+	if !errors.As(err, &cancel) && workflows.IsPermanent(err) {
+		err = river.JobCancel(err)
+	}
+	if errors.As(err, &cancel) || job.Attempt >= job.MaxAttempts {
+		w.deps.HandleFailure(context.Background(), job.Args.Input, err.Error())
+	}
+	return err
+}
+
+// fileRunner fans each file out as a summarize-file job and waits for
+// it to finish.
+func (w *StepWorker) fileRunner(client RiverClient, runID string) workflows.FileRunner {
+	return func(ctx context.Context, in workflows.SummarizeFileInput) (workflows.SummarizeFileOutput, error) {
+		args := FileArgs{RunID: runID, Input: in}
+		res, err := client.Insert(ctx, args, args.insertOpts())
+		if err != nil {
+			return workflows.SummarizeFileOutput{}, fmt.Errorf("enqueue %s: %w", in.Filename, err)
+		}
+		return awaitFile(ctx, client, res.Job, w.pollInterval)
+	}
+}
+
+// awaitFile polls a summarize-file job until it is finalized and
+// decodes the output it recorded.
+func awaitFile(ctx context.Context, client RiverClient, job *rivertype.JobRow, every time.Duration) (workflows.SummarizeFileOutput, error) {
+	var out workflows.SummarizeFileOutput
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		switch job.State {
+		case rivertype.JobStateCompleted:
+			if err := json.Unmarshal(job.Output(), &out); err != nil {
+				return out, fmt.Errorf("decode child %d: %w", job.ID, err)
+			}
+			return out, nil
+		case rivertype.JobStateCancelled, rivertype.JobStateDiscarded:
+			reason := string(job.State)
+			if n := len(job.Errors); n > 0 {
+				reason = job.Errors[n-1].Error
+			}
+			return out, fmt.Errorf("summarize-file job %d: %s", job.ID, reason)
+		}
+		select {
+		case <-ctx.Done():
+			return out, ctx.Err()
+		case <-ticker.C:
+		}
+		polled, err := client.JobGet(ctx, job.ID)
+		if err != nil {
+			return out, fmt.Errorf("poll child %d: %w", job.ID, err)
+		}
+		job = polled
+	}
+}
+
+// FileWorker summarizes one file for a summarize-files job and records
+// the result for it to pick up.
+type FileWorker struct {
+	river.WorkerDefaults[FileArgs]
+	deps workflows.Deps
+}
+
+func NewFileWorker(deps workflows.Deps) *FileWorker {
+	return &FileWorker{deps: deps}
+}
+
+// Timeout leaves room for a file summarized in many chunks.
+func (w *FileWorker) Timeout(*river.Job[FileArgs]) time.Duration { return 15 * time.Minute }
+
+// NextRetry backs off exponentially from 2s up to a minute, like the
+// Hatchet child task: the LLM gateway is usually only briefly busy.
+func (w *FileWorker) NextRetry(job *river.Job[FileArgs]) time.Time {
+	delay := time.Minute
+	if job.Attempt < 6 {
+		delay = time.Duration(1<<job.Attempt) * time.Second
+	}
+	return time.Now().Add(delay)
+}
+
+func (w *FileWorker) Work(ctx context.Context, job *river.Job[FileArgs]) error {
+	out, err := w.deps.SummarizeFileStep(ctx, job.Args.Input)
+	if err != nil {
+		if workflows.IsPermanent(err) {
+			return river.JobCancel(err)
+		}
+		return err
+	}
+	return river.RecordOutput(ctx, out)
+}
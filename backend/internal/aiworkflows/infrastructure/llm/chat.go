@@ -0,0 +1,245 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	aiapp "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/application"
+	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
+)
+
+// The OpenAI chat-completions wire format, shared by OpenRouterClient
+// and OpenAIClient. Provider extensions ride on optional fields so one
+// request type serves both.
+
+type chatRequest struct {
+	Model         string         `json:"model"`
+	Messages      []chatMessage  `json:"messages"`
+	Stream        bool           `json:"stream"`
+	Usage         *usageOptions  `json:"usage,omitempty"`
+	StreamOptions *streamOptions `json:"stream_options,omitempty"`
+}
+
+// usageOptions opts into OpenRouter's usage accounting, which adds the
+// charged cost to the response's usage block.
+type usageOptions struct {
+	Include bool `json:"include"`
+}
+
+// streamOptions asks an OpenAI-compatible server to append a usage
+// block to the last chunk of a stream.
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage *usageBlock `json:"usage,omitempty"`
+	Error *chatError  `json:"error,omitempty"`
+}
+
+// chatError is the `error` object OpenRouter puts in a 200 body or a
+// stream event when the upstream provider failed after the request was
+// accepted. Code is usually the HTTP status the provider answered with
+// (429, 502, ...), as a number or a string.
+type chatError struct {
+	Message string `json:"message"`
+	Code    any    `json:"code,omitempty"`
+}
+
+// err turns the in-body error into the error a non-200 answer would
+// have produced, so Classify sees a rate limit or an upstream outage
+// the same way whichever channel reported it.
+func (e *chatError) err(provider string) error {
+	var code int
+	switch c := e.Code.(type) {
+	case float64:
+		code = int(c)
+	case string:
+		code, _ = strconv.Atoi(c)
+	}
+	if code >= 400 && code < 600 {
+		return &StatusError{Provider: provider, StatusCode: code, Body: e.Message}
+	}
+	return fmt.Errorf("%s error: %s", provider, e.Message)
+}
+
+// usageBlock is the OpenAI-compatible usage object; `cost` is the
+// OpenRouter extension usageOptions asks for.
+type usageBlock struct {
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+func (u *usageBlock) domain() ai.TokenUsage {
+	return ai.TokenUsage{
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		CostUSD:          u.Cost,
+	}
+}
+
+// streamChunk is one `data:` event of a `stream: true` response. The
+// usage block only rides on the final chunk.
+type streamChunk struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *usageBlock `json:"usage,omitempty"`
+	Error *chatError  `json:"error,omitempty"`
+}
+
+// streamDone is the sentinel payload that ends an OpenAI-compatible
+// event stream.
+const streamDone = "[DONE]"
+
+// chatEndpoint is one OpenAI-compatible chat-completions URL plus the
+// headers every request to it carries. name prefixes error messages.
+type chatEndpoint struct {
+	name    string
+	url     string
+	headers map[string]string
+	http    *http.Client
+}
+
+// post sends req and returns the response once the server has accepted
+// it with a 200. The caller owns the body.
+func (e chatEndpoint) post(ctx context.Context, req chatRequest) (*http.Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("encode request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if req.Stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
+	for k, v := range e.headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	resp, err := e.http.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("%s post: %w", e.name, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		_ = resp.Body.Close()
+		return nil, &StatusError{Provider: e.name, StatusCode: resp.StatusCode, Body: string(raw)}
+	}
+	return resp, nil
+}
+
+// complete sends a non-streaming request and decodes the first choice.
+// model is reported when the server doesn't name one.
+func (e chatEndpoint) complete(ctx context.Context, req chatRequest) (aiapp.Completion, error) {
+	req.Stream = false
+	resp, err := e.post(ctx, req)
+	if err != nil {
+		return aiapp.Completion{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var out chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return aiapp.Completion{}, fmt.Errorf("decode response: %w", err)
+	}
+	if out.Error != nil {
+		return aiapp.Completion{}, out.Error.err(e.name)
+	}
+	if len(out.Choices) == 0 {
+		return aiapp.Completion{}, fmt.Errorf("%s: empty choices", e.name)
+	}
+	completion := aiapp.Completion{Text: out.Choices[0].Message.Content, Model: out.Model}
+	if completion.Model == "" {
+		completion.Model = req.Model
+	}
+	if out.Usage != nil {
+		completion.Usage = out.Usage.domain()
+	}
+	return completion, nil
+}
+
+// stream sends a `stream: true` request and hands each content delta
+// to onChunk as it is decoded. Lines that aren't `data:` events — SSE
+// comments such as OpenRouter's `: OPENROUTER PROCESSING` keep-alives —
+// are skipped. A stream that ends without the `[DONE]` sentinel is an
+// error: the text would be truncated.
+func (e chatEndpoint) stream(ctx context.Context, req chatRequest, onChunk func(string)) (aiapp.Completion, error) {
+	req.Stream = true
+	resp, err := e.post(ctx, req)
+	if err != nil {
+		return aiapp.Completion{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var text strings.Builder
+	completion := aiapp.Completion{Model: req.Model}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == streamDone {
+			completion.Text = text.String()
+			return completion, nil
+		}
+		var chunk streamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return aiapp.Completion{}, fmt.Errorf("decode stream chunk: %w", err)
+		}
+		if chunk.Error != nil {
+			return aiapp.Completion{}, chunk.Error.err(e.name)
+		}
+		if chunk.Model != "" {
+			completion.Model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			completion.Usage = chunk.Usage.domain()
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			text.WriteString(choice.Delta.Content)
+			onChunk(choice.Delta.Content)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return aiapp.Completion{}, fmt.Errorf("read stream: %w", err)
+	}
+	return aiapp.Completion{}, errors.New(e.name + ": stream ended before [DONE]")
+}
+
+// userPrompt is the single-message conversation every adapter sends.
+func userPrompt(prompt string) []chatMessage {
+	return []chatMessage{{Role: "user", Content: prompt}}
+}
@@ -0,0 +1,93 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// StatusError is a non-200 answer from an LLM server. Adapters return
+// it (wrapped or not) so FallbackClient can tell a rate limit from a
+// bad request without parsing message strings.
+type StatusError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s status %d: %s", e.Provider, e.StatusCode, e.Body)
+}
+
+// ErrorKind is the coarse class of an LLM failure.
+type ErrorKind string
+
+const (
+	KindRateLimited   ErrorKind = "rate_limited"   // 429
+	KindServerError   ErrorKind = "server_error"   // 5xx
+	KindTimeout       ErrorKind = "timeout"        // client timeout or deadline
+	KindUnavailable   ErrorKind = "unavailable"    // connection refused, reset or cut short
+	KindContextLength ErrorKind = "context_length" // prompt too long for this model
+	KindOther         ErrorKind = "other"          // auth, bad request, decode errors, ...
+)
+
+// contextLengthMarkers are the phrases providers put in their error
+// bodies when a prompt exceeds the model's window. There is no shared
+// status code for it: OpenAI-compatible servers answer 400, some
+// gateways 413 or 422.
+var contextLengthMarkers = []string{
+	"context_length_exceeded",
+	"context length",
+	"context window",
+	"maximum context",
+	"too many tokens",
+	"prompt is too long",
+}
+
+// Classify maps an adapter error to its ErrorKind.
+func Classify(err error) ErrorKind {
+	var se *StatusError
+	if errors.As(err, &se) {
+		switch {
+		case se.StatusCode == http.StatusTooManyRequests:
+			return KindRateLimited
+		case se.StatusCode >= 500:
+			return KindServerError
+		case mentionsContextLength(se.Body):
+			return KindContextLength
+		}
+		return KindOther
+	}
+	var ne net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &ne) && ne.Timeout()) {
+		return KindTimeout
+	}
+	// A server that is down or drops the connection never got to answer
+	// with a status: the error is the transport's.
+	var oe *net.OpError
+	var ue *url.Error
+	if errors.As(err, &oe) || errors.As(err, &ue) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return KindUnavailable
+	}
+	// Errors delivered inside a 200 body (OpenRouter's `error` object,
+	// a mid-stream error event) only have their message to go on.
+	if err != nil && mentionsContextLength(err.Error()) {
+		return KindContextLength
+	}
+	return KindOther
+}
+
+func mentionsContextLength(msg string) bool {
+	msg = strings.ToLower(msg)
+	for _, m := range contextLengthMarkers {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
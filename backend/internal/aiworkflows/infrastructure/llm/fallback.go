@@ -0,0 +1,147 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	aiapp "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/application"
+	"github.com/atilladeniz/next-go-pg/backend/pkg/logger"
+	"github.com/atilladeniz/next-go-pg/backend/pkg/metrics"
+)
+
+// Backend is one entry in a FallbackClient chain. Name is the
+// provider-qualified model label (e.g. `ollama:gemma4:e4b`) used in
+// logs, metrics and errors.
+type Backend struct {
+	Name   string
+	Client aiapp.LLMClient
+}
+
+// FallbackConfig tunes the per-backend circuit breakers. Zero values
+// pick the defaults.
+type FallbackConfig struct {
+	FailureThreshold int           // consecutive failures that open a circuit, default 3
+	Cooldown         time.Duration // how long an open circuit skips its backend, default 30s
+}
+
+const (
+	defaultFailureThreshold = 3
+	defaultBreakerCooldown  = 30 * time.Second
+)
+
+// ErrNoBackendAvailable is returned when every backend in the chain
+// either failed the call or had its circuit open.
+var ErrNoBackendAvailable = errors.New("llm: no backend available")
+
+// FallbackClient is an LLMClient over an ordered chain of backends.
+// Each call goes to the first backend whose circuit is closed and falls
+// through to the next on a failure another backend may not share:
+//
+//   - rate limits (429), server errors (5xx), timeouts and unreachable
+//     servers count against the backend's circuit, then fall through;
+//   - a prompt too long for the model falls through without counting —
+//     the backend is healthy, a later model may have a larger window;
+//   - anything else (bad request, rejected key, undecodable answer) is
+//     returned as is, so misconfiguration surfaces instead of being
+//     papered over by the next backend.
+//
+// The returned Completion is the answering backend's, so its Model
+// names the model that actually wrote the text.
+type FallbackClient struct {
+	backends []fallbackBackend
+}
+
+type fallbackBackend struct {
+	Backend
+	breaker *breaker
+}
+
+var _ aiapp.LLMClient = (*FallbackClient)(nil)
+
+// NewFallbackClient builds the chain in the given order.
+func NewFallbackClient(backends []Backend, cfg FallbackConfig) (*FallbackClient, error) {
+	if len(backends) == 0 {
+		return nil, errors.New("llm fallback: at least one backend is required")
+	}
+	threshold := cfg.FailureThreshold
+	if threshold <= 0 {
+		threshold = defaultFailureThreshold
+	}
+	cooldown := cfg.Cooldown
+	if cooldown <= 0 {
+		cooldown = defaultBreakerCooldown
+	}
+	c := &FallbackClient{}
+	for _, b := range backends {
+		c.backends = append(c.backends, fallbackBackend{Backend: b, breaker: newBreaker(threshold, cooldown)})
+	}
+	return c, nil
+}
+
+// Generate asks each backend in turn until one answers.
+func (c *FallbackClient) Generate(ctx context.Context, prompt string) (aiapp.Completion, error) {
+	return c.call(ctx, func(b fallbackBackend) (aiapp.Completion, bool, error) {
+		completion, err := b.Client.Generate(ctx, prompt)
+		return completion, false, err
+	})
+}
+
+// Stream asks each backend in turn until one answers. Once a backend
+// has delivered text it can't be taken back, so a failure after the
+// first chunk is returned instead of falling through.
+func (c *FallbackClient) Stream(ctx context.Context, prompt string, onChunk func(chunk string)) (aiapp.Completion, error) {
+	return c.call(ctx, func(b fallbackBackend) (aiapp.Completion, bool, error) {
+		emitted := false
+		completion, err := b.Client.Stream(ctx, prompt, func(chunk string) {
+			emitted = true
+			onChunk(chunk)
+		})
+		return completion, emitted, err
+	})
+}
+
+// call runs attempt against the chain. attempt reports whether the
+// backend already produced visible output, which rules out trying
+// another one.
+func (c *FallbackClient) call(ctx context.Context, attempt func(fallbackBackend) (aiapp.Completion, bool, error)) (aiapp.Completion, error) {
+	var errs []error
+	for _, b := range c.backends {
+		if err := ctx.Err(); err != nil {
+			return aiapp.Completion{}, err
+		}
+		if !b.breaker.allow() {
+			errs = append(errs, fmt.Errorf("%s: circuit open", b.Name))
+			continue
+		}
+		completion, committed, err := attempt(b)
+		if err == nil {
+			b.breaker.success()
+			return completion, nil
+		}
+		if ctx.Err() != nil {
+			// The caller gave up; that says nothing about the backend.
+			b.breaker.release()
+			return aiapp.Completion{}, err
+		}
+		kind := Classify(err)
+		metrics.AILLMBackendErrors.WithLabelValues(b.Name, string(kind)).Inc()
+		switch kind {
+		case KindRateLimited, KindServerError, KindTimeout, KindUnavailable:
+			if b.breaker.failure() {
+				logger.Warn().Err(err).Str("backend", b.Name).Str("kind", string(kind)).Msg("LLM backend circuit opened")
+			}
+		case KindContextLength:
+			b.breaker.release()
+		default:
+			b.breaker.release()
+			return aiapp.Completion{}, fmt.Errorf("%s: %w", b.Name, err)
+		}
+		if committed {
+			return aiapp.Completion{}, fmt.Errorf("%s: %w", b.Name, err)
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", b.Name, err))
+	}
+	return aiapp.Completion{}, fmt.Errorf("%w: %w", ErrNoBackendAvailable, errors.Join(errs...))
+}
@@ -0,0 +1,258 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	aiapp "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/application"
+)
+
+type fakeLLM struct {
+	model  string
+	errs   []error // returned in order; nil entries and exhaustion answer
+	chunks []string
+	calls  int
+}
+
+func (f *fakeLLM) next() error {
+	f.calls++
+	if len(f.errs) == 0 {
+		return nil
+	}
+	err := f.errs[0]
+	f.errs = f.errs[1:]
+	return err
+}
+
+func (f *fakeLLM) Generate(context.Context, string) (aiapp.Completion, error) {
+	if err := f.next(); err != nil {
+		return aiapp.Completion{}, err
+	}
+	return aiapp.Completion{Text: "from " + f.model, Model: f.model}, nil
+}
+
+func (f *fakeLLM) Stream(_ context.Context, _ string, onChunk func(string)) (aiapp.Completion, error) {
+	for _, c := range f.chunks {
+		onChunk(c)
+	}
+	if err := f.next(); err != nil {
+		return aiapp.Completion{}, err
+	}
+	return aiapp.Completion{Text: "from " + f.model, Model: f.model}, nil
+}
+
+func status(code int, body string) error {
+	return fmt.Errorf("wrapped: %w", &StatusError{Provider: "test", StatusCode: code, Body: body})
+}
+
+func TestClassify(t *testing.T) {
+	cases := map[string]struct {
+		err  error
+		want ErrorKind
+	}{
+		"429":               {status(http.StatusTooManyRequests, "slow down"), KindRateLimited},
+		"503":               {status(http.StatusServiceUnavailable, ""), KindServerError},
+		"deadline":          {fmt.Errorf("post: %w", context.DeadlineExceeded), KindTimeout},
+		"400 context":       {status(http.StatusBadRequest, `{"error":{"code":"context_length_exceeded"}}`), KindContextLength},
+		"in-body context":   {errors.New("openrouter error: This model's maximum context length is 8192 tokens"), KindContextLength},
+		"refused":           {fmt.Errorf("post: %w", &url.Error{Op: "Post", URL: "http://x", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}), KindUnavailable},
+		"cut short":         {fmt.Errorf("decode response: %w", io.ErrUnexpectedEOF), KindUnavailable},
+		"401":               {status(http.StatusUnauthorized, "bad key"), KindOther},
+		"plain bad request": {status(http.StatusBadRequest, "invalid model"), KindOther},
+	}
+	for name, tc := range cases {
+		if got := Classify(tc.err); got != tc.want {
+			t.Errorf("%s: Classify = %s, want %s", name, got, tc.want)
+		}
+	}
+}
+
+func newChain(t *testing.T, backends ...*fakeLLM) *FallbackClient {
+	t.Helper()
+	var bs []Backend
+	for _, b := range backends {
+		bs = append(bs, Backend{Name: b.model, Client: b})
+	}
+	c, err := NewFallbackClient(bs, FallbackConfig{FailureThreshold: 2, Cooldown: time.Minute})
+	if err != nil {
+		t.Fatalf("NewFallbackClient: %v", err)
+	}
+	return c
+}
+
+func TestFallbackFallsThroughOnTransientErrors(t *testing.T) {
+	primary := &fakeLLM{model: "primary", errs: []error{status(http.StatusTooManyRequests, "")}}
+	secondary := &fakeLLM{model: "secondary"}
+	c := newChain(t, primary, secondary)
+
+	got, err := c.Generate(context.Background(), "p")
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if got.Model != "secondary" {
+		t.Errorf("Model = %q, want the backend that answered", got.Model)
+	}
+}
+
+// closedPortURL is a base URL nothing listens on.
+func closedPortURL(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := l.Addr().String()
+	_ = l.Close()
+	return "http://" + addr + "/v1"
+}
+
+func TestFallbackFallsThroughWhenBackendIsDown(t *testing.T) {
+	down, err := NewOpenAIClient(OpenAIConfig{BaseURL: closedPortURL(t), Model: "down"})
+	if err != nil {
+		t.Fatalf("NewOpenAIClient: %v", err)
+	}
+	secondary := &fakeLLM{model: "secondary"}
+	c, err := NewFallbackClient([]Backend{
+		{Name: "down", Client: down},
+		{Name: "secondary", Client: secondary},
+	}, FallbackConfig{FailureThreshold: 2, Cooldown: time.Minute})
+	if err != nil {
+		t.Fatalf("NewFallbackClient: %v", err)
+	}
+
+	for range 3 {
+		got, err := c.Generate(context.Background(), "p")
+		if err != nil {
+			t.Fatalf("Generate: %v", err)
+		}
+		if got.Model != "secondary" {
+			t.Errorf("Model = %q, want the backend that answered", got.Model)
+		}
+	}
+	if _, err := c.Stream(context.Background(), "p", func(string) {}); err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	if c.backends[0].breaker.allow() {
+		t.Error("circuit still closed after the backend refused every connection")
+	}
+}
+
+func TestFallbackFallsThroughOnInBodyErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"error":{"message":"Provider returned error","code":429}}`))
+	}))
+	t.Cleanup(srv.Close)
+	router, err := NewOpenRouterClient(OpenRouterConfig{URL: srv.URL, APIKey: "k", Model: "routed"})
+	if err != nil {
+		t.Fatalf("NewOpenRouterClient: %v", err)
+	}
+	secondary := &fakeLLM{model: "secondary"}
+	c, err := NewFallbackClient([]Backend{
+		{Name: "openrouter", Client: router},
+		{Name: "secondary", Client: secondary},
+	}, FallbackConfig{})
+	if err != nil {
+		t.Fatalf("NewFallbackClient: %v", err)
+	}
+
+	got, err := c.Generate(context.Background(), "p")
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if got.Model != "secondary" {
+		t.Errorf("Model = %q, want the in-body 429 to fall through", got.Model)
+	}
+}
+
+func TestFallbackStopsOnNonTransientErrors(t *testing.T) {
+	primary := &fakeLLM{model: "primary", errs: []error{status(http.StatusUnauthorized, "bad key")}}
+	secondary := &fakeLLM{model: "secondary"}
+	c := newChain(t, primary, secondary)
+
+	if _, err := c.Generate(context.Background(), "p"); err == nil {
+		t.Fatal("Generate succeeded, want the 401 surfaced")
+	}
+	if secondary.calls != 0 {
+		t.Error("a rejected key fell through to the next backend")
+	}
+}
+
+func TestFallbackCircuitOpensAndRecovers(t *testing.T) {
+	down := status(http.StatusBadGateway, "")
+	primary := &fakeLLM{model: "primary", errs: []error{down, down}}
+	secondary := &fakeLLM{model: "secondary"}
+	c := newChain(t, primary, secondary)
+	now := time.Now()
+	c.backends[0].breaker.now = func() time.Time { return now }
+
+	for range 3 {
+		if _, err := c.Generate(context.Background(), "p"); err != nil {
+			t.Fatalf("Generate: %v", err)
+		}
+	}
+	if primary.calls != 2 {
+		t.Errorf("primary called %d times, want 2 (circuit open after the second failure)", primary.calls)
+	}
+
+	// After the cooldown one probe goes through; it succeeds and the
+	// circuit closes.
+	now = now.Add(2 * time.Minute)
+	got, err := c.Generate(context.Background(), "p")
+	if err != nil || got.Model != "primary" {
+		t.Fatalf("probe = %+v, %v; want primary to answer", got, err)
+	}
+	if _, err := c.Generate(context.Background(), "p"); err != nil || primary.calls != 4 {
+		t.Errorf("primary calls = %d after recovery, want 4", primary.calls)
+	}
+}
+
+func TestFallbackContextLengthDoesNotTripCircuit(t *testing.T) {
+	tooLong := status(http.StatusBadRequest, "maximum context length exceeded")
+	primary := &fakeLLM{model: "primary", errs: []error{tooLong, tooLong, tooLong}}
+	secondary := &fakeLLM{model: "secondary"}
+	c := newChain(t, primary, secondary)
+
+	for range 3 {
+		if _, err := c.Generate(context.Background(), "p"); err != nil {
+			t.Fatalf("Generate: %v", err)
+		}
+	}
+	if primary.calls != 3 {
+		t.Errorf("primary called %d times, want 3 — oversized prompts say nothing about its health", primary.calls)
+	}
+}
+
+func TestFallbackAllBackendsFail(t *testing.T) {
+	primary := &fakeLLM{model: "primary", errs: []error{status(http.StatusInternalServerError, "")}}
+	secondary := &fakeLLM{model: "secondary", errs: []error{status(http.StatusTooManyRequests, "")}}
+	c := newChain(t, primary, secondary)
+
+	_, err := c.Generate(context.Background(), "p")
+	if !errors.Is(err, ErrNoBackendAvailable) {
+		t.Fatalf("err = %v, want ErrNoBackendAvailable", err)
+	}
+	if Classify(err) != KindServerError {
+		t.Errorf("Classify(chain error) = %s, want the first backend's kind", Classify(err))
+	}
+}
+
+func TestFallbackStreamDoesNotFallThroughAfterOutput(t *testing.T) {
+	primary := &fakeLLM{model: "primary", chunks: []string{"half an "}, errs: []error{status(http.StatusBadGateway, "")}}
+	secondary := &fakeLLM{model: "secondary"}
+	c := newChain(t, primary, secondary)
+
+	if _, err := c.Stream(context.Background(), "p", func(string) {}); err == nil {
+		t.Fatal("Stream succeeded, want the mid-stream failure")
+	}
+	if secondary.calls != 0 {
+		t.Error("fell through after text was already streamed")
+	}
+}
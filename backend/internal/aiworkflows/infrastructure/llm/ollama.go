@@ -0,0 +1,218 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	aiapp "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/application"
+	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
+)
+
+// OllamaClient is the LLMClient implementation against a local Ollama
+// server's native `/api/chat` endpoint. Nothing leaves the machine and
+// nothing is billed, so usage carries token counts only. Model loads
+// are slow on first use; the default timeout is sized for that.
+type OllamaClient struct {
+	url   string
+	model string
+	http  *http.Client
+}
+
+var _ aiapp.LLMClient = (*OllamaClient)(nil)
+
+// OllamaConfig holds optional overrides; the zero value talks to a
+// default local install.
+type OllamaConfig struct {
+	URL     string        // default http://localhost:11434
+	Model   string        // default gemma4:e4b
+	Timeout time.Duration // default 5m (CPU inference + cold model load)
+}
+
+const (
+	defaultOllamaURL     = "http://localhost:11434"
+	defaultOllamaModel   = "gemma4:e4b"
+	defaultOllamaTimeout = 5 * time.Minute
+)
+
+// NewOllamaClient constructs the client. It never fails today; the
+// error return keeps the constructor shape of the other adapters.
+func NewOllamaClient(cfg OllamaConfig) (*OllamaClient, error) {
+	url := cfg.URL
+	if url == "" {
+		url = defaultOllamaURL
+	}
+	model := cfg.Model
+	if model == "" {
+		model = defaultOllamaModel
+	}
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = defaultOllamaTimeout
+	}
+	return &OllamaClient{
+		url:   strings.TrimRight(url, "/"),
+		model: model,
+		http:  &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Model returns the configured model identifier. Useful for logging.
+func (c *OllamaClient) Model() string { return c.model }
+
+// Ping checks the server is up and the model has been pulled, via
+// `GET /api/tags`. A missing model would otherwise only surface as a
+// 404 on the first workflow run.
+func (c *OllamaClient) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+"/api/tags", nil)
+	if err != nil {
+		return fmt.Errorf("build ping request: %w", err)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("ollama unreachable: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("ollama ping status %d: %s", resp.StatusCode, string(raw))
+	}
+	var tags struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return fmt.Errorf("decode tags: %w", err)
+	}
+	for _, m := range tags.Models {
+		// Ollama reports untagged pulls as `name:latest`.
+		if m.Name == c.model || m.Name == c.model+":latest" {
+			return nil
+		}
+	}
+	return fmt.Errorf("ollama: model %q not pulled — run `ollama pull %s`", c.model, c.model)
+}
+
+type ollamaChatRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+	Stream   bool          `json:"stream"`
+}
+
+// ollamaChatResponse is the whole answer when not streaming, and one
+// NDJSON line when streaming. Token counts arrive with done=true.
+type ollamaChatResponse struct {
+	Model   string `json:"model"`
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	Done            bool   `json:"done"`
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
+	Error           string `json:"error,omitempty"`
+}
+
+func (r ollamaChatResponse) usage() ai.TokenUsage {
+	return ai.TokenUsage{PromptTokens: r.PromptEvalCount, CompletionTokens: r.EvalCount}
+}
+
+// Generate sends a non-streaming chat request with the prompt as a
+// single user message.
+func (c *OllamaClient) Generate(ctx context.Context, prompt string) (aiapp.Completion, error) {
+	resp, err := c.postChat(ctx, prompt, false)
+	if err != nil {
+		return aiapp.Completion{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var out ollamaChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return aiapp.Completion{}, fmt.Errorf("decode response: %w", err)
+	}
+	if out.Error != "" {
+		return aiapp.Completion{}, fmt.Errorf("ollama error: %s", out.Error)
+	}
+	return c.completion(out, out.Message.Content), nil
+}
+
+// Stream sends a streaming chat request. Ollama streams NDJSON — one
+// response object per line — rather than SSE; the line with done=true
+// ends the answer and carries the token counts.
+func (c *OllamaClient) Stream(ctx context.Context, prompt string, onChunk func(chunk string)) (aiapp.Completion, error) {
+	resp, err := c.postChat(ctx, prompt, true)
+	if err != nil {
+		return aiapp.Completion{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var text strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var chunk ollamaChatResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return aiapp.Completion{}, fmt.Errorf("decode stream chunk: %w", err)
+		}
+		if chunk.Error != "" {
+			return aiapp.Completion{}, fmt.Errorf("ollama error: %s", chunk.Error)
+		}
+		if chunk.Message.Content != "" {
+			text.WriteString(chunk.Message.Content)
+			onChunk(chunk.Message.Content)
+		}
+		if chunk.Done {
+			return c.completion(chunk, text.String()), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return aiapp.Completion{}, fmt.Errorf("read stream: %w", err)
+	}
+	return aiapp.Completion{}, errors.New("ollama: stream ended before done")
+}
+
+func (c *OllamaClient) completion(r ollamaChatResponse, text string) aiapp.Completion {
+	model := r.Model
+	if model == "" {
+		model = c.model
+	}
+	return aiapp.Completion{Text: text, Model: model, Usage: r.usage()}
+}
+
+func (c *OllamaClient) postChat(ctx context.Context, prompt string, stream bool) (*http.Response, error) {
+	body, err := json.Marshal(ollamaChatRequest{
+		Model:    c.model,
+		Messages: userPrompt(prompt),
+		Stream:   stream,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+"/api/chat", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ollama post: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		_ = resp.Body.Close()
+		return nil, &StatusError{Provider: "ollama", StatusCode: resp.StatusCode, Body: string(raw)}
+	}
+	return resp, nil
+}
@@ -0,0 +1,93 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestOllama(t *testing.T, handler http.HandlerFunc) *OllamaClient {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	c, err := NewOllamaClient(OllamaConfig{URL: srv.URL, Model: "tiny"})
+	if err != nil {
+		t.Fatalf("NewOllamaClient: %v", err)
+	}
+	return c
+}
+
+func TestOllamaGenerate(t *testing.T) {
+	c := newTestOllama(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		var req ollamaChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		if req.Stream || req.Model != "tiny" || len(req.Messages) != 1 || req.Messages[0].Content != "hi" {
+			t.Errorf("request = %+v", req)
+		}
+		_, _ = w.Write([]byte(`{"model":"tiny","message":{"role":"assistant","content":"Local."},"done":true,"prompt_eval_count":12,"eval_count":3}`))
+	})
+
+	got, err := c.Generate(context.Background(), "hi")
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if got.Text != "Local." || got.Model != "tiny" {
+		t.Errorf("completion = %+v", got)
+	}
+	if got.Usage.PromptTokens != 12 || got.Usage.CompletionTokens != 3 || got.Usage.CostUSD != 0 {
+		t.Errorf("usage = %+v", got.Usage)
+	}
+}
+
+func TestOllamaStream(t *testing.T) {
+	c := newTestOllama(t, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(
+			`{"model":"tiny","message":{"content":"Runs "},"done":false}` + "\n" +
+				`{"model":"tiny","message":{"content":"offline."},"done":false}` + "\n" +
+				`{"model":"tiny","message":{"content":""},"done":true,"prompt_eval_count":9,"eval_count":2}` + "\n"))
+	})
+
+	var chunks []string
+	got, err := c.Stream(context.Background(), "hi", func(chunk string) { chunks = append(chunks, chunk) })
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	if strings.Join(chunks, "|") != "Runs |offline." || got.Text != "Runs offline." {
+		t.Errorf("chunks = %q, text = %q", chunks, got.Text)
+	}
+	if got.Usage.PromptTokens != 9 || got.Usage.CompletionTokens != 2 {
+		t.Errorf("usage = %+v", got.Usage)
+	}
+}
+
+func TestOllamaPing(t *testing.T) {
+	cases := map[string]struct {
+		tags    string
+		wantErr bool
+	}{
+		"model pulled":           {tags: `{"models":[{"name":"tiny"}]}`},
+		"model pulled as latest": {tags: `{"models":[{"name":"tiny:latest"}]}`},
+		"model missing":          {tags: `{"models":[{"name":"other:7b"}]}`, wantErr: true},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := newTestOllama(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/tags" {
+					t.Errorf("path = %s", r.URL.Path)
+				}
+				_, _ = w.Write([]byte(tc.tags))
+			})
+			if err := c.Ping(context.Background()); (err != nil) != tc.wantErr {
+				t.Errorf("Ping error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	aiapp "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/application"
+)
+
+// OpenAIClient is the LLMClient implementation for any server that
+// speaks the OpenAI chat-completions API at a base URL of its own —
+// vLLM, llama.cpp's server, LM Studio, LiteLLM, a stub server in tests,
+// or OpenAI itself. Unlike OpenRouter there is no cost reporting, so
+// usage carries token counts only.
+type OpenAIClient struct {
+	baseURL string
+	apiKey  string
+	model   string
+	http    *http.Client
+}
+
+var _ aiapp.LLMClient = (*OpenAIClient)(nil)
+
+// OpenAIConfig configures OpenAIClient. BaseURL includes the API
+// prefix, e.g. http://localhost:8000/v1.
+type OpenAIConfig struct {
+	BaseURL string        // REQUIRED
+	APIKey  string        // optional — local servers usually need none
+	Model   string        // REQUIRED — no sensible default across servers
+	Timeout time.Duration // default 120s
+}
+
+const defaultOpenAITimeout = 120 * time.Second
+
+// NewOpenAIClient constructs the client. Base URL and model are
+// required: there is no server or model every deployment shares.
+func NewOpenAIClient(cfg OpenAIConfig) (*OpenAIClient, error) {
+	if cfg.BaseURL == "" {
+		return nil, errors.New("openai-compatible: base url is required")
+	}
+	if cfg.Model == "" {
+		return nil, errors.New("openai-compatible: model is required")
+	}
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = defaultOpenAITimeout
+	}
+	return &OpenAIClient{
+		baseURL: strings.TrimRight(cfg.BaseURL, "/"),
+		apiKey:  cfg.APIKey,
+		model:   cfg.Model,
+		http:    &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Model returns the configured model identifier. Useful for logging.
+func (c *OpenAIClient) Model() string { return c.model }
+
+// Ping lists the server's models via `GET /models`, which every
+// OpenAI-compatible server implements and which costs no inference.
+func (c *OpenAIClient) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/models", nil)
+	if err != nil {
+		return fmt.Errorf("build ping request: %w", err)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("openai-compatible server unreachable: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode == http.StatusUnauthorized {
+		return errors.New("openai-compatible: api key rejected (401) — check OPENAI_COMPAT_API_KEY")
+	}
+	if resp.StatusCode != http.StatusOK {
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("openai-compatible ping status %d: %s", resp.StatusCode, string(raw))
+	}
+	return nil
+}
+
+// Generate sends a non-streaming chat completion with the prompt as a
+// single user message.
+func (c *OpenAIClient) Generate(ctx context.Context, prompt string) (aiapp.Completion, error) {
+	return c.chat().complete(ctx, chatRequest{Model: c.model, Messages: userPrompt(prompt)})
+}
+
+// Stream sends a `stream: true` chat completion. `stream_options`
+// asks the server for a final usage chunk; servers that ignore it
+// leave usage zero.
+func (c *OpenAIClient) Stream(ctx context.Context, prompt string, onChunk func(chunk string)) (aiapp.Completion, error) {
+	return c.chat().stream(ctx, chatRequest{
+		Model:         c.model,
+		Messages:      userPrompt(prompt),
+		StreamOptions: &streamOptions{IncludeUsage: true},
+	}, onChunk)
+}
+
+func (c *OpenAIClient) chat() chatEndpoint {
+	headers := map[string]string{}
+	if c.apiKey != "" {
+		headers["Authorization"] = "Bearer " + c.apiKey
+	}
+	return chatEndpoint{
+		name:    "openai-compatible",
+		url:     c.baseURL + "/chat/completions",
+		headers: headers,
+		http:    c.http,
+	}
+}
@@ -0,0 +1,102 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestOpenAI(t *testing.T, apiKey string, handler http.HandlerFunc) *OpenAIClient {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	c, err := NewOpenAIClient(OpenAIConfig{BaseURL: srv.URL + "/v1/", APIKey: apiKey, Model: "local-model"})
+	if err != nil {
+		t.Fatalf("NewOpenAIClient: %v", err)
+	}
+	return c
+}
+
+func TestNewOpenAIClientRequiresURLAndModel(t *testing.T) {
+	if _, err := NewOpenAIClient(OpenAIConfig{Model: "m"}); err == nil {
+		t.Error("missing base url accepted")
+	}
+	if _, err := NewOpenAIClient(OpenAIConfig{BaseURL: "http://localhost:8000/v1"}); err == nil {
+		t.Error("missing model accepted")
+	}
+}
+
+func TestOpenAIGenerateWithoutKey(t *testing.T) {
+	c := newTestOpenAI(t, "", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if h := r.Header.Get("Authorization"); h != "" {
+			t.Errorf("Authorization = %q, want none without a key", h)
+		}
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		if req.Usage != nil {
+			t.Error("request carries the OpenRouter usage extension")
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"ok"}}],"usage":{"prompt_tokens":5,"completion_tokens":1}}`))
+	})
+
+	got, err := c.Generate(context.Background(), "hi")
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if got.Text != "ok" || got.Model != "local-model" || got.Usage.TotalTokens() != 6 {
+		t.Errorf("completion = %+v", got)
+	}
+}
+
+func TestOpenAIStreamAsksForUsage(t *testing.T) {
+	c := newTestOpenAI(t, "secret", func(w http.ResponseWriter, r *http.Request) {
+		if h := r.Header.Get("Authorization"); h != "Bearer secret" {
+			t.Errorf("Authorization = %q", h)
+		}
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		if !req.Stream || req.StreamOptions == nil || !req.StreamOptions.IncludeUsage {
+			t.Errorf("request = %+v", req)
+		}
+		_, _ = w.Write([]byte(`data: {"choices":[{"delta":{"content":"ok"}}]}` + "\n\n" +
+			`data: {"choices":[],"usage":{"prompt_tokens":5,"completion_tokens":1}}` + "\n\n" +
+			"data: [DONE]\n\n"))
+	})
+
+	got, err := c.Stream(context.Background(), "hi", func(string) {})
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	if got.Text != "ok" || got.Usage.TotalTokens() != 6 {
+		t.Errorf("completion = %+v", got)
+	}
+}
+
+func TestOpenAIPing(t *testing.T) {
+	c := newTestOpenAI(t, "", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	})
+	if err := c.Ping(context.Background()); err != nil {
+		t.Errorf("Ping: %v", err)
+	}
+
+	rejected := newTestOpenAI(t, "bad", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	if err := rejected.Ping(context.Background()); err == nil {
+		t.Error("Ping accepted a 401")
+	}
+}
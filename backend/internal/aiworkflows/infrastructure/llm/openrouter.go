@@ -1,9 +1,7 @@
 package llm
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -105,77 +103,41 @@ func (c *OpenRouterClient) Ping(ctx context.Context) error {
 	return nil
 }
 
-type chatRequest struct {
-	Model    string        `json:"model"`
-	Messages []chatMessage `json:"messages"`
-	Stream   bool          `json:"stream"`
-}
-
-type chatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+// Generate sends a non-streaming chat completion. The prompt becomes a
+// single user message; OpenRouter then routes to whichever provider
+// backs `c.model`. Token counts and cost come from the response's
+// usage block.
+func (c *OpenRouterClient) Generate(ctx context.Context, prompt string) (aiapp.Completion, error) {
+	return c.chat().complete(ctx, c.request(prompt))
 }
 
-type chatResponse struct {
-	Choices []struct {
-		Message struct {
-			Content string `json:"content"`
-		} `json:"message"`
-	} `json:"choices"`
-	Error *struct {
-		Message string `json:"message"`
-		Code    any    `json:"code,omitempty"`
-	} `json:"error,omitempty"`
+// Stream sends a `stream: true` chat completion and hands each content
+// delta to onChunk as it is decoded. The usage block (with cost) rides
+// on the final chunk.
+func (c *OpenRouterClient) Stream(ctx context.Context, prompt string, onChunk func(chunk string)) (aiapp.Completion, error) {
+	return c.chat().stream(ctx, c.request(prompt), onChunk)
 }
 
-// Generate sends a non-streaming chat completion. The prompt becomes a
-// single user message; OpenRouter then routes to whichever provider
-// backs `c.model`.
-func (c *OpenRouterClient) Generate(ctx context.Context, prompt string) (string, error) {
-	body, err := json.Marshal(chatRequest{
-		Model: c.model,
-		Messages: []chatMessage{
-			{Role: "user", Content: prompt},
-		},
-		Stream: false,
-	})
-	if err != nil {
-		return "", fmt.Errorf("encode request: %w", err)
+func (c *OpenRouterClient) request(prompt string) chatRequest {
+	return chatRequest{
+		Model:    c.model,
+		Messages: userPrompt(prompt),
+		Usage:    &usageOptions{Include: true},
 	}
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+"/api/v1/chat/completions", bytes.NewReader(body))
-	if err != nil {
-		return "", fmt.Errorf("build request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+func (c *OpenRouterClient) chat() chatEndpoint {
+	headers := map[string]string{"Authorization": "Bearer " + c.apiKey}
 	if c.referer != "" {
-		req.Header.Set("HTTP-Referer", c.referer)
+		headers["HTTP-Referer"] = c.referer
 	}
 	if c.title != "" {
-		req.Header.Set("X-Title", c.title)
-	}
-
-	resp, err := c.http.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("openrouter post: %w", err)
-	}
-	defer func() { _ = resp.Body.Close() }()
-
-	if resp.StatusCode != http.StatusOK {
-		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
-		return "", fmt.Errorf("openrouter status %d: %s", resp.StatusCode, string(raw))
-	}
-
-	var out chatResponse
-	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
-		return "", fmt.Errorf("decode response: %w", err)
-	}
-	if out.Error != nil {
-		return "", fmt.Errorf("openrouter error: %s", out.Error.Message)
+		headers["X-Title"] = c.title
 	}
-	if len(out.Choices) == 0 {
-		return "", errors.New("openrouter: empty choices")
+	return chatEndpoint{
+		name:    "openrouter",
+		url:     c.url + "/api/v1/chat/completions",
+		headers: headers,
+		http:    c.http,
 	}
-	return out.Choices[0].Message.Content, nil
 }
@@ -0,0 +1,118 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *OpenRouterClient {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	c, err := NewOpenRouterClient(OpenRouterConfig{URL: srv.URL, APIKey: "test-key", Model: "test/model"})
+	if err != nil {
+		t.Fatalf("NewOpenRouterClient: %v", err)
+	}
+	return c
+}
+
+func TestGenerateReturnsUsage(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		if req.Usage == nil || !req.Usage.Include {
+			t.Errorf("request does not opt into usage accounting: %+v", req.Usage)
+		}
+		_, _ = w.Write([]byte(`{
+			"model": "routed/model",
+			"choices": [{"message": {"content": "It works."}}],
+			"usage": {"prompt_tokens": 120, "completion_tokens": 30, "total_tokens": 150, "cost": 0.00042}
+		}`))
+	})
+
+	got, err := c.Generate(context.Background(), "hi")
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if got.Text != "It works." || got.Model != "routed/model" {
+		t.Errorf("completion = %+v", got)
+	}
+	if got.Usage.PromptTokens != 120 || got.Usage.CompletionTokens != 30 || got.Usage.CostUSD != 0.00042 {
+		t.Errorf("usage = %+v", got.Usage)
+	}
+}
+
+func TestGenerateWithoutUsageBlock(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"choices": [{"message": {"content": "ok"}}]}`))
+	})
+
+	got, err := c.Generate(context.Background(), "hi")
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if got.Model != "test/model" {
+		t.Errorf("Model = %q, want the configured model as fallback", got.Model)
+	}
+	if !got.Usage.IsZero() {
+		t.Errorf("usage = %+v, want zero when unreported", got.Usage)
+	}
+}
+
+func TestStreamForwardsChunksInOrder(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		if !req.Stream {
+			t.Error("request does not ask for a stream")
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(": OPENROUTER PROCESSING\n\n" +
+			`data: {"model":"routed/model","choices":[{"delta":{"role":"assistant","content":""}}]}` + "\n\n" +
+			`data: {"model":"routed/model","choices":[{"delta":{"content":"It "}}]}` + "\n\n" +
+			`data: {"model":"routed/model","choices":[{"delta":{"content":"streams."}}]}` + "\n\n" +
+			`data: {"model":"routed/model","choices":[],"usage":{"prompt_tokens":80,"completion_tokens":4,"cost":0.0001}}` + "\n\n" +
+			"data: [DONE]\n\n"))
+	})
+
+	var chunks []string
+	got, err := c.Stream(context.Background(), "hi", func(chunk string) { chunks = append(chunks, chunk) })
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	if strings.Join(chunks, "|") != "It |streams." {
+		t.Errorf("chunks = %q", chunks)
+	}
+	if got.Text != "It streams." || got.Model != "routed/model" {
+		t.Errorf("completion = %+v", got)
+	}
+	if got.Usage.PromptTokens != 80 || got.Usage.CompletionTokens != 4 || got.Usage.CostUSD != 0.0001 {
+		t.Errorf("usage = %+v", got.Usage)
+	}
+}
+
+func TestStreamErrors(t *testing.T) {
+	cases := map[string]string{
+		"error event": `data: {"choices":[{"delta":{"content":"par"}}]}` + "\n\n" +
+			`data: {"error":{"message":"provider overloaded"}}` + "\n\n",
+		"truncated": `data: {"choices":[{"delta":{"content":"par"}}]}` + "\n\n",
+	}
+	for name, body := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte(body))
+			})
+			if _, err := c.Stream(context.Background(), "hi", func(string) {}); err == nil {
+				t.Error("Stream succeeded, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	aiapp "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/application"
+	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
+)
+
+// StubModel is the model the Stub reports.
+const StubModel = "stub"
+
+// Stub is an LLMClient that answers without a model: a short canned
+// answer naming the prompt's file, as a JSON object when the prompt
+// asks for one. It makes the whole pipeline runnable offline
+// (AI_LLM_PROVIDER=stub) and deterministic in tests. Usage estimates
+// four bytes per token, like the chunker; cost is zero.
+type Stub struct {
+	// Reply, when set, answers instead — e.g. to fail chosen prompts.
+	Reply func(prompt string) (string, error)
+}
+
+var _ aiapp.LLMClient = (*Stub)(nil)
+
+// Model returns StubModel.
+func (s *Stub) Model() string { return StubModel }
+
+// Ping always succeeds.
+func (s *Stub) Ping(context.Context) error { return nil }
+
+// Generate answers the prompt in one piece.
+func (s *Stub) Generate(ctx context.Context, prompt string) (aiapp.Completion, error) {
+	if err := ctx.Err(); err != nil {
+		return aiapp.Completion{}, err
+	}
+	text, err := s.answer(prompt)
+	if err != nil {
+		return aiapp.Completion{}, err
+	}
+	return aiapp.Completion{
+		Text:  text,
+		Model: StubModel,
+		Usage: ai.TokenUsage{PromptTokens: len(prompt) / 4, CompletionTokens: len(text) / 4},
+	}, nil
+}
+
+// Stream delivers the answer word by word.
+func (s *Stub) Stream(ctx context.Context, prompt string, onChunk func(chunk string)) (aiapp.Completion, error) {
+	completion, err := s.Generate(ctx, prompt)
+	if err != nil {
+		return aiapp.Completion{}, err
+	}
+	for _, word := range strings.SplitAfter(completion.Text, " ") {
+		onChunk(word)
+	}
+	return completion, nil
+}
+
+// stubFilename finds the file a per-file prompt is about.
+var stubFilename = regexp.MustCompile(`(?m)^FILENAME: (.+)$`)
+
+func (s *Stub) answer(prompt string) (string, error) {
+	if s.Reply != nil {
+		return s.Reply(prompt)
+	}
+	subject := "the repository"
+	if m := stubFilename.FindStringSubmatch(prompt); m != nil {
+		subject = strings.TrimSpace(m[1])
+	}
+	summary := fmt.Sprintf("Stub summary of %s.", subject)
+	if !strings.Contains(prompt, "single JSON object") {
+		return summary, nil
+	}
+	out, err := json.Marshal(map[string]any{
+		"summary": summary,
+		"purpose": fmt.Sprintf("Stands in for %s.", subject),
+		"risks":   []string{},
+	})
+	return string(out), err
+}
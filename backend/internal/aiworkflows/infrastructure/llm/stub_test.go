@@ -0,0 +1,66 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestStubAnswersPerPrompt(t *testing.T) {
+	s := &Stub{}
+	got, err := s.Generate(context.Background(), "Summarize this file.\nFILENAME: cmd/main.go\n\npackage main")
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if got.Text != "Stub summary of cmd/main.go." || got.Model != StubModel {
+		t.Errorf("completion = %+v", got)
+	}
+	if got.Usage.PromptTokens == 0 || got.Usage.CompletionTokens == 0 {
+		t.Errorf("usage = %+v, want estimated tokens", got.Usage)
+	}
+
+	got, err = s.Generate(context.Background(), "Reply with a single JSON object and nothing else.\nFILENAME: go.mod")
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	var structured struct {
+		Summary string   `json:"summary"`
+		Purpose string   `json:"purpose"`
+		Risks   []string `json:"risks"`
+	}
+	if err := json.Unmarshal([]byte(got.Text), &structured); err != nil {
+		t.Fatalf("structured answer %q: %v", got.Text, err)
+	}
+	if structured.Summary != "Stub summary of go.mod." || structured.Purpose == "" || structured.Risks == nil {
+		t.Errorf("structured answer = %+v", structured)
+	}
+}
+
+func TestStubStreamsWordByWord(t *testing.T) {
+	var chunks []string
+	got, err := (&Stub{}).Stream(context.Background(), "Summarize the repository.", func(c string) {
+		chunks = append(chunks, c)
+	})
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	if len(chunks) != 5 || strings.Join(chunks, "") != got.Text {
+		t.Errorf("chunks = %q, text = %q", chunks, got.Text)
+	}
+}
+
+func TestStubReplyAndCancellation(t *testing.T) {
+	boom := errors.New("rate limited")
+	s := &Stub{Reply: func(string) (string, error) { return "", boom }}
+	if _, err := s.Generate(context.Background(), "anything"); !errors.Is(err, boom) {
+		t.Errorf("err = %v, want the Reply error", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := (&Stub{}).Generate(ctx, "anything"); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
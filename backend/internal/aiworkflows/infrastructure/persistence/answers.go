@@ -0,0 +1,92 @@
+package persistence
+
+import (
+	"context"
+	"slices"
+
+	"gorm.io/gorm"
+
+	aiapp "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/application"
+	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
+	shared "github.com/atilladeniz/next-go-pg/backend/internal/shared/domain"
+)
+
+// AnswerStore is the GORM-backed implementation of the
+// application.AnswerStore port.
+type AnswerStore struct {
+	db *gorm.DB
+}
+
+var _ aiapp.AnswerStore = (*AnswerStore)(nil)
+
+func NewAnswerStore(db *gorm.DB) *AnswerStore {
+	return &AnswerStore{db: db}
+}
+
+// AddAnswer inserts a and writes the assigned ID and CreatedAt back.
+// Its tokens go on the user's quota ledger in the same transaction.
+func (s *AnswerStore) AddAnswer(ctx context.Context, a *ai.Answer) error {
+	m := gormAnswer{
+		SummaryID:        a.SummaryID,
+		UserID:           a.UserID.String(),
+		Question:         a.Question,
+		Answer:           a.Text,
+		Sources:          stringsJSON(a.Sources),
+		Citations:        stringsJSON(a.Citations),
+		Model:            a.Model,
+		PromptVersion:    a.PromptVersion,
+		PromptTokens:     a.Usage.PromptTokens,
+		CompletionTokens: a.Usage.CompletionTokens,
+		CostUSD:          a.Usage.CostUSD,
+	}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&m).Error; err != nil {
+			return err
+		}
+		if a.Usage.TotalTokens() == 0 {
+			return nil
+		}
+		return tx.Create(&gormUsageEntry{UserID: m.UserID, SummaryID: m.SummaryID, Tokens: a.Usage.TotalTokens()}).Error
+	})
+	if err != nil {
+		return err
+	}
+	a.ID = m.ID
+	a.CreatedAt = m.CreatedAt
+	return nil
+}
+
+// ListAnswers returns the summary's limit newest answers, oldest first.
+func (s *AnswerStore) ListAnswers(ctx context.Context, summaryID uint, limit int) ([]ai.Answer, error) {
+	var rows []gormAnswer
+	err := s.db.WithContext(ctx).
+		Where("summary_id = ?", summaryID).
+		Order("id DESC").
+		Limit(limit).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	slices.Reverse(rows)
+	out := make([]ai.Answer, 0, len(rows))
+	for _, m := range rows {
+		out = append(out, ai.Answer{
+			ID:            m.ID,
+			SummaryID:     m.SummaryID,
+			UserID:        shared.UserID(m.UserID),
+			Question:      m.Question,
+			Text:          m.Answer,
+			Sources:       m.Sources,
+			Citations:     m.Citations,
+			Model:         m.Model,
+			PromptVersion: m.PromptVersion,
+			Usage: ai.TokenUsage{
+				PromptTokens:     m.PromptTokens,
+				CompletionTokens: m.CompletionTokens,
+				CostUSD:          m.CostUSD,
+			},
+			CreatedAt: m.CreatedAt,
+		})
+	}
+	return out, nil
+}
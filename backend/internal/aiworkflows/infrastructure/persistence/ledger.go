@@ -0,0 +1,33 @@
+package persistence
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
+)
+
+// chargeRun records that userID started run id.
+func chargeRun(tx *gorm.DB, userID string, id uint) error {
+	return tx.Create(&gormUsageEntry{UserID: userID, SummaryID: id, Runs: 1}).Error
+}
+
+// chargeTokens records u's tokens against the user who started run
+// id. The user comes from the run's own ledger line rather than its
+// row, so a run deleted mid-flight still pays for what it spends.
+// Runs started before the ledger existed have no line and go free.
+func chargeTokens(tx *gorm.DB, id uint, u ai.TokenUsage) error {
+	if u.TotalTokens() == 0 {
+		return nil
+	}
+	var started gormUsageEntry
+	err := tx.Where("summary_id = ? AND runs > 0", id).Take(&started).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Create(&gormUsageEntry{UserID: started.UserID, SummaryID: id, Tokens: u.TotalTokens()}).Error
+}
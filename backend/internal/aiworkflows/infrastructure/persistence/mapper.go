@@ -1,6 +1,8 @@
 package persistence
 
 import (
+	"maps"
+
 	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
 	shared "github.com/atilladeniz/next-go-pg/backend/internal/shared/domain"
 )
@@ -16,6 +18,22 @@ func toDomain(m gormRepoSummary) (*ai.RepoSummary, error) {
 		if err != nil {
 			return nil, err
 		}
+		if r.Cached {
+			fs = fs.AsCached()
+		}
+		fs = fs.WithUsage(ai.TokenUsage{
+			PromptTokens:     r.PromptTokens,
+			CompletionTokens: r.CompletionTokens,
+			CostUSD:          r.CostUSD,
+		}).WithModel(r.Model).WithChunks(r.Chunks).WithBlobHash(r.BlobHash).WithRedactions(r.Redactions)
+		if r.Insights != nil {
+			fs = fs.WithInsights(ai.FileInsights{
+				Purpose:              r.Insights.Purpose,
+				KeySymbols:           r.Insights.KeySymbols,
+				ExternalDependencies: r.Insights.ExternalDependencies,
+				Risks:                r.Insights.Risks,
+			})
+		}
 		files = append(files, fs)
 	}
 	url, err := ai.NewRepoURL(m.RepoURL)
@@ -26,46 +44,172 @@ func toDomain(m gormRepoSummary) (*ai.RepoSummary, error) {
 	for k, v := range m.StepDurations {
 		durations[k] = v
 	}
+	var selection ai.FileSelection
+	if m.Selection != nil {
+		selection.Strategy = m.Selection.Strategy
+		selection.IgnorePatterns = m.Selection.IgnorePatterns
+		selection.SkippedSecrets = m.Selection.SkippedSecrets
+		selection.SkippedTooLarge = m.Selection.SkippedTooLarge
+		for _, f := range m.Selection.Files {
+			selection.Files = append(selection.Files, ai.SelectedFile{Path: f.Path, Reason: f.Reason})
+		}
+	}
+	var filter ai.FileFilter
+	if m.Filter != nil {
+		filter = ai.FileFilter{Include: m.Filter.Include, Exclude: m.Filter.Exclude, Subdir: m.Filter.Subdir}
+	}
+	var changelog ai.Changelog
+	if m.Changelog != nil {
+		changelog = ai.Changelog(*m.Changelog)
+	}
 	return &ai.RepoSummary{
-		ID:            m.ID,
-		UserID:        shared.UserID(m.UserID),
-		RepoURL:       url,
-		Status:        status,
-		Files:         files,
-		Summary:       m.Summary,
-		FailReason:    m.FailReason,
-		StartedAt:     m.StartedAt,
-		CompletedAt:   m.CompletedAt,
-		CreatedAt:     m.CreatedAt,
-		UpdatedAt:     m.UpdatedAt,
-		StepDurations: durations,
+		ID:               m.ID,
+		UserID:           shared.UserID(m.UserID),
+		RepoURL:          url,
+		Ref:              ai.GitRef(m.Ref),
+		CommitSHA:        m.CommitSHA,
+		CommitTime:       m.CommitTime,
+		RunID:            m.RunID,
+		OriginalID:       m.OriginalID,
+		RetryOf:          m.RetryOf,
+		BaseID:           m.BaseID,
+		Changelog:        changelog,
+		Filter:           filter,
+		Status:           status,
+		Files:            files,
+		Summary:          m.Summary,
+		FailReason:       m.FailReason,
+		StartedAt:        m.StartedAt,
+		CompletedAt:      m.CompletedAt,
+		CreatedAt:        m.CreatedAt,
+		UpdatedAt:        m.UpdatedAt,
+		StepDurations:    durations,
+		PromptVersions:   maps.Clone(map[string]string(m.PromptVersions)),
+		RedactionVersion: m.RedactionVersion,
+		Selection:        selection,
+		Directories:      directoryToDomain(m.Directories),
+		Usage: ai.TokenUsage{
+			PromptTokens:     m.PromptTokens,
+			CompletionTokens: m.CompletionTokens,
+			CostUSD:          m.CostUSD,
+		},
 	}, nil
 }
 
 func fromDomain(d *ai.RepoSummary) gormRepoSummary {
-	files := make(fileSummariesJSON, 0, len(d.Files))
-	for _, fs := range d.Files {
-		files = append(files, fileSummaryRecord{
-			Filename: fs.Filename(),
-			Summary:  fs.Summary(),
-		})
-	}
 	durations := make(stepDurationsJSON, len(d.StepDurations))
 	for k, v := range d.StepDurations {
 		durations[k] = v
 	}
+	var filter *fileFilterJSON
+	if !d.Filter.IsZero() {
+		filter = &fileFilterJSON{Include: d.Filter.Include, Exclude: d.Filter.Exclude, Subdir: d.Filter.Subdir}
+	}
 	return gormRepoSummary{
-		ID:            d.ID,
-		UserID:        d.UserID.String(),
-		RepoURL:       d.RepoURL.String(),
-		Status:        d.Status.String(),
-		Files:         files,
-		Summary:       d.Summary,
-		FailReason:    d.FailReason,
-		StepDurations: durations,
-		StartedAt:     d.StartedAt,
-		CompletedAt:   d.CompletedAt,
-		CreatedAt:     d.CreatedAt,
-		UpdatedAt:     d.UpdatedAt,
+		ID:               d.ID,
+		UserID:           d.UserID.String(),
+		RepoURL:          d.RepoURL.String(),
+		Ref:              d.Ref.String(),
+		CommitSHA:        d.CommitSHA,
+		CommitTime:       d.CommitTime,
+		RunID:            d.RunID,
+		OriginalID:       d.OriginalID,
+		RetryOf:          d.RetryOf,
+		BaseID:           d.BaseID,
+		Changelog:        changelogFromDomain(d.Changelog),
+		Status:           d.Status.String(),
+		Files:            filesFromDomain(d.Files),
+		Summary:          d.Summary,
+		FailReason:       d.FailReason,
+		StepDurations:    durations,
+		PromptVersions:   promptVersionsJSON(maps.Clone(d.PromptVersions)),
+		RedactionVersion: d.RedactionVersion,
+		Selection:        selectionFromDomain(d.Selection),
+		Filter:           filter,
+		Directories:      directoryFromDomain(d.Directories),
+		PromptTokens:     d.Usage.PromptTokens,
+		CompletionTokens: d.Usage.CompletionTokens,
+		CostUSD:          d.Usage.CostUSD,
+		StartedAt:        d.StartedAt,
+		CompletedAt:      d.CompletedAt,
+		CreatedAt:        d.CreatedAt,
+		UpdatedAt:        d.UpdatedAt,
+	}
+}
+
+func filesFromDomain(fss []ai.FileSummary) fileSummariesJSON {
+	files := make(fileSummariesJSON, 0, len(fss))
+	for _, fs := range fss {
+		u := fs.Usage()
+		var insights *fileInsightsRecord
+		if i := fs.Insights(); !i.IsZero() {
+			insights = &fileInsightsRecord{
+				Purpose:              i.Purpose,
+				KeySymbols:           i.KeySymbols,
+				ExternalDependencies: i.ExternalDependencies,
+				Risks:                i.Risks,
+			}
+		}
+		files = append(files, fileSummaryRecord{
+			Filename:         fs.Filename(),
+			Summary:          fs.Summary(),
+			Cached:           fs.Cached(),
+			Model:            fs.Model(),
+			PromptTokens:     u.PromptTokens,
+			CompletionTokens: u.CompletionTokens,
+			CostUSD:          u.CostUSD,
+			Insights:         insights,
+			Chunks:           fs.Chunks(),
+			BlobHash:         fs.BlobHash(),
+			Redactions:       fs.Redactions(),
+		})
+	}
+	return files
+}
+
+// selectionFromDomain is nil for a run that hasn't traversed yet.
+func selectionFromDomain(sel ai.FileSelection) *fileSelectionJSON {
+	if sel.Strategy == "" {
+		return nil
+	}
+	m := &fileSelectionJSON{
+		Strategy:        sel.Strategy,
+		IgnorePatterns:  sel.IgnorePatterns,
+		SkippedSecrets:  sel.SkippedSecrets,
+		SkippedTooLarge: sel.SkippedTooLarge,
+	}
+	for _, f := range sel.Files {
+		m.Files = append(m.Files, selectedFileRecord{Path: f.Path, Reason: f.Reason})
+	}
+	return m
+}
+
+func changelogFromDomain(c ai.Changelog) *changelogJSON {
+	if c.IsZero() {
+		return nil
+	}
+	m := changelogJSON(c)
+	return &m
+}
+
+func directoryToDomain(m *directoryJSON) ai.DirectorySummary {
+	if m == nil {
+		return ai.DirectorySummary{}
+	}
+	d := ai.DirectorySummary{Path: m.Path, Summary: m.Summary, Files: m.Files}
+	for i := range m.Children {
+		d.Children = append(d.Children, directoryToDomain(&m.Children[i]))
+	}
+	return d
+}
+
+func directoryFromDomain(d ai.DirectorySummary) *directoryJSON {
+	if d.IsZero() {
+		return nil
+	}
+	m := &directoryJSON{Path: d.Path, Summary: d.Summary, Files: d.Files}
+	for _, c := range d.Children {
+		m.Children = append(m.Children, *directoryFromDomain(c))
 	}
+	return m
 }
@@ -10,19 +10,38 @@ import (
 	"time"
 )
 
+// gormRepoSummary is the repo_summaries row. PromptTokens,
+// CompletionTokens and CostUSD are the run's LLM usage totals; per-file
+// usage lives inside Files.
 type gormRepoSummary struct {
-	ID            uint              `gorm:"primaryKey"`
-	UserID        string            `gorm:"index;not null"`
-	RepoURL       string            `gorm:"not null"`
-	Status        string            `gorm:"index;not null"`
-	Files         fileSummariesJSON `gorm:"type:jsonb;default:'[]'"`
-	Summary       string            `gorm:"type:text"`
-	FailReason    string            `gorm:"type:text"`
-	StepDurations stepDurationsJSON `gorm:"type:jsonb;default:'{}'"`
-	StartedAt     time.Time
-	CompletedAt   time.Time
-	CreatedAt     time.Time `gorm:"autoCreateTime"`
-	UpdatedAt     time.Time `gorm:"autoUpdateTime"`
+	ID               uint   `gorm:"primaryKey"`
+	UserID           string `gorm:"index;not null"`
+	RepoURL          string `gorm:"not null"`
+	Ref              string `gorm:"type:text;not null;default:''"`
+	CommitSHA        string `gorm:"column:commit_sha;type:text;not null;default:''"`
+	CommitTime       time.Time
+	RunID            string             `gorm:"type:text"`
+	OriginalID       uint               `gorm:"index"`
+	RetryOf          uint               `gorm:"not null;default:0"`
+	BaseID           uint               `gorm:"not null;default:0"`
+	Changelog        *changelogJSON     `gorm:"type:jsonb"`
+	Status           string             `gorm:"index;not null"`
+	Files            fileSummariesJSON  `gorm:"type:jsonb;default:'[]'"`
+	Summary          string             `gorm:"type:text"`
+	FailReason       string             `gorm:"type:text"`
+	StepDurations    stepDurationsJSON  `gorm:"type:jsonb;default:'{}'"`
+	PromptVersions   promptVersionsJSON `gorm:"type:jsonb;default:'{}'"`
+	RedactionVersion string             `gorm:"type:text;not null;default:''"`
+	Selection        *fileSelectionJSON `gorm:"type:jsonb"`
+	Filter           *fileFilterJSON    `gorm:"type:jsonb"`
+	Directories      *directoryJSON     `gorm:"type:jsonb"`
+	PromptTokens     int                `gorm:"not null;default:0"`
+	CompletionTokens int                `gorm:"not null;default:0"`
+	CostUSD          float64            `gorm:"column:cost_usd;not null;default:0"`
+	StartedAt        time.Time
+	CompletedAt      time.Time
+	CreatedAt        time.Time `gorm:"autoCreateTime"`
+	UpdatedAt        time.Time `gorm:"autoUpdateTime"`
 }
 
 func (gormRepoSummary) TableName() string { return "repo_summaries" }
@@ -34,6 +53,28 @@ func (gormRepoSummary) TableName() string { return "repo_summaries" }
 type fileSummaryRecord struct {
 	Filename string `json:"filename"`
 	Summary  string `json:"summary"`
+	Cached   bool   `json:"cached,omitempty"`
+	Model    string `json:"model,omitempty"`
+	// LLM usage of the call that produced the summary.
+	PromptTokens     int     `json:"promptTokens,omitempty"`
+	CompletionTokens int     `json:"completionTokens,omitempty"`
+	CostUSD          float64 `json:"costUsd,omitempty"`
+	// Insights is set for summaries generated in structured mode.
+	Insights *fileInsightsRecord `json:"insights,omitempty"`
+	// Chunks is set for files summarized in chunks.
+	Chunks int `json:"chunks,omitempty"`
+	// BlobHash is the git blob ID of the summarized content.
+	BlobHash string `json:"blobHash,omitempty"`
+	// Redactions counts the secrets redacted from the content, per rule.
+	Redactions map[string]int `json:"redactions,omitempty"`
+}
+
+// fileInsightsRecord is the persisted form of ai.FileInsights.
+type fileInsightsRecord struct {
+	Purpose              string   `json:"purpose,omitempty"`
+	KeySymbols           []string `json:"keySymbols,omitempty"`
+	ExternalDependencies []string `json:"externalDependencies,omitempty"`
+	Risks                []string `json:"risks,omitempty"`
 }
 
 // fileSummariesJSON is a slice of fileSummaryRecord with GORM
@@ -102,8 +143,230 @@ func (s *stepDurationsJSON) Scan(src any) error {
 	return json.Unmarshal(raw, s)
 }
 
+// promptVersionsJSON is a map[promptName]version backed by JSONB.
+type promptVersionsJSON map[string]string
+
+func (p promptVersionsJSON) Value() (driver.Value, error) {
+	if p == nil {
+		return "{}", nil
+	}
+	return json.Marshal(p)
+}
+
+func (p *promptVersionsJSON) Scan(src any) error {
+	if src == nil {
+		*p = nil
+		return nil
+	}
+	var raw []byte
+	switch v := src.(type) {
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return errors.New("promptVersionsJSON: unsupported scan source")
+	}
+	if len(raw) == 0 {
+		*p = nil
+		return nil
+	}
+	return json.Unmarshal(raw, p)
+}
+
+// fileSelectionJSON is the traverse step's file selection backed by
+// JSONB. A nil pointer (rows from before selections were recorded, or
+// runs that haven't traversed yet) stores NULL.
+type fileSelectionJSON struct {
+	Strategy        string               `json:"strategy"`
+	Files           []selectedFileRecord `json:"files"`
+	IgnorePatterns  []string             `json:"ignorePatterns,omitempty"`
+	SkippedSecrets  []string             `json:"skippedSecrets,omitempty"`
+	SkippedTooLarge []string             `json:"skippedTooLarge,omitempty"`
+}
+
+type selectedFileRecord struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason,omitempty"`
+}
+
+func (s fileSelectionJSON) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+func (s *fileSelectionJSON) Scan(src any) error {
+	var raw []byte
+	switch v := src.(type) {
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return errors.New("fileSelectionJSON: unsupported scan source")
+	}
+	return json.Unmarshal(raw, s)
+}
+
+// fileFilterJSON is the run's requested FileFilter backed by JSONB;
+// NULL when the run has none.
+type fileFilterJSON struct {
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+	Subdir  string   `json:"subdir,omitempty"`
+}
+
+func (f fileFilterJSON) Value() (driver.Value, error) {
+	return json.Marshal(f)
+}
+
+func (f *fileFilterJSON) Scan(src any) error {
+	var raw []byte
+	switch v := src.(type) {
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return errors.New("fileFilterJSON: unsupported scan source")
+	}
+	return json.Unmarshal(raw, f)
+}
+
+// directoryJSON is the run's directory outline backed by JSONB, one
+// nested record per directory; NULL until the directory step has run.
+type directoryJSON struct {
+	Path     string          `json:"path,omitempty"`
+	Summary  string          `json:"summary,omitempty"`
+	Files    []string        `json:"files,omitempty"`
+	Children []directoryJSON `json:"children,omitempty"`
+}
+
+func (d directoryJSON) Value() (driver.Value, error) {
+	return json.Marshal(d)
+}
+
+func (d *directoryJSON) Scan(src any) error {
+	var raw []byte
+	switch v := src.(type) {
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return errors.New("directoryJSON: unsupported scan source")
+	}
+	return json.Unmarshal(raw, d)
+}
+
+// changelogJSON is an incremental run's comparison with its base run
+// backed by JSONB; NULL for full runs and until the summarize-files
+// step has run.
+type changelogJSON struct {
+	BaseID     uint     `json:"baseId"`
+	BaseCommit string   `json:"baseCommit,omitempty"`
+	Added      []string `json:"added,omitempty"`
+	Modified   []string `json:"modified,omitempty"`
+	Removed    []string `json:"removed,omitempty"`
+	Unchanged  []string `json:"unchanged,omitempty"`
+}
+
+func (c changelogJSON) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+func (c *changelogJSON) Scan(src any) error {
+	var raw []byte
+	switch v := src.(type) {
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return errors.New("changelogJSON: unsupported scan source")
+	}
+	return json.Unmarshal(raw, c)
+}
+
+// gormSummaryCacheEntry is one row of the content-addressed per-file
+// summary cache. The composite primary key is the cache key, so a
+// concurrent Put of the same file is a no-op rather than a duplicate.
+type gormSummaryCacheEntry struct {
+	BlobHash      string    `gorm:"primaryKey;type:text"`
+	PromptVersion string    `gorm:"primaryKey;type:text"`
+	Model         string    `gorm:"primaryKey;type:text"`
+	Summary       string    `gorm:"type:text;not null"`
+	CreatedAt     time.Time `gorm:"autoCreateTime"`
+}
+
+func (gormSummaryCacheEntry) TableName() string { return "ai_file_summary_cache" }
+
+// gormAnswer is one question asked about a run and its answer, in
+// ai_answers. Rows go when their run is deleted.
+type gormAnswer struct {
+	ID               uint        `gorm:"primaryKey"`
+	SummaryID        uint        `gorm:"index;not null"`
+	UserID           string      `gorm:"index;not null"`
+	Question         string      `gorm:"type:text;not null"`
+	Answer           string      `gorm:"type:text;not null"`
+	Sources          stringsJSON `gorm:"type:jsonb;default:'[]'"`
+	Citations        stringsJSON `gorm:"type:jsonb;default:'[]'"`
+	Model            string      `gorm:"type:text;not null;default:''"`
+	PromptVersion    string      `gorm:"type:text;not null;default:''"`
+	PromptTokens     int         `gorm:"not null;default:0"`
+	CompletionTokens int         `gorm:"not null;default:0"`
+	CostUSD          float64     `gorm:"column:cost_usd;not null;default:0"`
+	CreatedAt        time.Time   `gorm:"autoCreateTime;index"`
+}
+
+func (gormAnswer) TableName() string { return "ai_answers" }
+
+// gormUsageEntry is one line of a user's quota ledger in
+// ai_usage_ledger: a run started, or tokens a run or an answer spent.
+// Nothing deletes from it, so deleting runs doesn't hand back quota.
+type gormUsageEntry struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    string    `gorm:"index;not null"`
+	SummaryID uint      `gorm:"index;not null"`
+	Runs      int       `gorm:"not null;default:0"`
+	Tokens    int       `gorm:"not null;default:0"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index"`
+}
+
+func (gormUsageEntry) TableName() string { return "ai_usage_ledger" }
+
+// stringsJSON is a list of strings backed by JSONB.
+type stringsJSON []string
+
+func (s stringsJSON) Value() (driver.Value, error) {
+	if s == nil {
+		return "[]", nil
+	}
+	return json.Marshal(s)
+}
+
+func (s *stringsJSON) Scan(src any) error {
+	if src == nil {
+		*s = nil
+		return nil
+	}
+	var raw []byte
+	switch v := src.(type) {
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return errors.New("stringsJSON: unsupported scan source")
+	}
+	if len(raw) == 0 {
+		*s = nil
+		return nil
+	}
+	return json.Unmarshal(raw, s)
+}
+
 // Entities returns the GORM models that AutoMigrate must process for
 // the aiworkflows context. Called from composition.runAutoMigrations.
 func Entities() []any {
-	return []any{&gormRepoSummary{}}
+	return []any{&gormRepoSummary{}, &gormSummaryCacheEntry{}, &gormAnswer{}, &gormUsageEntry{}}
 }
@@ -2,7 +2,9 @@ package persistence
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"time"
 
 	"gorm.io/gorm"
 
@@ -24,11 +26,18 @@ func NewRepository(db *gorm.DB) *Repository {
 }
 
 // Create inserts a fresh RepoSummary and writes the assigned ID back
-// onto the aggregate. The domain's pending events are NOT pulled here;
+// onto the aggregate. The run goes on its user's quota ledger in the
+// same transaction. The domain's pending events are NOT pulled here;
 // the caller (use case) owns event lifecycle.
 func (r *Repository) Create(ctx context.Context, agg *ai.RepoSummary) error {
 	m := fromDomain(agg)
-	if err := r.db.WithContext(ctx).Create(&m).Error; err != nil {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&m).Error; err != nil {
+			return err
+		}
+		return chargeRun(tx, m.UserID, m.ID)
+	})
+	if err != nil {
 		return err
 	}
 	agg.ID = m.ID
@@ -37,17 +46,149 @@ func (r *Repository) Create(ctx context.Context, agg *ai.RepoSummary) error {
 	return nil
 }
 
-// Save persists changes. Mutates fields back onto the aggregate without
-// replacing *agg, so AggregateBase.pendingEvents survives.
-func (r *Repository) Save(ctx context.Context, agg *ai.RepoSummary) error {
+// Transition writes the columns agg's new status sets, in one
+// statement conditional on the row's current status.
+func (r *Repository) Transition(ctx context.Context, agg *ai.RepoSummary, from ...ai.Status) error {
 	m := fromDomain(agg)
-	if err := r.db.WithContext(ctx).Save(&m).Error; err != nil {
-		return err
+	cols := map[string]any{"status": m.Status}
+	switch agg.Status {
+	case ai.StatusRunning:
+		cols["started_at"] = m.StartedAt
+		cols["prompt_versions"] = m.PromptVersions
+		cols["redaction_version"] = m.RedactionVersion
+	case ai.StatusCompleted:
+		cols["summary"] = m.Summary
+		cols["completed_at"] = m.CompletedAt
+	case ai.StatusFailed:
+		cols["fail_reason"] = m.FailReason
+		cols["completed_at"] = m.CompletedAt
+	case ai.StatusCancelled:
+		cols["completed_at"] = m.CompletedAt
+	}
+	statuses := make([]string, 0, len(from))
+	for _, st := range from {
+		statuses = append(statuses, st.String())
+	}
+	res := r.db.WithContext(ctx).
+		Model(&gormRepoSummary{}).
+		Where("id = ? AND status IN ?", agg.ID, statuses).
+		Updates(cols)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return aiapp.ErrStatusChanged
 	}
-	agg.UpdatedAt = m.UpdatedAt
 	return nil
 }
 
+// AppendFiles concatenates onto the files array and increments the
+// usage totals in place, so nothing read earlier is written back.
+func (r *Repository) AppendFiles(ctx context.Context, id uint, files []ai.FileSummary) error {
+	raw, err := json.Marshal(filesFromDomain(files))
+	if err != nil {
+		return err
+	}
+	var u ai.TokenUsage
+	for _, fs := range files {
+		u = u.Add(fs.Usage())
+	}
+	cols := usageIncrement(u)
+	cols["files"] = gorm.Expr("COALESCE(files, '[]'::jsonb) || ?::jsonb", string(raw))
+	return r.whileRunning(ctx, id, cols, u)
+}
+
+// AddUsage increments the usage totals in place, whatever the row's
+// status: the tokens were spent either way.
+func (r *Repository) AddUsage(ctx context.Context, id uint, u ai.TokenUsage) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&gormRepoSummary{}).
+			Where("id = ?", id).
+			Updates(usageIncrement(u)).Error
+		if err != nil {
+			return err
+		}
+		return chargeTokens(tx, id, u)
+	})
+}
+
+// Complete writes the summary, the completion time and the overview's
+// usage in one statement, so a retried store step can't count the
+// usage twice: once the row is completed, the retry matches nothing.
+func (r *Repository) Complete(ctx context.Context, agg *ai.RepoSummary, u ai.TokenUsage) error {
+	m := fromDomain(agg)
+	cols := usageIncrement(u)
+	cols["status"] = m.Status
+	cols["summary"] = m.Summary
+	cols["completed_at"] = m.CompletedAt
+	return r.whileRunning(ctx, agg.ID, cols, u)
+}
+
+func usageIncrement(u ai.TokenUsage) map[string]any {
+	return map[string]any{
+		"prompt_tokens":     gorm.Expr("prompt_tokens + ?", u.PromptTokens),
+		"completion_tokens": gorm.Expr("completion_tokens + ?", u.CompletionTokens),
+		"cost_usd":          gorm.Expr("cost_usd + ?", u.CostUSD),
+	}
+}
+
+// whileRunning sets cols on the row if it is still running, and
+// charges u to the quota ledger in the same transaction.
+func (r *Repository) whileRunning(ctx context.Context, id uint, cols map[string]any, u ai.TokenUsage) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&gormRepoSummary{}).
+			Where("id = ? AND status = ?", id, ai.StatusRunning.String()).
+			Updates(cols)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return aiapp.ErrStatusChanged
+		}
+		return chargeTokens(tx, id, u)
+	})
+}
+
+// AttachRun updates the run_id column alone.
+func (r *Repository) AttachRun(ctx context.Context, id uint, runID string) error {
+	return r.update(ctx, id, map[string]any{"run_id": runID})
+}
+
+// RecordStepDuration merges the one key into step_durations.
+func (r *Repository) RecordStepDuration(ctx context.Context, id uint, step string, ms int64) error {
+	return r.update(ctx, id, map[string]any{
+		"step_durations": gorm.Expr("COALESCE(step_durations, '{}'::jsonb) || jsonb_build_object(?::text, ?::bigint)", step, ms),
+	})
+}
+
+// RecordChangelog updates the changelog column alone.
+func (r *Repository) RecordChangelog(ctx context.Context, id uint, c ai.Changelog) error {
+	return r.update(ctx, id, map[string]any{"changelog": changelogFromDomain(c)})
+}
+
+// RecordCommit updates commit_sha and commit_time.
+func (r *Repository) RecordCommit(ctx context.Context, id uint, sha string, at time.Time) error {
+	return r.update(ctx, id, map[string]any{"commit_sha": sha, "commit_time": at})
+}
+
+// RecordSelection updates the selection column alone.
+func (r *Repository) RecordSelection(ctx context.Context, id uint, sel ai.FileSelection) error {
+	return r.update(ctx, id, map[string]any{"selection": selectionFromDomain(sel)})
+}
+
+// RecordDirectories updates the directories column alone.
+func (r *Repository) RecordDirectories(ctx context.Context, id uint, tree ai.DirectorySummary) error {
+	return r.update(ctx, id, map[string]any{"directories": directoryFromDomain(tree)})
+}
+
+// update sets cols on the row, whatever its status.
+func (r *Repository) update(ctx context.Context, id uint, cols map[string]any) error {
+	return r.db.WithContext(ctx).
+		Model(&gormRepoSummary{}).
+		Where("id = ?", id).
+		Updates(cols).Error
+}
+
 // GetByID returns ErrNotFound when the row is missing.
 func (r *Repository) GetByID(ctx context.Context, id uint) (*ai.RepoSummary, error) {
 	var m gormRepoSummary
@@ -64,18 +205,20 @@ func (r *Repository) GetByID(ctx context.Context, id uint) (*ai.RepoSummary, err
 // Delete removes the row in a single owner-scoped statement. The WHERE
 // clause does the auth check inline, so a cross-user request and a
 // missing row are indistinguishable on the wire — both return
-// ErrNotFound (see Store contract).
+// ErrNotFound (see Store contract). The run's answers go with it, in
+// the same transaction.
 func (r *Repository) Delete(ctx context.Context, userID shared.UserID, id uint) error {
-	res := r.db.WithContext(ctx).
-		Where("id = ? AND user_id = ?", id, string(userID)).
-		Delete(&gormRepoSummary{})
-	if res.Error != nil {
-		return res.Error
-	}
-	if res.RowsAffected == 0 {
-		return aiapp.ErrNotFound
-	}
-	return nil
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("id = ? AND user_id = ?", id, string(userID)).
+			Delete(&gormRepoSummary{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return aiapp.ErrNotFound
+		}
+		return tx.Where("summary_id = ?", id).Delete(&gormAnswer{}).Error
+	})
 }
 
 // ListByUserID returns the user's recent summaries, newest first.
@@ -102,3 +245,69 @@ func (r *Repository) ListByUserID(ctx context.Context, userID shared.UserID, lim
 	}
 	return out, nil
 }
+
+// ListAttempts returns every attempt in the retry chain started by
+// originalID, first attempt first.
+func (r *Repository) ListAttempts(ctx context.Context, originalID uint) ([]*ai.RepoSummary, error) {
+	var rows []gormRepoSummary
+	err := r.db.WithContext(ctx).
+		Where("id = ? OR original_id = ?", originalID, originalID).
+		Order("id ASC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*ai.RepoSummary, 0, len(rows))
+	for _, row := range rows {
+		agg, err := toDomain(row)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, agg)
+	}
+	return out, nil
+}
+
+// LatestCompleted returns the user's newest completed run of url.
+func (r *Repository) LatestCompleted(ctx context.Context, userID shared.UserID, url ai.RepoURL) (*ai.RepoSummary, error) {
+	var m gormRepoSummary
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND repo_url = ? AND status = ?", string(userID), url.String(), ai.StatusCompleted.String()).
+		Order("id DESC").
+		First(&m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, aiapp.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toDomain(m)
+}
+
+// UserUsage counts the user's active runs, and sums the runs started
+// today and the tokens spent this month from their quota ledger, which
+// deleting runs and answers leaves alone.
+func (r *Repository) UserUsage(ctx context.Context, userID shared.UserID, dayStart, monthStart time.Time) (aiapp.UserUsage, error) {
+	var active int64
+	err := r.db.WithContext(ctx).
+		Model(&gormRepoSummary{}).
+		Where("user_id = ? AND status IN ?", string(userID), []string{ai.StatusPending.String(), ai.StatusRunning.String()}).
+		Count(&active).Error
+	if err != nil {
+		return aiapp.UserUsage{}, err
+	}
+	var row struct {
+		RunsToday int
+		Tokens    int
+	}
+	err = r.db.WithContext(ctx).
+		Model(&gormUsageEntry{}).
+		Select(`COALESCE(SUM(runs) FILTER (WHERE created_at >= ?), 0) AS runs_today,
+			COALESCE(SUM(tokens) FILTER (WHERE created_at >= ?), 0) AS tokens`, dayStart, monthStart).
+		Where("user_id = ?", string(userID)).
+		Scan(&row).Error
+	if err != nil {
+		return aiapp.UserUsage{}, err
+	}
+	return aiapp.UserUsage{ActiveRuns: int(active), RunsToday: row.RunsToday, TokensThisMonth: row.Tokens}, nil
+}
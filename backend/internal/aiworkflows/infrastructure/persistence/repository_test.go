@@ -0,0 +1,158 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
+)
+
+// recorder is a database/sql connector that answers without a
+// database: every exec affects one row, every query returns one row of
+// zeros. It keeps the statements it was sent.
+type recorder struct {
+	mu         sync.Mutex
+	statements []string
+}
+
+func (r *recorder) record(query string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statements = append(r.statements, query)
+}
+
+func (r *recorder) Connect(context.Context) (driver.Conn, error) { return &recorderConn{r}, nil }
+func (r *recorder) Driver() driver.Driver                        { return r }
+func (r *recorder) Open(string) (driver.Conn, error)             { return &recorderConn{r}, nil }
+
+type recorderConn struct{ r *recorder }
+
+func (c *recorderConn) Prepare(query string) (driver.Stmt, error) {
+	return &recorderStmt{r: c.r, query: query}, nil
+}
+func (c *recorderConn) Close() error              { return nil }
+func (c *recorderConn) Begin() (driver.Tx, error) { return c, nil }
+func (c *recorderConn) Commit() error             { return nil }
+func (c *recorderConn) Rollback() error           { return nil }
+
+type recorderStmt struct {
+	r     *recorder
+	query string
+}
+
+func (s *recorderStmt) Close() error  { return nil }
+func (s *recorderStmt) NumInput() int { return -1 }
+
+func (s *recorderStmt) Exec([]driver.Value) (driver.Result, error) {
+	s.r.record(s.query)
+	return driver.RowsAffected(1), nil
+}
+
+// selectAlias finds the names a query gives its result columns.
+var selectAlias = regexp.MustCompile(`\bAS (\w+)`)
+
+func (s *recorderStmt) Query([]driver.Value) (driver.Rows, error) {
+	s.r.record(s.query)
+	cols := []string{"count"}
+	if strings.HasSuffix(s.query, `RETURNING "id"`) {
+		cols = []string{"id"}
+	} else if m := selectAlias.FindAllStringSubmatch(s.query, -1); m != nil {
+		cols = cols[:0]
+		for _, alias := range m {
+			cols = append(cols, alias[1])
+		}
+	}
+	return &zeroRow{cols: cols}, nil
+}
+
+type zeroRow struct {
+	cols []string
+	done bool
+}
+
+func (r *zeroRow) Columns() []string { return r.cols }
+func (r *zeroRow) Close() error      { return nil }
+
+func (r *zeroRow) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	for i := range dest {
+		dest[i] = int64(0)
+	}
+	return nil
+}
+
+// recordingRepo returns a Repository whose statements go to a recorder.
+func recordingRepo(t *testing.T) (*Repository, *recorder) {
+	t.Helper()
+	rec := &recorder{}
+	conn := sql.OpenDB(rec)
+	t.Cleanup(func() { _ = conn.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewRepository(db), rec
+}
+
+func TestQuotaLedgerOutlivesDeletes(t *testing.T) {
+	ctx := context.Background()
+	repo, rec := recordingRepo(t)
+	touches := func(stmt, table string) bool { return strings.Contains(stmt, `"`+table+`"`) }
+
+	// Starting a run puts it on the ledger.
+	agg := ai.NewRepoSummary("user-1", "https://github.com/acme/tool")
+	if err := repo.Create(ctx, agg); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	var charged bool
+	for _, stmt := range rec.statements {
+		charged = charged || strings.HasPrefix(stmt, "INSERT") && touches(stmt, "ai_usage_ledger")
+	}
+	if !charged {
+		t.Errorf("create ran %q, want a ledger insert", rec.statements)
+	}
+
+	// Deleting the run leaves the ledger alone.
+	rec.statements = nil
+	if err := repo.Delete(ctx, "user-1", 7); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	var deletes int
+	for _, stmt := range rec.statements {
+		if touches(stmt, "ai_usage_ledger") {
+			t.Errorf("delete touched the ledger: %s", stmt)
+		}
+		if strings.HasPrefix(stmt, "DELETE") {
+			deletes++
+		}
+	}
+	if deletes != 2 {
+		t.Errorf("delete ran %q, want the run and its answers deleted", rec.statements)
+	}
+
+	// Runs and tokens are summed from the ledger, not the runs.
+	rec.statements = nil
+	if _, err := repo.UserUsage(ctx, "user-1", time.Now(), time.Now()); err != nil {
+		t.Fatalf("usage: %v", err)
+	}
+	if len(rec.statements) != 2 {
+		t.Fatalf("usage ran %q, want 2 statements", rec.statements)
+	}
+	if !touches(rec.statements[1], "ai_usage_ledger") || !strings.Contains(rec.statements[1], "AS runs_today") {
+		t.Errorf("runs and tokens should come from the ledger: %s", rec.statements[1])
+	}
+}
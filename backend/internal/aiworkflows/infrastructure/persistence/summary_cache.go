@@ -0,0 +1,52 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	aiapp "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/application"
+)
+
+// SummaryCache is the GORM-backed implementation of the
+// application.SummaryCache port.
+type SummaryCache struct {
+	db *gorm.DB
+}
+
+var _ aiapp.SummaryCache = (*SummaryCache)(nil)
+
+func NewSummaryCache(db *gorm.DB) *SummaryCache {
+	return &SummaryCache{db: db}
+}
+
+// Get looks up a cached summary. A missing row is a miss, not an error.
+func (c *SummaryCache) Get(ctx context.Context, key aiapp.SummaryCacheKey) (string, bool, error) {
+	var m gormSummaryCacheEntry
+	err := c.db.WithContext(ctx).
+		Where("blob_hash = ? AND prompt_version = ? AND model = ?", key.BlobHash, key.PromptVersion, key.Model).
+		First(&m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return m.Summary, true, nil
+}
+
+// Put stores a summary. Entries are immutable: when two runs summarise
+// the same blob concurrently, the first write wins and the second is
+// dropped by the primary key.
+func (c *SummaryCache) Put(ctx context.Context, key aiapp.SummaryCacheKey, summary string) error {
+	return c.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&gormSummaryCacheEntry{
+			BlobHash:      key.BlobHash,
+			PromptVersion: key.PromptVersion,
+			Model:         key.Model,
+			Summary:       summary,
+		}).Error
+}
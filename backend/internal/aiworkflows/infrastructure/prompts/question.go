@@ -0,0 +1,26 @@
+package prompts
+
+import (
+	aiapp "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/application"
+)
+
+var _ aiapp.QuestionPrompter = (*Registry)(nil)
+
+// QuestionPrompt renders the question prompt, implementing
+// aiapp.QuestionPrompter. The version is selected per run, so every
+// question about one run uses the same template.
+func (r *Registry) QuestionPrompt(p aiapp.QuestionPrompt) (string, string, error) {
+	version := r.Select(Question, p.SummaryID)
+	vars := QuestionVars{Question: p.Question, Overview: p.Overview}
+	for _, s := range p.Sources {
+		vars.Sources = append(vars.Sources, FileSummaryVar{Filename: s.Path, Summary: s.Text})
+	}
+	for _, a := range p.History {
+		vars.History = append(vars.History, ExchangeVar{Question: a.Question, Answer: a.Text})
+	}
+	prompt, err := r.Render(Question, version, vars)
+	if err != nil {
+		return "", "", err
+	}
+	return prompt, version, nil
+}
@@ -0,0 +1,333 @@
+// Package prompts is the registry of the LLM prompt templates the
+// summarize-repo workflow renders. Templates are text/template files
+// laid out as `<name>/<version>.tmpl`; the built-in set is embedded
+// under templates/ and a config directory with the same layout can add
+// versions without a rebuild. It can't replace built-in ones.
+//
+// Which version a run uses is decided once, when the run starts, and
+// persisted on the run. The config directory may hold a
+// `selection.json` that splits runs between versions by weight:
+//
+//	{"file-summary": {"v1": 90, "v2": 10}}
+//
+// It is re-read on every selection, so an A/B split (or a rollback)
+// takes effect for the next run without a redeploy. Names it doesn't
+// mention use the built-in default.
+//
+// A version is an immutable identity — summary cache entries are keyed
+// by it. Change a prompt by adding a version, never by editing one.
+package prompts
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/atilladeniz/next-go-pg/backend/pkg/logger"
+)
+
+//go:embed templates
+var builtinFS embed.FS
+
+// Name identifies one prompt of the workflow.
+type Name string
+
+const (
+	FileSummary Name = "file-summary"
+	// FileSummaryJSON asks for the per-file summary as a JSON object
+	// (summary, purpose, keySymbols, externalDependencies, risks)
+	// instead of free text. Same variables as FileSummary.
+	FileSummaryJSON Name = "file-summary-json"
+	// FileChunk summarizes one part of a file too large for a single
+	// prompt; FileReduce and FileReduceJSON combine the parts' summaries
+	// into the file summary, as free text or JSON respectively.
+	FileChunk      Name = "file-chunk"
+	FileReduce     Name = "file-reduce"
+	FileReduceJSON Name = "file-reduce-json"
+	// Directory summarizes a directory from its files' and
+	// subdirectories' summaries.
+	Directory Name = "directory"
+	Aggregate Name = "aggregate"
+	// Question answers a user's question about a finished run from the
+	// summaries retrieved for it.
+	Question Name = "question"
+)
+
+// defaults is the version each prompt uses when selection.json doesn't
+// say otherwise.
+var defaults = map[Name]string{
+	FileSummary:     "v1",
+	FileSummaryJSON: "v1",
+	FileChunk:       "v1",
+	FileReduce:      "v1",
+	FileReduceJSON:  "v1",
+	Directory:       "v1",
+	Aggregate:       "v2",
+	Question:        "v1",
+}
+
+// Default is the version name uses when nothing selects another one,
+// and the version of runs started before versions were recorded.
+func Default(name Name) string { return defaults[name] }
+
+// selectionFile is the A/B weight file inside the config directory.
+const selectionFile = "selection.json"
+
+// FileVars are the variables of the file-summary prompts.
+type FileVars struct {
+	Filename string
+	Language string
+	Content  string
+}
+
+// ChunkVars are the variables of the file-chunk prompt: part Part of
+// Parts, covering lines StartLine to EndLine of the file.
+type ChunkVars struct {
+	Filename  string
+	Language  string
+	Part      int
+	Parts     int
+	StartLine int
+	EndLine   int
+	Content   string
+}
+
+// ReduceVars are the variables of the file-reduce prompts.
+type ReduceVars struct {
+	Filename string
+	Language string
+	Chunks   []ChunkSummaryVar
+}
+
+// ChunkSummaryVar is one part's summary fed into a file-reduce prompt.
+type ChunkSummaryVar struct {
+	Part      int
+	StartLine int
+	EndLine   int
+	Summary   string
+}
+
+// DirectoryVars are the variables of the directory prompt. Files are
+// the summaries of the files directly in Path, Directories those of its
+// subdirectories (Filename is the subdirectory's path).
+type DirectoryVars struct {
+	Path        string
+	Files       []FileSummaryVar
+	Directories []FileSummaryVar
+}
+
+// AggregateVars are the variables of the aggregate prompt. Directories
+// are the top-level directory summaries; v1 ignores them.
+type AggregateVars struct {
+	Files       []FileSummaryVar
+	Directories []FileSummaryVar
+}
+
+// QuestionVars are the variables of the question prompt. Sources are
+// the retrieved summaries (Filename is the path, with a trailing slash
+// for directories); History the most recent earlier exchanges about the
+// run, oldest first.
+type QuestionVars struct {
+	Question string
+	Overview string
+	Sources  []FileSummaryVar
+	History  []ExchangeVar
+}
+
+// ExchangeVar is one earlier question and its answer.
+type ExchangeVar struct {
+	Question string
+	Answer   string
+}
+
+// FileSummaryVar is one per-file summary fed into the aggregate prompt.
+type FileSummaryVar struct {
+	Filename string
+	Summary  string
+}
+
+// Registry resolves and renders prompt templates. The zero value serves
+// the built-in templates only.
+type Registry struct {
+	dir string
+
+	mu sync.Mutex
+	// shadowing holds the config-dir files already logged as ignored,
+	// so each is reported once rather than on every render.
+	shadowing map[string]bool
+}
+
+// NewRegistry returns a registry that adds the versions in dir to the
+// built-in templates. An empty dir disables it.
+func NewRegistry(dir string) *Registry {
+	return &Registry{dir: dir}
+}
+
+// Select picks the version of name for a run. The pick is a stable
+// function of runID, so the split is even across runs and the same run
+// always lands on the same side. A broken selection.json is logged and
+// ignored rather than failing runs.
+func (r *Registry) Select(name Name, runID uint) string {
+	weights, err := r.weights(name)
+	if err != nil {
+		logger.Warn().Err(err).Str("prompt", string(name)).Msg("Ignoring prompt selection - using default version")
+		return defaults[name]
+	}
+	if len(weights) == 0 {
+		return defaults[name]
+	}
+	versions := make([]string, 0, len(weights))
+	total := 0
+	for v, w := range weights {
+		versions = append(versions, v)
+		total += w
+	}
+	sort.Strings(versions)
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%s:%d", name, runID)
+	n := int(h.Sum32() % uint32(total))
+	for _, v := range versions {
+		if n < weights[v] {
+			return v
+		}
+		n -= weights[v]
+	}
+	return defaults[name]
+}
+
+// weights reads name's split from selection.json, keeping only versions
+// that exist and have a positive weight.
+func (r *Registry) weights(name Name) (map[string]int, error) {
+	if r.dir == "" {
+		return nil, nil
+	}
+	raw, err := os.ReadFile(filepath.Join(r.dir, selectionFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var all map[Name]map[string]int
+	if err := json.Unmarshal(raw, &all); err != nil {
+		return nil, fmt.Errorf("%s: %w", selectionFile, err)
+	}
+	out := make(map[string]int)
+	for v, w := range all[name] {
+		if w <= 0 {
+			continue
+		}
+		if _, err := r.source(name, v); err != nil {
+			return nil, fmt.Errorf("%s: %w", selectionFile, err)
+		}
+		out[v] = w
+	}
+	return out, nil
+}
+
+// Render executes version of name with vars.
+func (r *Registry) Render(name Name, version string, vars any) (string, error) {
+	src, err := r.source(name, version)
+	if err != nil {
+		return "", err
+	}
+	tmpl, err := template.New(string(name) + "/" + version).Option("missingkey=error").Parse(src)
+	if err != nil {
+		return "", fmt.Errorf("parse prompt %s/%s: %w", name, version, err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, vars); err != nil {
+		return "", fmt.Errorf("render prompt %s/%s: %w", name, version, err)
+	}
+	return b.String(), nil
+}
+
+// source returns the template text, built-in first. A config-dir file
+// with a built-in version's name is ignored: cache entries and recorded
+// runs name that version, so it must keep meaning the embedded text.
+// One trailing newline is dropped so an editor's final newline doesn't
+// end up in the prompt.
+func (r *Registry) source(name Name, version string) (string, error) {
+	if !validSegment(string(name)) || !validSegment(version) {
+		return "", fmt.Errorf("invalid prompt %q version %q", name, version)
+	}
+	rel := string(name) + "/" + version + ".tmpl"
+	path := ""
+	if r.dir != "" {
+		path = filepath.Join(r.dir, filepath.FromSlash(rel))
+	}
+	raw, err := builtinFS.ReadFile("templates/" + rel)
+	if err == nil && path != "" {
+		r.warnShadowing(path)
+	}
+	if errors.Is(err, fs.ErrNotExist) && path != "" {
+		raw, err = os.ReadFile(path)
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("unknown prompt %s/%s", name, version)
+	}
+	if err != nil {
+		return "", fmt.Errorf("read prompt %s/%s: %w", name, version, err)
+	}
+	return strings.TrimSuffix(string(raw), "\n"), nil
+}
+
+// warnShadowing logs, once per file, that path exists but is ignored
+// because a built-in version has its name.
+func (r *Registry) warnShadowing(path string) {
+	if _, err := os.Stat(path); err != nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.shadowing[path] {
+		return
+	}
+	if r.shadowing == nil {
+		r.shadowing = make(map[string]bool)
+	}
+	r.shadowing[path] = true
+	logger.Warn().Str("file", path).Msg("Ignoring prompt file that shadows a built-in version - add it as a new version instead")
+}
+
+// validSegment keeps names and versions from escaping the template
+// directories.
+func validSegment(s string) bool {
+	return s != "" && s != "." && s != ".." && !strings.ContainsAny(s, `/\`)
+}
+
+// Language names the programming language of a file for the
+// {{.Language}} variable, by extension. Unknown extensions yield
+// "plain text".
+func Language(filename string) string {
+	if lang, ok := languages[strings.ToLower(filepath.Ext(filename))]; ok {
+		return lang
+	}
+	return "plain text"
+}
+
+var languages = map[string]string{
+	".go":   "Go",
+	".ts":   "TypeScript",
+	".tsx":  "TypeScript (React)",
+	".js":   "JavaScript",
+	".jsx":  "JavaScript (React)",
+	".py":   "Python",
+	".rs":   "Rust",
+	".java": "Java",
+	".rb":   "Ruby",
+	".sql":  "SQL",
+	".md":   "Markdown",
+	".yaml": "YAML",
+	".yml":  "YAML",
+	".toml": "TOML",
+}
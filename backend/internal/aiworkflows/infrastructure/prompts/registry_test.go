@@ -0,0 +1,128 @@
+package prompts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	aiapp "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/application"
+	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
+)
+
+func TestBuiltinV1MatchesTheOriginalPrompts(t *testing.T) {
+	// Summary cache entries written before prompts were versioned are
+	// keyed "file-summary/v1"; v1 must render byte-for-byte what was
+	// sent then.
+	r := NewRegistry("")
+	got, err := r.Render(FileSummary, "v1", FileVars{Filename: "main.go", Language: "Go", Content: "package main\n"})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	want := "Summarize the following source file in 2-3 sentences. Focus on what it does, not the syntax.\n\nFILENAME: main.go\n\n---\npackage main\n\n---\n\nSUMMARY:"
+	if got != want {
+		t.Errorf("file-summary/v1 =\n%q\nwant\n%q", got, want)
+	}
+
+	got, err = r.Render(Aggregate, "v1", AggregateVars{Files: []FileSummaryVar{{"a.go", "Does A."}, {"b.go", "Does B."}}})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	want = "You are summarizing a Git repository. Below are short summaries of individual files. Produce a single 4-6 sentence overview describing what the repository does as a whole.\n\nFILE SUMMARIES:\n- a.go: Does A.\n- b.go: Does B.\n\nOVERVIEW:"
+	if got != want {
+		t.Errorf("aggregate/v1 =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfigDirAddsVersionsButDoesNotShadowBuiltins(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "file-summary", "v3.tmpl"), "{{.Language}} file {{.Filename}}\n")
+	writeFile(t, filepath.Join(dir, "aggregate", "v1.tmpl"), "{{len .Files}} files")
+	r := NewRegistry(dir)
+
+	got, err := r.Render(FileSummary, "v3", FileVars{Filename: "x.py", Language: Language("x.py")})
+	if err != nil || got != "Python file x.py" {
+		t.Errorf("file-summary/v3 = %q, %v", got, err)
+	}
+	// aggregate/v1 is built in: cache entries and past runs name it, so
+	// a config file of that name must not change what it renders.
+	got, err = r.Render(Aggregate, "v1", AggregateVars{Files: make([]FileSummaryVar, 2)})
+	if err != nil || !strings.HasPrefix(got, "You are summarizing a Git repository.") {
+		t.Errorf("shadowed aggregate/v1 = %q, %v", got, err)
+	}
+	if _, err := r.Render(FileSummary, "v2", FileVars{}); err != nil {
+		t.Errorf("built-in versions stay reachable with a config dir: %v", err)
+	}
+}
+
+func TestRenderRejectsUnknownAndEscapingVersions(t *testing.T) {
+	r := NewRegistry(t.TempDir())
+	for _, v := range []string{"v99", "../aggregate/v1", ""} {
+		if _, err := r.Render(FileSummary, v, FileVars{}); err == nil {
+			t.Errorf("Render(%q) succeeded", v)
+		}
+	}
+}
+
+func TestSelect(t *testing.T) {
+	dir := t.TempDir()
+	r := NewRegistry(dir)
+	if got := r.Select(FileSummary, 1); got != "v1" {
+		t.Errorf("without selection.json Select = %q, want default v1", got)
+	}
+
+	writeFile(t, filepath.Join(dir, selectionFile), `{"file-summary": {"v1": 50, "v2": 50}}`)
+	seen := map[string]int{}
+	for id := uint(1); id <= 200; id++ {
+		v := r.Select(FileSummary, id)
+		if v != r.Select(FileSummary, id) {
+			t.Fatalf("Select is not stable for run %d", id)
+		}
+		seen[v]++
+	}
+	if seen["v1"] < 60 || seen["v2"] < 60 {
+		t.Errorf("50/50 split over 200 runs = %v", seen)
+	}
+	if got := r.Select(Aggregate, 1); got != Default(Aggregate) {
+		t.Errorf("unmentioned prompt Select = %q, want default %q", got, Default(Aggregate))
+	}
+
+	// A selection naming a version that doesn't exist is ignored as a
+	// whole rather than sending runs to a missing template.
+	writeFile(t, filepath.Join(dir, selectionFile), `{"file-summary": {"v9": 100}}`)
+	if got := r.Select(FileSummary, 1); got != "v1" {
+		t.Errorf("Select with unknown version = %q, want default", got)
+	}
+}
+
+func TestQuestionPrompt(t *testing.T) {
+	r := NewRegistry("")
+	got, version, err := r.QuestionPrompt(aiapp.QuestionPrompt{
+		SummaryID: 7,
+		Question:  "Where is auth handled?",
+		Overview:  "A web app.",
+		Sources:   []aiapp.Source{{Path: "internal/auth/", Text: "Login and sessions."}},
+		History:   []ai.Answer{{Question: "What is it?", Text: "A web app [main.go]."}},
+	})
+	if err != nil {
+		t.Fatalf("QuestionPrompt: %v", err)
+	}
+	if version != Default(Question) {
+		t.Errorf("version = %q, want %q", version, Default(Question))
+	}
+	for _, want := range []string{"[internal/auth/] Login and sessions.", "Q: What is it?\nA: A web app [main.go].", "QUESTION: Where is auth handled?"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("prompt lacks %q:\n%s", want, got)
+		}
+	}
+}
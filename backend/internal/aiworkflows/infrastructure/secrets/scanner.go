@@ -0,0 +1,166 @@
+// Package secrets is the aiworkflows context's SecretScanner adapter:
+// regular expressions for well-known credential formats, an entropy
+// test for values that merely look random, and a list of file names
+// that only ever hold secrets.
+package secrets
+
+import (
+	"math"
+	"path"
+	"regexp"
+	"strings"
+
+	aiapp "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/application"
+)
+
+// Version identifies the rule set. Bump it whenever a rule changes so
+// cached summaries of differently redacted content are not reused.
+const Version = "v1"
+
+// rule is one credential format. With a secret group, only that part
+// of the match is replaced — the key in `API_KEY=…` stays readable, so
+// the summary can still say the file configures an API key. keep, when
+// set, lets a match through, e.g. a placeholder value.
+type rule struct {
+	name   string
+	re     *regexp.Regexp
+	secret int // capture group holding the secret; 0 = the whole match
+	keep   func(secret string) bool
+}
+
+// secretKey matches the names of variables and fields that hold
+// credentials, e.g. DB_PASSWORD, apiKey, client_secret.
+const secretKey = `[A-Za-z0-9_.-]*(?i:secret|passw(?:or)?d|token|api_?key|private_?key|access_?key|credential)[A-Za-z0-9_.-]*`
+
+var rules = []rule{
+	{name: "private-key", re: regexp.MustCompile(`-----BEGIN[A-Z0-9 ]*PRIVATE KEY(?: BLOCK)?-----(?s:.*?)(?:-----END[A-Z0-9 ]*PRIVATE KEY(?: BLOCK)?-----|\z)`)},
+	{name: "aws-access-key", re: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{name: "github-token", re: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{name: "slack-token", re: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{name: "stripe-key", re: regexp.MustCompile(`\b(?:sk|rk)_(?:live|test)_[A-Za-z0-9]{16,}\b`)},
+	{name: "google-api-key", re: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{name: "llm-api-key", re: regexp.MustCompile(`\bsk-(?:ant-|proj-|or-v1-)?[A-Za-z0-9_-]{32,}`)},
+	{name: "jwt", re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`)},
+	// The password of user:password@host in a URL.
+	{name: "url-credentials", re: regexp.MustCompile(`\b[a-z][a-z0-9+.-]*://[^\s:/@"']+:([^\s@/"']+)@`), secret: 1, keep: reference},
+	// KEY=value lines of .env files and shell scripts, quoted or not.
+	{name: "env-assignment", re: regexp.MustCompile(`(?m)^[ \t]*(?:export[ \t]+)?` + secretKey + `[ \t]*=[ \t]*["']?([^\s"'#]+)`), secret: 1, keep: weak},
+	// String literals assigned to credential-named keys in code and
+	// config: password: "…", "apiKey": "…", token = '…'.
+	{name: "secret-assignment", re: regexp.MustCompile(secretKey + `["']?[ \t]*(?::=|[:=])[ \t]*["']([^"'\s]{8,})["']`), secret: 1, keep: weak},
+	// Anything else long and random enough to be a key: a quoted string
+	// of 32+ base64 characters with more entropy than hex can have, so
+	// hashes and commit IDs stay, and more than long identifiers do.
+	{name: "high-entropy-string", re: regexp.MustCompile(`["'\x60]([A-Za-z0-9+/=_-]{32,})["'\x60]`), secret: 1, keep: func(s string) bool {
+		return entropy(s) < 4.2
+	}},
+}
+
+const redactedPrefix = "[REDACTED:"
+
+// Scanner is the SecretScanner adapter. The zero value is ready to use.
+type Scanner struct{}
+
+var _ aiapp.SecretScanner = Scanner{}
+
+// Version implements aiapp.SecretScanner.
+func (Scanner) Version() string { return Version }
+
+// Redact implements aiapp.SecretScanner. Each secret becomes
+// "[REDACTED:<rule>]".
+func (Scanner) Redact(content string) (string, map[string]int) {
+	var counts map[string]int
+	for _, r := range rules {
+		matches := r.re.FindAllStringSubmatchIndex(content, -1)
+		if matches == nil {
+			continue
+		}
+		var b strings.Builder
+		last := 0
+		for _, m := range matches {
+			start, end := m[2*r.secret], m[2*r.secret+1]
+			// An earlier rule may have redacted it already.
+			if start < 0 || strings.Contains(content[start:end], redactedPrefix) {
+				continue
+			}
+			if r.keep != nil && r.keep(content[start:end]) {
+				continue
+			}
+			if counts == nil {
+				counts = make(map[string]int)
+			}
+			counts[r.name]++
+			b.WriteString(content[last:start])
+			b.WriteString(redactedPrefix + r.name + "]")
+			last = end
+		}
+		b.WriteString(content[last:])
+		content = b.String()
+	}
+	return content, counts
+}
+
+// SecretStore implements aiapp.SecretScanner. Templates such as
+// .env.example are documentation, not secret stores, and stay.
+func (Scanner) SecretStore(p string) bool {
+	name := strings.ToLower(path.Base(p))
+	if name == ".env" {
+		return true
+	}
+	if rest, ok := strings.CutPrefix(name, ".env."); ok {
+		return !envTemplates[rest]
+	}
+	if secretFiles[name] {
+		return true
+	}
+	return secretExts[path.Ext(name)]
+}
+
+var envTemplates = map[string]bool{
+	"example": true, "sample": true, "template": true, "dist": true, "defaults": true,
+}
+
+var secretFiles = map[string]bool{
+	"id_rsa": true, "id_dsa": true, "id_ecdsa": true, "id_ed25519": true,
+	".npmrc": true, ".pypirc": true, ".netrc": true, ".pgpass": true,
+	".htpasswd": true, ".git-credentials": true, ".dockercfg": true,
+	"credentials": true, "credentials.json": true, "secrets.json": true,
+	"secrets.yaml": true, "secrets.yml": true, "secrets.toml": true,
+}
+
+var secretExts = map[string]bool{
+	".pem": true, ".key": true, ".p12": true, ".pfx": true, ".jks": true,
+	".keystore": true, ".ppk": true, ".kdbx": true, ".tfvars": true,
+}
+
+// reference reports values that name a secret rather than hold it:
+// ${DB_PASSWORD}, $TOKEN, <your-key>, {{ .Values.secret }}, %PASSWORD%.
+func reference(v string) bool {
+	return strings.ContainsAny(v[:1], "$<{%")
+}
+
+// weak reports values that aren't worth redacting: references, and
+// placeholders like "changeme" or "xxxxxxxx" — short, or with too
+// little entropy to have been generated.
+func weak(v string) bool {
+	return reference(v) || len(v) < 6 || entropy(v) < 3.0
+}
+
+// entropy is the Shannon entropy of s in bits per character.
+func entropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	freq := make(map[rune]int)
+	n := 0
+	for _, r := range s {
+		freq[r]++
+		n++
+	}
+	var h float64
+	for _, c := range freq {
+		p := float64(c) / float64(n)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
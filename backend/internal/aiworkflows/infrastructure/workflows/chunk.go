@@ -0,0 +1,146 @@
+package workflows
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultChunkTokens is the per-chunk token budget when Deps.ChunkTokens
+// is unset: small enough for the prompt around it to fit any model we
+// route to, large enough that most source files stay a single chunk.
+const DefaultChunkTokens = 2000
+
+// bytesPerToken is the estimate behind estimateTokens. Real tokenizers
+// average about four bytes per token on code and English; multi-byte
+// text is overestimated, which errs towards smaller chunks.
+const bytesPerToken = 4
+
+// fileChunk is one part of a file: lines StartLine to EndLine, 1-based
+// and inclusive. A line too long for a chunk of its own is split, so
+// consecutive chunks can share a line number.
+type fileChunk struct {
+	Content   string
+	StartLine int
+	EndLine   int
+}
+
+// estimateTokens approximates how many tokens s costs in a prompt.
+func estimateTokens(s string) int {
+	return (len(s) + bytesPerToken - 1) / bytesPerToken
+}
+
+// splitChunks splits content into chunks of at most budget estimated
+// tokens. Content within the budget comes back as a single chunk.
+// Otherwise a chunk ends, in order of preference, before a top-level
+// declaration (an unindented line after a blank one), after a blank
+// line, or at the line that would overflow it; a preferred cut is only
+// taken if the chunk keeps at least half its budget, so cuts don't
+// degenerate into slivers. Lines longer than the budget are split
+// between runes. Concatenating the chunks yields content unchanged.
+func splitChunks(content string, budget int) []fileChunk {
+	if budget <= 0 {
+		budget = DefaultChunkTokens
+	}
+	lines := strings.SplitAfter(content, "\n")
+	if n := len(lines); n > 1 && lines[n-1] == "" {
+		lines = lines[:n-1]
+	}
+	if estimateTokens(content) <= budget {
+		return []fileChunk{{Content: content, StartLine: 1, EndLine: len(lines)}}
+	}
+
+	var chunks []fileChunk
+	flush := func(from, to int) {
+		chunks = append(chunks, fileChunk{
+			Content:   strings.Join(lines[from:to], ""),
+			StartLine: from + 1,
+			EndLine:   to,
+		})
+	}
+	start, tokens := 0, 0
+	// Candidate cuts in the current chunk: the line index the chunk
+	// would end before, and the chunk's tokens up to there.
+	declCut, declTokens := -1, 0
+	blankCut, blankTokens := -1, 0
+	for i, line := range lines {
+		t := estimateTokens(line)
+		if t > budget {
+			if i > start {
+				flush(start, i)
+			}
+			for _, piece := range splitRunes(line, budget*bytesPerToken) {
+				chunks = append(chunks, fileChunk{Content: piece, StartLine: i + 1, EndLine: i + 1})
+			}
+			start, tokens = i+1, 0
+			declCut, blankCut = -1, -1
+			continue
+		}
+		for tokens+t > budget && i > start {
+			end := i
+			switch {
+			case declCut > start && declTokens >= budget/2:
+				end = declCut
+			case blankCut > start && blankTokens >= budget/2:
+				end = blankCut
+			}
+			flush(start, end)
+			tokens -= tokenSum(lines[start:end])
+			start = end
+			declCut, blankCut = -1, -1
+		}
+		if i > start && strings.TrimSpace(lines[i-1]) == "" {
+			blankCut, blankTokens = i, tokens
+			if startsDeclaration(line) {
+				declCut, declTokens = i, tokens
+			}
+		}
+		tokens += t
+	}
+	if start < len(lines) {
+		flush(start, len(lines))
+	}
+	return chunks
+}
+
+// startsDeclaration reports whether line is unindented code rather than
+// a closing bracket or blank: the shape of a top-level func, type,
+// class or def (or of the comment above one) in most languages.
+func startsDeclaration(line string) bool {
+	if line == "" {
+		return false
+	}
+	switch line[0] {
+	case ' ', '\t', '\r', '\n', '}', ')', ']':
+		return false
+	}
+	return true
+}
+
+func tokenSum(lines []string) int {
+	n := 0
+	for _, l := range lines {
+		n += estimateTokens(l)
+	}
+	return n
+}
+
+// splitRunes cuts s into pieces of at most maxBytes without splitting a
+// UTF-8 sequence. Invalid UTF-8 is cut at maxBytes.
+func splitRunes(s string, maxBytes int) []string {
+	var pieces []string
+	for len(s) > maxBytes {
+		cut := maxBytes
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		if cut == 0 {
+			cut = maxBytes
+		}
+		pieces = append(pieces, s[:cut])
+		s = s[cut:]
+	}
+	if s != "" {
+		pieces = append(pieces, s)
+	}
+	return pieces
+}
@@ -0,0 +1,117 @@
+package workflows
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitChunksSmallFileIsOneChunk(t *testing.T) {
+	chunks := splitChunks("package a\n\nfunc A() {}\n", 100)
+	if len(chunks) != 1 || chunks[0].StartLine != 1 || chunks[0].EndLine != 3 {
+		t.Errorf("chunks = %+v", chunks)
+	}
+}
+
+func TestSplitChunksCutsAtDeclarations(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("package big\n")
+	for _, name := range []string{"A", "B", "C", "D"} {
+		b.WriteString("\n// " + name + " does things.\nfunc " + name + "() {\n")
+		for range 6 {
+			b.WriteString("\tdoSomethingUseful()\n")
+		}
+		b.WriteString("}\n")
+	}
+	content := b.String()
+
+	// Each function is ~50 tokens; a budget of 120 fits two.
+	chunks := splitChunks(content, 120)
+	if len(chunks) < 2 {
+		t.Fatalf("got %d chunks, want several", len(chunks))
+	}
+	var joined strings.Builder
+	next := 1
+	for i, c := range chunks {
+		joined.WriteString(c.Content)
+		if estimateTokens(c.Content) > 120 {
+			t.Errorf("chunk %d is %d tokens, over budget", i, estimateTokens(c.Content))
+		}
+		if c.StartLine != next {
+			t.Errorf("chunk %d starts at line %d, want %d", i, c.StartLine, next)
+		}
+		next = c.EndLine + 1
+		if i > 0 && !strings.HasPrefix(c.Content, "// ") {
+			t.Errorf("chunk %d doesn't start at a declaration: %q", i, c.Content[:min(len(c.Content), 30)])
+		}
+	}
+	if joined.String() != content {
+		t.Error("chunks don't reassemble the file")
+	}
+}
+
+func TestSplitChunksLongLinesAreRuneSafe(t *testing.T) {
+	line := strings.Repeat("äöü€", 100) // 1000 bytes, no newline
+	chunks := splitChunks(line, 50)
+	var joined strings.Builder
+	for _, c := range chunks {
+		if !utf8.ValidString(c.Content) {
+			t.Fatalf("chunk cut a rune: %q", c.Content)
+		}
+		if len(c.Content) > 50*bytesPerToken {
+			t.Errorf("chunk is %d bytes", len(c.Content))
+		}
+		joined.WriteString(c.Content)
+	}
+	if len(chunks) < 5 || joined.String() != line {
+		t.Errorf("got %d chunks, reassembled equal: %v", len(chunks), joined.String() == line)
+	}
+}
+
+func TestSummarizeChunksMapsThenReduces(t *testing.T) {
+	chunks := []fileChunk{
+		{Content: "func A() {}\n", StartLine: 1, EndLine: 40},
+		{Content: "func B() {}\n", StartLine: 41, EndLine: 90},
+	}
+	llm := &scriptedLLM{answers: []string{"Defines A.", "Defines B.", `{"summary":"Defines A and B.","keySymbols":["A","B"]}`}}
+	out, cached, err := (Deps{LLM: llm, Store: running()}).summarizeChunks(context.Background(), SummarizeFileInput{Filename: "big.go"}, chunks, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Summary != "Defines A and B." || out.Chunks != 2 || out.Insights == nil || len(out.Insights.KeySymbols) != 2 {
+		t.Errorf("out = %+v", out)
+	}
+	if out.Usage.PromptTokens != 30 || out.Usage.CompletionTokens != 15 {
+		t.Errorf("usage = %+v, want all three calls counted", out.Usage)
+	}
+	if !strings.Contains(llm.prompts[0], "part 1 of 2 (lines 1-40)") {
+		t.Errorf("chunk prompt = %q", llm.prompts[0])
+	}
+	if !strings.Contains(llm.prompts[2], "- Part 2 (lines 41-90): Defines B.") {
+		t.Errorf("reduce prompt = %q", llm.prompts[2])
+	}
+	if parsed, err := parseStructuredSummary(cached); err != nil || parsed.Summary != out.Summary {
+		t.Errorf("cache value = %q", cached)
+	}
+}
+
+func TestSummarizeChunksStopsWhenCancelled(t *testing.T) {
+	chunks := []fileChunk{
+		{Content: "func A() {}\n", StartLine: 1, EndLine: 40},
+		{Content: "func B() {}\n", StartLine: 41, EndLine: 90},
+	}
+	for name, runningChecks := range map[string]int{"between chunks": 1, "before reduce": 2} {
+		t.Run(name, func(t *testing.T) {
+			llm := &scriptedLLM{answers: []string{"Defines A.", "Defines B.", "Defines A and B."}}
+			d := Deps{LLM: llm, Store: &statusStore{runningChecks: runningChecks}}
+			_, _, err := d.summarizeChunks(context.Background(), SummarizeFileInput{SummaryID: 1, Filename: "big.go"}, chunks, false)
+			if !IsPermanent(err) {
+				t.Fatalf("err = %v, want the run's cancellation", err)
+			}
+			if len(llm.prompts) != runningChecks {
+				t.Errorf("made %d LLM calls, want %d", len(llm.prompts), runningChecks)
+			}
+		})
+	}
+}
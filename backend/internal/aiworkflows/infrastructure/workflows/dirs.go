@@ -0,0 +1,190 @@
+package workflows
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hatchet-dev/hatchet/pkg/worker"
+
+	aiapp "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/application"
+	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
+	"github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/infrastructure/prompts"
+	shared "github.com/atilladeniz/next-go-pg/backend/internal/shared/domain"
+)
+
+// maxDirConcurrency bounds the directory prompts in flight at once.
+// Directories on the same level of the tree are independent; the fan-out
+// is small (a run has at most MaxFiles files), so a fixed cap is enough.
+const maxDirConcurrency = 4
+
+// SummarizeDirsStep builds the run's architecture outline: the
+// directories of the summarized files, each summarized from its files'
+// and subdirectories' summaries, deepest first. The outline and the
+// usage it cost are saved on the aggregate; the aggregate step reads
+// the top level back from the output.
+func (d Deps) SummarizeDirsStep(ctx context.Context, in WorkflowInput, traverse TraverseOutput, summaries SummarizeFilesOutput) (out DirectoriesOutput, err error) {
+	start := time.Now()
+	d.publishStep(ctx, in, aiapp.StepSummarizeDirs, aiapp.StepStateStarted, 0, "")
+	defer func() {
+		state := aiapp.StepStateCompleted
+		reason := ""
+		if err != nil {
+			state = aiapp.StepStateFailed
+			reason = err.Error()
+		}
+		d.publishStep(ctx, in, aiapp.StepSummarizeDirs, state, time.Since(start).Milliseconds(), reason)
+	}()
+	defer d.cleanupOnCancel(in.SummaryID, traverse.Path, &err)
+
+	if err = d.checkCancelled(ctx, in.SummaryID); err != nil {
+		return DirectoriesOutput{}, err
+	}
+	agg, err := d.Store.GetByID(ctx, in.SummaryID)
+	if err != nil {
+		return DirectoriesOutput{}, fmt.Errorf("load aggregate: %w", err)
+	}
+
+	texts := make(map[string]string, len(summaries.Summaries))
+	files := make([]string, 0, len(summaries.Summaries))
+	for _, s := range summaries.Summaries {
+		texts[s.Filename] = s.Summary
+		files = append(files, s.Filename)
+	}
+	tree := ai.NewDirectoryTree(files)
+	total := tree.Count()
+	var done atomic.Int32
+	onDone := func(dir string) {
+		d.Progress.PublishStep(ctx, aiapp.StepProgress{
+			SummaryID: in.SummaryID,
+			UserID:    shared.UserID(in.UserID),
+			Step:      aiapp.StepSummarizeDirs,
+			State:     aiapp.StepStateProgress,
+			FileIndex: int(done.Add(1)),
+			FileCount: total,
+			Filename:  dir + "/",
+		})
+	}
+	usage, err := d.summarizeTree(ctx, in.SummaryID, &tree, texts, promptVersion(agg, prompts.Directory), onDone)
+	if err != nil {
+		return DirectoriesOutput{}, err
+	}
+
+	// The tokens were spent either way; the outline only matters to a
+	// run that is still going.
+	_ = d.Store.AddUsage(ctx, in.SummaryID, usage)
+	if err = d.checkCancelled(ctx, in.SummaryID); err != nil {
+		return DirectoriesOutput{}, err
+	}
+	if err = d.Store.RecordDirectories(ctx, in.SummaryID, tree); err != nil {
+		return DirectoriesOutput{}, fmt.Errorf("save directories: %w", err)
+	}
+	return DirectoriesOutput{Root: directoryFrom(tree)}, nil
+}
+
+// summarizeTree fills in the Summary of every directory below root,
+// one tree level at a time from the deepest up, so a directory's
+// children are done before it is. texts maps file path to its summary.
+// A directory with a single summarized entry takes that entry's summary
+// as is; one with none stays empty. onDone is called per directory. A
+// run cancelled meanwhile stops before its next directory prompt.
+func (d Deps) summarizeTree(ctx context.Context, summaryID uint, root *ai.DirectorySummary, texts map[string]string, version string, onDone func(dir string)) (ai.TokenUsage, error) {
+	var (
+		mu    sync.Mutex
+		usage ai.TokenUsage
+	)
+	levels := treeLevels(root)
+	for i := len(levels) - 1; i >= 0; i-- {
+		level := levels[i]
+		errs := make([]error, len(level))
+		sem := make(chan struct{}, maxDirConcurrency)
+		var wg sync.WaitGroup
+		for j, dir := range level {
+			wg.Add(1)
+			go func(j int, dir *ai.DirectorySummary) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				u, err := d.summarizeDir(ctx, summaryID, dir, texts, version)
+				if err != nil {
+					errs[j] = err
+					return
+				}
+				mu.Lock()
+				usage = usage.Add(u)
+				mu.Unlock()
+				onDone(dir.Path)
+			}(j, dir)
+		}
+		wg.Wait()
+		for _, err := range errs {
+			if err != nil {
+				return ai.TokenUsage{}, err
+			}
+		}
+	}
+	return usage, nil
+}
+
+// summarizeDir writes dir's Summary from its entries'.
+func (d Deps) summarizeDir(ctx context.Context, summaryID uint, dir *ai.DirectorySummary, texts map[string]string, version string) (ai.TokenUsage, error) {
+	vars := prompts.DirectoryVars{Path: dir.Path}
+	for _, f := range dir.Files {
+		if s := texts[f]; s != "" {
+			vars.Files = append(vars.Files, prompts.FileSummaryVar{Filename: strings.TrimPrefix(f, dir.Path+"/"), Summary: s})
+		}
+	}
+	for _, c := range dir.Children {
+		if c.Summary != "" {
+			vars.Directories = append(vars.Directories, prompts.FileSummaryVar{Filename: strings.TrimPrefix(c.Path, dir.Path+"/"), Summary: c.Summary})
+		}
+	}
+	switch len(vars.Files) + len(vars.Directories) {
+	case 0:
+		return ai.TokenUsage{}, nil
+	case 1:
+		// Nothing to combine; a rephrasing would only cost tokens.
+		if len(vars.Files) == 1 {
+			dir.Summary = vars.Files[0].Summary
+		} else {
+			dir.Summary = vars.Directories[0].Summary
+		}
+		return ai.TokenUsage{}, nil
+	}
+	if err := d.checkCancelled(ctx, summaryID); err != nil {
+		return ai.TokenUsage{}, err
+	}
+	prompt, err := d.prompts().Render(prompts.Directory, version, vars)
+	if err != nil {
+		return ai.TokenUsage{}, worker.NewNonRetryableError(err)
+	}
+	completion, err := d.generate(ctx, prompt)
+	if err != nil {
+		return ai.TokenUsage{}, fmt.Errorf("llm generate (directory %s): %w", dir.Path, err)
+	}
+	dir.Summary = strings.TrimSpace(completion.Text)
+	return completion.Usage, nil
+}
+
+// treeLevels groups the directories below root by depth: index 0 holds
+// root's children, index 1 their children, and so on.
+func treeLevels(root *ai.DirectorySummary) [][]*ai.DirectorySummary {
+	var levels [][]*ai.DirectorySummary
+	current := []*ai.DirectorySummary{root}
+	for {
+		var next []*ai.DirectorySummary
+		for _, dir := range current {
+			for i := range dir.Children {
+				next = append(next, &dir.Children[i])
+			}
+		}
+		if len(next) == 0 {
+			return levels
+		}
+		levels = append(levels, next)
+		current = next
+	}
+}
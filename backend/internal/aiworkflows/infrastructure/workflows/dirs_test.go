@@ -0,0 +1,110 @@
+package workflows
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	aiapp "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/application"
+	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
+)
+
+// dirLLM answers directory prompts with the directory's name and
+// records the prompts. Safe for the concurrent calls of one tree level.
+type dirLLM struct {
+	mu      sync.Mutex
+	prompts []string
+}
+
+func (l *dirLLM) Generate(_ context.Context, prompt string) (aiapp.Completion, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prompts = append(l.prompts, prompt)
+	dir := strings.SplitN(strings.SplitN(prompt, "the directory ", 2)[1], "/ contains", 2)[0]
+	return aiapp.Completion{Text: " About " + dir + ". ", Usage: ai.TokenUsage{PromptTokens: 10, CompletionTokens: 5}}, nil
+}
+
+func (l *dirLLM) Stream(ctx context.Context, prompt string, onChunk func(string)) (aiapp.Completion, error) {
+	return l.Generate(ctx, prompt)
+}
+
+func TestSummarizeTreeBottomUp(t *testing.T) {
+	texts := map[string]string{
+		"README.md":                   "Project readme.",
+		"backend/go.mod":              "Go module.",
+		"backend/api/handler.go":      "HTTP handlers.",
+		"backend/api/routes.go":       "Route table.",
+		"backend/store/store.go":      "Database access.",
+		"frontend/src/page.tsx":       "Landing page.",
+		"backend/store/migrations.go": "",
+	}
+	files := make([]string, 0, len(texts))
+	for f := range texts {
+		files = append(files, f)
+	}
+	tree := ai.NewDirectoryTree(files)
+	llm := &dirLLM{}
+	var (
+		mu   sync.Mutex
+		done []string
+	)
+	onDone := func(dir string) {
+		mu.Lock()
+		defer mu.Unlock()
+		done = append(done, dir)
+	}
+	usage, err := (Deps{LLM: llm, Store: running()}).summarizeTree(context.Background(), 1, &tree, texts, "v1", onDone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// backend/api has two files and backend three entries: two calls.
+	// backend/store (one non-empty file) and frontend/src (one file)
+	// copy their only entry.
+	if len(llm.prompts) != 2 || usage.PromptTokens != 20 {
+		t.Fatalf("made %d calls, usage %+v; want 2 calls", len(llm.prompts), usage)
+	}
+	backend := tree.Children[0]
+	if backend.Summary != "About backend." || backend.Children[0].Summary != "About backend/api." {
+		t.Errorf("backend = %q, api = %q", backend.Summary, backend.Children[0].Summary)
+	}
+	if got := backend.Children[1].Summary; got != "Database access." {
+		t.Errorf("backend/store = %q, want its only file's summary", got)
+	}
+	if got := tree.Children[1].Summary; got != "Landing page." {
+		t.Errorf("frontend/src = %q", got)
+	}
+	// The backend prompt sees its children's summaries, named relative
+	// to it, and its own file.
+	last := llm.prompts[1]
+	for _, want := range []string{"- api/: About backend/api.", "- store/: Database access.", "- go.mod: Go module."} {
+		if !strings.Contains(last, want) {
+			t.Errorf("backend prompt lacks %q:\n%s", want, last)
+		}
+	}
+	if len(done) != 4 || slices.Index(done, "backend") < slices.Index(done, "backend/api") {
+		t.Errorf("progress = %q, want every directory, backend after backend/api", done)
+	}
+}
+
+func TestSummarizeTreeStopsWhenCancelled(t *testing.T) {
+	texts := map[string]string{
+		"backend/go.mod":         "Go module.",
+		"backend/api/handler.go": "HTTP handlers.",
+		"backend/api/routes.go":  "Route table.",
+	}
+	tree := ai.NewDirectoryTree([]string{"backend/go.mod", "backend/api/handler.go", "backend/api/routes.go"})
+	llm := &dirLLM{}
+	// backend/api is summarized, then the run is cancelled before the
+	// backend prompt.
+	d := Deps{LLM: llm, Store: &statusStore{runningChecks: 1}}
+	_, err := d.summarizeTree(context.Background(), 1, &tree, texts, "v1", func(string) {})
+	if !IsPermanent(err) {
+		t.Fatalf("err = %v, want the run's cancellation", err)
+	}
+	if len(llm.prompts) != 1 {
+		t.Errorf("made %d LLM calls after the cancel, want 1 before it", len(llm.prompts))
+	}
+}
@@ -2,15 +2,18 @@ package workflows
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/google/uuid"
+	"github.com/hatchet-dev/hatchet/pkg/client/rest"
 	hatchet "github.com/hatchet-dev/hatchet/sdks/go"
 
 	aiapp "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/application"
 )
 
 // Enqueuer is the HatchetEnqueuer adapter. It hides the Hatchet client
-// from the application layer; swapping engines means only this file
-// (plus workflow.go and steps.go) changes.
+// from the application layer; the River engine in the jobs package is
+// the other implementation of the same port.
 type Enqueuer struct {
 	Client *hatchet.Client
 }
@@ -24,16 +27,28 @@ func NewEnqueuer(client *hatchet.Client) *Enqueuer {
 // Returns the Hatchet run ID so the HTTP layer can echo it to the
 // client (the frontend uses it as a correlation key for SSE events).
 func (e *Enqueuer) EnqueueSummarizeRepo(ctx context.Context, in aiapp.EnqueueSummarizeRepoInput) (string, error) {
-	ref, err := e.Client.RunNoWait(ctx, WorkflowName, WorkflowInput{
-		SummaryID: in.SummaryID,
-		UserID:    in.UserID.String(),
-		RepoURL:   in.RepoURL.String(),
-	})
+	ref, err := e.Client.RunNoWait(ctx, WorkflowName, NewWorkflowInput(in))
 	if err != nil {
 		return "", err
 	}
 	return ref.RunId, nil
 }
 
+// CancelRun cancels the tasks of a `summarize-repo` run that have not
+// finished yet; finished tasks are left alone, so cancelling a
+// completed run is a no-op. Child `summarize-file` tasks also check
+// the aggregate's status before calling the LLM, so they stop even if
+// the engine does not cascade the cancel to them.
+func (e *Enqueuer) CancelRun(ctx context.Context, runID string) error {
+	id, err := uuid.Parse(runID)
+	if err != nil {
+		return fmt.Errorf("invalid run id %q: %w", runID, err)
+	}
+	_, err = e.Client.Runs().Cancel(ctx, rest.V1CancelTaskRequest{
+		ExternalIds: &[]uuid.UUID{id},
+	})
+	return err
+}
+
 // Static port-conformance check.
 var _ aiapp.HatchetEnqueuer = (*Enqueuer)(nil)
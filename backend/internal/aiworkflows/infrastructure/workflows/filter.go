@@ -0,0 +1,188 @@
+package workflows
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+
+	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
+	aigit "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/infrastructure/git"
+)
+
+// summaryIgnoreFile is read from the repository root; its rules use
+// .gitignore syntax and are applied on top of the run's FileFilter.
+const summaryIgnoreFile = ".summaryignore"
+
+// Bounds on .summaryignore: it comes from the repository, which is
+// untrusted input.
+const (
+	maxIgnoreFileBytes = 64 * 1024
+	maxIgnoreRules     = 500
+)
+
+// errBadSubdir is returned when the filter's subdirectory doesn't exist
+// in the working copy or leads outside it. Retrying can't fix either.
+var errBadSubdir = errors.New("subdirectory not found in repository")
+
+// errOutsideCheckout is returned for a file to summarize that resolves
+// outside the working copy, or to something other than a regular file.
+// Retrying can't fix it.
+var errOutsideCheckout = errors.New("file is not a regular file in the repository")
+
+// pathFilter applies a run's FileFilter and the repository's
+// .summaryignore to repo-relative, slash-separated paths.
+type pathFilter struct {
+	include []gitignore.Pattern
+	exclude []gitignore.Pattern
+	ignore  gitignore.Matcher // nil without a .summaryignore
+}
+
+// newPathFilter compiles f and reads root's .summaryignore. It also
+// returns the .summaryignore rules so the run can show them.
+func newPathFilter(root string, f ai.FileFilter) (*pathFilter, []string, error) {
+	pf := &pathFilter{}
+	for _, p := range f.Include {
+		pf.include = append(pf.include, gitignore.ParsePattern(p, nil))
+	}
+	for _, p := range f.Exclude {
+		pf.exclude = append(pf.exclude, gitignore.ParsePattern(p, nil))
+	}
+	rules, err := readIgnoreFile(filepath.Join(root, summaryIgnoreFile))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rules) > 0 {
+		patterns := make([]gitignore.Pattern, len(rules))
+		for i, r := range rules {
+			patterns[i] = gitignore.ParsePattern(r, nil)
+		}
+		pf.ignore = gitignore.NewMatcher(patterns)
+	}
+	return pf, rules, nil
+}
+
+// readIgnoreFile returns the rules of a .gitignore-style file, without
+// comments and blank lines. A missing file has no rules; a file that
+// isn't a regular file (a symlink out of the clone, say) is ignored.
+func readIgnoreFile(name string) ([]string, error) {
+	info, err := os.Lstat(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", summaryIgnoreFile, err)
+	}
+	if !info.Mode().IsRegular() {
+		return nil, nil
+	}
+	if info.Size() > maxIgnoreFileBytes {
+		return nil, fmt.Errorf("%s is larger than %d bytes", summaryIgnoreFile, maxIgnoreFileBytes)
+	}
+	raw, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", summaryIgnoreFile, err)
+	}
+	var rules []string
+	sc := bufio.NewScanner(bytes.NewReader(raw))
+	for sc.Scan() {
+		line := strings.TrimSuffix(sc.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if len(rules) == maxIgnoreRules {
+			return nil, fmt.Errorf("%s has more than %d rules", summaryIgnoreFile, maxIgnoreRules)
+		}
+		rules = append(rules, line)
+	}
+	return rules, nil
+}
+
+// restrictsExtensions reports whether Include replaces the built-in
+// extension list.
+func (f *pathFilter) restrictsExtensions() bool { return len(f.include) > 0 }
+
+// skipDir reports whether the walk should not descend into rel.
+func (f *pathFilter) skipDir(rel string) bool {
+	return f.excluded(strings.Split(rel, "/"), true)
+}
+
+// keepFile reports whether rel passes the filter. Include is only
+// consulted when set; otherwise the caller's extension rule applies.
+func (f *pathFilter) keepFile(rel string) bool {
+	segs := strings.Split(rel, "/")
+	if f.excluded(segs, false) {
+		return false
+	}
+	return !f.restrictsExtensions() || matchAny(f.include, segs, false)
+}
+
+func (f *pathFilter) excluded(segs []string, isDir bool) bool {
+	if matchAny(f.exclude, segs, isDir) {
+		return true
+	}
+	return f.ignore != nil && f.ignore.Match(segs, isDir)
+}
+
+func matchAny(patterns []gitignore.Pattern, segs []string, isDir bool) bool {
+	for _, p := range patterns {
+		if p.Match(segs, isDir) == gitignore.Exclude {
+			return true
+		}
+	}
+	return false
+}
+
+// scanBase resolves the directory the walk starts from: root, or its
+// subdir. The subdir must exist and, after following symlinks, stay
+// inside root.
+func scanBase(root, subdir string) (string, error) {
+	if subdir == "" {
+		return root, nil
+	}
+	base := filepath.Join(root, filepath.FromSlash(subdir))
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", err
+	}
+	realBase, err := filepath.EvalSymlinks(base)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", errBadSubdir, subdir)
+	}
+	if !aigit.Within(realRoot, realBase) {
+		return "", fmt.Errorf("%w: %s", errBadSubdir, subdir)
+	}
+	if info, err := os.Stat(realBase); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("%w: %s", errBadSubdir, subdir)
+	}
+	return realBase, nil
+}
+
+// readRepoFile reads the repo-relative file name from the working copy
+// at root. The walk only lists regular files and the cloner drops
+// symlinks that lead out of the checkout, but the content is what
+// reaches the LLM, so a path that — through a symlinked directory, say
+// — resolves outside root is refused here too, with errOutsideCheckout.
+func readRepoFile(root, name string) ([]byte, error) {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Join(root, filepath.FromSlash(name)))
+	if err != nil {
+		return nil, err
+	}
+	if !aigit.Within(realRoot, resolved) {
+		return nil, fmt.Errorf("%w: %s", errOutsideCheckout, name)
+	}
+	if info, err := os.Stat(resolved); err != nil || !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("%w: %s", errOutsideCheckout, name)
+	}
+	return os.ReadFile(resolved)
+}
@@ -0,0 +1,511 @@
+package workflows
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
+)
+
+// File selection strategies, recorded on the run.
+const (
+	// SelectionRanked scores every candidate on what makes a file worth
+	// reading first (README, manifest, entry point, import in-degree,
+	// recent change) and picks greedily while spreading the picks over
+	// the top-level directories. The default.
+	SelectionRanked = "ranked"
+	// SelectionAlphabetical is the original behaviour: the first
+	// MaxFiles candidates in lexical order.
+	SelectionAlphabetical = "alphabetical"
+)
+
+var (
+	includeExt = map[string]struct{}{
+		".go": {}, ".ts": {}, ".tsx": {}, ".js": {}, ".jsx": {},
+		".py": {}, ".rs": {}, ".java": {}, ".rb": {}, ".sql": {},
+		".md": {}, ".yaml": {}, ".yml": {}, ".toml": {},
+	}
+	// sourceExt are the extensions that hold code rather than docs or
+	// config; they get a small head start over the rest.
+	sourceExt = map[string]struct{}{
+		".go": {}, ".ts": {}, ".tsx": {}, ".js": {}, ".jsx": {},
+		".py": {}, ".rs": {}, ".java": {}, ".rb": {},
+	}
+	skipDir = map[string]struct{}{
+		".git": {}, "node_modules": {}, "vendor": {}, "dist": {},
+		"build": {}, ".next": {}, "target": {}, "__pycache__": {},
+	}
+	// manifests are selected whatever their extension. Keys are lower
+	// case.
+	manifests = map[string]struct{}{
+		"go.mod": {}, "package.json": {}, "cargo.toml": {}, "pyproject.toml": {},
+		"requirements.txt": {}, "setup.py": {}, "pom.xml": {}, "build.gradle": {},
+		"gemfile": {}, "composer.json": {}, "dockerfile": {}, "makefile": {},
+	}
+	entryPoints = map[string]struct{}{
+		"main.go": {}, "main.py": {}, "__main__.py": {}, "app.py": {},
+		"main.rs": {}, "lib.rs": {}, "index.ts": {}, "index.tsx": {},
+		"index.js": {}, "index.jsx": {}, "main.ts": {}, "main.js": {},
+		"server.ts": {}, "server.js": {}, "app.ts": {}, "app.tsx": {},
+	}
+	// noise is generated or machine-maintained content: never worth a
+	// slot while anything else is left.
+	noise = regexp.MustCompile(`(\.pb\.go|_gen\.go|\.gen\.ts|\.min\.js|zz_generated[^/]*|(^|/)pnpm-lock\.yaml)$`)
+	tests = regexp.MustCompile(`(_test\.go|\.(test|spec)\.[jt]sx?|(^|/)test_[^/]*\.py)$|(^|/)(tests?|__tests__|testdata)/`)
+)
+
+// Ranking weights. Only their order of magnitude matters: a root README
+// or manifest beats any plain source file, import in-degree and
+// recency separate source files from each other.
+const (
+	weightRootReadme   = 10
+	weightReadme       = 3
+	weightRootManifest = 8
+	weightManifest     = 4
+	weightEntryPoint   = 6
+	weightCmd          = 4
+	weightSource       = 1
+	maxWeightImports   = 6
+	maxWeightRecent    = 3
+	penaltyTest        = 3
+	penaltyCI          = 4
+	penaltyNoise       = 8
+	penaltyDepth       = 0.25
+	// penaltySpread is subtracted per file already picked from the same
+	// top-level directory, so one big directory can't take every slot.
+	penaltySpread = 2
+	// maxImportScan bounds how many files are read to count imports.
+	maxImportScan = 5000
+)
+
+// selectOptions configure selectFiles. Recent is the RepoHistory
+// signal (repo-relative paths) and may be nil.
+type selectOptions struct {
+	Strategy string
+	MaxFiles int
+	// MaxBytes is the per-file size ceiling; larger files are listed in
+	// FileSelection.SkippedTooLarge. 0 means no ceiling.
+	MaxBytes int64
+	Recent   map[string]time.Time
+	Filter   ai.FileFilter
+	// SecretStore, when set, reports files that hold secrets; they are
+	// never selected and are listed in FileSelection.SkippedSecrets.
+	SecretStore func(path string) bool
+}
+
+// selectFiles walks the cloned repo and returns up to MaxFiles paths to
+// summarize, with the reason each was picked. Filters by extension (plus
+// well-known manifests) and skips obvious noise (.git, vendored
+// node_modules, build output), then applies the run's FileFilter and
+// the repo's .summaryignore and leaves out secret stores. With a
+// Subdir, only that directory is walked and ranked as if it were the
+// root; returned paths stay repo-relative. Deterministic: the same
+// repo state and history yield the same selection across reruns.
+func selectFiles(root string, opts selectOptions) (ai.FileSelection, error) {
+	maxFiles := opts.MaxFiles
+	if maxFiles <= 0 {
+		maxFiles = 25
+	}
+	filter, rules, err := newPathFilter(root, opts.Filter)
+	if err != nil {
+		return ai.FileSelection{}, err
+	}
+	base, err := scanBase(root, opts.Filter.Subdir)
+	if err != nil {
+		return ai.FileSelection{}, err
+	}
+	prefix := ""
+	if opts.Filter.Subdir != "" {
+		prefix = opts.Filter.Subdir + "/"
+	}
+	candidates, tooLarge, err := walkCandidates(base, prefix, opts.MaxBytes, filter)
+	if err != nil {
+		return ai.FileSelection{}, err
+	}
+	sel := ai.FileSelection{Strategy: opts.Strategy, IgnorePatterns: rules}
+	for _, c := range tooLarge {
+		sel.SkippedTooLarge = append(sel.SkippedTooLarge, prefix+c)
+	}
+	if opts.SecretStore != nil {
+		candidates = slices.DeleteFunc(candidates, func(c string) bool {
+			if opts.SecretStore(prefix + c) {
+				sel.SkippedSecrets = append(sel.SkippedSecrets, prefix+c)
+				return true
+			}
+			return false
+		})
+	}
+	if opts.Strategy == SelectionAlphabetical {
+		if len(candidates) > maxFiles {
+			candidates = candidates[:maxFiles]
+		}
+		for _, c := range candidates {
+			sel.Files = append(sel.Files, ai.SelectedFile{Path: prefix + c, Reason: "alphabetical order"})
+		}
+		return sel, nil
+	}
+	sel.Strategy = SelectionRanked
+	recent := opts.Recent
+	if prefix != "" {
+		recent = make(map[string]time.Time)
+		for p, t := range opts.Recent {
+			if rel, ok := strings.CutPrefix(p, prefix); ok {
+				recent[rel] = t
+			}
+		}
+	}
+	for _, f := range pickSpread(rankFiles(base, candidates, recent), maxFiles) {
+		f.Path = prefix + f.Path
+		sel.Files = append(sel.Files, f)
+	}
+	return sel, nil
+}
+
+// walkCandidates lists the selectable files under base, slash-separated,
+// relative to base and in lexical order, and apart from them those that
+// would have been selectable but for being over maxBytes. prefix turns
+// such a path into a repo-relative one for the filter.
+func walkCandidates(base, prefix string, maxBytes int64, filter *pathFilter) (picked, tooLarge []string, err error) {
+	err = filepath.Walk(base, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(base, p)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if info.IsDir() {
+			if rel == "." {
+				return nil
+			}
+			if _, skip := skipDir[info.Name()]; skip || filter.skipDir(prefix+rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		if !filter.restrictsExtensions() {
+			_, manifest := manifests[strings.ToLower(info.Name())]
+			if _, ok := includeExt[strings.ToLower(filepath.Ext(p))]; !ok && !manifest {
+				return nil
+			}
+		}
+		if !filter.keepFile(prefix + rel) {
+			return nil
+		}
+		if maxBytes > 0 && info.Size() > maxBytes {
+			tooLarge = append(tooLarge, rel)
+			return nil
+		}
+		picked = append(picked, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Strings(picked)
+	return picked, tooLarge, nil
+}
+
+type rankedFile struct {
+	path    string
+	top     string // top-level directory, "" for files in the root
+	score   float64
+	reasons []string
+}
+
+// rankFiles scores every candidate. The result is sorted best first,
+// ties broken by path.
+func rankFiles(root string, paths []string, recent map[string]time.Time) []rankedFile {
+	inDegree := importInDegree(root, paths)
+	oldest, newest := timeRange(recent, paths)
+
+	out := make([]rankedFile, 0, len(paths))
+	for _, p := range paths {
+		f := rankedFile{path: p}
+		if i := strings.IndexByte(p, '/'); i >= 0 {
+			f.top = p[:i]
+		}
+		f.score, f.reasons = pathScore(p)
+		if n := inDegree[p]; n > 0 {
+			f.score += math.Min(1.5*math.Log2(1+float64(n)), maxWeightImports)
+			f.reasons = append(f.reasons, fmt.Sprintf("imported by %d %s", n, plural(n, "file", "files")))
+		}
+		if when, ok := recent[p]; ok {
+			w := float64(maxWeightRecent)
+			if span := newest.Sub(oldest); span > 0 {
+				w = 1 + float64(maxWeightRecent-1)*float64(when.Sub(oldest))/float64(span)
+			}
+			f.score += w
+			f.reasons = append(f.reasons, "changed "+when.UTC().Format("2006-01-02"))
+		}
+		out = append(out, f)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].score != out[j].score {
+			return out[i].score > out[j].score
+		}
+		return out[i].path < out[j].path
+	})
+	return out
+}
+
+// pathScore is the part of the score that follows from the path alone.
+func pathScore(p string) (float64, []string) {
+	base := strings.ToLower(path.Base(p))
+	depth := strings.Count(p, "/")
+	var score float64
+	var reasons []string
+	switch _, manifest := manifests[base]; {
+	case strings.HasPrefix(base, "readme"):
+		if depth == 0 {
+			score, reasons = weightRootReadme, append(reasons, "top-level README")
+		} else {
+			score, reasons = weightReadme, append(reasons, "README")
+		}
+	case manifest:
+		if depth == 0 {
+			score, reasons = weightRootManifest, append(reasons, "top-level manifest")
+		} else {
+			score, reasons = weightManifest, append(reasons, "manifest")
+		}
+	}
+	if _, ok := entryPoints[base]; ok {
+		score += weightEntryPoint
+		reasons = append(reasons, "entry point")
+	} else if strings.HasPrefix(p, "cmd/") || strings.Contains(p, "/cmd/") {
+		score += weightCmd
+		reasons = append(reasons, "under cmd/")
+	}
+	if _, ok := sourceExt[path.Ext(base)]; ok {
+		score += weightSource
+	}
+	if tests.MatchString(p) {
+		score -= penaltyTest
+	}
+	if strings.HasPrefix(p, ".github/") {
+		score -= penaltyCI
+	}
+	if noise.MatchString(p) {
+		score -= penaltyNoise
+	}
+	score -= penaltyDepth * float64(depth)
+	return score, reasons
+}
+
+// pickSpread takes files best first, docking penaltySpread from every
+// remaining file for each pick already made from its top-level
+// directory. Files in the root are the repo's front door and never
+// penalised.
+func pickSpread(ranked []rankedFile, maxFiles int) []ai.SelectedFile {
+	taken := make([]bool, len(ranked))
+	perTop := make(map[string]int)
+	var out []ai.SelectedFile
+	for len(out) < maxFiles && len(out) < len(ranked) {
+		best := -1
+		var bestScore float64
+		for i, f := range ranked {
+			if taken[i] {
+				continue
+			}
+			s := f.score
+			if f.top != "" {
+				s -= penaltySpread * float64(perTop[f.top])
+			}
+			if best < 0 || s > bestScore {
+				best, bestScore = i, s
+			}
+		}
+		f := ranked[best]
+		taken[best] = true
+		reason := strings.Join(f.reasons, "; ")
+		if reason == "" {
+			if perTop[f.top] == 0 && f.top != "" {
+				reason = "covers " + f.top + "/"
+			} else {
+				reason = "source file"
+			}
+		}
+		perTop[f.top]++
+		out = append(out, ai.SelectedFile{Path: f.path, Reason: reason})
+	}
+	return out
+}
+
+func timeRange(recent map[string]time.Time, paths []string) (oldest, newest time.Time) {
+	for _, p := range paths {
+		t, ok := recent[p]
+		if !ok {
+			continue
+		}
+		if oldest.IsZero() || t.Before(oldest) {
+			oldest = t
+		}
+		if t.After(newest) {
+			newest = t
+		}
+	}
+	return oldest, newest
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+var (
+	jsImport = regexp.MustCompile(`(?:\bfrom|\bimport|\brequire\()\s*['"](\.{1,2}/[^'"]+)['"]`)
+	pyImport = regexp.MustCompile(`(?m)^\s*(?:from\s+(\.*[\w.]*)\s+import|import\s+([\w.]+))`)
+	jsExts   = []string{"", ".ts", ".tsx", ".js", ".jsx", "/index.ts", "/index.tsx", "/index.js", "/index.jsx"}
+)
+
+// importInDegree counts, per candidate, how many other candidates import
+// it. It understands Go packages (via go.mod module paths), relative
+// JS/TS imports and Python modules; everything else has in-degree 0.
+// A Go import credits every file of the imported package.
+func importInDegree(root string, paths []string) map[string]int {
+	known := make(map[string]bool, len(paths))
+	goDirs := make(map[string][]string)
+	modules := make(map[string]string) // module path → directory
+	for _, p := range paths {
+		known[p] = true
+		if strings.HasSuffix(p, ".go") {
+			goDirs[path.Dir(p)] = append(goDirs[path.Dir(p)], p)
+		}
+		if path.Base(p) == "go.mod" {
+			if mod := modulePath(filepath.Join(root, filepath.FromSlash(p))); mod != "" {
+				modules[mod] = path.Dir(p)
+			}
+		}
+	}
+
+	in := make(map[string]int)
+	scanned := 0
+	for _, p := range paths {
+		ext := path.Ext(p)
+		if _, ok := sourceExt[ext]; !ok {
+			continue
+		}
+		if scanned++; scanned > maxImportScan {
+			break
+		}
+		src, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(p)))
+		if err != nil {
+			continue
+		}
+		targets := make(map[string]bool)
+		switch ext {
+		case ".go":
+			for _, dir := range goImportDirs(src, modules) {
+				for _, f := range goDirs[dir] {
+					targets[f] = true
+				}
+			}
+		case ".ts", ".tsx", ".js", ".jsx":
+			for _, m := range jsImport.FindAllSubmatch(src, -1) {
+				spec := path.Join(path.Dir(p), string(m[1]))
+				for _, ext := range jsExts {
+					if known[spec+ext] {
+						targets[spec+ext] = true
+						break
+					}
+				}
+			}
+		case ".py":
+			for _, m := range pyImport.FindAllSubmatch(src, -1) {
+				mod := string(m[1])
+				if mod == "" {
+					mod = string(m[2])
+				}
+				if f := pythonModule(p, mod, known); f != "" {
+					targets[f] = true
+				}
+			}
+		}
+		delete(targets, p)
+		for f := range targets {
+			in[f]++
+		}
+	}
+	return in
+}
+
+// goImportDirs maps a Go file's imports to repo directories, for
+// imports inside one of modules.
+func goImportDirs(src []byte, modules map[string]string) []string {
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ImportsOnly)
+	if err != nil {
+		return nil
+	}
+	var dirs []string
+	for _, imp := range f.Imports {
+		ip := strings.Trim(imp.Path.Value, `"`)
+		for mod, dir := range modules {
+			if ip == mod {
+				dirs = append(dirs, dir)
+			} else if rest, ok := strings.CutPrefix(ip, mod+"/"); ok {
+				dirs = append(dirs, path.Join(dir, rest))
+			}
+		}
+	}
+	return dirs
+}
+
+// modulePath reads the module directive of a go.mod file.
+func modulePath(file string) string {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return ""
+	}
+	sc := bufio.NewScanner(bytes.NewReader(raw))
+	for sc.Scan() {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(sc.Text()), "module "); ok {
+			return strings.Trim(strings.TrimSpace(rest), `"`)
+		}
+	}
+	return ""
+}
+
+// pythonModule resolves a Python import to a candidate file. Relative
+// imports resolve against the importing file's package, absolute ones
+// against the repo root and a src/ layout.
+func pythonModule(importer, mod string, known map[string]bool) string {
+	dots := len(mod) - len(strings.TrimLeft(mod, "."))
+	rel := strings.ReplaceAll(strings.TrimLeft(mod, "."), ".", "/")
+	var bases []string
+	if dots > 0 {
+		base := path.Dir(importer)
+		for range dots - 1 {
+			base = path.Dir(base)
+		}
+		bases = []string{base}
+	} else {
+		bases = []string{".", "src"}
+	}
+	for _, base := range bases {
+		p := path.Join(base, rel)
+		for _, f := range []string{p + ".py", p + "/__init__.py"} {
+			if known[f] {
+				return f
+			}
+		}
+	}
+	return ""
+}
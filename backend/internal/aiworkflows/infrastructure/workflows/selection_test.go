@@ -0,0 +1,261 @@
+package workflows
+
+import (
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
+)
+
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, body := range files {
+		full := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestSelectFilesRankedPrefersImportantFiles(t *testing.T) {
+	files := map[string]string{
+		"README.md":                  "# demo",
+		"go.mod":                     "module example.com/demo\n\ngo 1.22\n",
+		"cmd/demo/main.go":           "package main\n\nimport \"example.com/demo/core\"\n",
+		"core/core.go":               "package core\n",
+		"api/handler.go":             "package api\n\nimport \"example.com/demo/core\"\n",
+		"api/handler_test.go":        "package api\n",
+		".github/workflows/ci.yml":   "on: push",
+		".github/workflows/lint.yml": "on: push",
+		"docs/a.md":                  "a",
+		"docs/b.md":                  "b",
+		"docs/c.md":                  "c",
+		"web/src/index.ts":           "import { x } from './util'\n",
+		"web/src/util.ts":            "export const x = 1\n",
+	}
+	root := writeTree(t, files)
+
+	sel, err := selectFiles(root, selectOptions{Strategy: SelectionRanked, MaxFiles: 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sel.Strategy != SelectionRanked {
+		t.Errorf("strategy = %q", sel.Strategy)
+	}
+	got := sel.Paths()
+	// util.ts is imported but web/ already has a file; api/ isn't
+	// covered yet.
+	want := []string{"README.md", "go.mod", "cmd/demo/main.go", "web/src/index.ts", "core/core.go", "api/handler.go"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("picked %q, want %q", got, want)
+	}
+	reasons := make(map[string]string)
+	for _, f := range sel.Files {
+		reasons[f.Path] = f.Reason
+	}
+	if !strings.Contains(reasons["core/core.go"], "imported by 2 files") {
+		t.Errorf("core.go reason = %q", reasons["core/core.go"])
+	}
+	if reasons["api/handler.go"] != "covers api/" {
+		t.Errorf("handler.go reason = %q", reasons["api/handler.go"])
+	}
+	if reasons["README.md"] != "top-level README" {
+		t.Errorf("README reason = %q", reasons["README.md"])
+	}
+}
+
+func TestSelectFilesRankedSpreadsAndUsesHistory(t *testing.T) {
+	files := map[string]string{}
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		files["big/"+name+".go"] = "package big\n"
+	}
+	files["small/only.go"] = "package small\n"
+	files["other/x.go"] = "package other\n"
+	root := writeTree(t, files)
+	recent := map[string]time.Time{
+		"big/e.go":   time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		"other/x.go": time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	sel, err := selectFiles(root, selectOptions{Strategy: SelectionRanked, MaxFiles: 3, Recent: recent})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"big/e.go", "other/x.go", "small/only.go"}
+	if got := sel.Paths(); !slices.Equal(got, want) {
+		t.Fatalf("picked %q, want %q (one per directory, recent first)", got, want)
+	}
+	if sel.Files[0].Reason != "changed 2026-03-01" || sel.Files[2].Reason != "covers small/" {
+		t.Errorf("reasons = %+v", sel.Files)
+	}
+}
+
+func TestSelectFilesAlphabetical(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"b.go": "package b", "a.md": "a", "c.txt": "skipped", "node_modules/x.js": "skipped", "z.yaml": "z",
+	})
+	sel, err := selectFiles(root, selectOptions{Strategy: SelectionAlphabetical, MaxFiles: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sel.Paths(); !slices.Equal(got, []string{"a.md", "b.go"}) {
+		t.Errorf("picked %q", got)
+	}
+	if sel.Strategy != SelectionAlphabetical || sel.Files[0].Reason != "alphabetical order" {
+		t.Errorf("selection = %+v", sel)
+	}
+}
+
+func TestImportInDegreePython(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"pkg/__init__.py": "",
+		"pkg/models.py":   "",
+		"pkg/views.py":    "from .models import User\nfrom . import util\n",
+		"pkg/util.py":     "",
+		"app.py":          "import pkg.models\nfrom pkg import views\n",
+	})
+	paths, _, err := walkCandidates(root, "", 0, &pathFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := importInDegree(root, paths)
+	if in["pkg/models.py"] != 2 || in["pkg/__init__.py"] != 2 {
+		t.Errorf("in-degree = %v", in)
+	}
+}
+
+func TestSelectFilesFilter(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		".summaryignore":          "# generated\n*.pb.go\ndocs/\n!docs/keep.md\n",
+		"README.md":               "# top",
+		"backend/README.md":       "# backend",
+		"backend/api/api.pb.go":   "package api",
+		"backend/api/handler.go":  "package api",
+		"backend/db/schema.sql":   "create table t();",
+		"backend/db/gen/query.go": "package gen",
+		"backend/proto/api.proto": "syntax = \"proto3\";",
+		"docs/guide.md":           "guide",
+		"frontend/index.ts":       "export {}",
+	})
+
+	sel, err := selectFiles(root, selectOptions{
+		Strategy: SelectionAlphabetical,
+		MaxFiles: 25,
+		Filter:   ai.FileFilter{Exclude: []string{"gen/"}, Subdir: "backend"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"backend/README.md", "backend/api/handler.go", "backend/db/schema.sql"}
+	if got := sel.Paths(); !slices.Equal(got, want) {
+		t.Errorf("picked %q, want %q", got, want)
+	}
+	if !slices.Equal(sel.IgnorePatterns, []string{"*.pb.go", "docs/", "!docs/keep.md"}) {
+		t.Errorf("ignore patterns = %q", sel.IgnorePatterns)
+	}
+
+	// Include replaces the extension list, so .proto becomes selectable;
+	// .summaryignore still applies.
+	sel, err = selectFiles(root, selectOptions{
+		Strategy: SelectionRanked,
+		MaxFiles: 25,
+		Filter:   ai.FileFilter{Include: []string{"*.proto", "api/"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := sel.Paths()
+	slices.Sort(got)
+	if want := []string{"backend/api/handler.go", "backend/proto/api.proto"}; !slices.Equal(got, want) {
+		t.Errorf("picked %q, want %q", got, want)
+	}
+}
+
+func TestSelectFilesRejectsBadSubdir(t *testing.T) {
+	root := writeTree(t, map[string]string{"a.go": "package a"})
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(root, "link")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+	for _, subdir := range []string{"missing", "a.go", "link"} {
+		_, err := selectFiles(root, selectOptions{Filter: ai.FileFilter{Subdir: subdir}})
+		if !errors.Is(err, errBadSubdir) {
+			t.Errorf("subdir %q: err = %v, want errBadSubdir", subdir, err)
+		}
+	}
+}
+
+func TestReadRepoFileStaysInCheckout(t *testing.T) {
+	root := writeTree(t, map[string]string{"a.go": "package a", "lib/b.go": "package lib"})
+	outside := writeTree(t, map[string]string{"secret.env": "TOKEN=x"})
+	for link, target := range map[string]string{
+		"env.go":       filepath.Join(outside, "secret.env"),
+		"out":          outside,
+		"lib/alias.go": "../a.go",
+	} {
+		if err := os.Symlink(target, filepath.Join(root, filepath.FromSlash(link))); err != nil {
+			t.Skipf("symlinks unsupported: %v", err)
+		}
+	}
+
+	for _, name := range []string{"env.go", "out/secret.env", "lib"} {
+		if _, err := readRepoFile(root, name); !errors.Is(err, errOutsideCheckout) {
+			t.Errorf("%s: err = %v, want errOutsideCheckout", name, err)
+		}
+	}
+	for name, want := range map[string]string{"lib/b.go": "package lib", "lib/alias.go": "package a"} {
+		if body, err := readRepoFile(root, name); err != nil || string(body) != want {
+			t.Errorf("%s = %q, %v; want %q", name, body, err, want)
+		}
+	}
+}
+
+func TestSelectFilesSkipsSecretStores(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"main.go":              "package main",
+		"config/secrets.yaml":  "db: hunter2",
+		"config/settings.yaml": "port: 8080",
+		"api/secrets.toml":     "{}",
+	})
+	secret := func(p string) bool { return strings.HasPrefix(path.Base(p), "secrets.") }
+	sel, err := selectFiles(root, selectOptions{SecretStore: secret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sel.Paths(); slices.Contains(got, "config/secrets.yaml") || !slices.Contains(got, "config/settings.yaml") {
+		t.Errorf("selected %v", got)
+	}
+	if want := []string{"api/secrets.toml", "config/secrets.yaml"}; !slices.Equal(sel.SkippedSecrets, want) {
+		t.Errorf("SkippedSecrets = %v, want %v", sel.SkippedSecrets, want)
+	}
+}
+
+func TestSelectFilesListsFilesOverTheCeiling(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"main.go":       "package main",
+		"big/schema.go": strings.Repeat("// line\n", 64),
+		"big/data.bin":  strings.Repeat("x", 1024),
+	})
+	sel, err := selectFiles(root, selectOptions{MaxBytes: 256})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sel.Paths(); !slices.Equal(got, []string{"main.go"}) {
+		t.Errorf("selected %v", got)
+	}
+	// Files that weren't candidates anyway aren't reported.
+	if want := []string{"big/schema.go"}; !slices.Equal(sel.SkippedTooLarge, want) {
+		t.Errorf("SkippedTooLarge = %v, want %v", sel.SkippedTooLarge, want)
+	}
+}
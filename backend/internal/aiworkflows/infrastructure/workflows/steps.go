@@ -2,21 +2,23 @@ package workflows
 
 import (
 	"context"
+	"crypto/sha1"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
-	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
-	hatchet "github.com/hatchet-dev/hatchet/sdks/go"
+	"github.com/hatchet-dev/hatchet/pkg/worker"
 
 	aiapp "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/application"
 	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
+	"github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/infrastructure/prompts"
 	shared "github.com/atilladeniz/next-go-pg/backend/internal/shared/domain"
+	"github.com/atilladeniz/next-go-pg/backend/pkg/metrics"
 )
 
 // Deps holds the workflow's runtime dependencies. The dependency graph
@@ -24,12 +26,75 @@ import (
 // the workflow itself trivially testable in isolation (the Deps struct
 // is a single hand-off point for fakes).
 type Deps struct {
-	Cloner   aiapp.RepoCloner
+	Cloner aiapp.RepoCloner
+	// History is optional; it lets the ranked file selection favour
+	// recently changed files.
+	History  aiapp.RepoHistory
 	LLM      aiapp.LLMClient
 	Store    aiapp.Store
 	Progress aiapp.ProgressPublisher
-	MaxFiles int
+	// Cache is optional; nil sends every file to the LLM. Entries are
+	// keyed by the model that wrote them and looked up by Model, the
+	// primary one, so switching models never serves summaries another
+	// model wrote — nor does a fallback's answer pass for the primary's.
+	Cache aiapp.SummaryCache
+	Model string
+	// Prompts renders the per-file and aggregate prompts; nil serves
+	// the built-in templates only.
+	Prompts *prompts.Registry
+	// StructuredSummaries makes new runs ask for per-file summaries as
+	// JSON (purpose, key symbols, dependencies, risks) rather than
+	// free text.
+	StructuredSummaries bool
+	// FileSelection is the traverse step's strategy, SelectionRanked
+	// (the default when empty) or SelectionAlphabetical.
+	FileSelection string
+	MaxFiles      int
+	// MaxBytes is the per-file size ceiling. Files under it are chunked
+	// as needed; larger ones are left out and listed on the run's
+	// selection. 0 means no ceiling.
 	MaxBytes int64
+	// ChunkTokens is the token budget of one prompt's worth of file
+	// content; larger files are summarized chunk by chunk and the chunk
+	// summaries reduced into one. 0 means DefaultChunkTokens.
+	ChunkTokens int
+	// Secrets is optional; it keeps secret stores out of the selection
+	// and redacts credentials from file content before it is prompted.
+	// nil sends files as they are.
+	Secrets aiapp.SecretScanner
+}
+
+func (d Deps) chunkTokens() int {
+	if d.ChunkTokens <= 0 {
+		return DefaultChunkTokens
+	}
+	return d.ChunkTokens
+}
+
+func (d Deps) prompts() *prompts.Registry {
+	if d.Prompts == nil {
+		return &prompts.Registry{}
+	}
+	return d.Prompts
+}
+
+// filePrompt is the per-file prompt the run renders: the JSON one if
+// the run started in structured mode, the free-text one otherwise.
+func filePrompt(agg *ai.RepoSummary) (prompts.Name, string) {
+	if v := agg.PromptVersions[string(prompts.FileSummaryJSON)]; v != "" {
+		return prompts.FileSummaryJSON, v
+	}
+	return prompts.FileSummary, promptVersion(agg, prompts.FileSummary)
+}
+
+// promptVersion is the version of name the run renders. Runs started
+// before versions were recorded get the default, which is the prompt
+// they were started with.
+func promptVersion(agg *ai.RepoSummary, name prompts.Name) string {
+	if v := agg.PromptVersions[string(name)]; v != "" {
+		return v
+	}
+	return prompts.Default(name)
 }
 
 // publishStep is a small helper to keep the per-step start/end emissions
@@ -55,12 +120,7 @@ func (d Deps) publishStep(ctx context.Context, in WorkflowInput, name aiapp.Step
 // aggregate. Best-effort: failures here only mean refresh shows ?ms
 // instead of the precise time, not a workflow break.
 func (d Deps) recordDuration(ctx context.Context, summaryID uint, step string, ms int64) {
-	agg, err := d.Store.GetByID(ctx, summaryID)
-	if err != nil {
-		return
-	}
-	agg.RecordStepDuration(step, ms)
-	_ = d.Store.Save(ctx, agg)
+	_ = d.Store.RecordStepDuration(ctx, summaryID, step, ms)
 }
 
 // CloneStep performs a shallow clone of the requested repo and marks the
@@ -78,34 +138,81 @@ func (d Deps) CloneStep(ctx context.Context, in WorkflowInput) (out CloneOutput,
 		d.publishStep(ctx, in, aiapp.StepClone, state, time.Since(start).Milliseconds(), reason)
 	}()
 
-	if _, err = d.loadAndStart(ctx, in); err != nil {
+	agg, err := d.loadAndStart(ctx, in)
+	if err != nil {
 		return CloneOutput{}, err
 	}
+	if agg.Status == ai.StatusCancelled {
+		return CloneOutput{}, worker.NewNonRetryableError(errRunCancelled)
+	}
 	url, err := ai.NewRepoURL(in.RepoURL)
 	if err != nil {
 		return CloneOutput{}, fmt.Errorf("clone: invalid repo url: %w", err)
 	}
-	cloned, err := d.Cloner.Clone(ctx, url)
+	ref, err := ai.NewGitRef(in.Ref)
+	if err != nil {
+		return CloneOutput{}, worker.NewNonRetryableError(fmt.Errorf("clone: invalid ref: %w", err))
+	}
+	cloned, err := d.Cloner.Clone(ctx, url, ref)
+	if errors.Is(err, aiapp.ErrRefNotFound) || errors.Is(err, aiapp.ErrTargetNotAllowed) || errors.Is(err, aiapp.ErrCloneLimit) {
+		return CloneOutput{}, worker.NewNonRetryableError(fmt.Errorf("clone: %w", err))
+	}
 	if err != nil {
 		return CloneOutput{}, fmt.Errorf("clone: %w", err)
 	}
+	// Cancelled while cloning: nothing downstream will run to clean up.
+	if err = d.checkCancelled(ctx, in.SummaryID); err != nil {
+		_ = cloned.Cleanup()
+		return CloneOutput{}, err
+	}
+	// Record the commit now rather than at the end, so a run that fails
+	// later still says which tree it was looking at.
+	if err = d.recordCommit(ctx, in.SummaryID, cloned); err != nil {
+		_ = cloned.Cleanup()
+		return CloneOutput{}, err
+	}
 	// Cleanup runs in StoreStep at the natural end of the workflow.
 	return CloneOutput{Path: cloned.Path}, nil
 }
 
 // loadAndStart loads the aggregate, transitions pending → running, and
-// persists. Re-runs (retry of clone) tolerate already-running rows.
+// persists. The prompt versions the run will use are picked here, so
+// every later step (and every retry of one) renders the same ones.
+// Re-runs (retry of clone) tolerate already-running rows. A row that
+// left pending after it was loaded — cancelled, or started by another
+// attempt — is reloaded and returned as it now is.
 func (d Deps) loadAndStart(ctx context.Context, in WorkflowInput) (*ai.RepoSummary, error) {
 	agg, err := d.Store.GetByID(ctx, in.SummaryID)
 	if err != nil {
 		return nil, fmt.Errorf("load aggregate: %w", err)
 	}
 	if agg.Status == ai.StatusPending {
+		file, reduce := prompts.FileSummary, prompts.FileReduce
+		if d.StructuredSummaries {
+			file, reduce = prompts.FileSummaryJSON, prompts.FileReduceJSON
+		}
+		agg.UsePrompts(map[string]string{
+			string(file):              d.prompts().Select(file, agg.ID),
+			string(prompts.FileChunk): d.prompts().Select(prompts.FileChunk, agg.ID),
+			string(reduce):            d.prompts().Select(reduce, agg.ID),
+			string(prompts.Directory): d.prompts().Select(prompts.Directory, agg.ID),
+			string(prompts.Aggregate): d.prompts().Select(prompts.Aggregate, agg.ID),
+		})
+		if d.Secrets != nil {
+			agg.UseRedaction(d.Secrets.Version())
+		}
 		if err := agg.MarkStarted(time.Now().UTC()); err != nil {
 			return nil, fmt.Errorf("mark started: %w", err)
 		}
 		events := agg.PullEvents()
-		if err := d.Store.Save(ctx, agg); err != nil {
+		err := d.Store.Transition(ctx, agg, ai.StatusPending)
+		if errors.Is(err, aiapp.ErrStatusChanged) {
+			if agg, err = d.Store.GetByID(ctx, in.SummaryID); err != nil {
+				return nil, fmt.Errorf("load aggregate: %w", err)
+			}
+			return agg, nil
+		}
+		if err != nil {
 			return nil, fmt.Errorf("save after start: %w", err)
 		}
 		_ = d.Progress.Publish(ctx, events...)
@@ -113,7 +220,56 @@ func (d Deps) loadAndStart(ctx context.Context, in WorkflowInput) (*ai.RepoSumma
 	return agg, nil
 }
 
-// TraverseStep walks the cloned repo and selects files to summarize.
+// errRunCancelled is what a step returns once the user has cancelled
+// the run. Always wrapped non-retryable — retrying can't un-cancel.
+var errRunCancelled = errors.New("run cancelled")
+
+// IsPermanent reports whether a step's error is marked non-retryable:
+// the engine should fail the run rather than try the step again.
+func IsPermanent(err error) bool {
+	return worker.IsNonRetryableError(err)
+}
+
+// isCancelled reports whether the run's aggregate is in the cancelled
+// state. Load failures count as not cancelled; the step's own store
+// access will surface them.
+func (d Deps) isCancelled(ctx context.Context, summaryID uint) bool {
+	agg, err := d.Store.GetByID(ctx, summaryID)
+	return err == nil && agg.Status == ai.StatusCancelled
+}
+
+// checkCancelled is called at the top of each step (and before every
+// LLM call) so a cancelled run stops doing paid work even if the
+// engine's own cancellation hasn't reached this task.
+func (d Deps) checkCancelled(ctx context.Context, summaryID uint) error {
+	if d.isCancelled(ctx, summaryID) {
+		return worker.NewNonRetryableError(errRunCancelled)
+	}
+	return nil
+}
+
+// cleanupOnCancel is deferred by every step that runs after clone. If
+// the step failed because the run was cancelled — the engine cancelled
+// its context, or a status check tripped — no later step will run to
+// remove the working copy, so it is removed here and the error made
+// non-retryable. Pass an empty path to only fix up the error.
+func (d Deps) cleanupOnCancel(summaryID uint, path string, err *error) {
+	if *err == nil {
+		return
+	}
+	// The step's context is most likely cancelled already; the status
+	// check must still reach the database.
+	if !d.isCancelled(context.Background(), summaryID) {
+		return
+	}
+	if path != "" {
+		_ = os.RemoveAll(path)
+	}
+	*err = worker.NewNonRetryableError(errRunCancelled)
+}
+
+// TraverseStep walks the cloned repo and selects files to summarize,
+// recording the selection and the reason for each file on the run.
 // Deterministic, no retries.
 func (d Deps) TraverseStep(ctx context.Context, in WorkflowInput, path string) (out TraverseOutput, err error) {
 	start := time.Now()
@@ -127,12 +283,64 @@ func (d Deps) TraverseStep(ctx context.Context, in WorkflowInput, path string) (
 		}
 		d.publishStep(ctx, in, aiapp.StepTraverse, state, time.Since(start).Milliseconds(), reason)
 	}()
+	defer d.cleanupOnCancel(in.SummaryID, path, &err)
 
-	files, err := selectFiles(path, d.MaxFiles, d.MaxBytes)
+	if err = d.checkCancelled(ctx, in.SummaryID); err != nil {
+		return TraverseOutput{}, err
+	}
+	var recent map[string]time.Time
+	if d.History != nil && d.FileSelection != SelectionAlphabetical {
+		// Without history the ranking just loses one signal.
+		recent, _ = d.History.RecentChanges(ctx, path)
+	}
+	filter := in.Filter.domain()
+	opts := selectOptions{
+		Strategy: d.FileSelection,
+		MaxFiles: d.MaxFiles,
+		MaxBytes: d.MaxBytes,
+		Recent:   recent,
+		Filter:   filter,
+	}
+	if d.Secrets != nil {
+		opts.SecretStore = d.Secrets.SecretStore
+	}
+	sel, err := selectFiles(path, opts)
+	if errors.Is(err, errBadSubdir) {
+		return TraverseOutput{}, worker.NewNonRetryableError(err)
+	}
 	if err != nil {
 		return TraverseOutput{}, fmt.Errorf("traverse: %w", err)
 	}
-	return TraverseOutput{Path: path, Files: files}, nil
+	if len(sel.Files) == 0 && (!filter.IsZero() || len(sel.IgnorePatterns) > 0) {
+		// Summarising nothing would only produce an empty overview.
+		return TraverseOutput{}, worker.NewNonRetryableError(errors.New("no files left after applying the file filter"))
+	}
+	d.recordSelection(ctx, in.SummaryID, sel)
+	return TraverseOutput{Path: path, Files: sel.Paths()}, nil
+}
+
+// recordSelection persists the file selection onto the aggregate.
+// Best-effort like recordDuration: it only explains the run.
+func (d Deps) recordSelection(ctx context.Context, summaryID uint, sel ai.FileSelection) {
+	_ = d.Store.RecordSelection(ctx, summaryID, sel)
+}
+
+// recordCommit stores the commit the clone checked out. Unlike the
+// selection it is not best-effort: retries pin to this SHA.
+func (d Deps) recordCommit(ctx context.Context, summaryID uint, cloned aiapp.ClonedRepo) error {
+	if err := d.Store.RecordCommit(ctx, summaryID, cloned.Commit, cloned.CommitTime); err != nil {
+		return fmt.Errorf("save commit: %w", err)
+	}
+	return nil
+}
+
+// redact replaces the credentials in body, when Secrets is set, and
+// counts them per rule. Only the redacted content is ever prompted.
+func (d Deps) redact(body []byte) (string, map[string]int) {
+	if d.Secrets == nil {
+		return string(body), nil
+	}
+	return d.Secrets.Redact(string(body))
 }
 
 // SummarizeFileStep is the fan-out child task. Called per file by the
@@ -140,45 +348,327 @@ func (d Deps) TraverseStep(ctx context.Context, in WorkflowInput, path string) (
 // (Path, Filename) always produces the same prompt — actual LLM
 // determinism depends on the upstream provider's settings.
 //
-// Hatchet's SDK validates task function signatures via reflection, so the
-// first parameter MUST be hatchet.Context (which embeds context.Context
-// anyway — the LLM client treats it as a regular context).
-func (d Deps) SummarizeFileStep(ctx hatchet.Context, in SummarizeFileInput) (SummarizeFileOutput, error) {
-	full := filepath.Join(in.Path, in.Filename)
-	body, err := os.ReadFile(full)
+// It takes a plain context so either engine can run it; Build wraps it
+// in the hatchet.Context signature the Hatchet SDK requires.
+//
+// The child re-checks the run's status before calling the LLM, so
+// files still queued when the user cancels never reach the provider.
+// It never removes the shared working copy; the parent step does.
+//
+// A file whose exact content was already summarised with the same
+// prompt version and model is answered from Deps.Cache instead.
+//
+// With the JSON prompt the answer is parsed, repaired and validated;
+// see generateStructured.
+func (d Deps) SummarizeFileStep(ctx context.Context, in SummarizeFileInput) (out SummarizeFileOutput, err error) {
+	defer d.cleanupOnCancel(in.SummaryID, "", &err)
+	if err = d.checkCancelled(ctx, in.SummaryID); err != nil {
+		return SummarizeFileOutput{}, err
+	}
+	body, err := readRepoFile(in.Path, in.Filename)
+	if errors.Is(err, errOutsideCheckout) {
+		return SummarizeFileOutput{}, worker.NewNonRetryableError(err)
+	}
 	if err != nil {
 		return SummarizeFileOutput{}, fmt.Errorf("read %s: %w", in.Filename, err)
 	}
-	if int64(len(body)) > d.MaxBytes {
-		// Trim huge files so the LLM context window doesn't blow up.
-		body = body[:d.MaxBytes]
+	blob := gitBlobHash(body)
+	content, redactions := d.redact(body)
+	defer func() {
+		if err == nil {
+			out.BlobHash = blob
+			out.Redactions = redactions
+		}
+	}()
+	name := prompts.Name(in.PromptName)
+	if name == "" {
+		name = prompts.FileSummary
+	}
+	structured := name == prompts.FileSummaryJSON
+	version := in.PromptVersion
+	if version == "" {
+		version = prompts.Default(name)
+	}
+	chunks := splitChunks(content, d.chunkTokens())
+	// The blob hash is the one `git hash-object` reports. A chunked
+	// summary depends on the chunk and reduce prompts and the budget
+	// instead of the single-file prompt.
+	key := aiapp.SummaryCacheKey{
+		BlobHash:      blob,
+		PromptVersion: string(name) + "/" + version,
+		Model:         d.Model,
 	}
-	prompt := fmt.Sprintf(
-		"Summarize the following source file in 2-3 sentences. Focus on what it does, not the syntax.\n\nFILENAME: %s\n\n---\n%s\n---\n\nSUMMARY:",
-		in.Filename, string(body),
-	)
-	summary, err := d.LLM.Generate(ctx, prompt)
+	if len(chunks) > 1 {
+		key.PromptVersion = in.chunkedPromptVersion(structured, d.chunkTokens())
+	}
+	if d.Secrets != nil {
+		// The summary depends on what was redacted, too.
+		key.PromptVersion += "+redact/" + d.Secrets.Version()
+	}
+	if summary, ok := d.cachedSummary(ctx, key); ok {
+		out := SummarizeFileOutput{Filename: in.Filename, Summary: summary, Model: key.Model, Cached: true}
+		if len(chunks) > 1 {
+			out.Chunks = len(chunks)
+		}
+		if !structured {
+			return out, nil
+		}
+		// Structured entries hold the normalised JSON; one that no
+		// longer parses is regenerated.
+		if parsed, perr := parseStructuredSummary(summary); perr == nil {
+			out.Summary, out.Insights = parsed.Summary, parsed.insights()
+			return out, nil
+		}
+	}
+	if len(chunks) > 1 {
+		out, cacheValue, err := d.summarizeChunks(ctx, in, chunks, structured)
+		if err != nil {
+			return SummarizeFileOutput{}, err
+		}
+		if cacheValue != "" {
+			d.cachePut(ctx, key, out.Model, cacheValue)
+		}
+		return out, nil
+	}
+	prompt, err := d.prompts().Render(name, version, prompts.FileVars{
+		Filename: in.Filename,
+		Language: prompts.Language(in.Filename),
+		Content:  content,
+	})
+	if err != nil {
+		return SummarizeFileOutput{}, worker.NewNonRetryableError(err)
+	}
+	if structured {
+		parsed, completion, err := d.generateStructured(ctx, prompt)
+		if err != nil {
+			return SummarizeFileOutput{}, err
+		}
+		d.cachePut(ctx, key, completion.Model, parsed.encode())
+		return SummarizeFileOutput{
+			Filename: in.Filename,
+			Summary:  parsed.Summary,
+			Model:    completion.Model,
+			Usage:    usageFrom(completion.Usage),
+			Insights: parsed.insights(),
+		}, nil
+	}
+	completion, err := d.generate(ctx, prompt)
 	if err != nil {
 		return SummarizeFileOutput{}, fmt.Errorf("llm generate: %w", err)
 	}
+	summary := strings.TrimSpace(completion.Text)
+	if summary != "" {
+		d.cachePut(ctx, key, completion.Model, summary)
+	}
 	return SummarizeFileOutput{
 		Filename: in.Filename,
-		Summary:  strings.TrimSpace(summary),
+		Summary:  summary,
+		Model:    completion.Model,
+		Usage:    usageFrom(completion.Usage),
 	}, nil
 }
 
+// summarizeChunks is the map-reduce path for files over the chunk
+// budget: each chunk is summarized with the file-chunk prompt, in
+// order, then the reduce prompt turns the chunk summaries into the file
+// summary — JSON in structured mode. Usage covers every call; Model is
+// the one that wrote the final summary. The second return value is what
+// to cache: nothing when a fallback answered some of the calls, since
+// no one model wrote the summary.
+func (d Deps) summarizeChunks(ctx context.Context, in SummarizeFileInput, chunks []fileChunk, structured bool) (SummarizeFileOutput, string, error) {
+	language := prompts.Language(in.Filename)
+	chunkVersion, reduce, reduceVersion := in.chunkPrompts(structured)
+	notes := make([]prompts.ChunkSummaryVar, 0, len(chunks))
+	var usage ai.TokenUsage
+	models := map[string]bool{}
+	for i, c := range chunks {
+		// A cancelled run stops between chunks rather than after the
+		// whole file.
+		if err := ctx.Err(); err != nil {
+			return SummarizeFileOutput{}, "", err
+		}
+		if err := d.checkCancelled(ctx, in.SummaryID); err != nil {
+			return SummarizeFileOutput{}, "", err
+		}
+		prompt, err := d.prompts().Render(prompts.FileChunk, chunkVersion, prompts.ChunkVars{
+			Filename:  in.Filename,
+			Language:  language,
+			Part:      i + 1,
+			Parts:     len(chunks),
+			StartLine: c.StartLine,
+			EndLine:   c.EndLine,
+			Content:   c.Content,
+		})
+		if err != nil {
+			return SummarizeFileOutput{}, "", worker.NewNonRetryableError(err)
+		}
+		completion, err := d.generate(ctx, prompt)
+		if err != nil {
+			return SummarizeFileOutput{}, "", fmt.Errorf("llm generate (chunk %d/%d): %w", i+1, len(chunks), err)
+		}
+		usage = usage.Add(completion.Usage)
+		models[completion.Model] = true
+		notes = append(notes, prompts.ChunkSummaryVar{
+			Part:      i + 1,
+			StartLine: c.StartLine,
+			EndLine:   c.EndLine,
+			Summary:   strings.TrimSpace(completion.Text),
+		})
+	}
+
+	if err := d.checkCancelled(ctx, in.SummaryID); err != nil {
+		return SummarizeFileOutput{}, "", err
+	}
+	prompt, err := d.prompts().Render(reduce, reduceVersion, prompts.ReduceVars{
+		Filename: in.Filename,
+		Language: language,
+		Chunks:   notes,
+	})
+	if err != nil {
+		return SummarizeFileOutput{}, "", worker.NewNonRetryableError(err)
+	}
+	out := SummarizeFileOutput{Filename: in.Filename, Chunks: len(chunks)}
+	if structured {
+		parsed, completion, err := d.generateStructured(ctx, prompt)
+		if err != nil {
+			return SummarizeFileOutput{}, "", err
+		}
+		out.Summary, out.Insights = parsed.Summary, parsed.insights()
+		out.Model, out.Usage = completion.Model, usageFrom(completion.Usage.Add(usage))
+		models[completion.Model] = true
+		if len(models) > 1 {
+			return out, "", nil
+		}
+		return out, parsed.encode(), nil
+	}
+	completion, err := d.generate(ctx, prompt)
+	if err != nil {
+		return SummarizeFileOutput{}, "", fmt.Errorf("llm generate (reduce): %w", err)
+	}
+	out.Summary = strings.TrimSpace(completion.Text)
+	out.Model, out.Usage = completion.Model, usageFrom(completion.Usage.Add(usage))
+	models[completion.Model] = true
+	if len(models) > 1 {
+		return out, "", nil
+	}
+	return out, out.Summary, nil
+}
+
+// generateStructured asks for the JSON summary and parses it. An answer
+// that can't be repaired gets one more try with the problem quoted back
+// to the model; a second bad answer fails the task so the engine's
+// retry policy takes over. The returned completion carries the usage
+// of every call made.
+func (d Deps) generateStructured(ctx context.Context, prompt string) (structuredSummary, aiapp.Completion, error) {
+	completion, err := d.generate(ctx, prompt)
+	if err != nil {
+		return structuredSummary{}, aiapp.Completion{}, fmt.Errorf("llm generate: %w", err)
+	}
+	parsed, perr := parseStructuredSummary(completion.Text)
+	if perr == nil {
+		return parsed, completion, nil
+	}
+	retry, err := d.generate(ctx, repairPrompt(prompt, perr))
+	if err != nil {
+		return structuredSummary{}, aiapp.Completion{}, fmt.Errorf("llm generate (repair): %w", err)
+	}
+	retry.Usage = retry.Usage.Add(completion.Usage)
+	if parsed, perr = parseStructuredSummary(retry.Text); perr != nil {
+		return structuredSummary{}, aiapp.Completion{}, fmt.Errorf("malformed structured summary: %w", perr)
+	}
+	return parsed, retry, nil
+}
+
+// cachePut stores a summary under the model that wrote it. Best-effort:
+// a failed write only costs a future LLM call.
+func (d Deps) cachePut(ctx context.Context, key aiapp.SummaryCacheKey, model, summary string) {
+	if d.Cache == nil {
+		return
+	}
+	if model != "" {
+		key.Model = model
+	}
+	_ = d.Cache.Put(ctx, key, summary)
+}
+
+// generate calls the LLM and counts the tokens and cost it reports
+// against the model that answered.
+func (d Deps) generate(ctx context.Context, prompt string) (aiapp.Completion, error) {
+	completion, err := d.LLM.Generate(ctx, prompt)
+	if err != nil {
+		return aiapp.Completion{}, err
+	}
+	d.countUsage(completion)
+	return completion, nil
+}
+
+// stream is generate for answers the user watches arrive: onChunk gets
+// the text piece by piece, the returned completion has all of it.
+func (d Deps) stream(ctx context.Context, prompt string, onChunk func(string)) (aiapp.Completion, error) {
+	completion, err := d.LLM.Stream(ctx, prompt, onChunk)
+	if err != nil {
+		return aiapp.Completion{}, err
+	}
+	d.countUsage(completion)
+	return completion, nil
+}
+
+func (d Deps) countUsage(completion aiapp.Completion) {
+	model := completion.Model
+	if model == "" {
+		model = d.Model
+	}
+	u := completion.Usage
+	metrics.AILLMTokens.WithLabelValues(model, "prompt").Add(float64(u.PromptTokens))
+	metrics.AILLMTokens.WithLabelValues(model, "completion").Add(float64(u.CompletionTokens))
+	if u.CostUSD > 0 {
+		metrics.AILLMCostUSD.WithLabelValues(model).Add(u.CostUSD)
+	}
+}
+
+// cachedSummary consults the summary cache and counts the lookup. A
+// cache that errors is treated as a miss so it never fails the run.
+func (d Deps) cachedSummary(ctx context.Context, key aiapp.SummaryCacheKey) (string, bool) {
+	if d.Cache == nil {
+		return "", false
+	}
+	summary, ok, err := d.Cache.Get(ctx, key)
+	if err != nil || !ok {
+		metrics.AISummaryCacheLookups.WithLabelValues("miss").Inc()
+		return "", false
+	}
+	metrics.AISummaryCacheLookups.WithLabelValues("hit").Inc()
+	return summary, true
+}
+
+// gitBlobHash returns the SHA-1 object ID git assigns to a blob with
+// this content, so cache entries line up with the repository's own
+// content addressing.
+func gitBlobHash(body []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(body))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// FileRunner runs SummarizeFileStep for one file as a child task of the
+// engine in use and waits for its result.
+type FileRunner func(ctx context.Context, in SummarizeFileInput) (SummarizeFileOutput, error)
+
 // SummarizeFilesStep fans out across all files via child task calls.
-// Each child is independently checkpointed in Hatchet, so a mid-run
-// crash resumes from the last in-flight file. As each child completes,
+// Each child is independently checkpointed by the engine, so a mid-run
+// crash resumes from the last in-flight file. Files with a summary
+// already on record — from an earlier try of this step, from the
+// failed attempt this run retries, or unchanged since the base run of
+// an incremental run — are not fanned out at all. As each child completes,
 // we immediately publish a `summarize_files:progress` SSE event so the
 // frontend's counter advances in real time, rather than only firing the
 // final batch after wg.Wait().
 func (d Deps) SummarizeFilesStep(
 	ctx context.Context,
-	hctx hatchet.Context,
 	in WorkflowInput,
 	traverse TraverseOutput,
-	childTask *hatchet.StandaloneTask,
+	runFile FileRunner,
 ) (out SummarizeFilesOutput, err error) {
 	start := time.Now()
 	total := len(traverse.Files)
@@ -210,83 +700,264 @@ func (d Deps) SummarizeFilesStep(
 			d.recordDuration(ctx, in.SummaryID, string(aiapp.StepSummarizeFiles), durMs)
 		}
 	}()
+	defer d.cleanupOnCancel(in.SummaryID, traverse.Path, &err)
+
+	if err = d.checkCancelled(ctx, in.SummaryID); err != nil {
+		return SummarizeFilesOutput{}, err
+	}
+	agg, err := d.Store.GetByID(ctx, in.SummaryID)
+	if err != nil {
+		return SummarizeFilesOutput{}, fmt.Errorf("load aggregate: %w", err)
+	}
+	promptName, version := filePrompt(agg)
+	chunkVersion := promptVersion(agg, prompts.FileChunk)
+	reduceVersion := promptVersion(agg, prompts.FileReduce)
+	if promptName == prompts.FileSummaryJSON {
+		reduceVersion = promptVersion(agg, prompts.FileReduceJSON)
+	}
+	// Files this run already summarised (the step is being retried) or
+	// that the failed attempt it retries got through skip the LLM, and
+	// so do those an incremental run finds unchanged since its base.
+	reused := d.reusableSummaries(ctx, agg)
+	if agg.BaseID != 0 {
+		if err = d.carryOverUnchanged(ctx, agg, traverse, reused); err != nil {
+			return SummarizeFilesOutput{}, err
+		}
+	}
 
 	results := make([]SummarizeFileOutput, total)
 	errs := make([]error, total)
 	var completed atomic.Int32
 
+	// Per-file progress event — fires the moment a file is done, not
+	// after wg.Wait(). Counter is the number completed so far (1-based,
+	// monotonic).
+	fileDone := func(name string) {
+		n := int(completed.Add(1))
+		d.Progress.PublishStep(ctx, aiapp.StepProgress{
+			SummaryID: in.SummaryID,
+			UserID:    shared.UserID(in.UserID),
+			Step:      aiapp.StepSummarizeFiles,
+			State:     aiapp.StepStateProgress,
+			FileIndex: n,
+			FileCount: total,
+			Filename:  name,
+		})
+	}
+
 	var wg sync.WaitGroup
-	wg.Add(total)
 	for i, file := range traverse.Files {
+		if prev, ok := reused[file]; ok {
+			results[i] = prev
+			fileDone(file)
+			continue
+		}
+		wg.Add(1)
 		go func(idx int, name string) {
 			defer wg.Done()
-			res, runErr := childTask.Run(hctx, SummarizeFileInput{
-				SummaryID: in.SummaryID,
-				UserID:    in.UserID,
-				Path:      traverse.Path,
-				Filename:  name,
-				Total:     total,
+			res, runErr := runFile(ctx, SummarizeFileInput{
+				SummaryID:     in.SummaryID,
+				UserID:        in.UserID,
+				Path:          traverse.Path,
+				Filename:      name,
+				Total:         total,
+				PromptName:    string(promptName),
+				PromptVersion: version,
+				ChunkVersion:  chunkVersion,
+				ReduceVersion: reduceVersion,
 			})
 			if runErr != nil {
 				errs[idx] = runErr
 				return
 			}
-			var typed SummarizeFileOutput
-			if decErr := res.Into(&typed); decErr != nil {
-				errs[idx] = fmt.Errorf("decode child %q: %w", name, decErr)
-				return
-			}
-			results[idx] = typed
-
-			// Per-file progress event — fires the moment THIS file is
-			// summarized, not after wg.Wait(). Counter is the number
-			// completed so far (1-based, monotonic).
-			n := int(completed.Add(1))
-			d.Progress.PublishStep(ctx, aiapp.StepProgress{
-				SummaryID: in.SummaryID,
-				UserID:    shared.UserID(in.UserID),
-				Step:      aiapp.StepSummarizeFiles,
-				State:     aiapp.StepStateProgress,
-				FileIndex: n,
-				FileCount: total,
-				Filename:  name,
-			})
+			results[idx] = res
+			fileDone(name)
 		}(i, file)
 	}
 	wg.Wait()
 
+	// Persist the files that did succeed even when others failed, so a
+	// retry of this run (or of this step) doesn't pay for them again.
+	saveErr := d.saveFileSummaries(ctx, in.SummaryID, results, total)
 	for _, e := range errs {
 		if e != nil {
 			return SummarizeFilesOutput{}, e
 		}
 	}
+	if saveErr != nil {
+		return SummarizeFilesOutput{}, saveErr
+	}
 
-	// Persist per-file summaries on the aggregate in deterministic order.
-	agg, err := d.Store.GetByID(ctx, in.SummaryID)
+	return SummarizeFilesOutput{Summaries: results}, nil
+}
+
+// reusableSummaries maps filename → result for every file the run
+// does not need to send to the LLM again: those already on its
+// aggregate, plus those the failed attempt it retries produced if it
+// asked for the same file prompts. The retry picks its prompt versions
+// afresh, so an A/B split or a rollout can land it on other ones. A
+// missing predecessor (deleted meanwhile) just means nothing to reuse.
+func (d Deps) reusableSummaries(ctx context.Context, agg *ai.RepoSummary) map[string]SummarizeFileOutput {
+	out := make(map[string]SummarizeFileOutput)
+	// Usage is left behind: it was spent by the run that generated the
+	// summary, and this run reuses it for free.
+	add := func(files []ai.FileSummary) {
+		for _, f := range files {
+			out[f.Filename()] = SummarizeFileOutput{
+				Filename:   f.Filename(),
+				Summary:    f.Summary(),
+				Cached:     f.Cached(),
+				Model:      f.Model(),
+				Insights:   insightsFrom(f.Insights()),
+				Chunks:     f.Chunks(),
+				BlobHash:   f.BlobHash(),
+				Redactions: f.Redactions(),
+			}
+		}
+	}
+	if agg.RetryOf != 0 {
+		if prev, prevErr := d.Store.GetByID(ctx, agg.RetryOf); prevErr == nil && sameFilePrompts(prev, agg) {
+			add(prev.Files)
+		}
+	}
+	add(agg.Files)
+	return out
+}
+
+// carryOverUnchanged compares the run's files with those of its base
+// run, records the changelog, and adds the base's summaries of the
+// unchanged files to reused — unless the run already has its own. A
+// missing base (deleted meanwhile) means a full run.
+func (d Deps) carryOverUnchanged(ctx context.Context, agg *ai.RepoSummary, traverse TraverseOutput, reused map[string]SummarizeFileOutput) error {
+	base, err := d.Store.GetByID(ctx, agg.BaseID)
+	if errors.Is(err, aiapp.ErrNotFound) {
+		return nil
+	}
 	if err != nil {
-		return SummarizeFilesOutput{}, fmt.Errorf("load aggregate: %w", err)
+		return fmt.Errorf("load base run: %w", err)
+	}
+	changes, carried, err := carryOver(base, agg, traverse)
+	if err != nil {
+		return err
+	}
+	for name, r := range carried {
+		if _, ok := reused[name]; !ok {
+			reused[name] = r
+		}
+	}
+	d.recordChangelog(ctx, agg.ID, changes)
+	return nil
+}
+
+// carryOver works out which of the traversed files changed since base
+// by hashing them the way git does, and returns base's summaries of
+// the unchanged ones. Those summaries are only carried over when base
+// rendered the same per-file prompts as agg; otherwise every file is
+// summarized again (the summary cache still spares the LLM calls for
+// content it has seen).
+func carryOver(base, agg *ai.RepoSummary, traverse TraverseOutput) (ai.Changelog, map[string]SummarizeFileOutput, error) {
+	blobs := make(map[string]string, len(traverse.Files))
+	for _, name := range traverse.Files {
+		body, err := readRepoFile(traverse.Path, name)
+		if err != nil {
+			return ai.Changelog{}, nil, fmt.Errorf("read %s: %w", name, err)
+		}
+		blobs[name] = gitBlobHash(body)
+	}
+	changes := ai.NewChangelog(base, agg.CommitSHA, traverse.Files, blobs)
+	carried := make(map[string]SummarizeFileOutput, len(changes.Unchanged))
+	if !sameFilePrompts(base, agg) {
+		return changes, carried, nil
+	}
+	unchanged := make(map[string]bool, len(changes.Unchanged))
+	for _, name := range changes.Unchanged {
+		unchanged[name] = true
 	}
+	// As with retries, usage stays with the run that paid for it.
+	for _, f := range base.Files {
+		if unchanged[f.Filename()] {
+			carried[f.Filename()] = SummarizeFileOutput{
+				Filename:   f.Filename(),
+				Summary:    f.Summary(),
+				Cached:     f.Cached(),
+				Model:      f.Model(),
+				Insights:   insightsFrom(f.Insights()),
+				Chunks:     f.Chunks(),
+				BlobHash:   blobs[f.Filename()],
+				Redactions: f.Redactions(),
+			}
+		}
+	}
+	return changes, carried, nil
+}
+
+// sameFilePrompts reports whether two runs render the same per-file
+// prompts over contents redacted by the same rules, so a summary one
+// wrote is one the other would have asked for.
+func sameFilePrompts(a, b *ai.RepoSummary) bool {
+	nameA, versionA := filePrompt(a)
+	nameB, versionB := filePrompt(b)
+	reduce := prompts.FileReduce
+	if nameA == prompts.FileSummaryJSON {
+		reduce = prompts.FileReduceJSON
+	}
+	return nameA == nameB && versionA == versionB && a.RedactionVersion == b.RedactionVersion &&
+		promptVersion(a, prompts.FileChunk) == promptVersion(b, prompts.FileChunk) &&
+		promptVersion(a, reduce) == promptVersion(b, reduce)
+}
+
+// recordChangelog persists the comparison with the base run onto the
+// aggregate. Best-effort like recordSelection: it only explains the run.
+func (d Deps) recordChangelog(ctx context.Context, summaryID uint, changes ai.Changelog) {
+	_ = d.Store.RecordChangelog(ctx, summaryID, changes)
+}
+
+// saveFileSummaries appends the non-empty results the aggregate doesn't
+// have yet, in traverse order, and publishes the resulting events.
+func (d Deps) saveFileSummaries(ctx context.Context, summaryID uint, results []SummarizeFileOutput, total int) error {
+	agg, err := d.Store.GetByID(ctx, summaryID)
+	if err != nil {
+		return fmt.Errorf("load aggregate: %w", err)
+	}
+	files := make([]ai.FileSummary, 0, len(results))
 	for _, r := range results {
-		fs, fsErr := ai.NewFileSummary(r.Filename, r.Summary)
-		if fsErr != nil {
-			return SummarizeFilesOutput{}, fmt.Errorf("file summary value object: %w", fsErr)
+		if r.Filename == "" || agg.HasFile(r.Filename) {
+			continue
+		}
+		fs, err := ai.NewFileSummary(r.Filename, r.Summary)
+		if err != nil {
+			return fmt.Errorf("file summary value object: %w", err)
+		}
+		if r.Cached {
+			fs = fs.AsCached()
 		}
-		if appendErr := agg.AppendFileSummary(fs, total); appendErr != nil {
-			return SummarizeFilesOutput{}, fmt.Errorf("append file: %w", appendErr)
+		fs = fs.WithUsage(r.Usage.domain()).WithModel(r.Model).WithInsights(r.Insights.domain()).WithChunks(r.Chunks).WithBlobHash(r.BlobHash).WithRedactions(r.Redactions)
+		if err := agg.AppendFileSummary(fs, total); err != nil {
+			return fmt.Errorf("append file: %w", err)
 		}
+		files = append(files, fs)
 	}
 	events := agg.PullEvents()
-	if saveErr := d.Store.Save(ctx, agg); saveErr != nil {
-		return SummarizeFilesOutput{}, fmt.Errorf("save after fan-out: %w", saveErr)
+	if len(events) == 0 {
+		return nil
+	}
+	// Appended, not saved: a cancel that landed during the fan-out stays
+	// and fails the append with ErrStatusChanged.
+	if err := d.Store.AppendFiles(ctx, summaryID, files); err != nil {
+		return fmt.Errorf("save after fan-out: %w", err)
 	}
 	_ = d.Progress.Publish(ctx, events...)
-
-	return SummarizeFilesOutput{Summaries: results}, nil
+	return nil
 }
 
 // AggregateStep asks the LLM to produce a repo-level summary by stitching
-// the per-file summaries into one prompt.
-func (d Deps) AggregateStep(ctx context.Context, in WorkflowInput, summaries SummarizeFilesOutput) (out AggregateOutput, err error) {
+// the per-file summaries into one prompt. The answer is streamed: text
+// reaches the frontend as `aggregate` / `streaming` step events while
+// the model writes it, and the full text is what StoreStep persists.
+//
+// traverse is only used to clean up the working copy if the run is
+// cancelled while the overview is being generated.
+func (d Deps) AggregateStep(ctx context.Context, in WorkflowInput, traverse TraverseOutput, summaries SummarizeFilesOutput, dirs DirectoriesOutput) (out AggregateOutput, err error) {
 	start := time.Now()
 	d.publishStep(ctx, in, aiapp.StepAggregate, aiapp.StepStateStarted, 0, "")
 	defer func() {
@@ -298,26 +969,83 @@ func (d Deps) AggregateStep(ctx context.Context, in WorkflowInput, summaries Sum
 		}
 		d.publishStep(ctx, in, aiapp.StepAggregate, state, time.Since(start).Milliseconds(), reason)
 	}()
+	defer d.cleanupOnCancel(in.SummaryID, traverse.Path, &err)
 
+	if err = d.checkCancelled(ctx, in.SummaryID); err != nil {
+		return AggregateOutput{}, err
+	}
 	if len(summaries.Summaries) == 0 {
 		return AggregateOutput{}, errors.New("aggregate: empty per-file summaries")
 	}
-	var b strings.Builder
-	b.WriteString("You are summarizing a Git repository. Below are short summaries of individual files. Produce a single 4-6 sentence overview describing what the repository does as a whole.\n\nFILE SUMMARIES:\n")
+	agg, err := d.Store.GetByID(ctx, in.SummaryID)
+	if err != nil {
+		return AggregateOutput{}, fmt.Errorf("load aggregate: %w", err)
+	}
+	vars := prompts.AggregateVars{Files: make([]prompts.FileSummaryVar, 0, len(summaries.Summaries))}
 	for _, s := range summaries.Summaries {
-		b.WriteString("- ")
-		b.WriteString(s.Filename)
-		b.WriteString(": ")
-		b.WriteString(s.Summary)
-		b.WriteString("\n")
+		vars.Files = append(vars.Files, prompts.FileSummaryVar{Filename: s.Filename, Summary: s.Summary})
+	}
+	for _, c := range dirs.Root.Children {
+		if c.Summary != "" {
+			vars.Directories = append(vars.Directories, prompts.FileSummaryVar{Filename: c.Path, Summary: c.Summary})
+		}
+	}
+	prompt, err := d.prompts().Render(prompts.Aggregate, promptVersion(agg, prompts.Aggregate), vars)
+	if err != nil {
+		return AggregateOutput{}, worker.NewNonRetryableError(err)
 	}
-	b.WriteString("\nOVERVIEW:")
 
-	overview, err := d.LLM.Generate(ctx, b.String())
+	fwd := newChunkForwarder(func(delta string) {
+		d.Progress.PublishStep(ctx, aiapp.StepProgress{
+			SummaryID: in.SummaryID,
+			UserID:    shared.UserID(in.UserID),
+			Step:      aiapp.StepAggregate,
+			State:     aiapp.StepStateStreaming,
+			Delta:     delta,
+		})
+	})
+	overview, err := d.stream(ctx, prompt, fwd.add)
+	fwd.flush()
 	if err != nil {
 		return AggregateOutput{}, fmt.Errorf("llm aggregate: %w", err)
 	}
-	return AggregateOutput{Summary: strings.TrimSpace(overview)}, nil
+	return AggregateOutput{Summary: strings.TrimSpace(overview.Text), Usage: usageFrom(overview.Usage)}, nil
+}
+
+// chunkFlushInterval bounds how often streamed text is published.
+// Providers emit a chunk per token; one SSE event each would flood the
+// per-user replay history and, with several replicas, the backplane.
+const chunkFlushInterval = 150 * time.Millisecond
+
+// chunkForwarder batches streamed chunks and hands them to publish at
+// most once per chunkFlushInterval. Not safe for concurrent use; LLM
+// clients call onChunk from a single goroutine.
+type chunkForwarder struct {
+	publish   func(delta string)
+	pending   strings.Builder
+	lastFlush time.Time
+}
+
+func newChunkForwarder(publish func(delta string)) *chunkForwarder {
+	return &chunkForwarder{publish: publish}
+}
+
+func (f *chunkForwarder) add(chunk string) {
+	f.pending.WriteString(chunk)
+	if time.Since(f.lastFlush) >= chunkFlushInterval {
+		f.flush()
+	}
+}
+
+// flush publishes whatever is still buffered. Called once more after
+// the stream ends so the tail isn't held back.
+func (f *chunkForwarder) flush() {
+	f.lastFlush = time.Now()
+	if f.pending.Len() == 0 {
+		return
+	}
+	f.publish(f.pending.String())
+	f.pending.Reset()
 }
 
 // StoreStep marks the aggregate as completed and persists the final
@@ -339,17 +1067,26 @@ func (d Deps) StoreStep(
 		}
 		d.publishStep(ctx, in, aiapp.StepStore, state, time.Since(start).Milliseconds(), reason)
 	}()
+	// A run cancelled after aggregate makes MarkCompleted fail below;
+	// this turns that into a clean, non-retried stop.
+	defer d.cleanupOnCancel(in.SummaryID, traverse.Path, &err)
 
 	agg, err := d.Store.GetByID(ctx, in.SummaryID)
 	if err != nil {
 		return StoreOutput{}, fmt.Errorf("load aggregate: %w", err)
 	}
 	now := time.Now().UTC()
+	usage := aggregateOut.Usage.domain()
+	agg.RecordUsage(usage)
 	if err = agg.MarkCompleted(aggregateOut.Summary, now); err != nil {
 		return StoreOutput{}, fmt.Errorf("mark completed: %w", err)
 	}
 	events := agg.PullEvents()
-	if err = d.Store.Save(ctx, agg); err != nil {
+	// Conditional on running: a cancel that landed after the load above
+	// wins, and cleanupOnCancel turns the error into a clean stop. The
+	// usage goes in the same write, so a retry after a failed one
+	// doesn't count it twice.
+	if err = d.Store.Complete(ctx, agg, usage); err != nil {
 		return StoreOutput{}, fmt.Errorf("save after complete: %w", err)
 	}
 	_ = d.Progress.Publish(ctx, events...)
@@ -361,8 +1098,9 @@ func (d Deps) StoreStep(
 }
 
 // HandleFailure marks the aggregate as failed and publishes the event.
-// Wired to Hatchet's workflow OnFailure hook. Uses context.Background()
-// because the hatchet.Context handed to the failure hook may already be
+// Wired to Hatchet's workflow OnFailure hook, and called by the River
+// engine when a step fails for good. Uses context.Background()
+// because the context handed to the failure hook may already be
 // cancelled by the time we get here — and we still need to write the
 // terminal state to the DB regardless.
 func (d Deps) HandleFailure(_ context.Context, in WorkflowInput, reason string) {
@@ -378,60 +1116,8 @@ func (d Deps) HandleFailure(_ context.Context, in WorkflowInput, reason string)
 		return
 	}
 	events := agg.PullEvents()
-	if err := d.Store.Save(ctx, agg); err != nil {
+	if err := d.Store.Transition(ctx, agg, ai.StatusPending, ai.StatusRunning); err != nil {
 		return
 	}
 	_ = d.Progress.Publish(ctx, events...)
 }
-
-// selectFiles walks the cloned repo and returns up to MaxFiles paths to
-// summarize. Filters by extension and skips obvious noise (.git, vendored
-// node_modules, lockfiles). Deterministic ordering — same repo state
-// yields the same list across reruns.
-func selectFiles(root string, maxFiles int, maxBytes int64) ([]string, error) {
-	if maxFiles <= 0 {
-		maxFiles = 25
-	}
-	include := map[string]struct{}{
-		".go": {}, ".ts": {}, ".tsx": {}, ".js": {}, ".jsx": {},
-		".py": {}, ".rs": {}, ".java": {}, ".rb": {}, ".sql": {},
-		".md": {}, ".yaml": {}, ".yml": {}, ".toml": {},
-	}
-	skipDir := map[string]struct{}{
-		".git": {}, "node_modules": {}, "vendor": {}, "dist": {},
-		"build": {}, ".next": {}, "target": {}, "__pycache__": {},
-	}
-	var picked []string
-	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil
-		}
-		if info.IsDir() {
-			if _, skip := skipDir[info.Name()]; skip {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-		if maxBytes > 0 && info.Size() > maxBytes {
-			return nil
-		}
-		ext := strings.ToLower(filepath.Ext(path))
-		if _, ok := include[ext]; !ok {
-			return nil
-		}
-		rel, err := filepath.Rel(root, path)
-		if err != nil {
-			return nil
-		}
-		picked = append(picked, rel)
-		return nil
-	})
-	if err != nil {
-		return nil, err
-	}
-	sort.Strings(picked)
-	if len(picked) > maxFiles {
-		picked = picked[:maxFiles]
-	}
-	return picked, nil
-}
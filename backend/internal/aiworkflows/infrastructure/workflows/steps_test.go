@@ -0,0 +1,264 @@
+package workflows
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	aiapp "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/application"
+	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
+	"github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/infrastructure/prompts"
+)
+
+func TestGitBlobHashMatchesGit(t *testing.T) {
+	// `printf 'hello\n' | git hash-object --stdin`
+	if got := gitBlobHash([]byte("hello\n")); got != "ce013625030ba8dba906f756967f9e9ca394464a" {
+		t.Errorf("gitBlobHash = %s", got)
+	}
+	// The empty blob has a well-known ID.
+	if got := gitBlobHash(nil); got != "e69de29bb2d1d6434b8b29ae775ad8c2e48c5391" {
+		t.Errorf("gitBlobHash(empty) = %s", got)
+	}
+}
+
+type fakeCache struct {
+	entries map[aiapp.SummaryCacheKey]string
+	err     error
+}
+
+func (c *fakeCache) Get(_ context.Context, key aiapp.SummaryCacheKey) (string, bool, error) {
+	if c.err != nil {
+		return "", false, c.err
+	}
+	s, ok := c.entries[key]
+	return s, ok, nil
+}
+
+func (c *fakeCache) Put(_ context.Context, key aiapp.SummaryCacheKey, summary string) error {
+	c.entries[key] = summary
+	return nil
+}
+
+// statusStore answers status checks: the run reads as running for the
+// first runningChecks loads and as cancelled after, as if the user
+// cancelled it partway through a step. Only GetByID is implemented.
+type statusStore struct {
+	aiapp.Store
+	mu            sync.Mutex
+	runningChecks int
+	checks        int
+}
+
+func (s *statusStore) GetByID(_ context.Context, id uint) (*ai.RepoSummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checks++
+	status := ai.StatusRunning
+	if s.checks > s.runningChecks {
+		status = ai.StatusCancelled
+	}
+	return &ai.RepoSummary{ID: id, Status: status}, nil
+}
+
+// running is a statusStore whose run is never cancelled.
+func running() *statusStore {
+	return &statusStore{runningChecks: math.MaxInt}
+}
+
+func TestCachedSummary(t *testing.T) {
+	key := aiapp.SummaryCacheKey{BlobHash: "abc", PromptVersion: "file-summary/v1", Model: "openrouter:m1"}
+	cache := &fakeCache{entries: map[aiapp.SummaryCacheKey]string{key: "does things"}}
+	d := Deps{Cache: cache}
+
+	if got, ok := d.cachedSummary(context.Background(), key); !ok || got != "does things" {
+		t.Errorf("hit = (%q, %v)", got, ok)
+	}
+	other := key
+	other.Model = "openrouter:m2"
+	if _, ok := d.cachedSummary(context.Background(), other); ok {
+		t.Errorf("a different model must miss")
+	}
+
+	cache.err = errors.New("db down")
+	if _, ok := d.cachedSummary(context.Background(), key); ok {
+		t.Errorf("a failing cache must count as a miss")
+	}
+	if _, ok := (Deps{}).cachedSummary(context.Background(), key); ok {
+		t.Errorf("no cache configured must miss")
+	}
+}
+
+func TestCacheKeysEntriesByTheAnsweringModel(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	llm := &scriptedLLM{answers: []string{"Entry point.", "Entry point."}}
+	cache := &fakeCache{entries: map[aiapp.SummaryCacheKey]string{}}
+	in := SummarizeFileInput{SummaryID: 1, Path: dir, Filename: "main.go", Total: 1}
+
+	// A fallback ("m") answered for the primary: the entry is its, and
+	// a lookup for the primary doesn't find it.
+	d := Deps{Store: running(), LLM: llm, Cache: cache, Model: "primary"}
+	for range 2 {
+		if out, err := d.SummarizeFileStep(ctx, in); err != nil || out.Cached || out.Model != "m" {
+			t.Fatalf("out = %+v, %v; want m's fresh answer", out, err)
+		}
+	}
+	for key := range cache.entries {
+		if key.Model != "m" {
+			t.Errorf("cached under %q, want the answering model", key.Model)
+		}
+	}
+
+	// With m as the primary the entry is served, and names its model.
+	d.Model = "m"
+	out, err := d.SummarizeFileStep(ctx, in)
+	if err != nil || !out.Cached || out.Model != "m" || out.Summary != "Entry point." {
+		t.Errorf("out = %+v, %v; want a cache hit written by m", out, err)
+	}
+	if len(llm.prompts) != 2 {
+		t.Errorf("LLM called %d times, want 2", len(llm.prompts))
+	}
+}
+
+func TestChunkForwarderBatchesAndFlushesTail(t *testing.T) {
+	var published []string
+	f := newChunkForwarder(func(delta string) { published = append(published, delta) })
+
+	// The first chunk goes out immediately; the rest arrive within the
+	// flush interval and are held back until the final flush.
+	for _, c := range []string{"The ", "repo ", "does ", "things."} {
+		f.add(c)
+	}
+	f.flush()
+
+	if strings.Join(published, "") != "The repo does things." {
+		t.Fatalf("published %q, want the full text", published)
+	}
+	if len(published) != 2 {
+		t.Errorf("published %d events, want 2 (first chunk, then the batched tail)", len(published))
+	}
+	f.flush()
+	if len(published) != 2 {
+		t.Error("flush with nothing pending published an event")
+	}
+}
+
+func TestCarryOverUnchangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("main.go", "package main\n")
+	write("util.go", "package main // edited\n")
+	write("new.go", "package main // new\n")
+
+	summary := func(name, text, body string) ai.FileSummary {
+		fs, err := ai.NewFileSummary(name, text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fs.WithModel("m1").WithBlobHash(gitBlobHash([]byte(body)))
+	}
+	base := &ai.RepoSummary{ID: 1, CommitSHA: "aaa", Files: []ai.FileSummary{
+		summary("main.go", "Entry point.", "package main\n"),
+		summary("util.go", "Helpers.", "package main\n"),
+		summary("gone.go", "Deleted since.", "package main\n"),
+	}}
+	agg := &ai.RepoSummary{ID: 2, BaseID: 1, CommitSHA: "bbb"}
+	traverse := TraverseOutput{Path: dir, Files: []string{"main.go", "util.go", "new.go"}}
+
+	changes, carried, err := carryOver(base, agg, traverse)
+	if err != nil {
+		t.Fatalf("carryOver: %v", err)
+	}
+	if !slices.Equal(changes.Unchanged, []string{"main.go"}) || !slices.Equal(changes.Modified, []string{"util.go"}) ||
+		!slices.Equal(changes.Added, []string{"new.go"}) || !slices.Equal(changes.Removed, []string{"gone.go"}) {
+		t.Errorf("changelog = %+v", changes)
+	}
+	if len(carried) != 1 || carried["main.go"].Summary != "Entry point." || carried["main.go"].BlobHash != gitBlobHash([]byte("package main\n")) {
+		t.Errorf("carried = %+v, want main.go only", carried)
+	}
+
+	// A base written with other file prompts carries nothing over, but
+	// the changelog still says what changed.
+	base.PromptVersions = map[string]string{"file-summary": "v0"}
+	changes, carried, err = carryOver(base, agg, traverse)
+	if err != nil || len(carried) != 0 || len(changes.Unchanged) != 1 {
+		t.Errorf("other prompts: carried = %v, changelog = %+v, err = %v", carried, changes, err)
+	}
+
+	// So does a base whose files went through other redaction rules:
+	// its summaries may have seen a secret the new rules catch.
+	base.PromptVersions = nil
+	base.RedactionVersion = "v1"
+	agg.RedactionVersion = "v2"
+	changes, carried, err = carryOver(base, agg, traverse)
+	if err != nil || len(carried) != 0 || len(changes.Unchanged) != 1 {
+		t.Errorf("other redaction: carried = %v, changelog = %+v, err = %v", carried, changes, err)
+	}
+}
+
+// rowStore serves GetByID from a fixed set of runs.
+type rowStore struct {
+	aiapp.Store
+	rows map[uint]*ai.RepoSummary
+}
+
+func (s rowStore) GetByID(_ context.Context, id uint) (*ai.RepoSummary, error) {
+	if r, ok := s.rows[id]; ok {
+		return r, nil
+	}
+	return nil, aiapp.ErrNotFound
+}
+
+func TestRetryReusesOnlySummariesOfTheSamePrompts(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "selection.json"), []byte(`{"file-summary": {"v1": 50, "v2": 50}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	registry := prompts.NewRegistry(dir)
+	started := func(id uint) *ai.RepoSummary {
+		return &ai.RepoSummary{ID: id, PromptVersions: map[string]string{
+			string(prompts.FileSummary): registry.Select(prompts.FileSummary, id),
+		}}
+	}
+	fs, err := ai.NewFileSummary("main.go", "Entry point.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed := started(1)
+	failed.Files = []ai.FileSummary{fs}
+
+	// Find a retry the split lands on the failed attempt's version, and
+	// one it lands on the other.
+	var same, other *ai.RepoSummary
+	for id := uint(2); same == nil || other == nil; id++ {
+		retry := started(id)
+		retry.RetryOf = failed.ID
+		if retry.PromptVersions[string(prompts.FileSummary)] == failed.PromptVersions[string(prompts.FileSummary)] {
+			same = retry
+		} else {
+			other = retry
+		}
+	}
+	d := Deps{Store: rowStore{rows: map[uint]*ai.RepoSummary{failed.ID: failed}}, Prompts: registry}
+
+	if got := d.reusableSummaries(context.Background(), same); got["main.go"].Summary != "Entry point." {
+		t.Errorf("same prompts reused %v, want main.go", got)
+	}
+	if got := d.reusableSummaries(context.Background(), other); len(got) != 0 {
+		t.Errorf("other prompts reused %v, want nothing", got)
+	}
+}
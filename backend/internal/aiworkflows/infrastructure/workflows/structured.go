@@ -0,0 +1,139 @@
+package workflows
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
+)
+
+// structuredSummary is the JSON object the file-summary-json prompt asks
+// for. The field names are the contract with the prompt template.
+type structuredSummary struct {
+	Summary              string     `json:"summary"`
+	Purpose              string     `json:"purpose"`
+	KeySymbols           stringList `json:"keySymbols"`
+	ExternalDependencies stringList `json:"externalDependencies"`
+	Risks                stringList `json:"risks"`
+}
+
+// Bounds applied while normalising, so one verbose answer can't bloat
+// the files JSONB column or the aggregate prompt.
+const (
+	maxStructuredItems   = 12
+	maxStructuredItemLen = 200
+)
+
+// stringList accepts what models actually send for a list of strings:
+// an array, a single string, or null. Non-string array elements are
+// kept in their JSON form rather than failing the whole answer.
+type stringList []string
+
+func (l *stringList) UnmarshalJSON(raw []byte) error {
+	var one string
+	if err := json.Unmarshal(raw, &one); err == nil {
+		*l = stringList{one}
+		return nil
+	}
+	var many []json.RawMessage
+	if err := json.Unmarshal(raw, &many); err != nil {
+		return fmt.Errorf("want a list of strings: %w", err)
+	}
+	out := make(stringList, 0, len(many))
+	for _, m := range many {
+		var s string
+		if err := json.Unmarshal(m, &s); err != nil {
+			s = string(m)
+		}
+		out = append(out, s)
+	}
+	*l = out
+	return nil
+}
+
+var (
+	codeFence     = regexp.MustCompile("(?s)^```[a-zA-Z]*\\s*(.*?)\\s*```$")
+	trailingComma = regexp.MustCompile(`,(\s*[}\]])`)
+)
+
+// parseStructuredSummary extracts, repairs and validates the JSON
+// object in an LLM answer. Repairs cover the common slips: Markdown
+// fences, prose around the object, trailing commas. The result is
+// normalised (trimmed, de-duplicated, bounded); an answer with neither
+// summary nor purpose is rejected.
+func parseStructuredSummary(raw string) (structuredSummary, error) {
+	text := strings.TrimSpace(raw)
+	if m := codeFence.FindStringSubmatch(text); m != nil {
+		text = m[1]
+	}
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return structuredSummary{}, errors.New("no JSON object in the answer")
+	}
+	text = text[start : end+1]
+
+	var out structuredSummary
+	if err := json.Unmarshal([]byte(text), &out); err != nil {
+		if err2 := json.Unmarshal([]byte(trailingComma.ReplaceAllString(text, "$1")), &out); err2 != nil {
+			return structuredSummary{}, fmt.Errorf("invalid JSON: %w", err)
+		}
+	}
+	out.Summary = strings.TrimSpace(out.Summary)
+	out.Purpose = strings.TrimSpace(out.Purpose)
+	if out.Summary == "" {
+		out.Summary = out.Purpose
+	}
+	if out.Summary == "" {
+		return structuredSummary{}, errors.New(`"summary" and "purpose" are both empty`)
+	}
+	out.KeySymbols = normaliseList(out.KeySymbols)
+	out.ExternalDependencies = normaliseList(out.ExternalDependencies)
+	out.Risks = normaliseList(out.Risks)
+	return out, nil
+}
+
+func normaliseList(in stringList) stringList {
+	seen := make(map[string]bool, len(in))
+	out := make(stringList, 0, len(in))
+	for _, s := range in {
+		s = strings.TrimSpace(s)
+		if r := []rune(s); len(r) > maxStructuredItemLen {
+			s = string(r[:maxStructuredItemLen])
+		}
+		if s == "" || seen[s] {
+			continue
+		}
+		seen[s] = true
+		out = append(out, s)
+		if len(out) == maxStructuredItems {
+			break
+		}
+	}
+	return out
+}
+
+func (s structuredSummary) insights() *Insights {
+	return insightsFrom(ai.FileInsights{
+		Purpose:              s.Purpose,
+		KeySymbols:           s.KeySymbols,
+		ExternalDependencies: s.ExternalDependencies,
+		Risks:                s.Risks,
+	})
+}
+
+// encode is the form cached and handed between tasks: the normalised
+// object, so a cache hit parses without repairs.
+func (s structuredSummary) encode() string {
+	raw, _ := json.Marshal(s)
+	return string(raw)
+}
+
+// repairPrompt re-asks for the JSON object after a malformed answer,
+// quoting what was wrong with it.
+func repairPrompt(prompt string, cause error) string {
+	return prompt + "\n\nYour previous reply could not be used (" + cause.Error() +
+		"). Reply again with only the JSON object described above."
+}
@@ -0,0 +1,114 @@
+package workflows
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	aiapp "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/application"
+	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
+)
+
+func TestParseStructuredSummaryRepairs(t *testing.T) {
+	cases := map[string]string{
+		"plain":          `{"summary":"Parses config.","keySymbols":["Load"]}`,
+		"fenced":         "```json\n{\"summary\":\"Parses config.\",\"keySymbols\":[\"Load\"]}\n```",
+		"prose around":   "Sure! Here it is:\n{\"summary\": \"Parses config.\", \"keySymbols\": [\"Load\"]}\nHope that helps.",
+		"trailing comma": `{"summary":"Parses config.","keySymbols":["Load",],}`,
+		"single string":  `{"summary":"Parses config.","keySymbols":"Load"}`,
+	}
+	for name, raw := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := parseStructuredSummary(raw)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if got.Summary != "Parses config." || !slices.Equal(got.KeySymbols, stringList{"Load"}) {
+				t.Errorf("got %+v", got)
+			}
+		})
+	}
+}
+
+func TestParseStructuredSummaryRejects(t *testing.T) {
+	for _, raw := range []string{
+		"This file parses config.",
+		`{"summary": "", "purpose": "  "}`,
+		`{"summary": "x", "risks": {"a": 1}}`,
+	} {
+		if _, err := parseStructuredSummary(raw); err == nil {
+			t.Errorf("parse(%q) succeeded, want an error", raw)
+		}
+	}
+}
+
+func TestParseStructuredSummaryNormalises(t *testing.T) {
+	raw := `{"purpose":" Loads config ","risks":[" a ","a","",7,"` + strings.Repeat("é", 300) + `"],` +
+		`"externalDependencies":["1","2","3","4","5","6","7","8","9","10","11","12","13"]}`
+	got, err := parseStructuredSummary(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Summary != "Loads config" {
+		t.Errorf("summary = %q, want the purpose as fallback", got.Summary)
+	}
+	if len(got.Risks) != 3 || got.Risks[0] != "a" || got.Risks[1] != "7" {
+		t.Errorf("risks = %q", got.Risks)
+	}
+	if n := len([]rune(got.Risks[2])); n != maxStructuredItemLen {
+		t.Errorf("long item has %d runes, want %d", n, maxStructuredItemLen)
+	}
+	if len(got.ExternalDependencies) != maxStructuredItems {
+		t.Errorf("kept %d dependencies, want %d", len(got.ExternalDependencies), maxStructuredItems)
+	}
+
+	// The cached form parses back to the same thing.
+	again, err := parseStructuredSummary(got.encode())
+	if err != nil || again.encode() != got.encode() {
+		t.Errorf("round trip = %+v, %v", again, err)
+	}
+}
+
+// scriptedLLM answers Generate calls in order and records the prompts.
+type scriptedLLM struct {
+	answers []string
+	prompts []string
+}
+
+func (l *scriptedLLM) Generate(_ context.Context, prompt string) (aiapp.Completion, error) {
+	l.prompts = append(l.prompts, prompt)
+	text := l.answers[0]
+	l.answers = l.answers[1:]
+	return aiapp.Completion{Text: text, Model: "m", Usage: ai.TokenUsage{PromptTokens: 10, CompletionTokens: 5}}, nil
+}
+
+func (l *scriptedLLM) Stream(ctx context.Context, prompt string, onChunk func(string)) (aiapp.Completion, error) {
+	c, err := l.Generate(ctx, prompt)
+	if err == nil {
+		onChunk(c.Text)
+	}
+	return c, err
+}
+
+func TestGenerateStructuredRepairsOnce(t *testing.T) {
+	llm := &scriptedLLM{answers: []string{"It loads config.", `{"summary":"Loads config."}`}}
+	got, completion, err := (Deps{LLM: llm}).generateStructured(context.Background(), "PROMPT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Summary != "Loads config." {
+		t.Errorf("summary = %q", got.Summary)
+	}
+	if len(llm.prompts) != 2 || !strings.HasPrefix(llm.prompts[1], "PROMPT\n\n") {
+		t.Errorf("prompts = %q, want the original prompt re-sent with the complaint", llm.prompts)
+	}
+	if completion.Usage.PromptTokens != 20 || completion.Usage.CompletionTokens != 10 {
+		t.Errorf("usage = %+v, want both calls counted", completion.Usage)
+	}
+
+	llm = &scriptedLLM{answers: []string{"nope", "still nope"}}
+	if _, _, err := (Deps{LLM: llm}).generateStructured(context.Background(), "PROMPT"); err == nil {
+		t.Error("two malformed answers succeeded, want an error")
+	}
+}
@@ -1,9 +1,18 @@
-// Package workflows wires the aiworkflows bounded context to the
+// Package workflows holds the summarize-repo steps and wires them to the
 // Hatchet Go SDK. This package is the ONLY place in the codebase that
 // imports `github.com/hatchet-dev/hatchet/sdks/go` — the application
-// layer talks to it through the HatchetEnqueuer port.
+// layer talks to it through the HatchetEnqueuer port. The jobs package
+// runs the same steps on River instead.
 package workflows
 
+import (
+	"fmt"
+
+	aiapp "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/application"
+	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
+	"github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/infrastructure/prompts"
+)
+
 // WorkflowInput is the JSON payload enqueued for one summarize-repo run.
 // Carries the minimum the steps need; the aggregate is loaded by
 // SummaryID inside the workflow so we don't ship mutable state across
@@ -12,6 +21,43 @@ type WorkflowInput struct {
 	SummaryID uint   `json:"summaryId"`
 	UserID    string `json:"userId"`
 	RepoURL   string `json:"repoUrl"`
+	// Ref is the branch, tag or commit to clone; empty for the default
+	// branch.
+	Ref string `json:"ref,omitempty"`
+	// Filter is the user's file filter; nil for none.
+	Filter *Filter `json:"filter,omitempty"`
+}
+
+// NewWorkflowInput is the payload for the run an enqueue request starts.
+func NewWorkflowInput(in aiapp.EnqueueSummarizeRepoInput) WorkflowInput {
+	return WorkflowInput{
+		SummaryID: in.SummaryID,
+		UserID:    in.UserID.String(),
+		RepoURL:   in.RepoURL.String(),
+		Ref:       in.Ref.String(),
+		Filter:    filterFrom(in.Filter),
+	}
+}
+
+// Filter is the wire form of ai.FileFilter.
+type Filter struct {
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+	Subdir  string   `json:"subdir,omitempty"`
+}
+
+func filterFrom(f ai.FileFilter) *Filter {
+	if f.IsZero() {
+		return nil
+	}
+	return &Filter{Include: f.Include, Exclude: f.Exclude, Subdir: f.Subdir}
+}
+
+func (f *Filter) domain() ai.FileFilter {
+	if f == nil {
+		return ai.FileFilter{}
+	}
+	return ai.FileFilter{Include: f.Include, Exclude: f.Exclude, Subdir: f.Subdir}
 }
 
 // CloneOutput is the result of the clone step. Path is the on-disk
@@ -29,19 +75,99 @@ type TraverseOutput struct {
 }
 
 // SummarizeFileInput is the typed payload for each child `summarize-file`
-// task spawned during fan-out.
+// task spawned during fan-out. PromptName and PromptVersion are the
+// run's per-file prompt (free-text or JSON); empty means the default
+// free-text prompt. ChunkVersion and ReduceVersion are the versions of
+// the prompts for files over the chunk budget; empty means the default.
 type SummarizeFileInput struct {
-	SummaryID uint   `json:"summaryId"`
-	UserID    string `json:"userId"`
-	Path      string `json:"path"`
-	Filename  string `json:"filename"`
-	Total     int    `json:"total"`
+	SummaryID     uint   `json:"summaryId"`
+	UserID        string `json:"userId"`
+	Path          string `json:"path"`
+	Filename      string `json:"filename"`
+	Total         int    `json:"total"`
+	PromptName    string `json:"promptName,omitempty"`
+	PromptVersion string `json:"promptVersion,omitempty"`
+	ChunkVersion  string `json:"chunkVersion,omitempty"`
+	ReduceVersion string `json:"reduceVersion,omitempty"`
+}
+
+// chunkPrompts resolves the chunk prompt's version and the reduce
+// prompt with its version; the reduce prompt answers in JSON when
+// structured.
+func (in SummarizeFileInput) chunkPrompts(structured bool) (chunkVersion string, reduce prompts.Name, reduceVersion string) {
+	chunkVersion = in.ChunkVersion
+	if chunkVersion == "" {
+		chunkVersion = prompts.Default(prompts.FileChunk)
+	}
+	reduce = prompts.FileReduce
+	if structured {
+		reduce = prompts.FileReduceJSON
+	}
+	reduceVersion = in.ReduceVersion
+	if reduceVersion == "" {
+		reduceVersion = prompts.Default(reduce)
+	}
+	return chunkVersion, reduce, reduceVersion
+}
+
+// chunkedPromptVersion is the summary cache's prompt identity for a
+// chunked file: both prompts plus the budget, which decides where the
+// chunks are cut.
+func (in SummarizeFileInput) chunkedPromptVersion(structured bool, budget int) string {
+	chunkVersion, reduce, reduceVersion := in.chunkPrompts(structured)
+	return fmt.Sprintf("%s/%s+%s/%s@%d", prompts.FileChunk, chunkVersion, reduce, reduceVersion, budget)
 }
 
-// SummarizeFileOutput is the produced summary for one file.
+// SummarizeFileOutput is the produced summary for one file. Cached is
+// set when the summary came from the summary cache instead of the LLM;
+// otherwise Model is the model that answered. Insights is set in
+// structured mode. Chunks is the number of chunks a large file was
+// summarized in; 0 when it fit one prompt. BlobHash is the git blob ID
+// of the content summarized.
 type SummarizeFileOutput struct {
-	Filename string `json:"filename"`
-	Summary  string `json:"summary"`
+	Filename string    `json:"filename"`
+	Summary  string    `json:"summary"`
+	Cached   bool      `json:"cached,omitempty"`
+	Model    string    `json:"model,omitempty"`
+	Usage    Usage     `json:"usage"`
+	Insights *Insights `json:"insights,omitempty"`
+	Chunks   int       `json:"chunks,omitempty"`
+	BlobHash string    `json:"blobHash,omitempty"`
+	// Redactions counts, per rule, the secrets redacted from the file
+	// before it was prompted.
+	Redactions map[string]int `json:"redactions,omitempty"`
+}
+
+// Insights is the wire form of ai.FileInsights passed between tasks.
+type Insights struct {
+	Purpose              string   `json:"purpose,omitempty"`
+	KeySymbols           []string `json:"keySymbols,omitempty"`
+	ExternalDependencies []string `json:"externalDependencies,omitempty"`
+	Risks                []string `json:"risks,omitempty"`
+}
+
+func insightsFrom(i ai.FileInsights) *Insights {
+	if i.IsZero() {
+		return nil
+	}
+	return &Insights{
+		Purpose:              i.Purpose,
+		KeySymbols:           i.KeySymbols,
+		ExternalDependencies: i.ExternalDependencies,
+		Risks:                i.Risks,
+	}
+}
+
+func (i *Insights) domain() ai.FileInsights {
+	if i == nil {
+		return ai.FileInsights{}
+	}
+	return ai.FileInsights{
+		Purpose:              i.Purpose,
+		KeySymbols:           i.KeySymbols,
+		ExternalDependencies: i.ExternalDependencies,
+		Risks:                i.Risks,
+	}
 }
 
 // SummarizeFilesOutput collects all per-file results once the fan-out
@@ -50,9 +176,46 @@ type SummarizeFilesOutput struct {
 	Summaries []SummarizeFileOutput `json:"summaries"`
 }
 
+// DirectoriesOutput is the summarize-dirs step's architecture outline.
+type DirectoriesOutput struct {
+	Root Directory `json:"root"`
+}
+
+// Directory is the wire form of ai.DirectorySummary.
+type Directory struct {
+	Path     string      `json:"path,omitempty"`
+	Summary  string      `json:"summary,omitempty"`
+	Files    []string    `json:"files,omitempty"`
+	Children []Directory `json:"children,omitempty"`
+}
+
+func directoryFrom(d ai.DirectorySummary) Directory {
+	out := Directory{Path: d.Path, Summary: d.Summary, Files: d.Files}
+	for _, c := range d.Children {
+		out.Children = append(out.Children, directoryFrom(c))
+	}
+	return out
+}
+
 // AggregateOutput is the LLM-produced repo-level summary text.
 type AggregateOutput struct {
 	Summary string `json:"summary"`
+	Usage   Usage  `json:"usage"`
+}
+
+// Usage is the wire form of ai.TokenUsage passed between tasks.
+type Usage struct {
+	PromptTokens     int     `json:"promptTokens,omitempty"`
+	CompletionTokens int     `json:"completionTokens,omitempty"`
+	CostUSD          float64 `json:"costUsd,omitempty"`
+}
+
+func usageFrom(u ai.TokenUsage) Usage {
+	return Usage{PromptTokens: u.PromptTokens, CompletionTokens: u.CompletionTokens, CostUSD: u.CostUSD}
+}
+
+func (u Usage) domain() ai.TokenUsage {
+	return ai.TokenUsage{PromptTokens: u.PromptTokens, CompletionTokens: u.CompletionTokens, CostUSD: u.CostUSD}
 }
 
 // StoreOutput is empty; the persistence step's side effect (RepoSummary
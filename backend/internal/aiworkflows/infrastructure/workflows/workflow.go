@@ -1,6 +1,9 @@
 package workflows
 
 import (
+	"context"
+	"fmt"
+
 	hatchet "github.com/hatchet-dev/hatchet/sdks/go"
 )
 
@@ -16,7 +19,8 @@ type Definitions struct {
 	FileTask *hatchet.StandaloneTask
 }
 
-// Build wires the DAG: clone → traverse → summarize-files → aggregate → store.
+// Build wires the DAG: clone → traverse → summarize-files →
+// summarize-dirs → aggregate → store.
 // The fan-out child `summarize-file` is registered as a separate
 // StandaloneTask so each per-file call gets its own checkpoint and its
 // own retry policy.
@@ -26,7 +30,9 @@ func Build(client *hatchet.Client, deps Deps) Definitions {
 	// limited, or fail upstream.
 	fileTask := client.NewStandaloneTask(
 		StandaloneFileTask,
-		deps.SummarizeFileStep,
+		func(ctx hatchet.Context, in SummarizeFileInput) (SummarizeFileOutput, error) {
+			return deps.SummarizeFileStep(ctx, in)
+		},
 		hatchet.WithRetries(5),
 		hatchet.WithRetryBackoff(2, 60),
 	)
@@ -62,22 +68,47 @@ func Build(client *hatchet.Client, deps Deps) Definitions {
 			if err := ctx.ParentOutput(traverseT, &traverse); err != nil {
 				return SummarizeFilesOutput{}, err
 			}
-			return deps.SummarizeFilesStep(ctx, ctx, in, traverse, fileTask)
+			return deps.SummarizeFilesStep(ctx, in, traverse, childRunner(ctx, fileTask))
 		},
 		hatchet.WithParents(traverseT),
 		hatchet.WithRetries(3),
 	)
 
+	dirsT := wf.NewTask(
+		"summarize-dirs",
+		func(ctx hatchet.Context, in WorkflowInput) (DirectoriesOutput, error) {
+			var traverse TraverseOutput
+			if err := ctx.ParentOutput(traverseT, &traverse); err != nil {
+				return DirectoriesOutput{}, err
+			}
+			var summaries SummarizeFilesOutput
+			if err := ctx.ParentOutput(summarizeT, &summaries); err != nil {
+				return DirectoriesOutput{}, err
+			}
+			return deps.SummarizeDirsStep(ctx, in, traverse, summaries)
+		},
+		hatchet.WithParents(summarizeT),
+		hatchet.WithRetries(3),
+	)
+
 	aggregateT := wf.NewTask(
 		"aggregate",
 		func(ctx hatchet.Context, in WorkflowInput) (AggregateOutput, error) {
+			var traverse TraverseOutput
+			if err := ctx.ParentOutput(traverseT, &traverse); err != nil {
+				return AggregateOutput{}, err
+			}
 			var summaries SummarizeFilesOutput
 			if err := ctx.ParentOutput(summarizeT, &summaries); err != nil {
 				return AggregateOutput{}, err
 			}
-			return deps.AggregateStep(ctx, in, summaries)
+			var dirs DirectoriesOutput
+			if err := ctx.ParentOutput(dirsT, &dirs); err != nil {
+				return AggregateOutput{}, err
+			}
+			return deps.AggregateStep(ctx, in, traverse, summaries, dirs)
 		},
-		hatchet.WithParents(summarizeT),
+		hatchet.WithParents(dirsT),
 		hatchet.WithRetries(3),
 	)
 
@@ -107,3 +138,19 @@ func Build(client *hatchet.Client, deps Deps) Definitions {
 
 	return Definitions{Workflow: wf, FileTask: fileTask}
 }
+
+// childRunner runs each file as a `summarize-file` child of the task
+// behind ctx.
+func childRunner(ctx hatchet.Context, fileTask *hatchet.StandaloneTask) FileRunner {
+	return func(_ context.Context, in SummarizeFileInput) (SummarizeFileOutput, error) {
+		res, err := fileTask.Run(ctx, in)
+		if err != nil {
+			return SummarizeFileOutput{}, err
+		}
+		var out SummarizeFileOutput
+		if err := res.Into(&out); err != nil {
+			return SummarizeFileOutput{}, fmt.Errorf("decode child %q: %w", in.Filename, err)
+		}
+		return out, nil
+	}
+}
@@ -7,6 +7,7 @@ package http
 import (
 	"encoding/json"
 	"errors"
+	"math"
 	"net/http"
 	"strconv"
 
@@ -16,6 +17,7 @@ import (
 	ai "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/domain"
 	"github.com/atilladeniz/next-go-pg/backend/internal/platform/middleware"
 	shared "github.com/atilladeniz/next-go-pg/backend/internal/shared/domain"
+	"github.com/atilladeniz/next-go-pg/backend/pkg/logger"
 )
 
 // Handler exposes the aiworkflows context's HTTP endpoints.
@@ -24,6 +26,12 @@ type Handler struct {
 	getRepoSummary *aiapp.GetRepoSummary
 	listSummaries  *aiapp.ListUserSummaries
 	deleteSummary  *aiapp.DeleteUserSummary
+	cancelSummary  *aiapp.CancelSummary
+	retrySummary   *aiapp.RetrySummary
+	attempts       *aiapp.GetAttemptHistory
+	quota          *aiapp.Quota
+	askQuestion    *aiapp.AskQuestion
+	answers        *aiapp.GetAnswerHistory
 }
 
 // NewHandler returns a Handler. Any use case may be nil; in that case
@@ -33,9 +41,67 @@ func NewHandler(summarize *aiapp.SummarizeRepo, get *aiapp.GetRepoSummary, list
 	return &Handler{summarizeRepo: summarize, getRepoSummary: get, listSummaries: list, deleteSummary: del}
 }
 
+// WithCancel enables POST /ai/summaries/{id}/cancel. Cancelling needs
+// the workflow engine, so composition only wires it when Hatchet is
+// up; without it the endpoint responds with 503.
+func (h *Handler) WithCancel(cancel *aiapp.CancelSummary) *Handler {
+	h.cancelSummary = cancel
+	return h
+}
+
+// WithRetry enables POST /ai/summaries/{id}/retry. Like cancel, it
+// needs the workflow engine and answers 503 when it isn't wired.
+func (h *Handler) WithRetry(retry *aiapp.RetrySummary) *Handler {
+	h.retrySummary = retry
+	return h
+}
+
+// WithAttemptHistory adds the retry chain to GET /ai/summaries/{id}.
+// It only reads the store, so it works in degraded mode too.
+func (h *Handler) WithAttemptHistory(history *aiapp.GetAttemptHistory) *Handler {
+	h.attempts = history
+	return h
+}
+
+// WithQuota enables GET /ai/quota. Enforcement itself lives in the
+// use cases; this only reports. Store-only, so wired in degraded mode.
+func (h *Handler) WithQuota(quota *aiapp.Quota) *Handler {
+	h.quota = quota
+	return h
+}
+
+// WithAsk enables POST /ai/summaries/{id}/ask. Answering needs the LLM
+// client, which composition only builds alongside Hatchet; without it
+// the endpoint responds with 503.
+func (h *Handler) WithAsk(ask *aiapp.AskQuestion) *Handler {
+	h.askQuestion = ask
+	return h
+}
+
+// WithAnswerHistory enables GET /ai/summaries/{id}/questions. Store-only,
+// so wired in degraded mode.
+func (h *Handler) WithAnswerHistory(history *aiapp.GetAnswerHistory) *Handler {
+	h.answers = history
+	return h
+}
+
 // SummarizeRepoRequest is the wire-level request body.
 type SummarizeRepoRequest struct {
 	RepoURL string `json:"repoUrl" example:"https://github.com/owner/repo"`
+	// Ref is the branch, tag or full commit SHA to summarize; the
+	// default branch when empty.
+	Ref string `json:"ref,omitempty" example:"main"`
+	// Include and Exclude are optional .gitignore-style patterns matched
+	// against repo-relative paths. Include replaces the default file
+	// types; Exclude always wins.
+	Include []string `json:"include,omitempty" example:"*.go,*.proto"`
+	Exclude []string `json:"exclude,omitempty" example:"**/*_gen.go"`
+	// Subdir restricts the run to one directory of the repository.
+	Subdir string `json:"subdir,omitempty" example:"backend"`
+	// Incremental builds on the latest completed run of the same
+	// repository: only files added or modified since are summarized
+	// again. Without such a run it is a full run.
+	Incremental bool `json:"incremental,omitempty"`
 }
 
 // SummarizeRepoResponse is the 202 body returned to the caller.
@@ -43,12 +109,51 @@ type SummarizeRepoResponse struct {
 	SummaryID uint   `json:"summaryId" example:"42"`
 	RunID     string `json:"runId" example:"a1b2c3d4-..."`
 	Status    string `json:"status" example:"pending"`
+	// BaseID is the run an incremental request builds on; absent for a
+	// full run.
+	BaseID uint `json:"baseId,omitempty" example:"41"`
 }
 
 // FileSummaryDTO mirrors the persisted per-file summary.
 type FileSummaryDTO struct {
 	Filename string `json:"filename"`
 	Summary  string `json:"summary"`
+	// Cached is true when the summary was served from the summary cache.
+	Cached bool `json:"cached"`
+	// Model is the LLM model that wrote the summary; with a provider
+	// fallback chain it may not be the first one configured. Absent for
+	// cached summaries.
+	Model string `json:"model,omitempty" example:"openai/gpt-oss-120b"`
+	// Usage is what generating this summary cost; absent for cached
+	// and reused summaries.
+	Usage *TokenUsageDTO `json:"usage,omitempty"`
+	// Insights is the structured breakdown of the file; absent for
+	// summaries generated in free-text mode.
+	Insights *FileInsightsDTO `json:"insights,omitempty"`
+	// Chunks is how many chunks the file was summarized in because it
+	// was too large for one prompt; absent when it fit.
+	Chunks int `json:"chunks,omitempty" example:"4"`
+	// Redactions is how many secrets were redacted from the file before
+	// it was sent to the LLM; absent when none.
+	Redactions int `json:"redactions,omitempty" example:"2"`
+}
+
+// FileInsightsDTO is what the structured per-file prompt extracts
+// besides the summary text.
+type FileInsightsDTO struct {
+	Purpose              string   `json:"purpose,omitempty" example:"HTTP handlers for the AI workflows"`
+	KeySymbols           []string `json:"keySymbols,omitempty" example:"Handler,NewHandler"`
+	ExternalDependencies []string `json:"externalDependencies,omitempty" example:"github.com/gorilla/mux"`
+	Risks                []string `json:"risks,omitempty" example:"no request body size limit"`
+}
+
+// TokenUsageDTO is LLM token usage and, when the provider reports it,
+// cost in US dollars.
+type TokenUsageDTO struct {
+	PromptTokens     int     `json:"promptTokens"`
+	CompletionTokens int     `json:"completionTokens"`
+	TotalTokens      int     `json:"totalTokens"`
+	CostUSD          float64 `json:"costUsd"`
 }
 
 // RepoSummaryResponse is the 200 body for GET /ai/summaries/{id}.
@@ -62,6 +167,122 @@ type RepoSummaryResponse struct {
 	StartedAt     string           `json:"startedAt,omitempty"`
 	CompletedAt   string           `json:"completedAt,omitempty"`
 	StepDurations map[string]int64 `json:"stepDurations,omitempty"`
+	// CacheHits and CacheMisses split Files by whether the summary was
+	// served from the summary cache or generated by the LLM.
+	CacheHits   int `json:"cacheHits"`
+	CacheMisses int `json:"cacheMisses"`
+	// Usage totals the run's LLM calls: generated file summaries plus
+	// the repo-level overview.
+	Usage TokenUsageDTO `json:"usage"`
+	// RetryOf is the failed run this one retried; 0 for a first attempt.
+	RetryOf uint `json:"retryOf,omitempty"`
+	// Ref is the branch, tag or commit the run asked for; absent for
+	// the default branch.
+	Ref string `json:"ref,omitempty" example:"main"`
+	// CommitSHA and CommitTime identify the commit that was actually
+	// summarized; known once the clone step has run.
+	CommitSHA  string `json:"commitSha,omitempty" example:"9fceb02d0ae598e95dc970b74767f19372d61af8"`
+	CommitTime string `json:"commitTime,omitempty" example:"2026-03-01T12:00:00Z"`
+	// PromptVersions maps prompt name (file-summary, aggregate) to the
+	// template version the run used. Absent for runs that haven't
+	// started or predate prompt versioning.
+	PromptVersions map[string]string `json:"promptVersions,omitempty"`
+	// Selection is how the summarized files were chosen. Absent until
+	// the traverse step has run.
+	Selection *FileSelectionDTO `json:"selection,omitempty"`
+	// Filter is the effective file filter: what the request asked for
+	// plus the repository's .summaryignore rules. Absent when neither
+	// restricts anything.
+	Filter *FileFilterDTO `json:"filter,omitempty"`
+	// Directories is the architecture outline: the root of the tree of
+	// directory summaries. Absent until the directory step has run.
+	Directories *DirectorySummaryDTO `json:"directories,omitempty"`
+	// Attempts is the run's retry chain, oldest first, including the
+	// run itself. Only returned by GET /ai/summaries/{id}.
+	Attempts []AttemptDTO `json:"attempts,omitempty"`
+	// BaseID is the run an incremental run builds on; absent for a full
+	// run.
+	BaseID uint `json:"baseId,omitempty" example:"41"`
+	// Changelog is how the files compare to the base run. Absent for
+	// full runs and until the summarize-files step has run.
+	Changelog *ChangelogDTO `json:"changelog,omitempty"`
+	// Redactions counts the secrets kept from the LLM. Absent when
+	// nothing was redacted.
+	Redactions *RedactionsDTO `json:"redactions,omitempty"`
+}
+
+// RedactionsDTO counts the secrets redacted from the run's files before
+// they were prompted. The secrets themselves are not stored.
+type RedactionsDTO struct {
+	Total int `json:"total" example:"3"`
+	// ByRule maps the rule that matched (aws-access-key, private-key,
+	// env-assignment, …) to how often it did.
+	ByRule map[string]int `json:"byRule"`
+}
+
+// ChangelogDTO lists what changed since the base run. Only added and
+// modified files were summarized again; unchanged ones were carried
+// over. Removed files were summarized by the base run but not this one.
+type ChangelogDTO struct {
+	BaseID     uint     `json:"baseId" example:"41"`
+	BaseCommit string   `json:"baseCommit,omitempty" example:"9fceb02d0ae598e95dc970b74767f19372d61af8"`
+	Added      []string `json:"added" example:"backend/internal/stats/cache.go"`
+	Modified   []string `json:"modified" example:"backend/internal/stats/handler.go"`
+	Removed    []string `json:"removed" example:"backend/internal/stats/legacy.go"`
+	Unchanged  []string `json:"unchanged" example:"backend/cmd/server/main.go"`
+}
+
+// FileSelectionDTO is the traverse step's file selection.
+type FileSelectionDTO struct {
+	// Strategy is "ranked" or "alphabetical".
+	Strategy string `json:"strategy" example:"ranked"`
+	// Files is in selection order, best candidate first.
+	Files []SelectedFileDTO `json:"files"`
+	// SkippedSecrets are files left out because they hold secrets
+	// (.env files, private keys, credential files).
+	SkippedSecrets []string `json:"skippedSecrets,omitempty" example:"config/secrets.yaml"`
+	// SkippedTooLarge are files left out for being over the per-file
+	// size ceiling (AI_MAX_FILE_BYTES).
+	SkippedTooLarge []string `json:"skippedTooLarge,omitempty" example:"testdata/fixtures.json"`
+}
+
+// DirectorySummaryDTO is one directory of the architecture outline.
+// The root has an empty path and no summary (the run's summary is the
+// overview); directories that held only one subdirectory are folded
+// into it, so a path can span several levels.
+type DirectorySummaryDTO struct {
+	Path    string `json:"path" example:"backend/internal"`
+	Summary string `json:"summary,omitempty"`
+	// Files are the summarized files directly in the directory.
+	Files    []string              `json:"files,omitempty" example:"backend/go.mod"`
+	Children []DirectorySummaryDTO `json:"children,omitempty"`
+}
+
+// FileFilterDTO is the file filter a run applied.
+type FileFilterDTO struct {
+	Include []string `json:"include,omitempty" example:"*.go"`
+	Exclude []string `json:"exclude,omitempty" example:"**/*_gen.go"`
+	Subdir  string   `json:"subdir,omitempty" example:"backend"`
+	// SummaryIgnore are the rules read from the repository's
+	// .summaryignore; known once the traverse step has run.
+	SummaryIgnore []string `json:"summaryIgnore,omitempty" example:"docs/"`
+}
+
+// SelectedFileDTO is one selected file and why it was picked.
+type SelectedFileDTO struct {
+	Filename string `json:"filename" example:"cmd/server/main.go"`
+	Reason   string `json:"reason" example:"entry point; imported by 3 files"`
+}
+
+// AttemptDTO is one run in a retry chain.
+type AttemptDTO struct {
+	ID          uint   `json:"id"`
+	Attempt     int    `json:"attempt" example:"1"`
+	Status      string `json:"status"`
+	FileCount   int    `json:"fileCount"`
+	FailReason  string `json:"failReason,omitempty"`
+	StartedAt   string `json:"startedAt,omitempty"`
+	CompletedAt string `json:"completedAt,omitempty"`
 }
 
 // RepoSummaryListItem is the compact projection returned by GET /ai/summaries.
@@ -80,6 +301,55 @@ type RepoSummaryListResponse struct {
 	Items []RepoSummaryListItem `json:"items"`
 }
 
+// QuotaResponse is the 200 body for GET /ai/quota. A limit of 0 means
+// unlimited.
+type QuotaResponse struct {
+	Limits        QuotaLimitsDTO `json:"limits"`
+	Usage         QuotaUsageDTO  `json:"usage"`
+	DayResetsAt   string         `json:"dayResetsAt" example:"2026-05-02T00:00:00Z"`
+	MonthResetsAt string         `json:"monthResetsAt" example:"2026-06-01T00:00:00Z"`
+}
+
+// QuotaLimitsDTO mirrors the configured per-user limits.
+type QuotaLimitsDTO struct {
+	MaxConcurrentRuns  int `json:"maxConcurrentRuns" example:"2"`
+	MaxRunsPerDay      int `json:"maxRunsPerDay" example:"20"`
+	MonthlyTokenBudget int `json:"monthlyTokenBudget" example:"1000000"`
+}
+
+// QuotaUsageDTO is the user's consumption in the current windows.
+type QuotaUsageDTO struct {
+	ActiveRuns      int `json:"activeRuns"`
+	RunsToday       int `json:"runsToday"`
+	TokensThisMonth int `json:"tokensThisMonth"`
+}
+
+// AskRequest is the body of POST /ai/summaries/{id}/ask.
+type AskRequest struct {
+	Question string `json:"question" example:"Where is authentication handled?"`
+}
+
+// AnswerDTO is one question asked about a run and its answer.
+type AnswerDTO struct {
+	ID       uint   `json:"id"`
+	Question string `json:"question" example:"Where is authentication handled?"`
+	Answer   string `json:"answer" example:"Sessions are validated in the auth middleware [backend/internal/platform/middleware/auth.go]."`
+	// Citations are the paths the answer cites, in order of first
+	// mention; directories end in a slash.
+	Citations []string `json:"citations" example:"backend/internal/platform/middleware/auth.go"`
+	// Sources are the summaries the answer was grounded on, best match
+	// first.
+	Sources   []string       `json:"sources" example:"backend/internal/platform/middleware/auth.go,backend/internal/auth/"`
+	Model     string         `json:"model,omitempty" example:"openai/gpt-oss-120b"`
+	Usage     *TokenUsageDTO `json:"usage,omitempty"`
+	CreatedAt string         `json:"createdAt" example:"2026-05-01T12:00:00Z"`
+}
+
+// AnswerListResponse is the 200 body for GET /ai/summaries/{id}/questions.
+type AnswerListResponse struct {
+	Items []AnswerDTO `json:"items"`
+}
+
 // ErrorResponse is the aiworkflows error envelope.
 type ErrorResponse struct {
 	Error string `json:"error" example:"invalid repo url"`
@@ -87,7 +357,7 @@ type ErrorResponse struct {
 
 // SummarizeRepo godoc
 // @Summary  Trigger a repository summarization workflow
-// @Description Enqueues a Hatchet workflow that clones the repository, summarises individual files via the configured LLM provider (OpenRouter), and produces a repo-level summary.
+// @Description Enqueues a Hatchet workflow that clones the repository, summarises individual files via the configured LLM provider (OpenRouter), and produces a repo-level summary. An optional ref picks the branch, tag or commit (default branch otherwise); optional include/exclude patterns and a subdirectory narrow the files considered; a .summaryignore at the repository root is honoured too. With incremental set, the run builds on the caller's latest completed run of the same repository and only re-summarizes files added or modified since.
 // @Tags     ai
 // @Accept   json
 // @Produce  json
@@ -95,6 +365,7 @@ type ErrorResponse struct {
 // @Success  202 {object} SummarizeRepoResponse
 // @Failure  400 {object} ErrorResponse
 // @Failure  401 {object} ErrorResponse
+// @Failure  429 {object} ErrorResponse "Per-user quota exhausted; see Retry-After"
 // @Failure  503 {object} ErrorResponse
 // @Security BearerAuth
 // @Router   /ai/summarize-repo [post]
@@ -122,10 +393,18 @@ func (h *Handler) SummarizeRepo(w http.ResponseWriter, r *http.Request) {
 	}
 
 	out, err := h.summarizeRepo.Execute(r.Context(), aiapp.SummarizeRepoInput{
-		UserID:  uid,
-		RepoURL: req.RepoURL,
+		UserID:      uid,
+		RepoURL:     req.RepoURL,
+		Ref:         req.Ref,
+		Include:     req.Include,
+		Exclude:     req.Exclude,
+		Subdir:      req.Subdir,
+		Incremental: req.Incremental,
 	})
 	if err != nil {
+		if writeQuotaExceeded(w, err) {
+			return
+		}
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -134,6 +413,7 @@ func (h *Handler) SummarizeRepo(w http.ResponseWriter, r *http.Request) {
 		SummaryID: out.SummaryID,
 		RunID:     out.RunID,
 		Status:    string(ai.StatusPending),
+		BaseID:    out.BaseID,
 	})
 }
 
@@ -187,7 +467,17 @@ func (h *Handler) GetRepoSummary(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, toResponse(agg))
+	resp := toResponse(agg)
+	if h.attempts != nil {
+		history, err := h.attempts.Execute(r.Context(), agg)
+		if err != nil {
+			// The run itself loaded fine; answer without the history.
+			logger.Warn().Err(err).Uint("summary_id", agg.ID).Msg("Failed to load attempt history")
+		} else {
+			resp.Attempts = toAttempts(history)
+		}
+	}
+	writeJSON(w, resp)
 }
 
 // ListRepoSummaries godoc
@@ -291,19 +581,414 @@ func (h *Handler) DeleteRepoSummary(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// CancelRepoSummary godoc
+// @Summary  Cancel a running repository summarization
+// @Description Stops a pending or running run owned by the authenticated user: the run is marked cancelled and its workflow run is cancelled in Hatchet. Files not yet summarised are skipped. Returns 404 for missing rows AND cross-user cancels, 409 when the run has already finished.
+// @Tags     ai
+// @Produce  json
+// @Param    id path integer true "Summary ID"
+// @Success  200 {object} RepoSummaryResponse
+// @Failure  400 {object} ErrorResponse
+// @Failure  401 {object} ErrorResponse
+// @Failure  404 {object} ErrorResponse
+// @Failure  409 {object} ErrorResponse
+// @Failure  503 {object} ErrorResponse
+// @Security BearerAuth
+// @Router   /ai/summaries/{id}/cancel [post]
+func (h *Handler) CancelRepoSummary(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if h.cancelSummary == nil {
+		writeError(w, http.StatusServiceUnavailable, "ai workflows not configured")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id64, err := strconv.ParseUint(vars["id"], 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	uid, err := shared.NewUserID(user.ID)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "invalid user id")
+		return
+	}
+
+	agg, err := h.cancelSummary.Execute(r.Context(), aiapp.CancelSummaryInput{
+		UserID:    uid,
+		SummaryID: uint(id64),
+	})
+	switch {
+	case errors.Is(err, aiapp.ErrNotFound):
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	case errors.Is(err, aiapp.ErrAlreadyTerminal):
+		writeError(w, http.StatusConflict, "summary already finished")
+		return
+	case err != nil && agg == nil:
+		writeError(w, http.StatusInternalServerError, "failed to cancel summary")
+		return
+	case err != nil:
+		// The run is already cancelled in the database and its steps
+		// stop on their own; only the engine-side cancel failed.
+		logger.Warn().Err(err).Uint("summary_id", agg.ID).Msg("Workflow run cancel failed; run stops at its next status check")
+	}
+
+	writeJSON(w, toResponse(agg))
+}
+
+// RetryRepoSummary godoc
+// @Summary  Retry a failed repository summarization
+// @Description Starts a new attempt for a failed run owned by the authenticated user. The attempt is linked to the failed run and reuses the file summaries it already produced, so only the missing files and the repo-level overview call the LLM again. Returns 404 for missing rows AND cross-user retries, 409 when the run did not fail.
+// @Tags     ai
+// @Produce  json
+// @Param    id path integer true "Summary ID"
+// @Success  202 {object} SummarizeRepoResponse
+// @Failure  400 {object} ErrorResponse
+// @Failure  401 {object} ErrorResponse
+// @Failure  404 {object} ErrorResponse
+// @Failure  409 {object} ErrorResponse
+// @Failure  429 {object} ErrorResponse "Per-user quota exhausted; see Retry-After"
+// @Failure  503 {object} ErrorResponse
+// @Security BearerAuth
+// @Router   /ai/summaries/{id}/retry [post]
+func (h *Handler) RetryRepoSummary(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if h.retrySummary == nil {
+		writeError(w, http.StatusServiceUnavailable, "ai workflows not configured")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id64, err := strconv.ParseUint(vars["id"], 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	uid, err := shared.NewUserID(user.ID)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "invalid user id")
+		return
+	}
+
+	out, err := h.retrySummary.Execute(r.Context(), aiapp.RetrySummaryInput{
+		UserID:    uid,
+		SummaryID: uint(id64),
+	})
+	switch {
+	case errors.Is(err, aiapp.ErrNotFound):
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	case errors.Is(err, aiapp.ErrNotRetryable):
+		writeError(w, http.StatusConflict, "only failed summaries can be retried")
+		return
+	case writeQuotaExceeded(w, err):
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, "failed to retry summary")
+		return
+	}
+
+	writeJSONStatus(w, http.StatusAccepted, SummarizeRepoResponse{
+		SummaryID: out.SummaryID,
+		RunID:     out.RunID,
+		Status:    string(ai.StatusPending),
+	})
+}
+
+// AskQuestion godoc
+// @Summary  Ask a question about a repository summary
+// @Description Answers a question about a completed run owned by the authenticated user. The run's file and directory summaries are ranked against the question (BM25), the best matches go to the LLM together with the overview and the last few questions, and the answer cites the paths it relies on. The question and answer are stored with the run. Answers count against the monthly token budget. Returns 404 for missing rows AND cross-user questions, 409 when the run hasn't completed.
+// @Tags     ai
+// @Accept   json
+// @Produce  json
+// @Param    id path integer true "Summary ID"
+// @Param    request body AskRequest true "Question"
+// @Success  200 {object} AnswerDTO
+// @Failure  400 {object} ErrorResponse
+// @Failure  401 {object} ErrorResponse
+// @Failure  404 {object} ErrorResponse
+// @Failure  409 {object} ErrorResponse
+// @Failure  429 {object} ErrorResponse "Monthly token budget exhausted; see Retry-After"
+// @Failure  503 {object} ErrorResponse
+// @Security BearerAuth
+// @Router   /ai/summaries/{id}/ask [post]
+func (h *Handler) AskQuestion(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if h.askQuestion == nil {
+		writeError(w, http.StatusServiceUnavailable, "ai workflows not configured")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id64, err := strconv.ParseUint(vars["id"], 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	var req AskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request")
+		return
+	}
+
+	uid, err := shared.NewUserID(user.ID)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "invalid user id")
+		return
+	}
+
+	answer, err := h.askQuestion.Execute(r.Context(), aiapp.AskQuestionInput{
+		UserID:    uid,
+		SummaryID: uint(id64),
+		Question:  req.Question,
+	})
+	switch {
+	case errors.Is(err, ai.ErrInvalidQuestion):
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, aiapp.ErrNotFound):
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	case errors.Is(err, aiapp.ErrNotCompleted):
+		writeError(w, http.StatusConflict, "summary not completed")
+		return
+	case writeQuotaExceeded(w, err):
+		return
+	case err != nil:
+		logger.Warn().Err(err).Uint64("summary_id", id64).Msg("Failed to answer question")
+		writeError(w, http.StatusInternalServerError, "failed to answer question")
+		return
+	}
+
+	writeJSON(w, toAnswer(*answer))
+}
+
+// ListQuestions godoc
+// @Summary  List the questions asked about a repository summary
+// @Description Returns up to 50 of the most recent questions asked about a run owned by the authenticated user, with their answers, oldest first. Cross-user reads return 404.
+// @Tags     ai
+// @Produce  json
+// @Param    id path integer true "Summary ID"
+// @Success  200 {object} AnswerListResponse
+// @Failure  400 {object} ErrorResponse
+// @Failure  401 {object} ErrorResponse
+// @Failure  404 {object} ErrorResponse
+// @Failure  503 {object} ErrorResponse
+// @Security BearerAuth
+// @Router   /ai/summaries/{id}/questions [get]
+func (h *Handler) ListQuestions(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if h.answers == nil {
+		writeError(w, http.StatusServiceUnavailable, "ai workflows not configured")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id64, err := strconv.ParseUint(vars["id"], 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	uid, err := shared.NewUserID(user.ID)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "invalid user id")
+		return
+	}
+
+	answers, err := h.answers.Execute(r.Context(), aiapp.GetAnswerHistoryInput{
+		UserID:    uid,
+		SummaryID: uint(id64),
+	})
+	if err != nil {
+		if errors.Is(err, aiapp.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to load questions")
+		return
+	}
+	items := make([]AnswerDTO, 0, len(answers))
+	for _, a := range answers {
+		items = append(items, toAnswer(a))
+	}
+	writeJSON(w, AnswerListResponse{Items: items})
+}
+
+// GetQuota godoc
+// @Summary  Get the user's AI summarization quota
+// @Description Reports the authenticated user's per-user limits (0 = unlimited) and current usage: runs still pending or running, runs started today, and tokens used this month. Days and months are UTC calendar windows.
+// @Tags     ai
+// @Produce  json
+// @Success  200 {object} QuotaResponse
+// @Failure  401 {object} ErrorResponse
+// @Failure  503 {object} ErrorResponse
+// @Security BearerAuth
+// @Router   /ai/quota [get]
+func (h *Handler) GetQuota(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if h.quota == nil {
+		writeError(w, http.StatusServiceUnavailable, "ai workflows not configured")
+		return
+	}
+	uid, err := shared.NewUserID(user.ID)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "invalid user id")
+		return
+	}
+
+	st, err := h.quota.Status(r.Context(), uid)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load quota")
+		return
+	}
+	writeJSON(w, QuotaResponse{
+		Limits: QuotaLimitsDTO{
+			MaxConcurrentRuns:  st.Limits.MaxConcurrentRuns,
+			MaxRunsPerDay:      st.Limits.MaxRunsPerDay,
+			MonthlyTokenBudget: st.Limits.MonthlyTokenBudget,
+		},
+		Usage: QuotaUsageDTO{
+			ActiveRuns:      st.Usage.ActiveRuns,
+			RunsToday:       st.Usage.RunsToday,
+			TokensThisMonth: st.Usage.TokensThisMonth,
+		},
+		DayResetsAt:   st.DayReset.UTC().Format("2006-01-02T15:04:05Z"),
+		MonthResetsAt: st.MonthReset.UTC().Format("2006-01-02T15:04:05Z"),
+	})
+}
+
+// writeQuotaExceeded answers 429 with a Retry-After in whole seconds
+// when err is a quota error, and reports whether it did.
+func writeQuotaExceeded(w http.ResponseWriter, err error) bool {
+	var qe *aiapp.QuotaExceededError
+	if !errors.As(err, &qe) {
+		return false
+	}
+	secs := int(math.Ceil(qe.RetryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(max(secs, 1)))
+	writeError(w, http.StatusTooManyRequests, qe.Error())
+	return true
+}
+
+func toAttempts(history []*ai.RepoSummary) []AttemptDTO {
+	out := make([]AttemptDTO, 0, len(history))
+	for i, a := range history {
+		dto := AttemptDTO{
+			ID:         a.ID,
+			Attempt:    i + 1,
+			Status:     a.Status.String(),
+			FileCount:  len(a.Files),
+			FailReason: a.FailReason,
+		}
+		if !a.StartedAt.IsZero() {
+			dto.StartedAt = a.StartedAt.UTC().Format("2006-01-02T15:04:05Z")
+		}
+		if !a.CompletedAt.IsZero() {
+			dto.CompletedAt = a.CompletedAt.UTC().Format("2006-01-02T15:04:05Z")
+		}
+		out = append(out, dto)
+	}
+	return out
+}
+
 func toResponse(s *ai.RepoSummary) RepoSummaryResponse {
 	files := make([]FileSummaryDTO, 0, len(s.Files))
 	for _, f := range s.Files {
-		files = append(files, FileSummaryDTO{Filename: f.Filename(), Summary: f.Summary()})
+		dto := FileSummaryDTO{Filename: f.Filename(), Summary: f.Summary(), Cached: f.Cached(), Model: f.Model(), Chunks: f.Chunks()}
+		for _, n := range f.Redactions() {
+			dto.Redactions += n
+		}
+		if u := f.Usage(); !u.IsZero() {
+			usage := toUsage(u)
+			dto.Usage = &usage
+		}
+		if i := f.Insights(); !i.IsZero() {
+			dto.Insights = &FileInsightsDTO{
+				Purpose:              i.Purpose,
+				KeySymbols:           i.KeySymbols,
+				ExternalDependencies: i.ExternalDependencies,
+				Risks:                i.Risks,
+			}
+		}
+		files = append(files, dto)
 	}
 	resp := RepoSummaryResponse{
-		ID:         s.ID,
-		RepoURL:    s.RepoURL.String(),
-		Status:     s.Status.String(),
-		Files:      files,
-		Summary:    s.Summary,
-		FailReason: s.FailReason,
+		ID:             s.ID,
+		RepoURL:        s.RepoURL.String(),
+		Status:         s.Status.String(),
+		Ref:            s.Ref.String(),
+		CommitSHA:      s.CommitSHA,
+		Files:          files,
+		Summary:        s.Summary,
+		FailReason:     s.FailReason,
+		RetryOf:        s.RetryOf,
+		Usage:          toUsage(s.Usage),
+		PromptVersions: s.PromptVersions,
+	}
+	if s.Selection.Strategy != "" {
+		sel := &FileSelectionDTO{
+			Strategy:        s.Selection.Strategy,
+			Files:           make([]SelectedFileDTO, 0, len(s.Selection.Files)),
+			SkippedSecrets:  s.Selection.SkippedSecrets,
+			SkippedTooLarge: s.Selection.SkippedTooLarge,
+		}
+		for _, f := range s.Selection.Files {
+			sel.Files = append(sel.Files, SelectedFileDTO{Filename: f.Path, Reason: f.Reason})
+		}
+		resp.Selection = sel
+	}
+	if !s.Filter.IsZero() || len(s.Selection.IgnorePatterns) > 0 {
+		resp.Filter = &FileFilterDTO{
+			Include:       s.Filter.Include,
+			Exclude:       s.Filter.Exclude,
+			Subdir:        s.Filter.Subdir,
+			SummaryIgnore: s.Selection.IgnorePatterns,
+		}
+	}
+	if !s.CommitTime.IsZero() {
+		resp.CommitTime = s.CommitTime.UTC().Format("2006-01-02T15:04:05Z")
+	}
+	if !s.Directories.IsZero() {
+		dirs := toDirectory(s.Directories)
+		resp.Directories = &dirs
+	}
+	resp.BaseID = s.BaseID
+	if !s.Changelog.IsZero() {
+		resp.Changelog = toChangelog(s.Changelog)
+	}
+	if counts := s.Redactions(); counts != nil {
+		r := &RedactionsDTO{ByRule: counts}
+		for _, n := range counts {
+			r.Total += n
+		}
+		resp.Redactions = r
 	}
+	resp.CacheHits = s.CacheHits()
+	resp.CacheMisses = len(s.Files) - resp.CacheHits
 	if !s.StartedAt.IsZero() {
 		resp.StartedAt = s.StartedAt.UTC().Format("2006-01-02T15:04:05Z")
 	}
@@ -313,6 +998,63 @@ func toResponse(s *ai.RepoSummary) RepoSummaryResponse {
 	return resp
 }
 
+func toChangelog(c ai.Changelog) *ChangelogDTO {
+	orEmpty := func(paths []string) []string {
+		if paths == nil {
+			return []string{}
+		}
+		return paths
+	}
+	return &ChangelogDTO{
+		BaseID:     c.BaseID,
+		BaseCommit: c.BaseCommit,
+		Added:      orEmpty(c.Added),
+		Modified:   orEmpty(c.Modified),
+		Removed:    orEmpty(c.Removed),
+		Unchanged:  orEmpty(c.Unchanged),
+	}
+}
+
+func toAnswer(a ai.Answer) AnswerDTO {
+	dto := AnswerDTO{
+		ID:        a.ID,
+		Question:  a.Question,
+		Answer:    a.Text,
+		Citations: a.Citations,
+		Sources:   a.Sources,
+		Model:     a.Model,
+		CreatedAt: a.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"),
+	}
+	if dto.Citations == nil {
+		dto.Citations = []string{}
+	}
+	if dto.Sources == nil {
+		dto.Sources = []string{}
+	}
+	if !a.Usage.IsZero() {
+		usage := toUsage(a.Usage)
+		dto.Usage = &usage
+	}
+	return dto
+}
+
+func toDirectory(d ai.DirectorySummary) DirectorySummaryDTO {
+	dto := DirectorySummaryDTO{Path: d.Path, Summary: d.Summary, Files: d.Files}
+	for _, c := range d.Children {
+		dto.Children = append(dto.Children, toDirectory(c))
+	}
+	return dto
+}
+
+func toUsage(u ai.TokenUsage) TokenUsageDTO {
+	return TokenUsageDTO{
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		TotalTokens:      u.TotalTokens(),
+		CostUSD:          u.CostUSD,
+	}
+}
+
 func writeJSON(w http.ResponseWriter, payload any) {
 	writeJSONStatus(w, http.StatusOK, payload)
 }
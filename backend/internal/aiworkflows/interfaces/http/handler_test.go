@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 
@@ -40,16 +41,83 @@ func newFakeStore() *fakeStore {
 
 func (s *fakeStore) Create(_ context.Context, agg *ai.RepoSummary) error {
 	agg.ID = s.nextID
+	agg.CreatedAt = time.Now()
 	s.nextID++
 	s.rows[agg.ID] = agg
 	return nil
 }
 
-func (s *fakeStore) Save(_ context.Context, agg *ai.RepoSummary) error {
+func (s *fakeStore) AttachRun(_ context.Context, id uint, runID string) error {
+	if row, ok := s.rows[id]; ok {
+		row.AttachRun(runID)
+	}
+	return nil
+}
+
+func (s *fakeStore) Transition(_ context.Context, agg *ai.RepoSummary, _ ...ai.Status) error {
 	s.rows[agg.ID] = agg
 	return nil
 }
 
+func (s *fakeStore) AppendFiles(_ context.Context, id uint, files []ai.FileSummary) error {
+	row, ok := s.rows[id]
+	if !ok || row.Status != ai.StatusRunning {
+		return aiapp.ErrStatusChanged
+	}
+	for _, fs := range files {
+		_ = row.AppendFileSummary(fs, 0)
+	}
+	row.PullEvents()
+	return nil
+}
+
+func (s *fakeStore) AddUsage(_ context.Context, id uint, u ai.TokenUsage) error {
+	if row, ok := s.rows[id]; ok {
+		row.RecordUsage(u)
+	}
+	return nil
+}
+
+func (s *fakeStore) Complete(_ context.Context, agg *ai.RepoSummary, _ ai.TokenUsage) error {
+	s.rows[agg.ID] = agg
+	return nil
+}
+
+func (s *fakeStore) RecordChangelog(_ context.Context, id uint, c ai.Changelog) error {
+	if row, ok := s.rows[id]; ok {
+		row.RecordChangelog(c)
+	}
+	return nil
+}
+
+func (s *fakeStore) RecordCommit(_ context.Context, id uint, sha string, at time.Time) error {
+	if row, ok := s.rows[id]; ok {
+		row.RecordCommit(sha, at)
+	}
+	return nil
+}
+
+func (s *fakeStore) RecordDirectories(_ context.Context, id uint, tree ai.DirectorySummary) error {
+	if row, ok := s.rows[id]; ok {
+		row.RecordDirectories(tree)
+	}
+	return nil
+}
+
+func (s *fakeStore) RecordSelection(_ context.Context, id uint, sel ai.FileSelection) error {
+	if row, ok := s.rows[id]; ok {
+		row.RecordSelection(sel)
+	}
+	return nil
+}
+
+func (s *fakeStore) RecordStepDuration(_ context.Context, id uint, step string, ms int64) error {
+	if row, ok := s.rows[id]; ok {
+		row.RecordStepDuration(step, ms)
+	}
+	return nil
+}
+
 func (s *fakeStore) GetByID(_ context.Context, id uint) (*ai.RepoSummary, error) {
 	row, ok := s.rows[id]
 	if !ok {
@@ -77,6 +145,42 @@ func (s *fakeStore) Delete(_ context.Context, userID shared.UserID, id uint) err
 	return nil
 }
 
+func (s *fakeStore) ListAttempts(_ context.Context, originalID uint) ([]*ai.RepoSummary, error) {
+	var out []*ai.RepoSummary
+	for id := uint(1); id < s.nextID; id++ {
+		if row, ok := s.rows[id]; ok && (row.ID == originalID || row.OriginalID == originalID) {
+			out = append(out, row)
+		}
+	}
+	return out, nil
+}
+
+func (s *fakeStore) LatestCompleted(_ context.Context, userID shared.UserID, url ai.RepoURL) (*ai.RepoSummary, error) {
+	for id := s.nextID - 1; id > 0; id-- {
+		if row, ok := s.rows[id]; ok && row.UserID == userID && row.RepoURL == url && row.Status == ai.StatusCompleted {
+			return row, nil
+		}
+	}
+	return nil, aiapp.ErrNotFound
+}
+
+func (s *fakeStore) UserUsage(_ context.Context, userID shared.UserID, dayStart, _ time.Time) (aiapp.UserUsage, error) {
+	var u aiapp.UserUsage
+	for _, row := range s.rows {
+		if row.UserID != userID {
+			continue
+		}
+		if !row.Status.IsTerminal() {
+			u.ActiveRuns++
+		}
+		if !row.CreatedAt.Before(dayStart) {
+			u.RunsToday++
+		}
+		u.TokensThisMonth += row.Usage.TotalTokens()
+	}
+	return u, nil
+}
+
 type fakeEnqueuer struct {
 	runID string
 	err   error
@@ -86,6 +190,10 @@ func (e *fakeEnqueuer) EnqueueSummarizeRepo(_ context.Context, _ aiapp.EnqueueSu
 	return e.runID, e.err
 }
 
+func (e *fakeEnqueuer) CancelRun(_ context.Context, _ string) error {
+	return nil
+}
+
 func TestSummarizeRepo_HappyPath(t *testing.T) {
 	t.Parallel()
 	store := newFakeStore()
@@ -208,3 +316,356 @@ func TestGetRepoSummary_HappyPath(t *testing.T) {
 		t.Errorf("RepoURL = %q", resp.RepoURL)
 	}
 }
+
+func TestGetRepoSummary_ReportsCacheAndUsage(t *testing.T) {
+	t.Parallel()
+	store := newFakeStore()
+	owner, _ := shared.NewUserID("user-1")
+	url, _ := ai.NewRepoURL("https://github.com/owner/repo")
+	agg := ai.NewRepoSummary(owner, url)
+	_ = store.Create(context.Background(), agg)
+	_ = agg.MarkStarted(time.Now())
+	cached, _ := ai.NewFileSummary("a.go", "A")
+	fresh, _ := ai.NewFileSummary("b.go", "B")
+	_ = agg.AppendFileSummary(cached.AsCached(), 2)
+	_ = agg.AppendFileSummary(fresh.WithUsage(ai.TokenUsage{PromptTokens: 10, CompletionTokens: 5, CostUSD: 0.002}).WithModel("fallback/model").
+		WithInsights(ai.FileInsights{Purpose: "Entry point", Risks: []string{"no tests"}}).WithChunks(3).
+		WithRedactions(map[string]int{"aws-access-key": 1, "env-assignment": 2}), 2)
+	agg.Filter = ai.FileFilter{Subdir: "backend"}
+	agg.Ref = "v1.2.0"
+	agg.RecordCommit("9fceb02d0ae598e95dc970b74767f19372d61af8", time.Date(2026, 3, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600)))
+	agg.RecordDirectories(ai.DirectorySummary{Files: []string{"a.go", "b.go"}, Children: []ai.DirectorySummary{
+		{Path: "cmd/app", Summary: "The CLI.", Files: []string{"cmd/app/main.go"}},
+	}})
+	agg.RecordSelection(ai.FileSelection{Strategy: "ranked", Files: []ai.SelectedFile{
+		{Path: "b.go", Reason: "entry point"}, {Path: "a.go", Reason: "imported by 1 file"},
+	}, IgnorePatterns: []string{"docs/"}, SkippedSecrets: []string{".env.local"}})
+	agg.BaseID = 7
+	agg.RecordChangelog(ai.Changelog{BaseID: 7, BaseCommit: "abc", Modified: []string{"b.go"}, Unchanged: []string{"a.go"}})
+
+	h := aihttp.NewHandler(nil, &aiapp.GetRepoSummary{Store: store}, nil, nil)
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/ai/summaries/{id}", h.GetRepoSummary).Methods("GET")
+
+	req := withUser(httptest.NewRequest(stdhttp.MethodGet, "/api/v1/ai/summaries/1", nil), "user-1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var resp aihttp.RepoSummaryResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.CacheHits != 1 || resp.CacheMisses != 1 {
+		t.Errorf("cache hits/misses = %d/%d, want 1/1", resp.CacheHits, resp.CacheMisses)
+	}
+	if len(resp.Files) != 2 || !resp.Files[0].Cached || resp.Files[1].Cached {
+		t.Fatalf("Files = %+v, want only a.go cached", resp.Files)
+	}
+	if resp.Files[0].Usage != nil || resp.Files[1].Usage == nil || resp.Files[1].Usage.TotalTokens != 15 {
+		t.Errorf("file usage = %+v / %+v, want none for the cached file", resp.Files[0].Usage, resp.Files[1].Usage)
+	}
+	if resp.Usage.TotalTokens != 15 || resp.Usage.CostUSD != 0.002 {
+		t.Errorf("run usage = %+v", resp.Usage)
+	}
+	if resp.Files[0].Model != "" || resp.Files[1].Model != "fallback/model" {
+		t.Errorf("file models = %q / %q, want only b.go's answering model", resp.Files[0].Model, resp.Files[1].Model)
+	}
+	if resp.Files[0].Chunks != 0 || resp.Files[1].Chunks != 3 {
+		t.Errorf("chunks = %d / %d, want 0 / 3", resp.Files[0].Chunks, resp.Files[1].Chunks)
+	}
+	if resp.Files[0].Insights != nil {
+		t.Errorf("a.go has no insights, got %+v", resp.Files[0].Insights)
+	}
+	if in := resp.Files[1].Insights; in == nil || in.Purpose != "Entry point" || len(in.Risks) != 1 {
+		t.Errorf("b.go insights = %+v", in)
+	}
+	if sel := resp.Selection; sel == nil || sel.Strategy != "ranked" || len(sel.Files) != 2 || sel.Files[0].Reason != "entry point" || len(sel.SkippedSecrets) != 1 {
+		t.Errorf("selection = %+v", sel)
+	}
+	if r := resp.Redactions; r == nil || r.Total != 3 || r.ByRule["env-assignment"] != 2 || resp.Files[1].Redactions != 3 || resp.Files[0].Redactions != 0 {
+		t.Errorf("redactions = %+v, per file %d / %d", r, resp.Files[0].Redactions, resp.Files[1].Redactions)
+	}
+	if f := resp.Filter; f == nil || f.Subdir != "backend" || len(f.SummaryIgnore) != 1 {
+		t.Errorf("filter = %+v, want the subdir plus the .summaryignore rule", f)
+	}
+	if d := resp.Directories; d == nil || len(d.Files) != 2 || len(d.Children) != 1 || d.Children[0].Summary != "The CLI." {
+		t.Errorf("directories = %+v", d)
+	}
+	if resp.Ref != "v1.2.0" || resp.CommitSHA != "9fceb02d0ae598e95dc970b74767f19372d61af8" || resp.CommitTime != "2026-03-01T11:00:00Z" {
+		t.Errorf("ref/commit = %q %q %q", resp.Ref, resp.CommitSHA, resp.CommitTime)
+	}
+	if c := resp.Changelog; resp.BaseID != 7 || c == nil || c.BaseCommit != "abc" || len(c.Modified) != 1 || len(c.Unchanged) != 1 || c.Added == nil {
+		t.Errorf("base %d, changelog = %+v", resp.BaseID, c)
+	}
+}
+
+func TestCancelRepoSummary(t *testing.T) {
+	t.Parallel()
+	store := newFakeStore()
+	owner, _ := shared.NewUserID("user-1")
+	url, _ := ai.NewRepoURL("https://github.com/owner/repo")
+	agg := ai.NewRepoSummary(owner, url)
+	_ = store.Create(context.Background(), agg)
+
+	h := aihttp.NewHandler(nil, nil, nil, nil).
+		WithCancel(&aiapp.CancelSummary{Store: store, Enqueuer: &fakeEnqueuer{}})
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/ai/summaries/{id}/cancel", h.CancelRepoSummary).Methods("POST")
+
+	cancel := func(user string) *httptest.ResponseRecorder {
+		req := withUser(httptest.NewRequest(stdhttp.MethodPost, "/api/v1/ai/summaries/1/cancel", nil), user)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := cancel("other-user"); w.Code != stdhttp.StatusNotFound {
+		t.Fatalf("cross-user status = %d, want 404", w.Code)
+	}
+
+	w := cancel("user-1")
+	if w.Code != stdhttp.StatusOK {
+		t.Fatalf("status = %d, want 200; body=%s", w.Code, w.Body.String())
+	}
+	var resp aihttp.RepoSummaryResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Status != string(ai.StatusCancelled) {
+		t.Errorf("Status = %q, want cancelled", resp.Status)
+	}
+
+	if w := cancel("user-1"); w.Code != stdhttp.StatusConflict {
+		t.Fatalf("second cancel status = %d, want 409", w.Code)
+	}
+}
+
+func TestCancelRepoSummary_DegradedMode(t *testing.T) {
+	t.Parallel()
+	h := aihttp.NewHandler(nil, &aiapp.GetRepoSummary{Store: newFakeStore()}, nil, nil)
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/ai/summaries/{id}/cancel", h.CancelRepoSummary).Methods("POST")
+
+	req := withUser(httptest.NewRequest(stdhttp.MethodPost, "/api/v1/ai/summaries/1/cancel", nil), "user-1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != stdhttp.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", w.Code)
+	}
+}
+
+func TestRetryRepoSummary(t *testing.T) {
+	t.Parallel()
+	store := newFakeStore()
+	owner, _ := shared.NewUserID("user-1")
+	url, _ := ai.NewRepoURL("https://github.com/owner/repo")
+	agg := ai.NewRepoSummary(owner, url)
+	_ = store.Create(context.Background(), agg)
+	_ = agg.MarkStarted(time.Now())
+	_ = agg.MarkFailed("workflow failure", time.Now())
+
+	h := aihttp.NewHandler(nil, &aiapp.GetRepoSummary{Store: store}, nil, nil).
+		WithRetry(&aiapp.RetrySummary{Store: store, Enqueuer: &fakeEnqueuer{runID: "run-2"}}).
+		WithAttemptHistory(&aiapp.GetAttemptHistory{Store: store})
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/ai/summaries/{id}", h.GetRepoSummary).Methods("GET")
+	router.HandleFunc("/api/v1/ai/summaries/{id}/retry", h.RetryRepoSummary).Methods("POST")
+
+	retry := func(user, id string) *httptest.ResponseRecorder {
+		req := withUser(httptest.NewRequest(stdhttp.MethodPost, "/api/v1/ai/summaries/"+id+"/retry", nil), user)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := retry("other-user", "1"); w.Code != stdhttp.StatusNotFound {
+		t.Fatalf("cross-user status = %d, want 404", w.Code)
+	}
+
+	w := retry("user-1", "1")
+	if w.Code != stdhttp.StatusAccepted {
+		t.Fatalf("status = %d, want 202; body=%s", w.Code, w.Body.String())
+	}
+	var out aihttp.SummarizeRepoResponse
+	if err := json.NewDecoder(w.Body).Decode(&out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if out.SummaryID != 2 || out.RunID != "run-2" {
+		t.Errorf("response = %+v, want summary 2 on run-2", out)
+	}
+
+	// The new attempt is pending, so it can't be retried yet.
+	if w := retry("user-1", "2"); w.Code != stdhttp.StatusConflict {
+		t.Fatalf("retry of pending attempt status = %d, want 409", w.Code)
+	}
+
+	req := withUser(httptest.NewRequest(stdhttp.MethodGet, "/api/v1/ai/summaries/2", nil), "user-1")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var resp aihttp.RepoSummaryResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.RetryOf != 1 {
+		t.Errorf("RetryOf = %d, want 1", resp.RetryOf)
+	}
+	if len(resp.Attempts) != 2 || resp.Attempts[0].Status != "failed" || resp.Attempts[1].Attempt != 2 {
+		t.Errorf("Attempts = %+v, want failed attempt 1 then attempt 2", resp.Attempts)
+	}
+}
+
+func TestSummarizeRepo_QuotaExceeded(t *testing.T) {
+	t.Parallel()
+	store := newFakeStore()
+	quota := &aiapp.Quota{Store: store, Limits: aiapp.QuotaLimits{MaxConcurrentRuns: 1, MaxRunsPerDay: 10}}
+	h := aihttp.NewHandler(&aiapp.SummarizeRepo{Store: store, Enqueuer: &fakeEnqueuer{runID: "run-1"}, Quota: quota}, nil, nil, nil).
+		WithQuota(quota)
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/ai/summarize-repo", h.SummarizeRepo).Methods("POST")
+	router.HandleFunc("/api/v1/ai/quota", h.GetQuota).Methods("GET")
+
+	submit := func() *httptest.ResponseRecorder {
+		body := strings.NewReader(`{"repoUrl":"https://github.com/owner/repo"}`)
+		req := withUser(httptest.NewRequest(stdhttp.MethodPost, "/api/v1/ai/summarize-repo", body), "user-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := submit(); w.Code != stdhttp.StatusAccepted {
+		t.Fatalf("first run status = %d, want 202; body=%s", w.Code, w.Body.String())
+	}
+	w := submit()
+	if w.Code != stdhttp.StatusTooManyRequests {
+		t.Fatalf("second run status = %d, want 429", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("Retry-After = %q, want 60", got)
+	}
+
+	req := withUser(httptest.NewRequest(stdhttp.MethodGet, "/api/v1/ai/quota", nil), "user-1")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != stdhttp.StatusOK {
+		t.Fatalf("quota status = %d, want 200", w.Code)
+	}
+	var resp aihttp.QuotaResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Limits.MaxConcurrentRuns != 1 || resp.Usage.ActiveRuns != 1 || resp.Usage.RunsToday != 1 {
+		t.Errorf("quota = %+v", resp)
+	}
+	if resp.DayResetsAt == "" || resp.MonthResetsAt == "" {
+		t.Errorf("reset times missing: %+v", resp)
+	}
+}
+
+// fakeAnswers is an in-memory aiapp.AnswerStore.
+type fakeAnswers struct {
+	rows []ai.Answer
+}
+
+func (a *fakeAnswers) AddAnswer(_ context.Context, ans *ai.Answer) error {
+	ans.ID = uint(len(a.rows) + 1)
+	ans.CreatedAt = time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	a.rows = append(a.rows, *ans)
+	return nil
+}
+
+func (a *fakeAnswers) ListAnswers(_ context.Context, summaryID uint, _ int) ([]ai.Answer, error) {
+	var out []ai.Answer
+	for _, r := range a.rows {
+		if r.SummaryID == summaryID {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+type fakePrompter struct{}
+
+func (fakePrompter) QuestionPrompt(p aiapp.QuestionPrompt) (string, string, error) {
+	return p.Question, "v1", nil
+}
+
+type fakeLLM struct{ text string }
+
+func (l fakeLLM) Generate(context.Context, string) (aiapp.Completion, error) {
+	return aiapp.Completion{Text: l.text, Model: "m", Usage: ai.TokenUsage{PromptTokens: 10, CompletionTokens: 5}}, nil
+}
+
+func (l fakeLLM) Stream(ctx context.Context, prompt string, onChunk func(string)) (aiapp.Completion, error) {
+	onChunk(l.text)
+	return l.Generate(ctx, prompt)
+}
+
+func TestAskQuestion(t *testing.T) {
+	t.Parallel()
+	store := newFakeStore()
+	owner, _ := shared.NewUserID("user-1")
+	url, _ := ai.NewRepoURL("https://github.com/owner/repo")
+	agg := ai.NewRepoSummary(owner, url)
+	_ = store.Create(context.Background(), agg)
+	_ = agg.MarkStarted(time.Now())
+	fs, _ := ai.NewFileSummary("internal/auth/session.go", "Validates sessions.")
+	_ = agg.AppendFileSummary(fs, 1)
+	pending := ai.NewRepoSummary(owner, url)
+	_ = store.Create(context.Background(), pending)
+
+	answers := &fakeAnswers{}
+	h := aihttp.NewHandler(nil, nil, nil, nil).
+		WithAsk(&aiapp.AskQuestion{Store: store, Answers: answers, LLM: fakeLLM{text: "In [internal/auth/session.go]."}, Prompts: fakePrompter{}}).
+		WithAnswerHistory(&aiapp.GetAnswerHistory{Store: store, Answers: answers})
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/ai/summaries/{id}/ask", h.AskQuestion).Methods("POST")
+	router.HandleFunc("/api/v1/ai/summaries/{id}/questions", h.ListQuestions).Methods("GET")
+
+	ask := func(user, id, body string) *httptest.ResponseRecorder {
+		req := withUser(httptest.NewRequest(stdhttp.MethodPost, "/api/v1/ai/summaries/"+id+"/ask", strings.NewReader(body)), user)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := ask("user-1", "1", `{"question":"Where are sessions checked?"}`); w.Code != stdhttp.StatusConflict {
+		t.Fatalf("running run status = %d, want 409", w.Code)
+	}
+	_ = agg.MarkCompleted("An app.", time.Now())
+	if w := ask("other-user", "1", `{"question":"Where are sessions checked?"}`); w.Code != stdhttp.StatusNotFound {
+		t.Fatalf("cross-user status = %d, want 404", w.Code)
+	}
+	if w := ask("user-1", "1", `{"question":""}`); w.Code != stdhttp.StatusBadRequest {
+		t.Fatalf("empty question status = %d, want 400", w.Code)
+	}
+	if w := ask("user-1", "2", `{"question":"Where?"}`); w.Code != stdhttp.StatusConflict {
+		t.Fatalf("pending run status = %d, want 409", w.Code)
+	}
+
+	w := ask("user-1", "1", `{"question":"Where are sessions checked?"}`)
+	if w.Code != stdhttp.StatusOK {
+		t.Fatalf("status = %d, want 200; body=%s", w.Code, w.Body.String())
+	}
+	var got aihttp.AnswerDTO
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.Answer != "In [internal/auth/session.go]." || len(got.Citations) != 1 || got.Citations[0] != "internal/auth/session.go" {
+		t.Errorf("answer = %+v", got)
+	}
+	if got.Usage == nil || got.Usage.TotalTokens != 15 || got.CreatedAt != "2026-05-01T12:00:00Z" {
+		t.Errorf("answer usage/createdAt = %+v", got)
+	}
+
+	req := withUser(httptest.NewRequest(stdhttp.MethodGet, "/api/v1/ai/summaries/1/questions", nil), "user-1")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var list aihttp.AnswerListResponse
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(list.Items) != 1 || list.Items[0].Question != "Where are sessions checked?" {
+		t.Errorf("history = %+v", list.Items)
+	}
+}
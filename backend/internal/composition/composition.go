@@ -13,6 +13,7 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -44,8 +45,12 @@ import (
 	aiapp "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/application"
 	aievents "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/infrastructure/events"
 	aigit "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/infrastructure/git"
+	aiinprocess "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/infrastructure/inprocess"
+	aijobs "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/infrastructure/jobs"
 	aillm "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/infrastructure/llm"
 	aipersist "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/infrastructure/persistence"
+	aiprompts "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/infrastructure/prompts"
+	aisecrets "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/infrastructure/secrets"
 	aiworkflows "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/infrastructure/workflows"
 	aihttp "github.com/atilladeniz/next-go-pg/backend/internal/aiworkflows/interfaces/http"
 
@@ -86,9 +91,13 @@ type App struct {
 	sseBroker     *sse.Broker
 
 	// AI workflow worker — running goroutine + cancel. Nil when
-	// HATCHET_CLIENT_TOKEN is not set (degraded boot).
+	// HATCHET_CLIENT_TOKEN is not set (degraded boot) or the River
+	// engine runs the workflow.
 	hatchetWorker     *aiworkflows.Worker
 	hatchetWorkerStop context.CancelFunc
+
+	// In-process AI workflow engine, when AI_WORKFLOW_ENGINE=inprocess.
+	aiEngine *aiinprocess.Engine
 }
 
 // Build assembles the dependency graph.
@@ -109,8 +118,23 @@ func Build(ctx context.Context, in Inputs) (*App, error) {
 		app.db = db
 	}
 
-	// Platform: SSE broker.
-	sseBroker := sse.NewBroker()
+	// Shared pgx pool — River's queue and the SSE backplane both run
+	// on it.
+	if db != nil {
+		if pool, err := pgxpool.New(ctx, cfg.GetDatabaseURLForPgx()); err != nil {
+			logger.Warn().Err(err).Msg("Failed to create pgx pool - background jobs and SSE backplane disabled")
+		} else {
+			app.pgxPool = pool
+		}
+	}
+
+	// Platform: SSE broker. The user resolver lets each connection
+	// receive its owner's targeted events (ai-progress, export-progress).
+	sseOpts := []sse.Option{sse.WithUserIDFunc(middleware.GetUserIDFromContext)}
+	if bp := buildSSEBackplane(app.pgxPool); bp != nil {
+		sseOpts = append(sseOpts, sse.WithBackplane(bp))
+	}
+	sseBroker := sse.NewBroker(sseOpts...)
 	app.sseBroker = sseBroker
 	logger.Info().Msg("SSE broker initialized")
 
@@ -141,44 +165,53 @@ func Build(ctx context.Context, in Inputs) (*App, error) {
 	}
 	exportStore := exportsinfra.NewMemoryStore()
 
+	// AI workflows context — wired ahead of the River queue, which runs
+	// its steps when AI_WORKFLOW_ENGINE=river.
+	var aiWiring *aiWorkflows
+	if db != nil {
+		aiWiring = newAIWorkflows(ctx, db, sseBroker, app.pgxPool != nil)
+	}
+
 	// River queue — wires per-context workers.
 	var notifEnqueuer notifapp.JobEnqueuer
 	var exportsEnqueuer exportsapp.JobEnqueuer
-	if db != nil {
-		if pool, err := pgxpool.New(ctx, cfg.GetDatabaseURLForPgx()); err != nil {
-			logger.Warn().Err(err).Msg("Failed to create pgx pool for River - background jobs disabled")
-		} else {
-			app.pgxPool = pool
-			if err := riverPkg.RunMigrations(ctx, pool); err != nil {
-				logger.Warn().Err(err).Msg("River migrations failed - background jobs may not work")
-			}
+	var aiQueue aijobs.RiverClient
+	if pool := app.pgxPool; pool != nil {
+		if err := riverPkg.RunMigrations(ctx, pool); err != nil {
+			logger.Warn().Err(err).Msg("River migrations failed - background jobs may not work")
+		}
 
-			workers := river.NewWorkers()
-			notifjobs.Register(workers, emailSender)
-			exportsjobs.Register(workers, sseBroker, exportStore, statsReader)
+		workers := river.NewWorkers()
+		notifjobs.Register(workers, emailSender)
+		exportsjobs.Register(workers, sseBroker, exportStore, statsReader)
+		riverCfg := riverPkg.DefaultConfig()
+		if aiWiring.onRiver() {
+			aijobs.Register(workers, *aiWiring.deps)
+			riverCfg.Queues = map[string]int{
+				river.QueueDefault: riverCfg.MaxWorkers,
+				aijobs.FileQueue:   aiFileWorkers,
+			}
+		}
 
-			client, err := riverPkg.NewClient(ctx, pool, workers, riverPkg.DefaultConfig())
-			if err != nil {
-				logger.Warn().Err(err).Msg("Failed to create River client - background jobs disabled")
+		client, err := riverPkg.NewClient(ctx, pool, workers, riverCfg)
+		if err != nil {
+			logger.Warn().Err(err).Msg("Failed to create River client - background jobs disabled")
+		} else {
+			if err := client.Start(ctx); err != nil {
+				logger.Error().Err(err).Msg("Failed to start River client")
 			} else {
-				if err := client.Start(ctx); err != nil {
-					logger.Error().Err(err).Msg("Failed to start River client")
-				} else {
-					logger.Info().Msg("River job queue initialized and started")
-					app.riverJobQueue = client
-					notifEnqueuer = notifjobs.NewEnqueuer(client.Client)
-					exportsEnqueuer = exportsjobs.NewEnqueuer(client.Client)
-				}
+				logger.Info().Msg("River job queue initialized and started")
+				app.riverJobQueue = client
+				notifEnqueuer = notifjobs.NewEnqueuer(client.Client)
+				exportsEnqueuer = exportsjobs.NewEnqueuer(client.Client)
+				aiQueue = client.Client
 			}
 		}
 	}
 
-	// AI workflows context — gated on HATCHET_CLIENT_TOKEN. Without it
-	// we skip the Hatchet wiring entirely so `just dev` still boots
-	// when the AI compose profile is down.
 	var aiHandler *aihttp.Handler
-	if db != nil {
-		aiHandler = buildAIWorkflowsHandler(ctx, app, db, sseBroker)
+	if aiWiring != nil {
+		aiHandler = aiWiring.handler(ctx, app, aiQueue)
 	}
 
 	// HTTP layer — per-context handlers.
@@ -224,9 +257,9 @@ func Build(ctx context.Context, in Inputs) (*App, error) {
 	return app, nil
 }
 
-// Shutdown stops the HTTP server, the Hatchet worker, River, the SSE
+// Shutdown stops the HTTP server, the AI workflow engine, River, the SSE
 // broker and closes the pgx pool. Order matters: HTTP first so no new
-// SSE connections arrive, Hatchet worker so no new tasks are claimed,
+// SSE connections arrive, the AI engine so no new tasks are claimed,
 // then the broker drains existing clients, then the rest.
 func (a *App) Shutdown(ctx context.Context) {
 	if a.HTTPServer != nil {
@@ -238,6 +271,12 @@ func (a *App) Shutdown(ctx context.Context) {
 		logger.Info().Msg("Stopping Hatchet worker...")
 		a.hatchetWorkerStop()
 	}
+	if a.aiEngine != nil {
+		logger.Info().Msg("Stopping in-process AI workflow runs...")
+		if err := a.aiEngine.Shutdown(ctx); err != nil {
+			logger.Error().Err(err).Msg("In-process AI workflow engine shutdown error")
+		}
+	}
 	if a.sseBroker != nil {
 		if err := a.sseBroker.Shutdown(ctx); err != nil {
 			logger.Error().Err(err).Msg("SSE broker shutdown error")
@@ -254,34 +293,89 @@ func (a *App) Shutdown(ctx context.Context) {
 	}
 }
 
-// buildAIWorkflowsHandler wires the aiworkflows bounded context end to
-// end. It is gated on HATCHET_CLIENT_TOKEN: without a token we cannot
-// dial hatchet-lite, so we skip the wiring and return a handler that
-// responds with 503 (Service Unavailable). The store and use cases
-// still work in degraded mode so GET /ai/summaries/{id} can answer for
-// rows enqueued before a restart.
-func buildAIWorkflowsHandler(ctx context.Context, app *App, db *gorm.DB, broker *sse.Broker) *aihttp.Handler {
-	repo := aipersist.NewRepository(db)
-	getUC := &aiapp.GetRepoSummary{Store: repo}
-	listUC := &aiapp.ListUserSummaries{Store: repo}
-	deleteUC := &aiapp.DeleteUserSummary{Store: repo}
+// aiWorkflows is the aiworkflows bounded context wired up to the
+// point where an engine takes over. Build creates it before the River
+// queue, so the River engine can register its workers, and turns it
+// into the HTTP handler once the queue is running.
+type aiWorkflows struct {
+	engine string
+
+	repo            *aipersist.Repository
+	answers         *aipersist.AnswerStore
+	quota           *aiapp.Quota
+	getUC           *aiapp.GetRepoSummary
+	listUC          *aiapp.ListUserSummaries
+	deleteUC        *aiapp.DeleteUserSummary
+	historyUC       *aiapp.GetAttemptHistory
+	answerHistoryUC *aiapp.GetAnswerHistory
+
+	// Nil in degraded mode: the engine is unavailable or the LLM client
+	// could not be built.
+	deps     *aiworkflows.Deps
+	llm      aiapp.LLMClient
+	llmLabel string
+	prompts  *aiprompts.Registry
+	progress aiapp.ProgressPublisher
+}
 
-	token := os.Getenv("HATCHET_CLIENT_TOKEN")
-	if token == "" {
-		logger.Warn().Msg("HATCHET_CLIENT_TOKEN unset — AI workflows disabled (degraded boot). GET /ai/summaries/{id} still serves existing rows.")
-		return aihttp.NewHandler(nil, getUC, listUC, deleteUC)
-	}
+// AI workflow engines, picked by AI_WORKFLOW_ENGINE.
+const (
+	aiEngineHatchet   = "hatchet"
+	aiEngineRiver     = "river"
+	aiEngineInProcess = "inprocess"
+)
 
-	client, err := hatchet.NewClient()
-	if err != nil {
-		logger.Warn().Err(err).Msg("Hatchet client init failed — AI workflows disabled")
-		return aihttp.NewHandler(nil, getUC, listUC, deleteUC)
+// aiFileWorkers is how many files the River engine summarizes at once,
+// as many as the Hatchet worker has slots.
+const aiFileWorkers = 10
+
+// newAIWorkflows wires the store, the read-side use cases and — when
+// the engine can run — the steps' dependencies. AI_WORKFLOW_ENGINE picks
+// the engine: "hatchet" (the default) is gated on HATCHET_CLIENT_TOKEN,
+// so `just dev` still boots when the AI compose profile is down;
+// "river" runs the steps as jobs on the app's own River queue and needs
+// the pgx pool instead; "inprocess" runs them in goroutines of this
+// process, for local runs without either. Without its engine the
+// context boots degraded:
+// the store and use cases still work so GET /ai/summaries/{id} can
+// answer for rows enqueued before a restart.
+func newAIWorkflows(ctx context.Context, db *gorm.DB, broker *sse.Broker, haveQueue bool) *aiWorkflows {
+	repo := aipersist.NewRepository(db)
+	answers := aipersist.NewAnswerStore(db)
+	w := &aiWorkflows{
+		engine:          os.Getenv("AI_WORKFLOW_ENGINE"),
+		repo:            repo,
+		answers:         answers,
+		quota:           &aiapp.Quota{Store: repo, Limits: buildQuotaLimits()},
+		getUC:           &aiapp.GetRepoSummary{Store: repo},
+		listUC:          &aiapp.ListUserSummaries{Store: repo},
+		deleteUC:        &aiapp.DeleteUserSummary{Store: repo},
+		historyUC:       &aiapp.GetAttemptHistory{Store: repo},
+		answerHistoryUC: &aiapp.GetAnswerHistory{Store: repo, Answers: answers},
+	}
+	switch w.engine {
+	case "", aiEngineHatchet:
+		w.engine = aiEngineHatchet
+		if os.Getenv("HATCHET_CLIENT_TOKEN") == "" {
+			logger.Warn().Msg("HATCHET_CLIENT_TOKEN unset — AI workflows disabled (degraded boot). GET /ai/summaries/{id} still serves existing rows.")
+			return w
+		}
+	case aiEngineRiver:
+		if !haveQueue {
+			logger.Warn().Msg("AI_WORKFLOW_ENGINE=river but the job queue is unavailable — AI workflows disabled")
+			return w
+		}
+	case aiEngineInProcess:
+		logger.Warn().Msg("AI_WORKFLOW_ENGINE=inprocess — AI workflow runs are lost on restart; not for production")
+	default:
+		logger.Warn().Str("engine", w.engine).Msg("Unknown AI_WORKFLOW_ENGINE — AI workflows disabled")
+		return w
 	}
 
-	llmClient, llmLabel, err := buildLLMClient(ctx)
+	llmClient, llmLabel, llmModel, err := buildLLMClient(ctx)
 	if err != nil {
 		logger.Warn().Err(err).Msg("LLM client init failed — AI workflows disabled")
-		return aihttp.NewHandler(nil, getUC, listUC, deleteUC)
+		return w
 	}
 	maxFiles := 25
 	if raw := os.Getenv("AI_MAX_FILES"); raw != "" {
@@ -289,44 +383,227 @@ func buildAIWorkflowsHandler(ctx context.Context, app *App, db *gorm.DB, broker
 			maxFiles = n
 		}
 	}
+	chunkTokens := 0
+	if raw := os.Getenv("AI_CHUNK_TOKENS"); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+			chunkTokens = n
+		}
+	}
+	// Large files are chunked, so this only keeps a single huge file
+	// (a fixture, a vendored bundle) from costing a run hundreds of
+	// prompts.
+	maxFileBytes := int64(512 * 1024)
+	if raw := os.Getenv("AI_MAX_FILE_BYTES"); raw != "" {
+		if n, err := strconv.ParseInt(raw, 10, 64); err == nil && n > 0 {
+			maxFileBytes = n
+		}
+	}
+	progress := aievents.NewPublisher(broker)
+	prompts := aiprompts.NewRegistry(os.Getenv("AI_PROMPTS_DIR"))
+	cloner := buildCloner()
 	deps := aiworkflows.Deps{
-		Cloner:   aigit.NewCloner("", 50*1024*1024),
+		Cloner:   cloner,
+		History:  cloner,
 		LLM:      llmClient,
 		Store:    repo,
-		Progress: aievents.NewPublisher(broker),
+		Progress: progress,
+		Cache:    aipersist.NewSummaryCache(db),
+		Model:    llmModel,
+		Prompts:  prompts,
 		MaxFiles: maxFiles,
-		MaxBytes: 64 * 1024,
+		MaxBytes: maxFileBytes,
+		// Structured JSON summaries are the default; "false" falls back
+		// to free text, e.g. for small local models that can't hold the
+		// format.
+		StructuredSummaries: os.Getenv("AI_STRUCTURED_SUMMARIES") != "false",
+		// "alphabetical" restores the old first-N-by-path selection.
+		FileSelection: os.Getenv("AI_FILE_SELECTION"),
+		// Files over this many (estimated) tokens are summarized in
+		// chunks; 0 keeps aiworkflows.DefaultChunkTokens.
+		ChunkTokens: chunkTokens,
 	}
-	_ = llmLabel // surfaced via the wired-log below
+	// Secret stores are skipped and credentials redacted before file
+	// contents reach the LLM; "false" turns that off, e.g. for a local
+	// model that never leaves the host.
+	if os.Getenv("AI_REDACT_SECRETS") != "false" {
+		deps.Secrets = aisecrets.Scanner{}
+	}
+	w.deps = &deps
+	w.llm, w.llmLabel = llmClient, llmLabel
+	w.prompts, w.progress = prompts, progress
+	return w
+}
 
-	worker, err := aiworkflows.NewWorker(client, deps, "ai-workflows-worker")
-	if err != nil {
-		logger.Warn().Err(err).Msg("Hatchet worker init failed — AI workflows disabled")
-		return aihttp.NewHandler(nil, getUC, listUC, deleteUC)
+// onRiver reports whether the River queue has to run the workflow.
+func (w *aiWorkflows) onRiver() bool {
+	return w != nil && w.deps != nil && w.engine == aiEngineRiver
+}
+
+// handler starts the engine's side and returns the context's HTTP
+// handler: Hatchet gets its worker goroutine, River only needs the
+// running queue's client, the in-process engine is built here. Any
+// failure here falls back to the degraded handler, which answers
+// enqueue requests with 503 (Service Unavailable).
+func (w *aiWorkflows) handler(ctx context.Context, app *App, queue aijobs.RiverClient) *aihttp.Handler {
+	degraded := aihttp.NewHandler(nil, w.getUC, w.listUC, w.deleteUC).
+		WithAttemptHistory(w.historyUC).
+		WithAnswerHistory(w.answerHistoryUC).
+		WithQuota(w.quota)
+	if w.deps == nil {
+		return degraded
 	}
 
-	workerCtx, cancel := context.WithCancel(ctx)
-	app.hatchetWorker = worker
-	app.hatchetWorkerStop = cancel
-	go func() {
-		logger.Info().Str("worker", "ai-workflows-worker").Msg("Starting Hatchet worker")
-		if err := worker.Start(workerCtx); err != nil && !errors.Is(err, context.Canceled) {
-			logger.Error().Err(err).Msg("Hatchet worker exited with error")
+	var enqueuer aiapp.HatchetEnqueuer
+	switch w.engine {
+	case aiEngineRiver:
+		if queue == nil {
+			logger.Warn().Msg("River job queue not running — AI workflows disabled")
+			return degraded
+		}
+		enqueuer = aijobs.NewEnqueuer(queue)
+	case aiEngineInProcess:
+		app.aiEngine = aiinprocess.NewEngine(*w.deps)
+		enqueuer = app.aiEngine
+	default:
+		client, err := hatchet.NewClient()
+		if err != nil {
+			logger.Warn().Err(err).Msg("Hatchet client init failed — AI workflows disabled")
+			return degraded
+		}
+		worker, err := aiworkflows.NewWorker(client, *w.deps, "ai-workflows-worker")
+		if err != nil {
+			logger.Warn().Err(err).Msg("Hatchet worker init failed — AI workflows disabled")
+			return degraded
 		}
-	}()
 
-	enqueuer := aiworkflows.NewEnqueuer(client)
-	summarizeUC := &aiapp.SummarizeRepo{Store: repo, Enqueuer: enqueuer}
+		workerCtx, cancel := context.WithCancel(ctx)
+		app.hatchetWorker = worker
+		app.hatchetWorkerStop = cancel
+		go func() {
+			logger.Info().Str("worker", "ai-workflows-worker").Msg("Starting Hatchet worker")
+			if err := worker.Start(workerCtx); err != nil && !errors.Is(err, context.Canceled) {
+				logger.Error().Err(err).Msg("Hatchet worker exited with error")
+			}
+		}()
+		enqueuer = aiworkflows.NewEnqueuer(client)
+	}
 
-	logger.Info().Str("llm", llmLabel).Msg("AI workflows context wired: Hatchet + LLM")
-	return aihttp.NewHandler(summarizeUC, getUC, listUC, deleteUC)
+	repo, quota := w.repo, w.quota
+	summarizeUC := &aiapp.SummarizeRepo{Store: repo, Enqueuer: enqueuer, Quota: quota}
+	cancelUC := &aiapp.CancelSummary{Store: repo, Enqueuer: enqueuer, Progress: w.progress}
+	retryUC := &aiapp.RetrySummary{Store: repo, Enqueuer: enqueuer, Quota: quota}
+	askUC := &aiapp.AskQuestion{Store: repo, Answers: w.answers, LLM: w.llm, Prompts: w.prompts, Quota: quota}
+
+	logger.Info().Str("engine", w.engine).Str("llm", w.llmLabel).Msg("AI workflows context wired")
+	return aihttp.NewHandler(summarizeUC, w.getUC, w.listUC, w.deleteUC).
+		WithCancel(cancelUC).
+		WithRetry(retryUC).
+		WithAttemptHistory(w.historyUC).
+		WithAsk(askUC).
+		WithAnswerHistory(w.answerHistoryUC).
+		WithQuota(quota)
 }
 
-// buildLLMClient constructs the OpenRouter LLM client and verifies the
-// API key with a cheap auth-info ping. Returned label is the
-// human-readable model identifier surfaced in logs — never the secret.
+// buildCloner configures the repository cloner's limits and, through
+// buildClonePolicy, its targets. Environment:
 //
-// Required env:
+//	AI_CLONE_MAX_BYTES   — bytes a clone may transfer and check out,
+//	                       default 52428800 (50 MiB)
+//	AI_CLONE_MAX_OBJECTS — objects in the fetched pack, default 100000
+//	AI_CLONE_TIMEOUT     — wall clock per clone, default 5m
+//
+// 0 disables a limit; a malformed value keeps the default.
+func buildCloner() *aigit.Cloner {
+	limit := func(key string, def int64) int64 {
+		if raw := os.Getenv(key); raw != "" {
+			if n, err := strconv.ParseInt(raw, 10, 64); err == nil && n >= 0 {
+				return n
+			}
+			logger.Warn().Str("key", key).Str("value", raw).Msg("Invalid AI clone limit - using default")
+		}
+		return def
+	}
+	maxBytes := limit("AI_CLONE_MAX_BYTES", 50*1024*1024)
+	cloner := aigit.NewCloner("", maxBytes)
+	cloner.MaxObjects = limit("AI_CLONE_MAX_OBJECTS", aigit.DefaultMaxObjects)
+	if raw := os.Getenv("AI_CLONE_TIMEOUT"); raw != "" {
+		if d, err := time.ParseDuration(raw); err == nil && d >= 0 {
+			cloner.Timeout = d
+		} else {
+			logger.Warn().Str("key", "AI_CLONE_TIMEOUT").Str("value", raw).Msg("Invalid AI clone limit - using default")
+		}
+	}
+	cloner.Policy = buildClonePolicy()
+	return cloner
+}
+
+// buildClonePolicy reads which hosts repositories may be cloned from.
+// Environment:
+//
+//	AI_CLONE_ALLOW_HOSTS   — comma-separated hosts or *.domain patterns;
+//	                         empty allows any public host
+//	AI_CLONE_DENY_HOSTS    — comma-separated patterns refused regardless
+//	AI_CLONE_ALLOW_PRIVATE — "true" permits loopback, private and
+//	                         link-local addresses (self-hosted forges)
+//
+// Internal addresses, cloud metadata endpoints included, are refused by
+// default.
+func buildClonePolicy() *aigit.TargetPolicy {
+	hosts := func(key string) []string {
+		var out []string
+		for _, h := range strings.Split(os.Getenv(key), ",") {
+			if h = strings.TrimSpace(h); h != "" {
+				out = append(out, h)
+			}
+		}
+		return out
+	}
+	return &aigit.TargetPolicy{
+		AllowHosts:   hosts("AI_CLONE_ALLOW_HOSTS"),
+		DenyHosts:    hosts("AI_CLONE_DENY_HOSTS"),
+		AllowPrivate: os.Getenv("AI_CLONE_ALLOW_PRIVATE") == "true",
+	}
+}
+
+// buildQuotaLimits reads the per-user AI limits. Environment:
+//
+//	AI_MAX_CONCURRENT_RUNS  — runs pending or running at once, default 2
+//	AI_MAX_RUNS_PER_DAY     — runs started per UTC day, default 20
+//	AI_MONTHLY_TOKEN_BUDGET — LLM tokens per UTC month, default 1000000
+//
+// 0 disables a limit; a malformed value keeps the default.
+func buildQuotaLimits() aiapp.QuotaLimits {
+	limit := func(key string, def int) int {
+		if raw := os.Getenv(key); raw != "" {
+			if n, err := strconv.Atoi(raw); err == nil && n >= 0 {
+				return n
+			}
+			logger.Warn().Str("key", key).Str("value", raw).Msg("Invalid AI quota limit - using default")
+		}
+		return def
+	}
+	return aiapp.QuotaLimits{
+		MaxConcurrentRuns:  limit("AI_MAX_CONCURRENT_RUNS", 2),
+		MaxRunsPerDay:      limit("AI_MAX_RUNS_PER_DAY", 20),
+		MonthlyTokenBudget: limit("AI_MONTHLY_TOKEN_BUDGET", 1_000_000),
+	}
+}
+
+// buildLLMClient constructs the configured LLM client and pings it.
+// Returned label is the provider-qualified model identifier surfaced in
+// logs — never a secret. Returned model is the primary model, the one
+// summary cache lookups are keyed by.
+//
+// AI_LLM_PROVIDER picks the adapter:
+//
+//	openrouter (default) — cloud gateway, see OPENROUTER_* below
+//	ollama               — local Ollama server, works offline
+//	openai               — any OpenAI-compatible server (vLLM, llama.cpp,
+//	                       LM Studio, a stub server in tests)
+//	stub                 — canned answers, no model; for local runs of
+//	                       the pipeline only
+//
+// OpenRouter env:
 //
 //	OPENROUTER_API_KEY                — fails startup if unset
 //	OPENROUTER_MODEL                  — default openai/gpt-oss-120b
@@ -334,31 +611,191 @@ func buildAIWorkflowsHandler(ctx context.Context, app *App, db *gorm.DB, broker
 //	OPENROUTER_TIMEOUT                — Go duration, default 60s
 //	OPENROUTER_REFERER, OPENROUTER_TITLE — optional analytics headers
 //
+// Ollama env:
+//
+//	OLLAMA_URL     — default http://localhost:11434
+//	OLLAMA_MODEL   — default gemma4:e4b, must already be pulled
+//	OLLAMA_TIMEOUT — Go duration, default 5m
+//
+// OpenAI-compatible env:
+//
+//	OPENAI_COMPAT_URL     — base URL incl. API prefix, e.g. http://localhost:8000/v1 (required)
+//	OPENAI_COMPAT_MODEL   — required
+//	OPENAI_COMPAT_API_KEY — optional
+//	OPENAI_COMPAT_TIMEOUT — Go duration, default 120s
+//
+// Fallback chain env (optional, replaces AI_LLM_PROVIDER when set):
+//
+//	AI_LLM_BACKENDS          — ordered provider:model list, e.g.
+//	                           "openrouter:openai/gpt-oss-120b,ollama:gemma4:e4b";
+//	                           each provider still reads its own env above
+//	AI_LLM_BREAKER_FAILURES  — consecutive failures that open a backend's circuit, default 3
+//	AI_LLM_BREAKER_COOLDOWN  — Go duration an open circuit skips its backend, default 30s
+//
 // This function fails fast at boot rather than letting the first
-// workflow run discover a misconfigured/missing key.
-func buildLLMClient(ctx context.Context) (aiapp.LLMClient, string, error) {
-	cfg := aillm.OpenRouterConfig{
-		URL:     os.Getenv("OPENROUTER_URL"),
-		APIKey:  os.Getenv("OPENROUTER_API_KEY"),
-		Model:   os.Getenv("OPENROUTER_MODEL"),
-		Referer: os.Getenv("OPENROUTER_REFERER"),
-		Title:   os.Getenv("OPENROUTER_TITLE"),
+// workflow run discover a misconfigured/missing key or model.
+func buildLLMClient(ctx context.Context) (aiapp.LLMClient, string, string, error) {
+	if raw := os.Getenv("AI_LLM_BACKENDS"); raw != "" {
+		return buildLLMFallbackChain(ctx, raw)
+	}
+	client, label, err := newLLMClient(os.Getenv("AI_LLM_PROVIDER"), "")
+	if err != nil {
+		return nil, "", "", err
 	}
-	if raw := os.Getenv("OPENROUTER_TIMEOUT"); raw != "" {
-		if d, err := time.ParseDuration(raw); err == nil {
-			cfg.Timeout = d
+	if err := pingLLM(ctx, client); err != nil {
+		return nil, "", "", fmt.Errorf("%s ping: %w", label, err)
+	}
+	return client, label, client.Model(), nil
+}
+
+// buildLLMFallbackChain wires AI_LLM_BACKENDS into a FallbackClient. A
+// backend that fails its boot ping is left out with a warning; only a
+// chain with no reachable backend at all fails. The label joins every
+// configured backend; the primary model is the first one's, whether or
+// not it answered the ping, so every replica looks the cache up alike.
+func buildLLMFallbackChain(ctx context.Context, raw string) (aiapp.LLMClient, string, string, error) {
+	var backends []aillm.Backend
+	var labels []string
+	var primary string
+	for _, spec := range strings.Split(raw, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		// Models contain ':' themselves (gemma4:e4b); split on the first.
+		provider, model, ok := strings.Cut(spec, ":")
+		if !ok || model == "" {
+			return nil, "", "", fmt.Errorf("AI_LLM_BACKENDS entry %q: want provider:model", spec)
+		}
+		client, label, err := newLLMClient(provider, model)
+		if err != nil {
+			return nil, "", "", fmt.Errorf("AI_LLM_BACKENDS entry %q: %w", spec, err)
 		}
+		if primary == "" {
+			primary = client.Model()
+		}
+		labels = append(labels, label)
+		if err := pingLLM(ctx, client); err != nil {
+			logger.Warn().Err(err).Str("backend", label).Msg("LLM backend ping failed - left out of the fallback chain")
+			continue
+		}
+		backends = append(backends, aillm.Backend{Name: label, Client: client})
+	}
+	if len(backends) == 0 {
+		return nil, "", "", errors.New("AI_LLM_BACKENDS: no backend reachable")
 	}
-	client, err := aillm.NewOpenRouterClient(cfg)
+	cfg := aillm.FallbackConfig{Cooldown: envDuration("AI_LLM_BREAKER_COOLDOWN")}
+	if n, err := strconv.Atoi(os.Getenv("AI_LLM_BREAKER_FAILURES")); err == nil {
+		cfg.FailureThreshold = n
+	}
+	client, err := aillm.NewFallbackClient(backends, cfg)
 	if err != nil {
-		return nil, "", err
+		return nil, "", "", err
 	}
+	return client, strings.Join(labels, ">"), primary, nil
+}
+
+func pingLLM(ctx context.Context, client pingableLLM) error {
 	pingCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
-	if err := client.Ping(pingCtx); err != nil {
-		return nil, "", fmt.Errorf("openrouter ping: %w", err)
+	return client.Ping(pingCtx)
+}
+
+// pingableLLM is what every adapter offers composition: the port plus
+// the model it asks for and a boot-time health check.
+type pingableLLM interface {
+	aiapp.LLMClient
+	Model() string
+	Ping(ctx context.Context) error
+}
+
+// newLLMClient builds one adapter from its provider's env. A non-empty
+// model overrides the provider's model variable.
+func newLLMClient(provider, model string) (pingableLLM, string, error) {
+	pick := func(key string) string {
+		if model != "" {
+			return model
+		}
+		return os.Getenv(key)
+	}
+	switch provider {
+	case "", "openrouter":
+		cfg := aillm.OpenRouterConfig{
+			URL:     os.Getenv("OPENROUTER_URL"),
+			APIKey:  os.Getenv("OPENROUTER_API_KEY"),
+			Model:   pick("OPENROUTER_MODEL"),
+			Referer: os.Getenv("OPENROUTER_REFERER"),
+			Title:   os.Getenv("OPENROUTER_TITLE"),
+			Timeout: envDuration("OPENROUTER_TIMEOUT"),
+		}
+		client, err := aillm.NewOpenRouterClient(cfg)
+		if err != nil {
+			return nil, "", err
+		}
+		return client, "openrouter:" + client.Model(), nil
+	case "ollama":
+		client, err := aillm.NewOllamaClient(aillm.OllamaConfig{
+			URL:     os.Getenv("OLLAMA_URL"),
+			Model:   pick("OLLAMA_MODEL"),
+			Timeout: envDuration("OLLAMA_TIMEOUT"),
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		return client, "ollama:" + client.Model(), nil
+	case "openai":
+		client, err := aillm.NewOpenAIClient(aillm.OpenAIConfig{
+			BaseURL: os.Getenv("OPENAI_COMPAT_URL"),
+			APIKey:  os.Getenv("OPENAI_COMPAT_API_KEY"),
+			Model:   pick("OPENAI_COMPAT_MODEL"),
+			Timeout: envDuration("OPENAI_COMPAT_TIMEOUT"),
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		return client, "openai:" + client.Model(), nil
+	case "stub":
+		return &aillm.Stub{}, "stub:" + aillm.StubModel, nil
+	default:
+		return nil, "", fmt.Errorf("unknown AI_LLM_PROVIDER %q (want openrouter, ollama, openai or stub)", provider)
+	}
+}
+
+// envDuration parses a Go duration from the environment. Unset or
+// malformed values yield 0, which the adapters read as "use default".
+func envDuration(key string) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return 0
+	}
+	return d
+}
+
+// buildSSEBackplane picks how SSE events reach clients on other
+// replicas. Environment:
+//
+//	SSE_BACKPLANE         — "memory" (default, single node) or "postgres"
+//	SSE_BACKPLANE_CHANNEL — NOTIFY channel, default "sse_events"
+//
+// Postgres mode needs the shared pgx pool; without it we log and fall
+// back to in-memory so a degraded boot still serves local clients.
+func buildSSEBackplane(pool *pgxpool.Pool) sse.Backplane {
+	switch mode := os.Getenv("SSE_BACKPLANE"); mode {
+	case "", "memory":
+		return nil
+	case "postgres":
+		if pool == nil {
+			logger.Warn().Msg("SSE_BACKPLANE=postgres but no pgx pool - SSE events stay on this replica")
+			return nil
+		}
+		logger.Info().Msg("SSE backplane: postgres LISTEN/NOTIFY")
+		return sse.NewPostgresBackplane(pool, sse.PostgresBackplaneConfig{
+			Channel: os.Getenv("SSE_BACKPLANE_CHANNEL"),
+		})
+	default:
+		logger.Warn().Str("mode", mode).Msg("Unknown SSE_BACKPLANE - SSE events stay on this replica")
+		return nil
 	}
-	return client, "openrouter:" + client.Model(), nil
 }
 
 // statsToExportsReader is the anti-corruption layer between the stats
@@ -454,7 +891,7 @@ func buildRouter(d routerDeps) http.Handler {
 	apiRouter.Handle("/stats", d.combinedAuth.RequireAuth(http.HandlerFunc(d.statsHandler.GetUserStats))).Methods("GET", "OPTIONS")
 	apiRouter.Handle("/stats", d.combinedAuth.RequireAuth(http.HandlerFunc(d.statsHandler.UpdateUserStats))).Methods("POST", "OPTIONS")
 
-	apiRouter.Handle("/events", d.sseBroker).Methods("GET")
+	apiRouter.Handle("/events", d.combinedAuth.RequireAuth(d.sseBroker)).Methods("GET")
 	apiRouter.HandleFunc("/trigger-update", func(w http.ResponseWriter, _ *http.Request) {
 		d.sseBroker.Broadcast("stats-updated", `{"trigger":"manual"}`)
 		w.Header().Set("Content-Type", "application/json")
@@ -476,9 +913,14 @@ func buildRouter(d routerDeps) http.Handler {
 
 	if d.aiHandler != nil {
 		apiRouter.Handle("/ai/summarize-repo", d.combinedAuth.RequireAuth(http.HandlerFunc(d.aiHandler.SummarizeRepo))).Methods("POST", "OPTIONS")
+		apiRouter.Handle("/ai/quota", d.combinedAuth.RequireAuth(http.HandlerFunc(d.aiHandler.GetQuota))).Methods("GET", "OPTIONS")
 		apiRouter.Handle("/ai/summaries", d.combinedAuth.RequireAuth(http.HandlerFunc(d.aiHandler.ListRepoSummaries))).Methods("GET", "OPTIONS")
 		apiRouter.Handle("/ai/summaries/{id}", d.combinedAuth.RequireAuth(http.HandlerFunc(d.aiHandler.GetRepoSummary))).Methods("GET", "OPTIONS")
 		apiRouter.Handle("/ai/summaries/{id}", d.combinedAuth.RequireAuth(http.HandlerFunc(d.aiHandler.DeleteRepoSummary))).Methods("DELETE", "OPTIONS")
+		apiRouter.Handle("/ai/summaries/{id}/cancel", d.combinedAuth.RequireAuth(http.HandlerFunc(d.aiHandler.CancelRepoSummary))).Methods("POST", "OPTIONS")
+		apiRouter.Handle("/ai/summaries/{id}/retry", d.combinedAuth.RequireAuth(http.HandlerFunc(d.aiHandler.RetryRepoSummary))).Methods("POST", "OPTIONS")
+		apiRouter.Handle("/ai/summaries/{id}/ask", d.combinedAuth.RequireAuth(http.HandlerFunc(d.aiHandler.AskQuestion))).Methods("POST", "OPTIONS")
+		apiRouter.Handle("/ai/summaries/{id}/questions", d.combinedAuth.RequireAuth(http.HandlerFunc(d.aiHandler.ListQuestions))).Methods("GET", "OPTIONS")
 	}
 
 	return router
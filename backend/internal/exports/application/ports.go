@@ -26,11 +26,12 @@ type Result struct {
 }
 
 // ProgressPublisher emits per-job progress events to clients (SSE).
-// The exports context broadcasts its own "export-progress" topic; it
-// does not share the stats context's domain event publisher because
+// The exports context sends its own "export-progress" event; it does
+// not share the stats context's domain event publisher because
 // progress is an infrastructure-level fan-out, not a domain event.
+// Events are addressed to the job's owner only.
 type ProgressPublisher interface {
-	Broadcast(eventName, payload string)
+	SendToUser(userID, eventName, payload string)
 }
 
 // JobEnqueuer schedules export work. Only one method today.
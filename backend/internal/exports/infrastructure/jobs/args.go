@@ -14,7 +14,7 @@ type DataExportArgs struct {
 
 func (DataExportArgs) Kind() string { return "data_export" }
 
-// ProgressUpdate is what the worker broadcasts to subscribed clients.
+// ProgressUpdate is what the worker sends to the job owner's clients.
 type ProgressUpdate struct {
 	JobID      string         `json:"jobId"`
 	Status     exports.Status `json:"status"`
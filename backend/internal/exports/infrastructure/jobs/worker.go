@@ -42,7 +42,7 @@ func (w *DataExportWorker) Work(ctx context.Context, job *river.Job[DataExportAr
 		Str("data_type", args.DataType).
 		Msg("Starting data export job")
 
-	w.sendProgress(ProgressUpdate{
+	w.sendProgress(args.UserID, ProgressUpdate{
 		JobID:    args.JobID,
 		Status:   exports.StatusProcessing,
 		Progress: 0,
@@ -50,7 +50,7 @@ func (w *DataExportWorker) Work(ctx context.Context, job *river.Job[DataExportAr
 	})
 	time.Sleep(500 * time.Millisecond)
 
-	w.sendProgress(ProgressUpdate{
+	w.sendProgress(args.UserID, ProgressUpdate{
 		JobID:    args.JobID,
 		Status:   exports.StatusProcessing,
 		Progress: 20,
@@ -60,7 +60,7 @@ func (w *DataExportWorker) Work(ctx context.Context, job *river.Job[DataExportAr
 	data := w.gather(ctx, args.UserID, args.DataType)
 	time.Sleep(500 * time.Millisecond)
 
-	w.sendProgress(ProgressUpdate{
+	w.sendProgress(args.UserID, ProgressUpdate{
 		JobID:    args.JobID,
 		Status:   exports.StatusProcessing,
 		Progress: 50,
@@ -87,7 +87,7 @@ func (w *DataExportWorker) Work(ctx context.Context, job *river.Job[DataExportAr
 	}
 	if err != nil {
 		logger.Error().Err(err).Str("job_id", args.JobID).Msg("Export conversion failed")
-		w.sendProgress(ProgressUpdate{
+		w.sendProgress(args.UserID, ProgressUpdate{
 			JobID:    args.JobID,
 			Status:   exports.StatusFailed,
 			Progress: 0,
@@ -98,7 +98,7 @@ func (w *DataExportWorker) Work(ctx context.Context, job *river.Job[DataExportAr
 	}
 
 	time.Sleep(500 * time.Millisecond)
-	w.sendProgress(ProgressUpdate{
+	w.sendProgress(args.UserID, ProgressUpdate{
 		JobID:    args.JobID,
 		Status:   exports.StatusProcessing,
 		Progress: 80,
@@ -114,7 +114,7 @@ func (w *DataExportWorker) Work(ctx context.Context, job *river.Job[DataExportAr
 		ExpiresAt:   time.Now().Add(1 * time.Hour),
 	})
 
-	w.sendProgress(ProgressUpdate{
+	w.sendProgress(args.UserID, ProgressUpdate{
 		JobID:      args.JobID,
 		Status:     exports.StatusCompleted,
 		Progress:   100,
@@ -132,12 +132,12 @@ func (w *DataExportWorker) Work(ctx context.Context, job *river.Job[DataExportAr
 	return nil
 }
 
-func (w *DataExportWorker) sendProgress(update ProgressUpdate) {
+func (w *DataExportWorker) sendProgress(userID string, update ProgressUpdate) {
 	if w.progress == nil {
 		return
 	}
 	payload, _ := json.Marshal(update)
-	w.progress.Broadcast("export-progress", string(payload))
+	w.progress.SendToUser(userID, "export-progress", string(payload))
 }
 
 func (w *DataExportWorker) gather(ctx context.Context, userID, dataType string) []map[string]any {
@@ -34,7 +34,7 @@ func (c *CORSMiddleware) Handler(next http.Handler) http.Handler {
 
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, Cookie, X-Webhook-Secret")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, Cookie, X-Webhook-Secret, Last-Event-ID")
 
 		// Handle preflight
 		if r.Method == "OPTIONS" {
@@ -57,7 +57,7 @@ func DefaultCORSConfig() CORSConfig {
 	return CORSConfig{
 		AllowedOrigins:   []string{"http://localhost:3000"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Content-Type", "Authorization", "X-Requested-With", "Cookie", "X-Webhook-Secret"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization", "X-Requested-With", "Cookie", "X-Webhook-Secret", "Last-Event-ID"},
 		AllowCredentials: true,
 	}
 }
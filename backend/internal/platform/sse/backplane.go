@@ -0,0 +1,103 @@
+package sse
+
+import (
+	"context"
+	"time"
+
+	"github.com/atilladeniz/next-go-pg/backend/pkg/metrics"
+)
+
+// Backplane relays events between broker replicas so a publish on one
+// node reaches clients connected to any node. Without one the broker
+// runs in-memory only, which is all a single-node dev setup needs.
+//
+// Implementations must not hand a replica back its own events: the
+// broker already delivered those locally when they were published.
+type Backplane interface {
+	// Publish forwards a locally published event to the other
+	// replicas. ID is not carried — every replica numbers the events
+	// it delivers itself.
+	Publish(ctx context.Context, ev Event) error
+	// Listen calls deliver for every event published by another
+	// replica until ctx is cancelled. It owns reconnecting; a non-nil
+	// return means the backplane gave up for good.
+	Listen(ctx context.Context, deliver func(Event)) error
+}
+
+// relayQueueSize bounds the events waiting to go out on the
+// backplane. Publishers never block on the network; when the queue is
+// full the event still reaches local clients but not remote ones.
+const relayQueueSize = 256
+
+// relayPublishTimeout bounds a single backplane publish so a stuck
+// database connection cannot wedge the relay goroutine.
+const relayPublishTimeout = 5 * time.Second
+
+// WithBackplane fans events out across replicas through bp. Events
+// are still delivered to local clients immediately; the backplane
+// carries them to the other nodes.
+func WithBackplane(bp Backplane) Option {
+	return func(b *Broker) { b.backplane = bp }
+}
+
+// startRelay launches the goroutines that push local events onto the
+// backplane and feed remote ones into the dispatch loop. They stop
+// when Shutdown cancels relayCtx.
+func (b *Broker) startRelay() {
+	ctx, cancel := context.WithCancel(context.Background())
+	b.relayCancel = cancel
+	b.relay = make(chan Event, relayQueueSize)
+	b.relayDone = make(chan struct{})
+
+	forwarded := make(chan struct{})
+	go func() {
+		defer close(forwarded)
+		b.forward(ctx)
+	}()
+	go func() {
+		defer close(b.relayDone)
+		if err := b.backplane.Listen(ctx, b.dispatchLocal); err != nil && ctx.Err() == nil {
+			metrics.SSEBackplaneErrors.WithLabelValues("listen").Inc()
+		}
+		<-forwarded
+	}()
+}
+
+func (b *Broker) forward(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-b.relay:
+			pubCtx, cancel := context.WithTimeout(ctx, relayPublishTimeout)
+			if err := b.backplane.Publish(pubCtx, ev); err != nil && ctx.Err() == nil {
+				metrics.SSEBackplaneErrors.WithLabelValues("publish").Inc()
+			}
+			cancel()
+		}
+	}
+}
+
+// enqueueRelay hands ev to the forward goroutine without blocking.
+func (b *Broker) enqueueRelay(ev Event) {
+	select {
+	case b.relay <- ev:
+	default:
+		metrics.SSEBackplaneErrors.WithLabelValues("queue_full").Inc()
+	}
+}
+
+// stopRelay cancels the relay goroutines and waits for them, or for
+// ctx to expire.
+func (b *Broker) stopRelay(ctx context.Context) error {
+	if b.relayCancel == nil {
+		return nil
+	}
+	b.relayCancel()
+	select {
+	case <-b.relayDone:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,113 @@
+package sse
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryHub links brokers in one process the way a database would
+// link replicas, so relay behaviour can be tested without Postgres.
+type memoryHub struct {
+	mu    sync.Mutex
+	nodes []*hubNode
+}
+
+type hubNode struct {
+	hub     *memoryHub
+	inbox   chan Event
+	publish chan Event // every event this node sent, for assertions
+}
+
+func (h *memoryHub) node() *hubNode {
+	n := &hubNode{hub: h, inbox: make(chan Event, 16), publish: make(chan Event, 16)}
+	h.mu.Lock()
+	h.nodes = append(h.nodes, n)
+	h.mu.Unlock()
+	return n
+}
+
+func (n *hubNode) Publish(_ context.Context, ev Event) error {
+	n.publish <- ev
+	n.hub.mu.Lock()
+	defer n.hub.mu.Unlock()
+	for _, peer := range n.hub.nodes {
+		if peer != n {
+			peer.inbox <- ev
+		}
+	}
+	return nil
+}
+
+func (n *hubNode) Listen(ctx context.Context, deliver func(Event)) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev := <-n.inbox:
+			deliver(ev)
+		}
+	}
+}
+
+func TestBackplaneRelaysEventsToOtherReplicas(t *testing.T) {
+	hub := &memoryHub{}
+	a := NewBroker(WithHeartbeatInterval(time.Hour), WithBackplane(hub.node()))
+	b := NewBroker(WithHeartbeatInterval(time.Hour), WithBackplane(hub.node()))
+	defer func() { _ = a.Shutdown(context.Background()) }()
+	defer func() { _ = b.Shutdown(context.Background()) }()
+
+	onA := make(chan Event, 10)
+	onB := make(chan Event, 10)
+	alice := map[string]struct{}{UserTopic("alice"): {}}
+	a.register <- subscription{ch: onA, topics: alice}
+	b.register <- subscription{ch: onB, topics: alice}
+
+	a.SendToUser("alice", "ai-progress", "step")
+
+	for name, ch := range map[string]chan Event{"local": onA, "remote": onB} {
+		select {
+		case ev := <-ch:
+			if ev.Type != "ai-progress" || ev.Data != "step" || ev.ID == 0 {
+				t.Errorf("%s replica got %+v", name, ev)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s replica did not receive the event", name)
+		}
+	}
+
+	// The remote copy must not bounce back to the origin.
+	select {
+	case ev := <-onA:
+		t.Errorf("origin received its own event twice: %+v", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestBrokerWithoutBackplaneStaysLocal(t *testing.T) {
+	b := NewBroker(WithHeartbeatInterval(time.Hour))
+	defer func() { _ = b.Shutdown(context.Background()) }()
+	if b.relay != nil {
+		t.Errorf("in-memory broker should not start a relay")
+	}
+	if err := b.stopRelay(context.Background()); err != nil {
+		t.Errorf("stopRelay without backplane: %v", err)
+	}
+}
+
+func TestShutdownStopsBackplaneListener(t *testing.T) {
+	hub := &memoryHub{}
+	b := NewBroker(WithHeartbeatInterval(time.Hour), WithBackplane(hub.node()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := b.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	select {
+	case <-b.relayDone:
+	default:
+		t.Errorf("relay goroutines still running after Shutdown")
+	}
+}
@@ -1,13 +1,27 @@
-// Package sse owns the platform-wide Server-Sent Events broker. It
-// is a fan-out: every connected HTTP client receives every broadcast
-// event, with drop-on-buffer-full back-pressure so a slow client
-// cannot stall the rest.
+// Package sse owns the platform-wide Server-Sent Events broker. Every
+// event carries an optional topic: untargeted events fan out to every
+// connected client, targeted events only reach clients subscribed to
+// that topic. Authenticated clients are implicitly subscribed to their
+// own user topic, so per-user payloads never leave the owner's
+// browser. Delivery uses drop-on-buffer-full back-pressure so a slow
+// client cannot stall the rest.
+//
+// Every event gets a monotonically increasing ID and is kept in a
+// bounded per-topic ring buffer. A reconnecting client that sends
+// Last-Event-ID is replayed what it missed, or told to reset when the
+// gap is older than the buffer.
+//
+// With a Backplane configured (see WithBackplane) events published on
+// one replica are relayed to clients connected to every other replica;
+// without one the broker is in-memory and single-node.
 package sse
 
 import (
 	"context"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -20,16 +34,59 @@ import (
 // under that.
 const defaultHeartbeatInterval = 25 * time.Second
 
-// Event is a single SSE message.
+// userTopicPrefix namespaces the implicit per-user topics. Clients
+// cannot subscribe to these by name — the broker derives the one
+// topic they are entitled to from the authenticated request.
+const userTopicPrefix = "user:"
+
+// maxTopicsPerClient caps how many named topics a single connection
+// may request via ?topic=, so a client can't make every dispatch walk
+// an unbounded set.
+const maxTopicsPerClient = 16
+
+// resetEventType tells a resuming client its gap could not be
+// replayed and it must reload state from the REST API.
+const resetEventType = "reset"
+
+// Event is a single SSE message. An empty Topic reaches every client.
+// ID is assigned by the broker at dispatch time; callers leave it zero.
 type Event struct {
-	Type string
-	Data string
+	ID    uint64
+	Type  string
+	Data  string
+	Topic string
+}
+
+// UserTopic returns the private topic for userID. Events published on
+// it are delivered only to connections authenticated as that user.
+func UserTopic(userID string) string {
+	return userTopicPrefix + userID
+}
+
+// subscription is a registered client: its delivery channel plus the
+// set of topics it receives in addition to untargeted events. When
+// resume is non-nil the run loop answers on it with the events missed
+// since lastEventID, computed atomically with the registration so
+// nothing falls between replay and live delivery.
+type subscription struct {
+	ch          chan Event
+	topics      map[string]struct{}
+	lastEventID uint64
+	resume      chan replay
+}
+
+func (s subscription) wants(ev Event) bool {
+	if ev.Topic == "" {
+		return true
+	}
+	_, ok := s.topics[ev.Topic]
+	return ok
 }
 
 // Broker manages SSE client connections.
 type Broker struct {
-	clients    map[chan Event]struct{}
-	register   chan chan Event
+	clients    map[chan Event]subscription
+	register   chan subscription
 	unregister chan chan Event
 	broadcast  chan Event
 	done       chan struct{} // closed by Shutdown to signal run() to exit
@@ -39,6 +96,21 @@ type Broker struct {
 	shutdown bool
 
 	heartbeatInterval time.Duration
+	userID            func(context.Context) string
+
+	// Replay state — owned by run(), never touched elsewhere.
+	history          map[string]*history
+	historySize      int
+	maxHistoryTopics int
+	historyFloor     uint64 // newest ID held by an evicted topic ring
+	firstID          uint64 // exclusive lower bound of IDs this broker issues
+	lastID           uint64
+
+	// Cross-replica relay — nil backplane means in-memory only.
+	backplane   Backplane
+	relay       chan Event
+	relayCancel context.CancelFunc
+	relayDone   chan struct{}
 }
 
 // Option configures a Broker. Production callers should not need any.
@@ -50,22 +122,56 @@ func WithHeartbeatInterval(d time.Duration) Option {
 	return func(b *Broker) { b.heartbeatInterval = d }
 }
 
+// WithUserIDFunc tells ServeHTTP how to find the authenticated user on
+// the request context (e.g. middleware.GetUserIDFromContext). Without
+// it, or when it returns "", a connection only receives untargeted
+// events and the named topics it asked for.
+func WithUserIDFunc(f func(context.Context) string) Option {
+	return func(b *Broker) { b.userID = f }
+}
+
+// WithHistorySize sets how many recent events are retained per topic
+// for Last-Event-ID replay. Zero disables replay: every resuming client
+// gets a reset. Default 128.
+func WithHistorySize(n int) Option {
+	return func(b *Broker) { b.historySize = n }
+}
+
+// WithMaxHistoryTopics caps how many topic rings are kept. When full,
+// the least recently written topic is dropped. Default 1024.
+func WithMaxHistoryTopics(n int) Option {
+	return func(b *Broker) { b.maxHistoryTopics = n }
+}
+
 // NewBroker creates a new SSE broker and starts its dispatch loop.
 // Call Shutdown to stop the loop and release the goroutine.
 func NewBroker(opts ...Option) *Broker {
 	b := &Broker{
-		clients:           make(map[chan Event]struct{}),
-		register:          make(chan chan Event),
+		clients:           make(map[chan Event]subscription),
+		register:          make(chan subscription),
 		unregister:        make(chan chan Event),
 		broadcast:         make(chan Event),
 		done:              make(chan struct{}),
 		closed:            make(chan struct{}),
 		heartbeatInterval: defaultHeartbeatInterval,
+		history:           make(map[string]*history),
+		historySize:       defaultHistorySize,
+		maxHistoryTopics:  defaultMaxHistoryTopics,
 	}
+	// Every ID issued is strictly greater than firstID, so a client
+	// holding firstID has seen nothing yet but is still replayable.
+	b.firstID = uint64(time.Now().UnixMicro())
+	b.lastID = b.firstID
 	for _, o := range opts {
 		o(b)
 	}
+	if b.maxHistoryTopics <= 0 {
+		b.maxHistoryTopics = defaultMaxHistoryTopics
+	}
 	go b.run()
+	if b.backplane != nil {
+		b.startRelay()
+	}
 	return b
 }
 
@@ -77,11 +183,14 @@ func (b *Broker) run() {
 			b.drainClients()
 			return
 
-		case client := <-b.register:
+		case sub := <-b.register:
 			b.mu.Lock()
-			b.clients[client] = struct{}{}
+			b.clients[sub.ch] = sub
 			b.mu.Unlock()
 			metrics.SSEConnectionsActive.Inc()
+			if sub.resume != nil {
+				sub.resume <- b.replayFor(sub)
+			}
 
 		case client := <-b.unregister:
 			b.mu.Lock()
@@ -93,8 +202,13 @@ func (b *Broker) run() {
 			b.mu.Unlock()
 
 		case event := <-b.broadcast:
+			event.ID = b.nextEventID()
+			b.record(event)
 			b.mu.RLock()
-			for client := range b.clients {
+			for client, sub := range b.clients {
+				if !sub.wants(event) {
+					continue
+				}
 				select {
 				case client <- event:
 					metrics.SSEMessagesSent.Inc()
@@ -121,42 +235,72 @@ func (b *Broker) drainClients() {
 	}
 }
 
-// Broadcast sends an event to every connected client. No-op after Shutdown.
+// Broadcast sends an untargeted system event to every connected
+// client. No-op after Shutdown.
 func (b *Broker) Broadcast(eventType string, data string) {
+	b.dispatch(Event{Type: eventType, Data: data})
+}
+
+// Publish sends an event to the clients subscribed to topic. An empty
+// topic is the same as Broadcast.
+func (b *Broker) Publish(topic, eventType, data string) {
+	b.dispatch(Event{Type: eventType, Data: data, Topic: topic})
+}
+
+// SendToUser sends an event only to connections authenticated as
+// userID. An empty userID drops the event instead of widening it to a
+// broadcast — a publisher that lost track of its owner must not leak
+// the payload to everyone.
+func (b *Broker) SendToUser(userID, eventType, data string) {
+	if userID == "" {
+		return
+	}
+	b.Publish(UserTopic(userID), eventType, data)
+}
+
+// dispatch delivers a locally published event to this replica's
+// clients and hands it to the backplane for the others.
+func (b *Broker) dispatch(event Event) {
+	if b.isShutdown() {
+		return
+	}
+	b.dispatchLocal(event)
+	if b.backplane != nil {
+		b.enqueueRelay(event)
+	}
+}
+
+// dispatchLocal feeds event into this replica's run loop only. It is
+// also the sink for events arriving from the backplane.
+func (b *Broker) dispatchLocal(event Event) {
 	if b.isShutdown() {
 		return
 	}
 	select {
-	case b.broadcast <- Event{Type: eventType, Data: data}:
+	case b.broadcast <- event:
 	case <-b.done:
-		// Shutdown raced with this Broadcast — drop the event.
+		// Shutdown raced with this publish — drop the event.
 	}
 }
 
-// Shutdown signals the dispatch loop to exit and waits for it (or
-// for ctx to expire). Safe to call multiple times.
+// Shutdown signals the dispatch loop and the backplane relay to exit
+// and waits for them (or for ctx to expire). Safe to call multiple
+// times.
 func (b *Broker) Shutdown(ctx context.Context) error {
 	b.mu.Lock()
-	if b.shutdown {
-		b.mu.Unlock()
-		// Already shutting down — still wait for run() to finish.
-		select {
-		case <-b.closed:
-			return nil
-		case <-ctx.Done():
-			return ctx.Err()
-		}
+	if !b.shutdown {
+		b.shutdown = true
+		close(b.done)
 	}
-	b.shutdown = true
+	// A repeated call still waits for run() and the relay to finish.
 	b.mu.Unlock()
 
-	close(b.done)
 	select {
 	case <-b.closed:
-		return nil
 	case <-ctx.Done():
 		return ctx.Err()
 	}
+	return b.stopRelay(ctx)
 }
 
 func (b *Broker) isShutdown() bool {
@@ -165,8 +309,52 @@ func (b *Broker) isShutdown() bool {
 	return b.shutdown
 }
 
+// lastEventID reads the resume cursor. Browsers send the
+// Last-Event-ID header on EventSource auto-reconnect; clients that
+// reconnect by hand can pass ?lastEventId= instead. ok is false when
+// neither is present or the value is malformed (treated as a fresh
+// connection).
+func lastEventID(r *http.Request) (uint64, bool) {
+	raw := r.Header.Get("Last-Event-ID")
+	if raw == "" {
+		raw = r.URL.Query().Get("lastEventId")
+	}
+	if raw == "" {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(strings.TrimSpace(raw), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// requestedTopics collects the named topics a client asked for via
+// repeated or comma-separated ?topic= parameters. User topics are
+// silently ignored: the only one a client may receive is its own,
+// and that one is added from the authenticated context.
+func requestedTopics(r *http.Request) map[string]struct{} {
+	topics := make(map[string]struct{})
+	for _, raw := range r.URL.Query()["topic"] {
+		for _, t := range strings.Split(raw, ",") {
+			t = strings.TrimSpace(t)
+			if t == "" || strings.HasPrefix(t, userTopicPrefix) {
+				continue
+			}
+			if len(topics) >= maxTopicsPerClient {
+				return topics
+			}
+			topics[t] = struct{}{}
+		}
+	}
+	return topics
+}
+
 // ServeHTTP streams SSE events to a single client. A periodic
 // heartbeat comment keeps idle connections alive behind proxies.
+// Mount it behind the auth middleware so the user topic can be
+// resolved; CORS headers are left to the router's CORS middleware
+// (a wildcard origin here would break credentialed EventSource).
 func (b *Broker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	flusher, ok := w.(http.Flusher)
 	if !ok {
@@ -177,14 +365,24 @@ func (b *Broker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 
+	topics := requestedTopics(r)
+	if b.userID != nil {
+		if uid := b.userID(r.Context()); uid != "" {
+			topics[UserTopic(uid)] = struct{}{}
+		}
+	}
 	client := make(chan Event, 10)
+	sub := subscription{ch: client, topics: topics}
+	if id, ok := lastEventID(r); ok {
+		sub.lastEventID = id
+		sub.resume = make(chan replay, 1)
+	}
 
 	// Register. If the broker is mid-shutdown, the register channel
 	// has no reader — fall through to a 503 instead of blocking.
 	select {
-	case b.register <- client:
+	case b.register <- sub:
 	case <-b.done:
 		http.Error(w, "SSE broker shutting down", http.StatusServiceUnavailable)
 		return
@@ -202,6 +400,17 @@ func (b *Broker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}()
 
 	fmt.Fprint(w, "event: connected\ndata: {\"status\":\"ok\"}\n\n")
+	if sub.resume != nil {
+		// Sent by run() right after registration; buffered, so this
+		// never blocks past the register hand-off.
+		missed := <-sub.resume
+		if missed.reset {
+			writeEvent(w, Event{ID: missed.head, Type: resetEventType, Data: `{"reason":"history-gap"}`})
+		}
+		for _, ev := range missed.events {
+			writeEvent(w, ev)
+		}
+	}
 	flusher.Flush()
 
 	ticker := time.NewTicker(b.heartbeatInterval)
@@ -214,7 +423,7 @@ func (b *Broker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				// Broker drained us during shutdown.
 				return
 			}
-			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, event.Data)
+			writeEvent(w, event)
 			flusher.Flush()
 
 		case <-ticker.C:
@@ -228,6 +437,15 @@ func (b *Broker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// writeEvent serialises one event in SSE wire format. The id line is
+// what the browser echoes back as Last-Event-ID on reconnect.
+func writeEvent(w http.ResponseWriter, ev Event) {
+	if ev.ID > 0 {
+		fmt.Fprintf(w, "id: %d\n", ev.ID)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, ev.Data)
+}
+
 // ClientCount returns the number of currently connected clients.
 func (b *Broker) ClientCount() int {
 	b.mu.RLock()
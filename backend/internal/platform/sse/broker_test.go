@@ -30,8 +30,8 @@ func TestBroadcastDeliversToAllClients(t *testing.T) {
 
 	c1 := make(chan Event, 1)
 	c2 := make(chan Event, 1)
-	b.register <- c1
-	b.register <- c2
+	b.register <- subscription{ch: c1}
+	b.register <- subscription{ch: c2}
 
 	if !waitFor(t, time.Second, func() bool { return b.ClientCount() == 2 }) {
 		t.Fatalf("expected 2 clients, got %d", b.ClientCount())
@@ -57,8 +57,8 @@ func TestUnregisterStopsDelivery(t *testing.T) {
 
 	c1 := make(chan Event, 1)
 	c2 := make(chan Event, 1)
-	b.register <- c1
-	b.register <- c2
+	b.register <- subscription{ch: c1}
+	b.register <- subscription{ch: c2}
 
 	if !waitFor(t, time.Second, func() bool { return b.ClientCount() == 2 }) {
 		t.Fatalf("setup: expected 2 clients")
@@ -102,8 +102,8 @@ func TestSlowClientDoesNotBlockOthers(t *testing.T) {
 
 	fast := make(chan Event, 10)
 
-	b.register <- slow
-	b.register <- fast
+	b.register <- subscription{ch: slow}
+	b.register <- subscription{ch: fast}
 	if !waitFor(t, time.Second, func() bool { return b.ClientCount() == 2 }) {
 		t.Fatalf("setup: expected 2 clients")
 	}
@@ -120,6 +120,118 @@ func TestSlowClientDoesNotBlockOthers(t *testing.T) {
 	}
 }
 
+func TestSendToUserReachesOnlyThatUser(t *testing.T) {
+	b := NewBroker()
+	defer func() { _ = b.Shutdown(context.Background()) }()
+
+	alice := make(chan Event, 1)
+	bob := make(chan Event, 1)
+	anon := make(chan Event, 1)
+	b.register <- subscription{ch: alice, topics: map[string]struct{}{UserTopic("alice"): {}}}
+	b.register <- subscription{ch: bob, topics: map[string]struct{}{UserTopic("bob"): {}}}
+	b.register <- subscription{ch: anon}
+	if !waitFor(t, time.Second, func() bool { return b.ClientCount() == 3 }) {
+		t.Fatalf("setup: expected 3 clients")
+	}
+
+	b.SendToUser("alice", "ai-progress", `{"summaryId":1}`)
+	// Untargeted marker so we know dispatch of the first event finished.
+	b.Broadcast("marker", "x")
+
+	select {
+	case ev := <-alice:
+		if ev.Type != "ai-progress" {
+			t.Errorf("alice got %+v, want ai-progress first", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("alice did not receive her event")
+	}
+	for name, c := range map[string]chan Event{"bob": bob, "anon": anon} {
+		select {
+		case ev := <-c:
+			if ev.Type != "marker" {
+				t.Errorf("%s received another user's event: %+v", name, ev)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s did not receive the broadcast marker", name)
+		}
+	}
+}
+
+func TestSendToUserWithEmptyUserIsDropped(t *testing.T) {
+	b := NewBroker()
+	defer func() { _ = b.Shutdown(context.Background()) }()
+
+	c := make(chan Event, 2)
+	b.register <- subscription{ch: c}
+	if !waitFor(t, time.Second, func() bool { return b.ClientCount() == 1 }) {
+		t.Fatalf("setup: expected 1 client")
+	}
+
+	b.SendToUser("", "ai-progress", "secret")
+	b.Broadcast("marker", "x")
+
+	select {
+	case ev := <-c:
+		if ev.Type != "marker" {
+			t.Errorf("event for empty user leaked as broadcast: %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("marker not delivered")
+	}
+}
+
+func TestPublishRespectsTopicSubscriptions(t *testing.T) {
+	b := NewBroker()
+	defer func() { _ = b.Shutdown(context.Background()) }()
+
+	sub := make(chan Event, 1)
+	other := make(chan Event, 1)
+	b.register <- subscription{ch: sub, topics: map[string]struct{}{"exports": {}}}
+	b.register <- subscription{ch: other, topics: map[string]struct{}{"stats": {}}}
+	if !waitFor(t, time.Second, func() bool { return b.ClientCount() == 2 }) {
+		t.Fatalf("setup: expected 2 clients")
+	}
+
+	b.Publish("exports", "export-progress", "{}")
+	b.Broadcast("marker", "x")
+
+	select {
+	case ev := <-sub:
+		if ev.Type != "export-progress" || ev.Topic != "exports" {
+			t.Errorf("subscriber got %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("topic subscriber did not receive event")
+	}
+	select {
+	case ev := <-other:
+		if ev.Type != "marker" {
+			t.Errorf("non-subscriber received topic event: %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("marker not delivered")
+	}
+}
+
+func TestRequestedTopicsIgnoresUserTopics(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/events?topic=exports,user:bob&topic=stats&topic=", nil)
+	got := requestedTopics(req)
+
+	if _, ok := got["exports"]; !ok {
+		t.Errorf("expected exports topic, got %v", got)
+	}
+	if _, ok := got["stats"]; !ok {
+		t.Errorf("expected stats topic, got %v", got)
+	}
+	if _, ok := got[UserTopic("bob")]; ok {
+		t.Errorf("client must not be able to subscribe to another user's topic")
+	}
+	if len(got) != 2 {
+		t.Errorf("expected 2 topics, got %v", got)
+	}
+}
+
 func TestShutdownIsIdempotentAndUnblocks(t *testing.T) {
 	b := NewBroker()
 
@@ -150,7 +262,7 @@ func TestShutdownDrainsConnectedClients(t *testing.T) {
 	b := NewBroker()
 
 	c := make(chan Event, 1)
-	b.register <- c
+	b.register <- subscription{ch: c}
 	if !waitFor(t, time.Second, func() bool { return b.ClientCount() == 1 }) {
 		t.Fatalf("setup: expected 1 client")
 	}
@@ -290,3 +402,49 @@ func TestServeHTTPViaTestServerStreamsBroadcasts(t *testing.T) {
 		t.Fatalf("timeout waiting for broadcast")
 	}
 }
+
+type ctxUserKey struct{}
+
+func TestServeHTTPSubscribesAuthenticatedUser(t *testing.T) {
+	b := NewBroker(
+		WithHeartbeatInterval(time.Hour),
+		WithUserIDFunc(func(ctx context.Context) string {
+			uid, _ := ctx.Value(ctxUserKey{}).(string)
+			return uid
+		}),
+	)
+	defer func() { _ = b.Shutdown(context.Background()) }()
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxUserKey{}, "alice"))
+	req := httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx)
+	rec := &flushRecorder{rec: httptest.NewRecorder()}
+
+	done := make(chan struct{})
+	go func() {
+		b.ServeHTTP(rec, req)
+		close(done)
+	}()
+	if !waitFor(t, time.Second, func() bool { return b.ClientCount() == 1 }) {
+		cancel()
+		<-done
+		t.Fatalf("client did not register")
+	}
+
+	b.SendToUser("bob", "ai-progress", `{"owner":"bob"}`)
+	b.SendToUser("alice", "ai-progress", `{"owner":"alice"}`)
+
+	ok := waitFor(t, time.Second, func() bool {
+		return strings.Contains(rec.body(), `{"owner":"alice"}`)
+	})
+	cancel()
+	<-done
+	if !ok {
+		t.Fatalf("expected alice's event in body, got: %q", rec.body())
+	}
+	if strings.Contains(rec.body(), `{"owner":"bob"}`) {
+		t.Errorf("alice's stream contains bob's event: %q", rec.body())
+	}
+	if got := rec.rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("broker must leave CORS to the middleware, got Allow-Origin %q", got)
+	}
+}
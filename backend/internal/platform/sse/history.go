@@ -0,0 +1,159 @@
+package sse
+
+import (
+	"sort"
+	"time"
+)
+
+// Defaults for the replay buffer. 128 events per topic covers a full
+// summarize-repo run (5 steps × start/end plus one tick per file at
+// the default 25-file cap) with headroom; 1024 topics bounds memory to
+// roughly the number of users active within the retention window.
+const (
+	defaultHistorySize      = 128
+	defaultMaxHistoryTopics = 1024
+)
+
+// history is a fixed-size ring of the most recent events on one topic.
+// It is owned by the broker's run goroutine and never locked.
+type history struct {
+	events []Event
+	start  int
+	count  int
+	// evicted is the ID of the newest event pushed out of the ring. A
+	// client whose last seen ID is below it has missed events we can
+	// no longer replay.
+	evicted uint64
+	// touched is the ID of the newest event written, used to pick the
+	// idlest topic when the topic cap is reached.
+	touched uint64
+}
+
+func newHistory(size int) *history {
+	return &history{events: make([]Event, size)}
+}
+
+func (h *history) push(ev Event) {
+	size := len(h.events)
+	if h.count == size {
+		h.evicted = h.events[h.start].ID
+		h.events[h.start] = ev
+		h.start = (h.start + 1) % size
+	} else {
+		h.events[(h.start+h.count)%size] = ev
+		h.count++
+	}
+	h.touched = ev.ID
+}
+
+// after appends every retained event with ID > lastID to out, oldest
+// first.
+func (h *history) after(lastID uint64, out []Event) []Event {
+	size := len(h.events)
+	for i := 0; i < h.count; i++ {
+		ev := h.events[(h.start+i)%size]
+		if ev.ID > lastID {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
+
+// replay is what a resuming client receives before live delivery
+// starts: either the events it missed, or reset=true when the gap
+// can't be bridged and it must reload state from the REST API.
+type replay struct {
+	events []Event
+	reset  bool
+	head   uint64 // newest ID issued so far; lets a reset move the client's cursor
+}
+
+// nextEventID returns a strictly increasing ID. IDs are seeded from
+// the wall clock in microseconds so they keep increasing across
+// process restarts, which is what lets replayFor recognise a
+// Last-Event-ID issued by a previous process.
+func (b *Broker) nextEventID() uint64 {
+	id := uint64(time.Now().UnixMicro())
+	if id <= b.lastID {
+		id = b.lastID + 1
+	}
+	b.lastID = id
+	return id
+}
+
+// record appends ev to its topic's ring, evicting the least recently
+// written topic if the topic cap is reached.
+func (b *Broker) record(ev Event) {
+	if b.historySize <= 0 {
+		return
+	}
+	h, ok := b.history[ev.Topic]
+	if !ok {
+		if len(b.history) >= b.maxHistoryTopics {
+			b.evictIdlestTopic()
+		}
+		h = newHistory(b.historySize)
+		b.history[ev.Topic] = h
+	}
+	h.push(ev)
+}
+
+func (b *Broker) evictIdlestTopic() {
+	var (
+		idlest string
+		oldest uint64
+		found  bool
+	)
+	for topic, h := range b.history {
+		if topic == "" {
+			// Keep the broadcast ring — every client replays it.
+			continue
+		}
+		if !found || h.touched < oldest {
+			idlest, oldest, found = topic, h.touched, true
+		}
+	}
+	if !found {
+		return
+	}
+	// Anything that topic ever held is gone now; remember the newest
+	// ID so a client resuming on it gets a reset, not silence.
+	if b.historyFloor < oldest {
+		b.historyFloor = oldest
+	}
+	delete(b.history, idlest)
+}
+
+// replayFor computes what a client resuming from lastID has missed on
+// the untargeted stream plus its subscribed topics.
+func (b *Broker) replayFor(sub subscription) replay {
+	out := replay{head: b.lastID}
+	if b.historySize <= 0 || sub.lastEventID < b.firstID {
+		// Replay disabled, or the ID was issued before this broker
+		// started — whatever happened in between is unknowable.
+		out.reset = true
+		return out
+	}
+	topics := make([]string, 0, len(sub.topics)+1)
+	topics = append(topics, "")
+	for t := range sub.topics {
+		topics = append(topics, t)
+	}
+	for _, t := range topics {
+		h, ok := b.history[t]
+		if !ok {
+			if sub.lastEventID < b.historyFloor {
+				out.reset = true
+				return out
+			}
+			continue
+		}
+		if h.evicted > sub.lastEventID {
+			out.reset = true
+			return out
+		}
+		out.events = h.after(sub.lastEventID, out.events)
+	}
+	sort.Slice(out.events, func(i, j int) bool { return out.events[i].ID < out.events[j].ID })
+	return out
+}
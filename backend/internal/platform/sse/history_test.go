@@ -0,0 +1,211 @@
+package sse
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistoryRingEvictsOldest(t *testing.T) {
+	h := newHistory(3)
+	for id := uint64(1); id <= 5; id++ {
+		h.push(Event{ID: id, Type: "e"})
+	}
+
+	got := h.after(0, nil)
+	if len(got) != 3 {
+		t.Fatalf("expected 3 retained events, got %d", len(got))
+	}
+	for i, want := range []uint64{3, 4, 5} {
+		if got[i].ID != want {
+			t.Errorf("event %d: ID = %d, want %d", i, got[i].ID, want)
+		}
+	}
+	if h.evicted != 2 {
+		t.Errorf("evicted = %d, want 2", h.evicted)
+	}
+	if tail := h.after(4, nil); len(tail) != 1 || tail[0].ID != 5 {
+		t.Errorf("after(4) = %+v, want only ID 5", tail)
+	}
+}
+
+func TestNextEventIDIsStrictlyIncreasing(t *testing.T) {
+	b := &Broker{}
+	prev := b.nextEventID()
+	for range 1000 {
+		id := b.nextEventID()
+		if id <= prev {
+			t.Fatalf("ID %d not greater than previous %d", id, prev)
+		}
+		prev = id
+	}
+}
+
+// newReplayBroker returns a broker whose run loop is NOT started, so
+// tests can drive record/replayFor directly without racing it.
+func newReplayBroker(size, maxTopics int) *Broker {
+	return &Broker{
+		history:          make(map[string]*history),
+		historySize:      size,
+		maxHistoryTopics: maxTopics,
+		firstID:          1,
+		lastID:           1,
+	}
+}
+
+func (b *Broker) emit(topic string) Event {
+	ev := Event{ID: b.nextEventID(), Type: "e", Topic: topic}
+	b.record(ev)
+	return ev
+}
+
+func TestReplayForReturnsMissedEventsInOrder(t *testing.T) {
+	b := newReplayBroker(8, 8)
+	seen := b.emit("")
+	mine := b.emit(UserTopic("alice"))
+	_ = b.emit(UserTopic("bob"))
+	sys := b.emit("")
+
+	got := b.replayFor(subscription{
+		topics:      map[string]struct{}{UserTopic("alice"): {}},
+		lastEventID: seen.ID,
+	})
+	if got.reset {
+		t.Fatalf("unexpected reset")
+	}
+	if len(got.events) != 2 || got.events[0].ID != mine.ID || got.events[1].ID != sys.ID {
+		t.Errorf("replay = %+v, want alice's event then the broadcast", got.events)
+	}
+}
+
+func TestReplayForResetsWhenGapExceedsBuffer(t *testing.T) {
+	b := newReplayBroker(2, 8)
+	first := b.emit(UserTopic("alice"))
+	for range 3 {
+		b.emit(UserTopic("alice"))
+	}
+
+	got := b.replayFor(subscription{
+		topics:      map[string]struct{}{UserTopic("alice"): {}},
+		lastEventID: first.ID,
+	})
+	if !got.reset {
+		t.Errorf("expected reset when evicted events are newer than Last-Event-ID")
+	}
+	if got.head != b.lastID {
+		t.Errorf("reset head = %d, want %d", got.head, b.lastID)
+	}
+}
+
+func TestReplayForResetsOnIDFromPreviousProcess(t *testing.T) {
+	b := newReplayBroker(8, 8)
+	b.firstID = 1_000
+	b.lastID = 1_000
+
+	got := b.replayFor(subscription{lastEventID: 500})
+	if !got.reset {
+		t.Errorf("expected reset for an ID issued before this broker started")
+	}
+}
+
+func TestReplayForResetsOnEvictedTopic(t *testing.T) {
+	b := newReplayBroker(8, 1)
+	seen := b.emit(UserTopic("alice"))
+	_ = b.emit(UserTopic("alice"))
+	// Cap of one topic: writing bob's topic evicts alice's ring.
+	_ = b.emit(UserTopic("bob"))
+
+	got := b.replayFor(subscription{
+		topics:      map[string]struct{}{UserTopic("alice"): {}},
+		lastEventID: seen.ID,
+	})
+	if !got.reset {
+		t.Errorf("expected reset when the client's topic ring was evicted")
+	}
+}
+
+func TestServeHTTPReplaysFromLastEventID(t *testing.T) {
+	b := NewBroker(WithHeartbeatInterval(time.Hour))
+	defer func() { _ = b.Shutdown(context.Background()) }()
+
+	// No clients yet: events only land in history. The run loop
+	// handles channel messages in order, so the probe registration
+	// below is processed after all three are recorded.
+	b.Broadcast("step", "1")
+	b.Broadcast("step", "2")
+	b.Broadcast("step", "3")
+
+	// Learn the assigned IDs by replaying from before the first one.
+	probe := replayOf(t, b, b.firstID)
+	if len(probe) != 3 {
+		t.Fatalf("setup: expected 3 events in history, got %d", len(probe))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx)
+	req.Header.Set("Last-Event-ID", fmt.Sprint(probe[0].ID))
+	rec := &flushRecorder{rec: httptest.NewRecorder()}
+
+	done := make(chan struct{})
+	go func() {
+		b.ServeHTTP(rec, req)
+		close(done)
+	}()
+	ok := waitFor(t, time.Second, func() bool {
+		return strings.Contains(rec.body(), "data: 3")
+	})
+	cancel()
+	<-done
+	if !ok {
+		t.Fatalf("expected replayed events, got: %q", rec.body())
+	}
+	body := rec.body()
+	if strings.Contains(body, "data: 1\n") {
+		t.Errorf("event at Last-Event-ID must not be replayed: %q", body)
+	}
+	if !strings.Contains(body, fmt.Sprintf("id: %d\nevent: step\ndata: 2", probe[1].ID)) {
+		t.Errorf("expected event 2 with its id line, got: %q", body)
+	}
+}
+
+func TestServeHTTPSendsResetForUnknownLastEventID(t *testing.T) {
+	b := NewBroker(WithHeartbeatInterval(time.Hour))
+	defer func() { _ = b.Shutdown(context.Background()) }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/events?lastEventId=42", nil).WithContext(ctx)
+	rec := &flushRecorder{rec: httptest.NewRecorder()}
+
+	done := make(chan struct{})
+	go func() {
+		b.ServeHTTP(rec, req)
+		close(done)
+	}()
+	ok := waitFor(t, time.Second, func() bool {
+		return strings.Contains(rec.body(), "event: reset")
+	})
+	cancel()
+	<-done
+	if !ok {
+		t.Fatalf("expected reset event, got: %q", rec.body())
+	}
+}
+
+// replayOf reads run-loop-owned history through the register channel
+// so the test never races the dispatch goroutine.
+func replayOf(t *testing.T, b *Broker, from uint64) []Event {
+	t.Helper()
+	ch := make(chan Event, 1)
+	resume := make(chan replay, 1)
+	b.register <- subscription{ch: ch, lastEventID: from, resume: resume}
+	r := <-resume
+	b.unregister <- ch
+	if r.reset {
+		t.Fatalf("unexpected reset replaying from %d", from)
+	}
+	return r.events
+}
@@ -0,0 +1,297 @@
+package sse
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/atilladeniz/next-go-pg/backend/pkg/logger"
+	"github.com/atilladeniz/next-go-pg/backend/pkg/metrics"
+)
+
+// DefaultPostgresChannel is the NOTIFY channel used when
+// PostgresBackplaneConfig.Channel is empty.
+const DefaultPostgresChannel = "sse_events"
+
+// notifyPayloadLimit is the largest NOTIFY payload we send. Postgres
+// rejects payloads of 8000 bytes or more in the default configuration;
+// the margin leaves room for the frame header.
+const notifyPayloadLimit = 7900
+
+// frameVersion prefixes every frame so a rolling deploy that changes
+// the wire format can ignore frames it doesn't understand.
+const frameVersion = "v1"
+
+// Reassembly bounds: a message whose chunks haven't all arrived after
+// reassemblyTimeout is discarded, and at most maxPartialMessages are
+// buffered at once.
+const (
+	reassemblyTimeout  = 30 * time.Second
+	maxPartialMessages = 1024
+)
+
+// Listener reconnect backoff.
+const (
+	listenRetryMin = time.Second
+	listenRetryMax = 30 * time.Second
+)
+
+// PostgresBackplaneConfig configures NewPostgresBackplane.
+type PostgresBackplaneConfig struct {
+	// Channel is the LISTEN/NOTIFY channel. Every replica sharing a
+	// database must use the same one. Default "sse_events".
+	Channel string
+}
+
+// PostgresBackplane relays events over Postgres LISTEN/NOTIFY on the
+// app's existing pgx pool. Payloads above the NOTIFY limit are split
+// into chunks that are sent in one transaction and reassembled on the
+// receiving side.
+//
+// NOTIFY is fire-and-forget: a replica whose listener is reconnecting
+// misses whatever was published meanwhile. Its clients recover through
+// the same Last-Event-ID reset path as any other gap.
+type PostgresBackplane struct {
+	pool    *pgxpool.Pool
+	channel string
+	origin  string
+	nextMsg atomic.Uint64
+}
+
+// NewPostgresBackplane returns a backplane publishing and listening on
+// pool. Each instance gets a random origin ID so it can recognise and
+// skip its own notifications.
+func NewPostgresBackplane(pool *pgxpool.Pool, cfg PostgresBackplaneConfig) *PostgresBackplane {
+	channel := cfg.Channel
+	if channel == "" {
+		channel = DefaultPostgresChannel
+	}
+	return &PostgresBackplane{pool: pool, channel: channel, origin: newOriginID()}
+}
+
+func newOriginID() string {
+	var buf [8]byte
+	_, _ = rand.Read(buf[:])
+	return hex.EncodeToString(buf[:])
+}
+
+// Publish sends ev to every other replica.
+func (p *PostgresBackplane) Publish(ctx context.Context, ev Event) error {
+	frames, err := encodeFrames(p.origin, p.nextMsg.Add(1), ev)
+	if err != nil {
+		return err
+	}
+	if len(frames) == 1 {
+		_, err := p.pool.Exec(ctx, "SELECT pg_notify($1, $2)", p.channel, frames[0])
+		return err
+	}
+	// Notifications raised in one transaction are delivered together
+	// on commit, so a listener never sees a partial message it has to
+	// wait on, and a failed send leaves no orphaned chunks.
+	return pgx.BeginFunc(ctx, p.pool, func(tx pgx.Tx) error {
+		for _, f := range frames {
+			if _, err := tx.Exec(ctx, "SELECT pg_notify($1, $2)", p.channel, f); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Listen holds one pooled connection in LISTEN mode and delivers
+// other replicas' events until ctx is cancelled, reconnecting with
+// backoff when the connection drops.
+func (p *PostgresBackplane) Listen(ctx context.Context, deliver func(Event)) error {
+	r := newReassembler(p.origin)
+	wait := listenRetryMin
+	for {
+		err := p.listenOnce(ctx, r, deliver)
+		if ctx.Err() != nil {
+			return nil
+		}
+		metrics.SSEBackplaneErrors.WithLabelValues("listen").Inc()
+		logger.Warn().Err(err).Dur("retry_in", wait).Msg("SSE backplane listener disconnected")
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil
+		}
+		wait = min(wait*2, listenRetryMax)
+	}
+}
+
+func (p *PostgresBackplane) listenOnce(ctx context.Context, r *reassembler, deliver func(Event)) error {
+	pooled, err := p.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire: %w", err)
+	}
+	// A connection in LISTEN mode must never go back to the pool, so
+	// take it out for good and close it ourselves.
+	conn := pooled.Hijack()
+	defer func() { _ = conn.Close(context.Background()) }()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{p.channel}.Sanitize()); err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	logger.Info().Str("channel", p.channel).Msg("SSE backplane listening")
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		ev, ok, err := r.add(n.Payload, time.Now())
+		if err != nil {
+			metrics.SSEBackplaneErrors.WithLabelValues("reassembly").Inc()
+			continue
+		}
+		if ok {
+			deliver(ev)
+		}
+	}
+}
+
+// wireEvent is the JSON body carried across the backplane. IDs are
+// per-replica, so they are not sent.
+type wireEvent struct {
+	Type  string `json:"t"`
+	Data  string `json:"d"`
+	Topic string `json:"p,omitempty"`
+}
+
+// encodeFrames serialises ev into one or more NOTIFY payloads of the
+// form "v1|origin|msgID|seq|total|chunk". Chunks split on UTF-8
+// boundaries because NOTIFY payloads must be valid text.
+func encodeFrames(origin string, msgID uint64, ev Event) ([]string, error) {
+	body, err := json.Marshal(wireEvent{Type: ev.Type, Data: ev.Data, Topic: ev.Topic})
+	if err != nil {
+		return nil, err
+	}
+	// Size the header for the worst case (up to 9999 chunks) so every
+	// frame fits regardless of its sequence number.
+	budget := notifyPayloadLimit - len(frameHeader(origin, msgID, 9999, 9999))
+	chunks := splitUTF8(string(body), budget)
+	if len(chunks) > 9999 {
+		return nil, fmt.Errorf("sse: event too large for backplane (%d bytes)", len(body))
+	}
+	frames := make([]string, len(chunks))
+	for i, c := range chunks {
+		frames[i] = frameHeader(origin, msgID, i, len(chunks)) + c
+	}
+	return frames, nil
+}
+
+func frameHeader(origin string, msgID uint64, seq, total int) string {
+	return frameVersion + "|" + origin + "|" + strconv.FormatUint(msgID, 10) + "|" +
+		strconv.Itoa(seq) + "|" + strconv.Itoa(total) + "|"
+}
+
+// splitUTF8 cuts s into pieces of at most limit bytes without
+// splitting a multi-byte rune.
+func splitUTF8(s string, limit int) []string {
+	var out []string
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		out = append(out, s[:cut])
+		s = s[cut:]
+	}
+	return append(out, s)
+}
+
+var errMalformedFrame = errors.New("sse: malformed backplane frame")
+
+// partial is a chunked message still waiting for some of its frames.
+type partial struct {
+	chunks   []string
+	received int
+	started  time.Time
+}
+
+// reassembler turns frames back into events, dropping this replica's
+// own frames. It is used by a single listener goroutine and not
+// locked.
+type reassembler struct {
+	origin  string
+	pending map[string]*partial
+}
+
+func newReassembler(origin string) *reassembler {
+	return &reassembler{origin: origin, pending: make(map[string]*partial)}
+}
+
+// add consumes one frame. ok is true when it completed an event.
+func (r *reassembler) add(frame string, now time.Time) (ev Event, ok bool, err error) {
+	parts := strings.SplitN(frame, "|", 6)
+	if len(parts) != 6 || parts[0] != frameVersion {
+		return Event{}, false, errMalformedFrame
+	}
+	origin, msgID, chunk := parts[1], parts[2], parts[5]
+	if origin == r.origin {
+		return Event{}, false, nil
+	}
+	seq, err1 := strconv.Atoi(parts[3])
+	total, err2 := strconv.Atoi(parts[4])
+	if err1 != nil || err2 != nil || total < 1 || seq < 0 || seq >= total {
+		return Event{}, false, errMalformedFrame
+	}
+	if total == 1 {
+		return decodeWireEvent(chunk)
+	}
+
+	r.expire(now)
+	key := origin + "|" + msgID
+	p, found := r.pending[key]
+	if !found {
+		if len(r.pending) >= maxPartialMessages {
+			return Event{}, false, fmt.Errorf("sse: %d partial backplane messages pending", len(r.pending))
+		}
+		p = &partial{chunks: make([]string, total), started: now}
+		r.pending[key] = p
+	}
+	if len(p.chunks) != total {
+		delete(r.pending, key)
+		return Event{}, false, errMalformedFrame
+	}
+	if p.chunks[seq] == "" {
+		p.chunks[seq] = chunk
+		p.received++
+	}
+	if p.received < total {
+		return Event{}, false, nil
+	}
+	delete(r.pending, key)
+	return decodeWireEvent(strings.Join(p.chunks, ""))
+}
+
+// expire drops partial messages that have waited too long for their
+// remaining chunks.
+func (r *reassembler) expire(now time.Time) {
+	for key, p := range r.pending {
+		if now.Sub(p.started) > reassemblyTimeout {
+			delete(r.pending, key)
+			metrics.SSEBackplaneErrors.WithLabelValues("reassembly").Inc()
+		}
+	}
+}
+
+func decodeWireEvent(body string) (Event, bool, error) {
+	var w wireEvent
+	if err := json.Unmarshal([]byte(body), &w); err != nil {
+		return Event{}, false, fmt.Errorf("sse: decode backplane event: %w", err)
+	}
+	return Event{Type: w.Type, Data: w.Data, Topic: w.Topic}, true, nil
+}
@@ -0,0 +1,111 @@
+package sse
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestEncodeFramesFitsSmallEventInOneFrame(t *testing.T) {
+	frames, err := encodeFrames("node-a", 1, Event{Type: "ai-progress", Data: `{"ok":true}`, Topic: UserTopic("alice")})
+	if err != nil {
+		t.Fatalf("encodeFrames: %v", err)
+	}
+	if len(frames) != 1 {
+		t.Fatalf("expected a single frame, got %d", len(frames))
+	}
+
+	r := newReassembler("node-b")
+	ev, ok, err := r.add(frames[0], time.Now())
+	if err != nil || !ok {
+		t.Fatalf("add: ok=%v err=%v", ok, err)
+	}
+	if ev.Type != "ai-progress" || ev.Data != `{"ok":true}` || ev.Topic != UserTopic("alice") {
+		t.Errorf("round trip = %+v", ev)
+	}
+}
+
+func TestEncodeFramesChunksLargePayloadOnRuneBoundaries(t *testing.T) {
+	// Three-byte runes guarantee some chunk boundary would land mid-rune
+	// if splitting were byte-based.
+	data := strings.Repeat("€", 10_000)
+	frames, err := encodeFrames("node-a", 7, Event{Type: "big", Data: data})
+	if err != nil {
+		t.Fatalf("encodeFrames: %v", err)
+	}
+	if len(frames) < 4 {
+		t.Fatalf("expected the 30 KB payload to be chunked, got %d frames", len(frames))
+	}
+	for i, f := range frames {
+		if len(f) > notifyPayloadLimit {
+			t.Errorf("frame %d is %d bytes, over the %d limit", i, len(f), notifyPayloadLimit)
+		}
+		if !utf8.ValidString(f) {
+			t.Errorf("frame %d is not valid UTF-8", i)
+		}
+	}
+
+	// Deliver out of order; only the last frame completes the event.
+	r := newReassembler("node-b")
+	now := time.Now()
+	for i := len(frames) - 1; i > 0; i-- {
+		if _, ok, err := r.add(frames[i], now); ok || err != nil {
+			t.Fatalf("frame %d: ok=%v err=%v before all chunks arrived", i, ok, err)
+		}
+	}
+	ev, ok, err := r.add(frames[0], now)
+	if err != nil || !ok {
+		t.Fatalf("final frame: ok=%v err=%v", ok, err)
+	}
+	if ev.Data != data {
+		t.Errorf("reassembled data differs: got %d bytes, want %d", len(ev.Data), len(data))
+	}
+	if len(r.pending) != 0 {
+		t.Errorf("expected no pending messages, got %d", len(r.pending))
+	}
+}
+
+func TestReassemblerSkipsOwnFrames(t *testing.T) {
+	frames, _ := encodeFrames("node-a", 1, Event{Type: "e", Data: "x"})
+	r := newReassembler("node-a")
+	if _, ok, err := r.add(frames[0], time.Now()); ok || err != nil {
+		t.Errorf("own frame: ok=%v err=%v, want silently skipped", ok, err)
+	}
+}
+
+func TestReassemblerExpiresIncompleteMessages(t *testing.T) {
+	frames, _ := encodeFrames("node-a", 1, Event{Type: "e", Data: strings.Repeat("x", 20_000)})
+	r := newReassembler("node-b")
+	start := time.Now()
+	if _, _, err := r.add(frames[0], start); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	// Another message arriving after the timeout sweeps the stale one.
+	other, _ := encodeFrames("node-a", 2, Event{Type: "e", Data: strings.Repeat("y", 20_000)})
+	if _, _, err := r.add(other[0], start.Add(reassemblyTimeout+time.Second)); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if _, stale := r.pending["node-a|1"]; stale {
+		t.Errorf("expected message 1 to be expired")
+	}
+	if len(r.pending) != 1 {
+		t.Errorf("pending = %d, want only message 2", len(r.pending))
+	}
+}
+
+func TestReassemblerRejectsMalformedFrames(t *testing.T) {
+	r := newReassembler("node-b")
+	for _, f := range []string{
+		"",
+		"hello",
+		"v0|node-a|1|0|1|{}",
+		"v1|node-a|1|2|2|{}",
+		"v1|node-a|1|0|1|not json",
+	} {
+		if _, ok, err := r.add(f, time.Now()); ok || err == nil {
+			t.Errorf("add(%q): ok=%v err=%v, want error", f, ok, err)
+		}
+	}
+}
@@ -16,7 +16,7 @@ import (
 // Broadcaster is the minimal upstream the publisher needs. The
 // platform SSE broker satisfies it without knowing about stats.
 type Broadcaster interface {
-	Broadcast(eventName, payload string)
+	SendToUser(userID, eventName, payload string)
 }
 
 // Publisher dispatches stats domain events to the broadcaster.
@@ -41,7 +41,7 @@ func (p *Publisher) Publish(_ context.Context, events ...shared.DomainEvent) err
 	for _, event := range events {
 		switch e := event.(type) {
 		case stats.StatIncremented:
-			p.broadcaster.Broadcast("stats-updated", fmt.Sprintf(`{"field":"%s"}`, e.Field.String()))
+			p.broadcaster.SendToUser(e.UserID.String(), "stats-updated", fmt.Sprintf(`{"field":"%s"}`, e.Field.String()))
 		}
 	}
 	return nil
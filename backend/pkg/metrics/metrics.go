@@ -93,6 +93,17 @@ var (
 		},
 	)
 
+	// SSEBackplaneErrors counts events that failed to cross the
+	// multi-replica backplane, by operation (publish, listen,
+	// queue_full, reassembly).
+	SSEBackplaneErrors = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sse_backplane_errors_total",
+			Help: "Total number of SSE backplane failures by operation",
+		},
+		[]string{"op"},
+	)
+
 	// RateLimitHits counts rate limit hits
 	RateLimitHits = promauto.NewCounter(
 		prometheus.CounterOpts{
@@ -110,6 +121,47 @@ var (
 		[]string{"status"},
 	)
 
+	// AISummaryCacheLookups counts per-file summary cache lookups by
+	// result (hit, miss).
+	AISummaryCacheLookups = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ai_summary_cache_lookups_total",
+			Help: "Total number of per-file summary cache lookups by result",
+		},
+		[]string{"result"},
+	)
+
+	// AILLMTokens counts tokens consumed by AI workflow LLM calls, by
+	// the model that answered and kind (prompt, completion).
+	AILLMTokens = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ai_llm_tokens_total",
+			Help: "Total number of LLM tokens consumed by AI workflows by model and kind",
+		},
+		[]string{"model", "kind"},
+	)
+
+	// AILLMCostUSD sums the provider-reported cost of AI workflow LLM
+	// calls, by model. Providers that don't report cost add nothing.
+	AILLMCostUSD = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ai_llm_cost_usd_total",
+			Help: "Total provider-reported LLM cost of AI workflows in USD by model",
+		},
+		[]string{"model"},
+	)
+
+	// AILLMBackendErrors counts failed calls per LLM backend in the
+	// fallback chain, by error kind (rate_limited, server_error,
+	// timeout, context_length, other).
+	AILLMBackendErrors = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ai_llm_backend_errors_total",
+			Help: "Total number of failed LLM backend calls by backend and error kind",
+		},
+		[]string{"backend", "kind"},
+	)
+
 	// AppInfo provides application metadata
 	AppInfo = promauto.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -11,8 +11,14 @@ const API_BASE = process.env.NEXT_PUBLIC_API_URL || "http://localhost:8080"
 //   - kind=step       — fine-grained per-step / per-file transitions
 //   - kind=lifecycle  — run-level (running/completed/failed/cancelled)
 
-export type StepName = "clone" | "traverse" | "summarize_files" | "aggregate" | "store"
-export type StepState = "started" | "completed" | "failed" | "progress"
+export type StepName =
+	| "clone"
+	| "traverse"
+	| "summarize_files"
+	| "summarize_dirs"
+	| "aggregate"
+	| "store"
+export type StepState = "started" | "completed" | "failed" | "progress" | "streaming"
 export type RunStatus = "pending" | "running" | "completed" | "failed" | "cancelled"
 
 interface ProgressPayload {
@@ -27,6 +33,7 @@ interface ProgressPayload {
 	fileIndex?: number
 	fileCount?: number
 	reason?: string
+	delta?: string
 }
 
 export type StepStatus = "pending" | "running" | "completed" | "failed"
@@ -39,6 +46,9 @@ export interface StepView {
 	fileCount?: number
 	filename?: string
 	reason?: string
+	// Overview text streamed so far (aggregate only). Cleared when the
+	// step (re)starts, so a retried aggregate doesn't repeat itself.
+	text?: string
 }
 
 export interface ProgressView {
@@ -46,13 +56,21 @@ export interface ProgressView {
 	runStatus: RunStatus
 }
 
-export const STEP_ORDER: StepName[] = ["clone", "traverse", "summarize_files", "aggregate", "store"]
+export const STEP_ORDER: StepName[] = [
+	"clone",
+	"traverse",
+	"summarize_files",
+	"summarize_dirs",
+	"aggregate",
+	"store",
+]
 
 const initialView = (): ProgressView => ({
 	steps: {
 		clone: { name: "clone", status: "pending" },
 		traverse: { name: "traverse", status: "pending" },
 		summarize_files: { name: "summarize_files", status: "pending" },
+		summarize_dirs: { name: "summarize_dirs", status: "pending" },
 		aggregate: { name: "aggregate", status: "pending" },
 		store: { name: "store", status: "pending" },
 	},
@@ -67,11 +85,12 @@ const initialView = (): ProgressView => ({
 type Listener = (data: ProgressPayload) => void
 let connection: EventSource | null = null
 const listeners = new Set<Listener>()
+const resetListeners = new Set<() => void>()
 
 function ensureConnection() {
 	if (typeof window === "undefined") return null
 	if (connection) return connection
-	const es = new EventSource(`${API_BASE}/api/v1/events`)
+	const es = new EventSource(`${API_BASE}/api/v1/events`, { withCredentials: true })
 	es.addEventListener("ai-progress", (event) => {
 		let data: ProgressPayload
 		try {
@@ -81,6 +100,12 @@ function ensureConnection() {
 		}
 		for (const l of listeners) l(data)
 	})
+	// The browser resends Last-Event-ID on auto-reconnect and the
+	// backend replays what we missed. When the gap is too old to
+	// replay it sends `reset` instead — refetch from the REST API.
+	es.addEventListener("reset", () => {
+		for (const r of resetListeners) r()
+	})
 	connection = es
 	return es
 }
@@ -109,6 +134,11 @@ export function useAIProgress(summaryId: number): ProgressView {
 			return
 		}
 		setView(initialView())
+		const onReset = () => {
+			queryClient.invalidateQueries({ queryKey: getGetAiSummariesIdQueryKey(summaryId) })
+			queryClient.invalidateQueries({ queryKey: getGetAiSummariesQueryKey() })
+		}
+		resetListeners.add(onReset)
 		const off = subscribe((data) => {
 			if (data.summaryId !== summaryId) return
 			setView((prev) => reduce(prev, data))
@@ -121,7 +151,10 @@ export function useAIProgress(summaryId: number): ProgressView {
 				queryClient.invalidateQueries({ queryKey: getGetAiSummariesQueryKey() })
 			}
 		})
-		return off
+		return () => {
+			resetListeners.delete(onReset)
+			off()
+		}
 	}, [summaryId, queryClient])
 
 	return view
@@ -139,7 +172,15 @@ function reduce(prev: ProgressView, ev: ProgressPayload): ProgressView {
 	let next: StepView = current
 	switch (ev.state) {
 		case "started":
-			next = { ...current, status: "running", fileCount: ev.fileCount ?? current.fileCount }
+			next = {
+				...current,
+				status: "running",
+				fileCount: ev.fileCount ?? current.fileCount,
+				text: undefined,
+			}
+			break
+		case "streaming":
+			next = { ...current, status: "running", text: (current.text ?? "") + (ev.delta ?? "") }
 			break
 		case "progress":
 			next = {
@@ -0,0 +1,31 @@
+"use client"
+
+import {
+	getGetAiSummariesIdQuestionsQueryKey,
+	useGetAiSummariesIdQuestions,
+	usePostAiSummariesIdAsk,
+} from "@shared/api/endpoints/ai/ai"
+import { useQueryClient } from "@tanstack/react-query"
+
+// useQuestions loads the questions already asked about a run, oldest
+// first. Same keying rule as useRepoSummary: pass the real id and gate
+// with `enabled`.
+export function useQuestions(summaryId: number, enabled = true) {
+	return useGetAiSummariesIdQuestions(summaryId, {
+		query: { enabled: enabled && summaryId > 0 },
+	})
+}
+
+// useAskQuestion posts a question and refreshes the run's question list
+// once the answer is stored.
+export function useAskQuestion(summaryId: number) {
+	const queryClient = useQueryClient()
+	return usePostAiSummariesIdAsk({
+		mutation: {
+			onSuccess: () =>
+				queryClient.invalidateQueries({
+					queryKey: getGetAiSummariesIdQuestionsQueryKey(summaryId),
+				}),
+		},
+	})
+}
@@ -0,0 +1,115 @@
+"use client"
+
+import type { AiworkflowsInterfacesHttpAnswerDTO } from "@shared/api/models"
+import { Button } from "@shared/ui/button"
+import { Input } from "@shared/ui/input"
+import { Loader2, MessageSquare, XCircle } from "lucide-react"
+import { Fragment, useState } from "react"
+import { useAskQuestion, useQuestions } from "../model/use-questions"
+
+// AnswerText renders an answer with its [path] citations set in mono, so
+// they read as references rather than prose.
+function AnswerText({ text }: { text: string }) {
+	const parts = text.split(/(\[[^\]\n]+\])/)
+	return (
+		<p className="whitespace-pre-wrap text-sm leading-relaxed">
+			{parts.map((part, i) =>
+				part.startsWith("[") && part.endsWith("]") ? (
+					<code key={i} className="rounded bg-muted px-1 font-mono text-xs">
+						{part.slice(1, -1)}
+					</code>
+				) : (
+					<Fragment key={i}>{part}</Fragment>
+				),
+			)}
+		</p>
+	)
+}
+
+function AnswerItem({ answer }: { answer: AiworkflowsInterfacesHttpAnswerDTO }) {
+	const citations = answer.citations ?? []
+	return (
+		<div className="space-y-1 py-3 first:pt-0 last:pb-0">
+			<p className="text-sm font-medium">{answer.question}</p>
+			<AnswerText text={answer.answer ?? ""} />
+			{citations.length > 0 && (
+				<div className="flex flex-wrap gap-1 pt-1">
+					{citations.map((c) => (
+						<span
+							key={c}
+							className="rounded border px-1.5 py-0.5 font-mono text-[10px] text-muted-foreground"
+						>
+							{c}
+						</span>
+					))}
+				</div>
+			)}
+		</div>
+	)
+}
+
+// AskPanel lets the user ask questions about a completed run. Answers
+// are grounded on the run's file and directory summaries and cite the
+// paths they rely on; earlier questions stay listed above the input.
+export function AskPanel({ summaryId }: { summaryId: number }) {
+	const [question, setQuestion] = useState("")
+	const [error, setError] = useState<string | null>(null)
+	const history = useQuestions(summaryId)
+	const mutation = useAskQuestion(summaryId)
+	const items = history.data?.status === 200 ? (history.data.data.items ?? []) : []
+
+	const handleSubmit = async (e: React.FormEvent) => {
+		e.preventDefault()
+		setError(null)
+		try {
+			const response = await mutation.mutateAsync({ id: summaryId, data: { question } })
+			if (response.status === 200) {
+				setQuestion("")
+			} else {
+				setError(response.data.error ?? "Frage fehlgeschlagen")
+			}
+		} catch (err) {
+			console.error("[ai-summarize] ask failed", err)
+			setError("Frage fehlgeschlagen")
+		}
+	}
+
+	return (
+		<div className="rounded-lg border bg-card p-4">
+			<h3 className="mb-2 text-xs font-semibold uppercase tracking-wide text-muted-foreground">
+				Fragen
+			</h3>
+			{items.length > 0 && (
+				<div className="mb-3 divide-y">
+					{items.map((a) => (
+						<AnswerItem key={a.id} answer={a} />
+					))}
+				</div>
+			)}
+			<form onSubmit={handleSubmit} className="flex gap-2">
+				<Input
+					placeholder="z. B. Wo wird die Authentifizierung behandelt?"
+					value={question}
+					onChange={(e) => setQuestion(e.target.value)}
+					maxLength={500}
+					disabled={mutation.isPending}
+					className="flex-1"
+				/>
+				<Button type="submit" disabled={mutation.isPending || !question.trim()}>
+					{mutation.isPending ? (
+						<Loader2 className="h-4 w-4 animate-spin" />
+					) : (
+						<MessageSquare className="h-4 w-4" />
+					)}
+					<span className="sr-only">Fragen</span>
+				</Button>
+			</form>
+			{error && (
+				<div className="mt-2 flex items-center gap-2 text-sm text-destructive">
+					<XCircle className="h-4 w-4" />
+					<span>{error}</span>
+				</div>
+			)}
+		</div>
+	)
+}
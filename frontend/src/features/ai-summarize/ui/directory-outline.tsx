@@ -0,0 +1,68 @@
+"use client"
+
+import type { AiworkflowsInterfacesHttpDirectorySummaryDTO } from "@shared/api/models"
+import { Collapsible, CollapsibleContent, CollapsibleTrigger } from "@shared/ui/collapsible"
+import { ChevronRight, Folder } from "lucide-react"
+
+type Directory = AiworkflowsInterfacesHttpDirectorySummaryDTO
+
+// relativeName is a directory's path below its parent. Folded
+// directories (backend/internal/api) keep every segment they span.
+function relativeName(path: string, parent: string): string {
+	return parent && path.startsWith(`${parent}/`) ? path.slice(parent.length + 1) : path
+}
+
+function DirectoryNode({ dir, parent, depth }: { dir: Directory; parent: string; depth: number }) {
+	const children = dir.children ?? []
+	const fileCount = dir.files?.length ?? 0
+	return (
+		<Collapsible defaultOpen={depth === 0}>
+			<CollapsibleTrigger className="group flex w-full items-start gap-1.5 py-1 text-left">
+				<ChevronRight
+					className={
+						children.length > 0
+							? "mt-0.5 h-3.5 w-3.5 shrink-0 text-muted-foreground transition-transform group-data-[state=open]:rotate-90"
+							: "mt-0.5 h-3.5 w-3.5 shrink-0 opacity-0"
+					}
+				/>
+				<Folder className="mt-0.5 h-3.5 w-3.5 shrink-0 text-muted-foreground" />
+				<div className="min-w-0">
+					<span className="font-mono text-xs">{relativeName(dir.path ?? "", parent)}/</span>
+					{fileCount > 0 && (
+						<span className="ml-2 text-[10px] text-muted-foreground">
+							{fileCount} Datei{fileCount === 1 ? "" : "en"}
+						</span>
+					)}
+					{dir.summary && (
+						<p className="text-xs leading-relaxed text-muted-foreground">{dir.summary}</p>
+					)}
+				</div>
+			</CollapsibleTrigger>
+			{children.length > 0 && (
+				<CollapsibleContent className="ml-3 border-l pl-2">
+					{children.map((c) => (
+						<DirectoryNode key={c.path} dir={c} parent={dir.path ?? ""} depth={depth + 1} />
+					))}
+				</CollapsibleContent>
+			)}
+		</Collapsible>
+	)
+}
+
+// DirectoryOutline renders the run's architecture outline: the
+// directories of the summarized files, each with the summary the
+// workflow built from its contents. Top-level directories start open.
+export function DirectoryOutline({ root }: { root: Directory }) {
+	const children = root.children ?? []
+	if (children.length === 0) return null
+	return (
+		<div className="rounded-lg border bg-card p-3">
+			<h3 className="mb-1 text-xs font-semibold uppercase tracking-wide text-muted-foreground">
+				Architektur
+			</h3>
+			{children.map((c) => (
+				<DirectoryNode key={c.path} dir={c} parent="" depth={0} />
+			))}
+		</div>
+	)
+}
@@ -3,26 +3,55 @@
 import { getGetAiSummariesQueryKey } from "@shared/api/endpoints/ai/ai"
 import { Button } from "@shared/ui/button"
 import { Card, CardContent } from "@shared/ui/card"
+import { Collapsible, CollapsibleContent, CollapsibleTrigger } from "@shared/ui/collapsible"
 import { Input } from "@shared/ui/input"
+import { Label } from "@shared/ui/label"
 import { useQueryClient } from "@tanstack/react-query"
-import { FileSearch, Loader2, XCircle } from "lucide-react"
+import { ChevronDown, FileSearch, Loader2, XCircle } from "lucide-react"
 import { useRouter, useSearchParams } from "next/navigation"
 import { useState } from "react"
 import { useSummarizeRepo } from "../model/use-summarize"
 
+// splitPatterns turns the comma- or newline-separated pattern fields into
+// the request's glob lists; the backend trims and validates them.
+function splitPatterns(raw: string): string[] | undefined {
+	const patterns = raw
+		.split(/[,\n]/)
+		.map((s) => s.trim())
+		.filter(Boolean)
+	return patterns.length > 0 ? patterns : undefined
+}
+
 // NewRunForm is the always-visible header — paste a repo URL, hit submit,
 // the new run appears as a card in the list below and auto-expands.
+// The optional fields pick a branch, tag or commit and narrow the run to a
+// subdirectory or glob set; "incremental" reuses the last completed run of
+// the same repo for files that haven't changed.
 export function NewRunForm() {
 	const router = useRouter()
 	const params = useSearchParams()
 	const queryClient = useQueryClient()
 	const [repoUrl, setRepoUrl] = useState("")
+	const [ref, setRef] = useState("")
+	const [subdir, setSubdir] = useState("")
+	const [include, setInclude] = useState("")
+	const [exclude, setExclude] = useState("")
+	const [incremental, setIncremental] = useState(false)
 	const mutation = useSummarizeRepo()
 
 	const handleSubmit = async (e: React.FormEvent) => {
 		e.preventDefault()
 		try {
-			const response = (await mutation.mutateAsync({ data: { repoUrl } })) as {
+			const response = (await mutation.mutateAsync({
+				data: {
+					repoUrl,
+					ref: ref.trim() || undefined,
+					subdir: subdir.trim() || undefined,
+					include: splitPatterns(include),
+					exclude: splitPatterns(exclude),
+					incremental: incremental || undefined,
+				},
+			})) as {
 				data?: { summaryId?: number }
 			}
 			const id = response?.data?.summaryId
@@ -71,6 +100,53 @@ export function NewRunForm() {
 					</Button>
 				</form>
 
+				<Collapsible className="mt-2">
+					<CollapsibleTrigger className="group flex items-center gap-1 text-xs text-muted-foreground hover:text-foreground">
+						<ChevronDown className="h-3 w-3 transition-transform group-data-[state=open]:rotate-180" />
+						Ref, Dateifilter und Modus (optional)
+					</CollapsibleTrigger>
+					<CollapsibleContent className="mt-2 grid gap-2 sm:grid-cols-2">
+						<Input
+							placeholder="Branch, Tag oder Commit-SHA, z. B. main"
+							value={ref}
+							onChange={(e) => setRef(e.target.value)}
+							disabled={mutation.isPending}
+						/>
+						<Input
+							placeholder="Unterverzeichnis, z. B. backend"
+							value={subdir}
+							onChange={(e) => setSubdir(e.target.value)}
+							disabled={mutation.isPending}
+						/>
+						<Input
+							placeholder="Einschließen, z. B. *.go, *.proto"
+							value={include}
+							onChange={(e) => setInclude(e.target.value)}
+							disabled={mutation.isPending}
+						/>
+						<Input
+							placeholder="Ausschließen, z. B. **/*_gen.go"
+							value={exclude}
+							onChange={(e) => setExclude(e.target.value)}
+							disabled={mutation.isPending}
+						/>
+						<div className="flex items-center gap-2 sm:col-span-2">
+							<input
+								type="checkbox"
+								id="incremental"
+								checked={incremental}
+								onChange={(e) => setIncremental(e.target.checked)}
+								disabled={mutation.isPending}
+								className="rounded"
+							/>
+							<Label htmlFor="incremental" className="text-xs font-normal text-muted-foreground">
+								Inkrementell: nur Dateien neu zusammenfassen, die sich seit dem letzten abgeschlossenen Run
+								geändert haben
+							</Label>
+						</div>
+					</CollapsibleContent>
+				</Collapsible>
+
 				{mutation.isError && (
 					<div className="mt-3 flex items-center gap-2 rounded-md border border-destructive/40 bg-destructive/5 p-2 text-sm text-destructive">
 						<XCircle className="h-4 w-4" />
@@ -80,7 +156,8 @@ export function NewRunForm() {
 
 				<p className="mt-2 text-xs text-muted-foreground">
 					Öffentliche http(s)-URLs. Mehrere Runs parallel möglich — jeder erscheint als eigene Karte
-					unten und aktualisiert sich live.
+					unten und aktualisiert sich live. Ohne Ref wird der Standard-Branch verwendet; Filtermuster folgen der .gitignore-Syntax; eine
+					.summaryignore im Repository wird zusätzlich beachtet.
 				</p>
 			</CardContent>
 		</Card>
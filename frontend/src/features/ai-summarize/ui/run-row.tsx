@@ -2,7 +2,13 @@
 
 import * as AccordionPrimitive from "@radix-ui/react-accordion"
 import { getGetAiSummariesQueryKey, useDeleteAiSummariesId } from "@shared/api/endpoints/ai/ai"
-import type { AiworkflowsInterfacesHttpRepoSummaryResponse } from "@shared/api/models"
+import type {
+	AiworkflowsInterfacesHttpChangelogDTO,
+	AiworkflowsInterfacesHttpFileFilterDTO,
+	AiworkflowsInterfacesHttpFileInsightsDTO,
+	AiworkflowsInterfacesHttpRedactionsDTO,
+	AiworkflowsInterfacesHttpRepoSummaryResponse,
+} from "@shared/api/models"
 import { cn } from "@shared/lib/utils"
 import { Accordion, AccordionContent, AccordionItem, AccordionTrigger } from "@shared/ui/accordion"
 import {
@@ -19,7 +25,7 @@ import {
 import { Button } from "@shared/ui/button"
 import { useQueryClient } from "@tanstack/react-query"
 import { CheckCircle2, ChevronDown, Circle, Loader2, Trash2, XCircle } from "lucide-react"
-import { useMemo } from "react"
+import { Fragment, useMemo } from "react"
 import {
 	STEP_ORDER,
 	type StepName,
@@ -28,11 +34,14 @@ import {
 	useAIProgress,
 } from "../model/use-ai-progress"
 import { useRepoSummary } from "../model/use-summary"
+import { AskPanel } from "./ask-panel"
+import { DirectoryOutline } from "./directory-outline"
 
 const stepLabel: Record<StepName, string> = {
 	clone: "Repository klonen",
 	traverse: "Dateien analysieren",
 	summarize_files: "Dateien zusammenfassen",
+	summarize_dirs: "Verzeichnisse zusammenfassen",
 	aggregate: "Gesamt-Zusammenfassung",
 	store: "Ergebnis speichern",
 }
@@ -112,7 +121,10 @@ function StepIcon({ status }: { status: StepStatus }) {
 }
 
 function StepRow({ step }: { step: StepView }) {
-	const showSub = step.name === "summarize_files" && step.fileCount && step.fileCount > 0
+	const isDirs = step.name === "summarize_dirs"
+	const showSub =
+		(step.name === "summarize_files" || isDirs) && step.fileCount && step.fileCount > 0
+	const unit = isDirs ? ["Verzeichnis", "se"] : ["Datei", "en"]
 	return (
 		<div
 			className={cn(
@@ -126,7 +138,7 @@ function StepRow({ step }: { step: StepView }) {
 				{showSub && (
 					<div className="text-xs text-muted-foreground truncate">
 						{step.status === "completed"
-							? `${step.fileCount} Datei${step.fileCount === 1 ? "" : "en"} verarbeitet`
+							? `${step.fileCount} ${unit[0]}${step.fileCount === 1 ? "" : unit[1]} verarbeitet`
 							: `${step.fileIndex ?? 0} / ${step.fileCount}${
 									step.filename ? ` — ${step.filename}` : ""
 								}`}
@@ -160,6 +172,150 @@ export interface RunRowProps {
 	onDeleted?: (id: number) => void
 }
 
+// FilterSummary shows the run's effective file filter: the request's
+// subdirectory and patterns plus the repository's .summaryignore rules.
+function FilterSummary({ filter }: { filter: AiworkflowsInterfacesHttpFileFilterDTO }) {
+	const rows: [string, string][] = []
+	if (filter.subdir) rows.push(["Verzeichnis", `${filter.subdir}/`])
+	if (filter.include?.length) rows.push(["Nur", filter.include.join(", ")])
+	if (filter.exclude?.length) rows.push(["Ohne", filter.exclude.join(", ")])
+	if (filter.summaryIgnore?.length) rows.push([".summaryignore", filter.summaryIgnore.join(", ")])
+	return (
+		<div className="rounded-lg border bg-card p-3">
+			<h3 className="mb-1 text-xs font-semibold uppercase tracking-wide text-muted-foreground">
+				Dateifilter
+			</h3>
+			<dl className="grid grid-cols-[auto_1fr] gap-x-3 gap-y-1 text-xs">
+				{rows.map(([label, value]) => (
+					<Fragment key={label}>
+						<dt className="text-muted-foreground">{label}</dt>
+						<dd className="break-all font-mono">{value}</dd>
+					</Fragment>
+				))}
+			</dl>
+		</div>
+	)
+}
+
+// CommitLine names the commit the run summarized: the requested ref (or
+// the default branch), the short SHA and the commit date.
+function CommitLine({ result }: { result: AiworkflowsInterfacesHttpRepoSummaryResponse }) {
+	if (!result.commitSha) return null
+	return (
+		<p className="text-xs text-muted-foreground">
+			Stand:{" "}
+			<span className="font-mono text-foreground">
+				{result.ref || "Standard-Branch"} @ {result.commitSha.slice(0, 7)}
+			</span>
+			{result.commitTime && ` · ${new Date(result.commitTime).toLocaleDateString("de-DE")}`}
+		</p>
+	)
+}
+
+// ChangelogSummary shows what an incremental run found changed since the
+// run it builds on. Unchanged files were carried over, so only their
+// count is shown.
+function ChangelogSummary({ changelog }: { changelog: AiworkflowsInterfacesHttpChangelogDTO }) {
+	const rows: [string, string[]][] = [
+		["Neu", changelog.added ?? []],
+		["Geändert", changelog.modified ?? []],
+		["Entfernt", changelog.removed ?? []],
+	]
+	return (
+		<div className="rounded-lg border bg-card p-3">
+			<h3 className="mb-1 text-xs font-semibold uppercase tracking-wide text-muted-foreground">
+				Änderungen seit Run #{changelog.baseId}
+				{changelog.baseCommit && ` (${changelog.baseCommit.slice(0, 7)})`}
+			</h3>
+			<dl className="grid grid-cols-[auto_1fr] gap-x-3 gap-y-1 text-xs">
+				{rows.map(([label, paths]) =>
+					paths.length > 0 ? (
+						<Fragment key={label}>
+							<dt className="text-muted-foreground">{label}</dt>
+							<dd className="break-all font-mono">{paths.join(", ")}</dd>
+						</Fragment>
+					) : null,
+				)}
+				<dt className="text-muted-foreground">Unverändert</dt>
+				<dd>{changelog.unchanged?.length ?? 0} Dateien übernommen</dd>
+			</dl>
+		</div>
+	)
+}
+
+// SecretsNotice says what was kept from the LLM: secrets redacted from
+// file contents, by rule, and files skipped as secret stores. Only
+// counts and paths are known; the secrets themselves are never stored.
+function SecretsNotice({
+	redactions,
+	skipped,
+}: {
+	redactions?: AiworkflowsInterfacesHttpRedactionsDTO
+	skipped: string[]
+}) {
+	const rules = Object.entries(redactions?.byRule ?? {}).sort(([a], [b]) => a.localeCompare(b))
+	return (
+		<div className="rounded-lg border bg-card p-3">
+			<h3 className="mb-1 text-xs font-semibold uppercase tracking-wide text-muted-foreground">
+				Geheimnisse
+			</h3>
+			<dl className="grid grid-cols-[auto_1fr] gap-x-3 gap-y-1 text-xs">
+				{rules.length > 0 && (
+					<>
+						<dt className="text-muted-foreground">Geschwärzt</dt>
+						<dd>
+							{redactions?.total} ({rules.map(([rule, n]) => `${rule}: ${n}`).join(", ")})
+						</dd>
+					</>
+				)}
+				{skipped.length > 0 && (
+					<>
+						<dt className="text-muted-foreground">Übersprungen</dt>
+						<dd className="break-all font-mono">{skipped.join(", ")}</dd>
+					</>
+				)}
+			</dl>
+		</div>
+	)
+}
+
+const selectionStrategyLabel: Record<string, string> = {
+	ranked: "priorisiert",
+	alphabetical: "alphabetisch",
+}
+
+const insightLists: {
+	key: "keySymbols" | "externalDependencies" | "risks"
+	label: string
+}[] = [
+	{ key: "keySymbols", label: "Symbole" },
+	{ key: "externalDependencies", label: "Abhängigkeiten" },
+	{ key: "risks", label: "Risiken" },
+]
+
+function FileInsights({ insights }: { insights: AiworkflowsInterfacesHttpFileInsightsDTO }) {
+	return (
+		<dl className="grid grid-cols-[auto_1fr] gap-x-3 gap-y-1 pt-1 text-xs">
+			{insights.purpose && (
+				<>
+					<dt className="text-muted-foreground">Zweck</dt>
+					<dd>{insights.purpose}</dd>
+				</>
+			)}
+			{insightLists.map(({ key, label }) => {
+				const items = insights[key]
+				if (!items || items.length === 0) return null
+				return (
+					<Fragment key={key}>
+						<dt className="text-muted-foreground">{label}</dt>
+						<dd className={key === "risks" ? "" : "font-mono"}>{items.join(", ")}</dd>
+					</Fragment>
+				)
+			})}
+		</dl>
+	)
+}
+
 // RunRow renders one run as a shadcn AccordionItem. The parent
 // <Accordion type="multiple"> drives open/close state — we only need to
 // fetch the detail when the card is currently open. Multi-mode lets the
@@ -188,6 +344,17 @@ export function RunRow({
 		| undefined
 	const result = detailEnvelope?.data
 
+	// Why the traverse step picked each file, keyed by filename.
+	const selectionReasons = useMemo(
+		() =>
+			new Map(
+				(result?.selection?.files ?? []).flatMap((f) =>
+					f.filename && f.reason ? [[f.filename, f.reason] as const] : [],
+				),
+			),
+		[result?.selection],
+	)
+
 	const effectiveStatus =
 		live.runStatus !== "pending"
 			? live.runStatus
@@ -227,6 +394,7 @@ export function RunRow({
 				ensure("traverse", "completed")
 			}
 			if (filesDone) ensure("summarize_files", "completed")
+			if (result?.directories || hasSummary) ensure("summarize_dirs", "completed")
 			if (hasSummary) ensure("aggregate", "completed")
 		}
 
@@ -383,13 +551,55 @@ export function RunRow({
 						))}
 					</div>
 
-					{result?.status === "completed" && result.summary && (
+					{result?.status === "completed" && result.summary ? (
 						<div className="rounded-lg border bg-card p-4">
 							<h3 className="mb-2 text-xs font-semibold uppercase tracking-wide text-muted-foreground">
 								Zusammenfassung
 							</h3>
 							<p className="whitespace-pre-wrap text-sm leading-relaxed">{result.summary}</p>
 						</div>
+					) : (
+						// Live overview while the aggregate step streams it; the
+						// persisted summary replaces it once the run completes.
+						live.steps.aggregate.text && (
+							<div className="rounded-lg border bg-card p-4">
+								<h3 className="mb-2 text-xs font-semibold uppercase tracking-wide text-muted-foreground">
+									Zusammenfassung
+								</h3>
+								<p className="whitespace-pre-wrap text-sm leading-relaxed">
+									{live.steps.aggregate.text.trimStart()}
+									{live.steps.aggregate.status === "running" && (
+										<span className="ml-0.5 inline-block h-4 w-1.5 animate-pulse bg-primary/60 align-text-bottom" />
+									)}
+								</p>
+							</div>
+						)
+					)}
+
+					{result && <CommitLine result={result} />}
+
+					{result?.changelog && <ChangelogSummary changelog={result.changelog} />}
+
+					{result?.directories && <DirectoryOutline root={result.directories} />}
+
+					{result?.status === "completed" && <AskPanel summaryId={id} />}
+
+					{result?.filter && <FilterSummary filter={result.filter} />}
+
+					{result && (result.redactions || (result.selection?.skippedSecrets?.length ?? 0) > 0) && (
+						<SecretsNotice
+							redactions={result.redactions}
+							skipped={result.selection?.skippedSecrets ?? []}
+						/>
+					)}
+
+					{(result?.selection?.skippedTooLarge?.length ?? 0) > 0 && (
+						<div className="rounded-lg border bg-card p-3 text-xs">
+							<span className="text-muted-foreground">Zu groß, übersprungen: </span>
+							<span className="break-all font-mono">
+								{result?.selection?.skippedTooLarge?.join(", ")}
+							</span>
+						</div>
 					)}
 
 					{result?.files && result.files.length > 0 && (
@@ -400,16 +610,43 @@ export function RunRow({
 						>
 							<AccordionItem value="files" className="border-b-0">
 								<AccordionTrigger className="items-center px-3 py-2 text-sm font-medium hover:no-underline data-[state=open]:border-b">
-									Dateien ({result.files.length})
+									<span>
+										Dateien ({result.files.length})
+										{result.selection && (
+											<span className="ml-2 text-xs font-normal text-muted-foreground">
+												Auswahl: {selectionStrategyLabel[result.selection.strategy ?? ""] ??
+													result.selection.strategy}
+											</span>
+										)}
+									</span>
 								</AccordionTrigger>
 								<AccordionContent className="p-0">
 									<div className="divide-y">
 										{result.files.map((f) => (
 											<div key={f.filename} className="space-y-1 p-3">
-												<div className="font-mono text-xs">{f.filename}</div>
+												<div className="flex items-baseline justify-between gap-2">
+													<div className="truncate font-mono text-xs">{f.filename}</div>
+													{f.model || f.chunks || f.redactions ? (
+														<div className="shrink-0 font-mono text-[10px] text-muted-foreground">
+															{[
+																f.chunks && `${f.chunks} Abschnitte`,
+																f.redactions && `${f.redactions} geschwärzt`,
+																f.model,
+															]
+																.filter(Boolean)
+																.join(" · ")}
+														</div>
+													) : null}
+												</div>
+												{selectionReasons.get(f.filename) && (
+													<div className="text-[10px] text-muted-foreground">
+														{selectionReasons.get(f.filename)}
+													</div>
+												)}
 												<div className="text-sm leading-relaxed text-muted-foreground">
 													{f.summary}
 												</div>
+												{f.insights && <FileInsights insights={f.insights} />}
 											</div>
 										))}
 									</div>
@@ -38,7 +38,7 @@ export function useExport(options?: UseExportOptions) {
 	useEffect(() => {
 		if (typeof window === "undefined") return
 
-		const eventSource = new EventSource(`${API_BASE}/api/v1/events`)
+		const eventSource = new EventSource(`${API_BASE}/api/v1/events`, { withCredentials: true })
 		eventSourceRef.current = eventSource
 
 		eventSource.addEventListener("export-progress", (event) => {
@@ -21,7 +21,7 @@ export function useSSE() {
 			eventSourceRef.current.close()
 		}
 
-		const eventSource = new EventSource(`${API_BASE}/api/v1/events`)
+		const eventSource = new EventSource(`${API_BASE}/api/v1/events`, { withCredentials: true })
 		eventSourceRef.current = eventSource
 
 		eventSource.onopen = () => {
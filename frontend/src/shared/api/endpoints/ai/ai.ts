@@ -25,7 +25,11 @@ import type {
 } from '@tanstack/react-query';
 
 import type {
+  AiworkflowsInterfacesHttpAnswerDTO,
+  AiworkflowsInterfacesHttpAnswerListResponse,
+  AiworkflowsInterfacesHttpAskRequest,
   AiworkflowsInterfacesHttpErrorResponse,
+  AiworkflowsInterfacesHttpQuotaResponse,
   AiworkflowsInterfacesHttpRepoSummaryListResponse,
   AiworkflowsInterfacesHttpRepoSummaryResponse,
   AiworkflowsInterfacesHttpSummarizeRepoRequest,
@@ -39,6 +43,131 @@ type SecondParameter<T extends (...args: never) => unknown> = Parameters<T>[1];
 
 
 
+/**
+ * Reports the authenticated user's per-user limits (0 = unlimited) and current usage: runs still pending or running, runs started today, and tokens used this month. Days and months are UTC calendar windows.
+ * @summary Get the user's AI summarization quota
+ */
+export type getAiQuotaResponse200 = {
+  data: AiworkflowsInterfacesHttpQuotaResponse
+  status: 200
+}
+
+export type getAiQuotaResponse401 = {
+  data: AiworkflowsInterfacesHttpErrorResponse
+  status: 401
+}
+
+export type getAiQuotaResponse503 = {
+  data: AiworkflowsInterfacesHttpErrorResponse
+  status: 503
+}
+    
+export type getAiQuotaResponseSuccess = (getAiQuotaResponse200) & {
+  headers: Headers;
+};
+export type getAiQuotaResponseError = (getAiQuotaResponse401 | getAiQuotaResponse503) & {
+  headers: Headers;
+};
+
+export type getAiQuotaResponse = (getAiQuotaResponseSuccess | getAiQuotaResponseError)
+
+export const getGetAiQuotaUrl = () => {
+
+
+  
+
+  return `http://localhost:8080/api/v1/ai/quota`
+}
+
+export const getAiQuota = async ( options?: RequestInit): Promise<getAiQuotaResponse> => {
+  
+  return customFetch<getAiQuotaResponse>(getGetAiQuotaUrl(),
+  {      
+    ...options,
+    method: 'GET'
+    
+    
+  }
+);}
+
+
+
+
+
+export const getGetAiQuotaQueryKey = () => {
+    return [
+    `http://localhost:8080/api/v1/ai/quota`
+    ] as const;
+    }
+
+    
+export const getGetAiQuotaQueryOptions = <TData = Awaited<ReturnType<typeof getAiQuota>>, TError = AiworkflowsInterfacesHttpErrorResponse>( options?: { query?:Partial<UseQueryOptions<Awaited<ReturnType<typeof getAiQuota>>, TError, TData>>, request?: SecondParameter<typeof customFetch>}
+) => {
+
+const {query: queryOptions, request: requestOptions} = options ?? {};
+
+  const queryKey =  queryOptions?.queryKey ?? getGetAiQuotaQueryKey();
+
+  
+
+    const queryFn: QueryFunction<Awaited<ReturnType<typeof getAiQuota>>> = ({ signal }) => getAiQuota({ signal, ...requestOptions });
+
+      
+
+      
+
+   return  { queryKey, queryFn, ...queryOptions} as UseQueryOptions<Awaited<ReturnType<typeof getAiQuota>>, TError, TData> & { queryKey: DataTag<QueryKey, TData, TError> }
+}
+
+export type GetAiQuotaQueryResult = NonNullable<Awaited<ReturnType<typeof getAiQuota>>>
+export type GetAiQuotaQueryError = AiworkflowsInterfacesHttpErrorResponse
+
+
+export function useGetAiQuota<TData = Awaited<ReturnType<typeof getAiQuota>>, TError = AiworkflowsInterfacesHttpErrorResponse>(
+  options: { query:Partial<UseQueryOptions<Awaited<ReturnType<typeof getAiQuota>>, TError, TData>> & Pick<
+        DefinedInitialDataOptions<
+          Awaited<ReturnType<typeof getAiQuota>>,
+          TError,
+          Awaited<ReturnType<typeof getAiQuota>>
+        > , 'initialData'
+      >, request?: SecondParameter<typeof customFetch>}
+ , queryClient?: QueryClient
+  ):  DefinedUseQueryResult<TData, TError> & { queryKey: DataTag<QueryKey, TData, TError> }
+export function useGetAiQuota<TData = Awaited<ReturnType<typeof getAiQuota>>, TError = AiworkflowsInterfacesHttpErrorResponse>(
+  options?: { query?:Partial<UseQueryOptions<Awaited<ReturnType<typeof getAiQuota>>, TError, TData>> & Pick<
+        UndefinedInitialDataOptions<
+          Awaited<ReturnType<typeof getAiQuota>>,
+          TError,
+          Awaited<ReturnType<typeof getAiQuota>>
+        > , 'initialData'
+      >, request?: SecondParameter<typeof customFetch>}
+ , queryClient?: QueryClient
+  ):  UseQueryResult<TData, TError> & { queryKey: DataTag<QueryKey, TData, TError> }
+export function useGetAiQuota<TData = Awaited<ReturnType<typeof getAiQuota>>, TError = AiworkflowsInterfacesHttpErrorResponse>(
+  options?: { query?:Partial<UseQueryOptions<Awaited<ReturnType<typeof getAiQuota>>, TError, TData>>, request?: SecondParameter<typeof customFetch>}
+ , queryClient?: QueryClient
+  ):  UseQueryResult<TData, TError> & { queryKey: DataTag<QueryKey, TData, TError> }
+/**
+ * @summary Get the user's AI summarization quota
+ */
+
+export function useGetAiQuota<TData = Awaited<ReturnType<typeof getAiQuota>>, TError = AiworkflowsInterfacesHttpErrorResponse>(
+  options?: { query?:Partial<UseQueryOptions<Awaited<ReturnType<typeof getAiQuota>>, TError, TData>>, request?: SecondParameter<typeof customFetch>}
+ , queryClient?: QueryClient 
+ ):  UseQueryResult<TData, TError> & { queryKey: DataTag<QueryKey, TData, TError> } {
+
+  const queryOptions = getGetAiQuotaQueryOptions(options)
+
+  const query = useQuery(queryOptions, queryClient) as  UseQueryResult<TData, TError> & { queryKey: DataTag<QueryKey, TData, TError> };
+
+  query.queryKey = queryOptions.queryKey ;
+
+  return query;
+}
+
+
+
+
 /**
  * Returns up to 50 of the authenticated user's runs, newest first. Used by the AI page to show a history of past runs.
  * @summary List the user's recent repository summaries
@@ -394,6 +523,492 @@ export function useGetAiSummariesId<TData = Awaited<ReturnType<typeof getAiSumma
 
 
 
+/**
+ * Answers a question about a completed run owned by the authenticated user. The run's file and directory summaries are ranked against the question (BM25), the best matches go to the LLM together with the overview and the last few questions, and the answer cites the paths it relies on. The question and answer are stored with the run. Answers count against the monthly token budget. Returns 404 for missing rows AND cross-user questions, 409 when the run hasn't completed.
+ * @summary Ask a question about a repository summary
+ */
+export type postAiSummariesIdAskResponse200 = {
+  data: AiworkflowsInterfacesHttpAnswerDTO
+  status: 200
+}
+
+export type postAiSummariesIdAskResponse400 = {
+  data: AiworkflowsInterfacesHttpErrorResponse
+  status: 400
+}
+
+export type postAiSummariesIdAskResponse401 = {
+  data: AiworkflowsInterfacesHttpErrorResponse
+  status: 401
+}
+
+export type postAiSummariesIdAskResponse404 = {
+  data: AiworkflowsInterfacesHttpErrorResponse
+  status: 404
+}
+
+export type postAiSummariesIdAskResponse409 = {
+  data: AiworkflowsInterfacesHttpErrorResponse
+  status: 409
+}
+
+export type postAiSummariesIdAskResponse429 = {
+  data: AiworkflowsInterfacesHttpErrorResponse
+  status: 429
+}
+
+export type postAiSummariesIdAskResponse503 = {
+  data: AiworkflowsInterfacesHttpErrorResponse
+  status: 503
+}
+    
+export type postAiSummariesIdAskResponseSuccess = (postAiSummariesIdAskResponse200) & {
+  headers: Headers;
+};
+export type postAiSummariesIdAskResponseError = (postAiSummariesIdAskResponse400 | postAiSummariesIdAskResponse401 | postAiSummariesIdAskResponse404 | postAiSummariesIdAskResponse409 | postAiSummariesIdAskResponse429 | postAiSummariesIdAskResponse503) & {
+  headers: Headers;
+};
+
+export type postAiSummariesIdAskResponse = (postAiSummariesIdAskResponseSuccess | postAiSummariesIdAskResponseError)
+
+export const getPostAiSummariesIdAskUrl = (id: number,) => {
+
+
+  
+
+  return `http://localhost:8080/api/v1/ai/summaries/${id}/ask`
+}
+
+export const postAiSummariesIdAsk = async (id: number,
+    aiworkflowsInterfacesHttpAskRequest: AiworkflowsInterfacesHttpAskRequest, options?: RequestInit): Promise<postAiSummariesIdAskResponse> => {
+  
+  return customFetch<postAiSummariesIdAskResponse>(getPostAiSummariesIdAskUrl(id),
+  {      
+    ...options,
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json', ...options?.headers },
+    body: JSON.stringify(
+      aiworkflowsInterfacesHttpAskRequest,)
+  }
+);}
+
+
+
+export const getPostAiSummariesIdAskMutationOptions = <TError = AiworkflowsInterfacesHttpErrorResponse,
+    TContext = unknown>(options?: { mutation?:UseMutationOptions<Awaited<ReturnType<typeof postAiSummariesIdAsk>>, TError,{id: number;data: AiworkflowsInterfacesHttpAskRequest}, TContext>, request?: SecondParameter<typeof customFetch>}
+): UseMutationOptions<Awaited<ReturnType<typeof postAiSummariesIdAsk>>, TError,{id: number;data: AiworkflowsInterfacesHttpAskRequest}, TContext> => {
+
+const mutationKey = ['postAiSummariesIdAsk'];
+const {mutation: mutationOptions, request: requestOptions} = options ?
+      options.mutation && 'mutationKey' in options.mutation && options.mutation.mutationKey ?
+      options
+      : {...options, mutation: {...options.mutation, mutationKey}}
+      : {mutation: { mutationKey, }, request: undefined};
+
+      
+
+
+      const mutationFn: MutationFunction<Awaited<ReturnType<typeof postAiSummariesIdAsk>>, {id: number;data: AiworkflowsInterfacesHttpAskRequest}> = (props) => {
+          const {id,data} = props ?? {};
+
+          return  postAiSummariesIdAsk(id,data,requestOptions)
+        }
+
+        
+
+
+  return  { mutationFn, ...mutationOptions }}
+
+    export type PostAiSummariesIdAskMutationResult = NonNullable<Awaited<ReturnType<typeof postAiSummariesIdAsk>>>
+    export type PostAiSummariesIdAskMutationBody = AiworkflowsInterfacesHttpAskRequest
+    export type PostAiSummariesIdAskMutationError = AiworkflowsInterfacesHttpErrorResponse
+
+    /**
+ * @summary Ask a question about a repository summary
+ */
+export const usePostAiSummariesIdAsk = <TError = AiworkflowsInterfacesHttpErrorResponse,
+    TContext = unknown>(options?: { mutation?:UseMutationOptions<Awaited<ReturnType<typeof postAiSummariesIdAsk>>, TError,{id: number;data: AiworkflowsInterfacesHttpAskRequest}, TContext>, request?: SecondParameter<typeof customFetch>}
+ , queryClient?: QueryClient): UseMutationResult<
+        Awaited<ReturnType<typeof postAiSummariesIdAsk>>,
+        TError,
+        {id: number;data: AiworkflowsInterfacesHttpAskRequest},
+        TContext
+      > => {
+
+      const mutationOptions = getPostAiSummariesIdAskMutationOptions(options);
+
+      return useMutation(mutationOptions, queryClient);
+    }
+    
+
+
+
+/**
+ * Stops a pending or running run owned by the authenticated user: the run is marked cancelled and its workflow run is cancelled in Hatchet. Files not yet summarised are skipped. Returns 404 for missing rows AND cross-user cancels, 409 when the run has already finished.
+ * @summary Cancel a running repository summarization
+ */
+export type postAiSummariesIdCancelResponse200 = {
+  data: AiworkflowsInterfacesHttpRepoSummaryResponse
+  status: 200
+}
+
+export type postAiSummariesIdCancelResponse400 = {
+  data: AiworkflowsInterfacesHttpErrorResponse
+  status: 400
+}
+
+export type postAiSummariesIdCancelResponse401 = {
+  data: AiworkflowsInterfacesHttpErrorResponse
+  status: 401
+}
+
+export type postAiSummariesIdCancelResponse404 = {
+  data: AiworkflowsInterfacesHttpErrorResponse
+  status: 404
+}
+
+export type postAiSummariesIdCancelResponse409 = {
+  data: AiworkflowsInterfacesHttpErrorResponse
+  status: 409
+}
+
+export type postAiSummariesIdCancelResponse503 = {
+  data: AiworkflowsInterfacesHttpErrorResponse
+  status: 503
+}
+    
+export type postAiSummariesIdCancelResponseSuccess = (postAiSummariesIdCancelResponse200) & {
+  headers: Headers;
+};
+export type postAiSummariesIdCancelResponseError = (postAiSummariesIdCancelResponse400 | postAiSummariesIdCancelResponse401 | postAiSummariesIdCancelResponse404 | postAiSummariesIdCancelResponse409 | postAiSummariesIdCancelResponse503) & {
+  headers: Headers;
+};
+
+export type postAiSummariesIdCancelResponse = (postAiSummariesIdCancelResponseSuccess | postAiSummariesIdCancelResponseError)
+
+export const getPostAiSummariesIdCancelUrl = (id: number,) => {
+
+
+  
+
+  return `http://localhost:8080/api/v1/ai/summaries/${id}/cancel`
+}
+
+export const postAiSummariesIdCancel = async (id: number, options?: RequestInit): Promise<postAiSummariesIdCancelResponse> => {
+  
+  return customFetch<postAiSummariesIdCancelResponse>(getPostAiSummariesIdCancelUrl(id),
+  {      
+    ...options,
+    method: 'POST'
+    
+    
+  }
+);}
+
+
+
+export const getPostAiSummariesIdCancelMutationOptions = <TError = AiworkflowsInterfacesHttpErrorResponse,
+    TContext = unknown>(options?: { mutation?:UseMutationOptions<Awaited<ReturnType<typeof postAiSummariesIdCancel>>, TError,{id: number}, TContext>, request?: SecondParameter<typeof customFetch>}
+): UseMutationOptions<Awaited<ReturnType<typeof postAiSummariesIdCancel>>, TError,{id: number}, TContext> => {
+
+const mutationKey = ['postAiSummariesIdCancel'];
+const {mutation: mutationOptions, request: requestOptions} = options ?
+      options.mutation && 'mutationKey' in options.mutation && options.mutation.mutationKey ?
+      options
+      : {...options, mutation: {...options.mutation, mutationKey}}
+      : {mutation: { mutationKey, }, request: undefined};
+
+      
+
+
+      const mutationFn: MutationFunction<Awaited<ReturnType<typeof postAiSummariesIdCancel>>, {id: number}> = (props) => {
+          const {id} = props ?? {};
+
+          return  postAiSummariesIdCancel(id,requestOptions)
+        }
+
+        
+
+
+  return  { mutationFn, ...mutationOptions }}
+
+    export type PostAiSummariesIdCancelMutationResult = NonNullable<Awaited<ReturnType<typeof postAiSummariesIdCancel>>>
+    
+    export type PostAiSummariesIdCancelMutationError = AiworkflowsInterfacesHttpErrorResponse
+
+    /**
+ * @summary Cancel a running repository summarization
+ */
+export const usePostAiSummariesIdCancel = <TError = AiworkflowsInterfacesHttpErrorResponse,
+    TContext = unknown>(options?: { mutation?:UseMutationOptions<Awaited<ReturnType<typeof postAiSummariesIdCancel>>, TError,{id: number}, TContext>, request?: SecondParameter<typeof customFetch>}
+ , queryClient?: QueryClient): UseMutationResult<
+        Awaited<ReturnType<typeof postAiSummariesIdCancel>>,
+        TError,
+        {id: number},
+        TContext
+      > => {
+
+      const mutationOptions = getPostAiSummariesIdCancelMutationOptions(options);
+
+      return useMutation(mutationOptions, queryClient);
+    }
+    
+
+
+
+/**
+ * Returns up to 50 of the most recent questions asked about a run owned by the authenticated user, with their answers, oldest first. Cross-user reads return 404.
+ * @summary List the questions asked about a repository summary
+ */
+export type getAiSummariesIdQuestionsResponse200 = {
+  data: AiworkflowsInterfacesHttpAnswerListResponse
+  status: 200
+}
+
+export type getAiSummariesIdQuestionsResponse400 = {
+  data: AiworkflowsInterfacesHttpErrorResponse
+  status: 400
+}
+
+export type getAiSummariesIdQuestionsResponse401 = {
+  data: AiworkflowsInterfacesHttpErrorResponse
+  status: 401
+}
+
+export type getAiSummariesIdQuestionsResponse404 = {
+  data: AiworkflowsInterfacesHttpErrorResponse
+  status: 404
+}
+
+export type getAiSummariesIdQuestionsResponse503 = {
+  data: AiworkflowsInterfacesHttpErrorResponse
+  status: 503
+}
+    
+export type getAiSummariesIdQuestionsResponseSuccess = (getAiSummariesIdQuestionsResponse200) & {
+  headers: Headers;
+};
+export type getAiSummariesIdQuestionsResponseError = (getAiSummariesIdQuestionsResponse400 | getAiSummariesIdQuestionsResponse401 | getAiSummariesIdQuestionsResponse404 | getAiSummariesIdQuestionsResponse503) & {
+  headers: Headers;
+};
+
+export type getAiSummariesIdQuestionsResponse = (getAiSummariesIdQuestionsResponseSuccess | getAiSummariesIdQuestionsResponseError)
+
+export const getGetAiSummariesIdQuestionsUrl = (id: number,) => {
+
+
+  
+
+  return `http://localhost:8080/api/v1/ai/summaries/${id}/questions`
+}
+
+export const getAiSummariesIdQuestions = async (id: number, options?: RequestInit): Promise<getAiSummariesIdQuestionsResponse> => {
+  
+  return customFetch<getAiSummariesIdQuestionsResponse>(getGetAiSummariesIdQuestionsUrl(id),
+  {      
+    ...options,
+    method: 'GET'
+    
+    
+  }
+);}
+
+
+
+
+
+export const getGetAiSummariesIdQuestionsQueryKey = (id?: number,) => {
+    return [
+    `http://localhost:8080/api/v1/ai/summaries/${id}/questions`
+    ] as const;
+    }
+
+    
+export const getGetAiSummariesIdQuestionsQueryOptions = <TData = Awaited<ReturnType<typeof getAiSummariesIdQuestions>>, TError = AiworkflowsInterfacesHttpErrorResponse>(id: number, options?: { query?:Partial<UseQueryOptions<Awaited<ReturnType<typeof getAiSummariesIdQuestions>>, TError, TData>>, request?: SecondParameter<typeof customFetch>}
+) => {
+
+const {query: queryOptions, request: requestOptions} = options ?? {};
+
+  const queryKey =  queryOptions?.queryKey ?? getGetAiSummariesIdQuestionsQueryKey(id);
+
+  
+
+    const queryFn: QueryFunction<Awaited<ReturnType<typeof getAiSummariesIdQuestions>>> = ({ signal }) => getAiSummariesIdQuestions(id, { signal, ...requestOptions });
+
+      
+
+      
+
+   return  { queryKey, queryFn, enabled: !!(id), ...queryOptions} as UseQueryOptions<Awaited<ReturnType<typeof getAiSummariesIdQuestions>>, TError, TData> & { queryKey: DataTag<QueryKey, TData, TError> }
+}
+
+export type GetAiSummariesIdQuestionsQueryResult = NonNullable<Awaited<ReturnType<typeof getAiSummariesIdQuestions>>>
+export type GetAiSummariesIdQuestionsQueryError = AiworkflowsInterfacesHttpErrorResponse
+
+
+export function useGetAiSummariesIdQuestions<TData = Awaited<ReturnType<typeof getAiSummariesIdQuestions>>, TError = AiworkflowsInterfacesHttpErrorResponse>(
+ id: number, options: { query:Partial<UseQueryOptions<Awaited<ReturnType<typeof getAiSummariesIdQuestions>>, TError, TData>> & Pick<
+        DefinedInitialDataOptions<
+          Awaited<ReturnType<typeof getAiSummariesIdQuestions>>,
+          TError,
+          Awaited<ReturnType<typeof getAiSummariesIdQuestions>>
+        > , 'initialData'
+      >, request?: SecondParameter<typeof customFetch>}
+ , queryClient?: QueryClient
+  ):  DefinedUseQueryResult<TData, TError> & { queryKey: DataTag<QueryKey, TData, TError> }
+export function useGetAiSummariesIdQuestions<TData = Awaited<ReturnType<typeof getAiSummariesIdQuestions>>, TError = AiworkflowsInterfacesHttpErrorResponse>(
+ id: number, options?: { query?:Partial<UseQueryOptions<Awaited<ReturnType<typeof getAiSummariesIdQuestions>>, TError, TData>> & Pick<
+        UndefinedInitialDataOptions<
+          Awaited<ReturnType<typeof getAiSummariesIdQuestions>>,
+          TError,
+          Awaited<ReturnType<typeof getAiSummariesIdQuestions>>
+        > , 'initialData'
+      >, request?: SecondParameter<typeof customFetch>}
+ , queryClient?: QueryClient
+  ):  UseQueryResult<TData, TError> & { queryKey: DataTag<QueryKey, TData, TError> }
+export function useGetAiSummariesIdQuestions<TData = Awaited<ReturnType<typeof getAiSummariesIdQuestions>>, TError = AiworkflowsInterfacesHttpErrorResponse>(
+ id: number, options?: { query?:Partial<UseQueryOptions<Awaited<ReturnType<typeof getAiSummariesIdQuestions>>, TError, TData>>, request?: SecondParameter<typeof customFetch>}
+ , queryClient?: QueryClient
+  ):  UseQueryResult<TData, TError> & { queryKey: DataTag<QueryKey, TData, TError> }
+/**
+ * @summary List the questions asked about a repository summary
+ */
+
+export function useGetAiSummariesIdQuestions<TData = Awaited<ReturnType<typeof getAiSummariesIdQuestions>>, TError = AiworkflowsInterfacesHttpErrorResponse>(
+ id: number, options?: { query?:Partial<UseQueryOptions<Awaited<ReturnType<typeof getAiSummariesIdQuestions>>, TError, TData>>, request?: SecondParameter<typeof customFetch>}
+ , queryClient?: QueryClient 
+ ):  UseQueryResult<TData, TError> & { queryKey: DataTag<QueryKey, TData, TError> } {
+
+  const queryOptions = getGetAiSummariesIdQuestionsQueryOptions(id,options)
+
+  const query = useQuery(queryOptions, queryClient) as  UseQueryResult<TData, TError> & { queryKey: DataTag<QueryKey, TData, TError> };
+
+  query.queryKey = queryOptions.queryKey ;
+
+  return query;
+}
+
+
+
+
+/**
+ * Starts a new attempt for a failed run owned by the authenticated user. The attempt is linked to the failed run and reuses the file summaries it already produced, so only the missing files and the repo-level overview call the LLM again. Returns 404 for missing rows AND cross-user retries, 409 when the run did not fail.
+ * @summary Retry a failed repository summarization
+ */
+export type postAiSummariesIdRetryResponse202 = {
+  data: AiworkflowsInterfacesHttpSummarizeRepoResponse
+  status: 202
+}
+
+export type postAiSummariesIdRetryResponse400 = {
+  data: AiworkflowsInterfacesHttpErrorResponse
+  status: 400
+}
+
+export type postAiSummariesIdRetryResponse401 = {
+  data: AiworkflowsInterfacesHttpErrorResponse
+  status: 401
+}
+
+export type postAiSummariesIdRetryResponse404 = {
+  data: AiworkflowsInterfacesHttpErrorResponse
+  status: 404
+}
+
+export type postAiSummariesIdRetryResponse409 = {
+  data: AiworkflowsInterfacesHttpErrorResponse
+  status: 409
+}
+
+export type postAiSummariesIdRetryResponse429 = {
+  data: AiworkflowsInterfacesHttpErrorResponse
+  status: 429
+}
+
+export type postAiSummariesIdRetryResponse503 = {
+  data: AiworkflowsInterfacesHttpErrorResponse
+  status: 503
+}
+    
+export type postAiSummariesIdRetryResponseSuccess = (postAiSummariesIdRetryResponse202) & {
+  headers: Headers;
+};
+export type postAiSummariesIdRetryResponseError = (postAiSummariesIdRetryResponse400 | postAiSummariesIdRetryResponse401 | postAiSummariesIdRetryResponse404 | postAiSummariesIdRetryResponse409 | postAiSummariesIdRetryResponse429 | postAiSummariesIdRetryResponse503) & {
+  headers: Headers;
+};
+
+export type postAiSummariesIdRetryResponse = (postAiSummariesIdRetryResponseSuccess | postAiSummariesIdRetryResponseError)
+
+export const getPostAiSummariesIdRetryUrl = (id: number,) => {
+
+
+  
+
+  return `http://localhost:8080/api/v1/ai/summaries/${id}/retry`
+}
+
+export const postAiSummariesIdRetry = async (id: number, options?: RequestInit): Promise<postAiSummariesIdRetryResponse> => {
+  
+  return customFetch<postAiSummariesIdRetryResponse>(getPostAiSummariesIdRetryUrl(id),
+  {      
+    ...options,
+    method: 'POST'
+    
+    
+  }
+);}
+
+
+
+export const getPostAiSummariesIdRetryMutationOptions = <TError = AiworkflowsInterfacesHttpErrorResponse,
+    TContext = unknown>(options?: { mutation?:UseMutationOptions<Awaited<ReturnType<typeof postAiSummariesIdRetry>>, TError,{id: number}, TContext>, request?: SecondParameter<typeof customFetch>}
+): UseMutationOptions<Awaited<ReturnType<typeof postAiSummariesIdRetry>>, TError,{id: number}, TContext> => {
+
+const mutationKey = ['postAiSummariesIdRetry'];
+const {mutation: mutationOptions, request: requestOptions} = options ?
+      options.mutation && 'mutationKey' in options.mutation && options.mutation.mutationKey ?
+      options
+      : {...options, mutation: {...options.mutation, mutationKey}}
+      : {mutation: { mutationKey, }, request: undefined};
+
+      
+
+
+      const mutationFn: MutationFunction<Awaited<ReturnType<typeof postAiSummariesIdRetry>>, {id: number}> = (props) => {
+          const {id} = props ?? {};
+
+          return  postAiSummariesIdRetry(id,requestOptions)
+        }
+
+        
+
+
+  return  { mutationFn, ...mutationOptions }}
+
+    export type PostAiSummariesIdRetryMutationResult = NonNullable<Awaited<ReturnType<typeof postAiSummariesIdRetry>>>
+    
+    export type PostAiSummariesIdRetryMutationError = AiworkflowsInterfacesHttpErrorResponse
+
+    /**
+ * @summary Retry a failed repository summarization
+ */
+export const usePostAiSummariesIdRetry = <TError = AiworkflowsInterfacesHttpErrorResponse,
+    TContext = unknown>(options?: { mutation?:UseMutationOptions<Awaited<ReturnType<typeof postAiSummariesIdRetry>>, TError,{id: number}, TContext>, request?: SecondParameter<typeof customFetch>}
+ , queryClient?: QueryClient): UseMutationResult<
+        Awaited<ReturnType<typeof postAiSummariesIdRetry>>,
+        TError,
+        {id: number},
+        TContext
+      > => {
+
+      const mutationOptions = getPostAiSummariesIdRetryMutationOptions(options);
+
+      return useMutation(mutationOptions, queryClient);
+    }
+    
+
+
+
 /**
  * Enqueues a Hatchet workflow that clones the repository, summarises individual files via the configured LLM provider (OpenRouter), and produces a repo-level summary.
  * @summary Trigger a repository summarization workflow
@@ -413,6 +1028,11 @@ export type postAiSummarizeRepoResponse401 = {
   status: 401
 }
 
+export type postAiSummarizeRepoResponse429 = {
+  data: AiworkflowsInterfacesHttpErrorResponse
+  status: 429
+}
+
 export type postAiSummarizeRepoResponse503 = {
   data: AiworkflowsInterfacesHttpErrorResponse
   status: 503
@@ -421,7 +1041,7 @@ export type postAiSummarizeRepoResponse503 = {
 export type postAiSummarizeRepoResponseSuccess = (postAiSummarizeRepoResponse202) & {
   headers: Headers;
 };
-export type postAiSummarizeRepoResponseError = (postAiSummarizeRepoResponse400 | postAiSummarizeRepoResponse401 | postAiSummarizeRepoResponse503) & {
+export type postAiSummarizeRepoResponseError = (postAiSummarizeRepoResponse400 | postAiSummarizeRepoResponse401 | postAiSummarizeRepoResponse429 | postAiSummarizeRepoResponse503) & {
   headers: Headers;
 };
 
@@ -0,0 +1,27 @@
+/**
+ * Generated by orval v7.21.0 🍺
+ * Do not edit manually.
+ * Next-Go-PG API
+ * Go Clean Architecture API with Better Auth integration
+ * OpenAPI spec version: 1.0
+ */
+import type { AiworkflowsInterfacesHttpTokenUsageDTO } from './aiworkflowsInterfacesHttpTokenUsageDTO';
+
+export interface AiworkflowsInterfacesHttpAnswerDTO {
+  answer?: string;
+  /**
+   * Citations are the paths the answer cites, in order of first
+   * mention; directories end in a slash.
+   */
+  citations?: string[];
+  createdAt?: string;
+  id?: number;
+  model?: string;
+  question?: string;
+  /**
+   * Sources are the summaries the answer was grounded on, best match
+   * first.
+   */
+  sources?: string[];
+  usage?: AiworkflowsInterfacesHttpTokenUsageDTO;
+}
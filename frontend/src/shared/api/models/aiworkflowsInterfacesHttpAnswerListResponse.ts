@@ -0,0 +1,12 @@
+/**
+ * Generated by orval v7.21.0 🍺
+ * Do not edit manually.
+ * Next-Go-PG API
+ * Go Clean Architecture API with Better Auth integration
+ * OpenAPI spec version: 1.0
+ */
+import type { AiworkflowsInterfacesHttpAnswerDTO } from './aiworkflowsInterfacesHttpAnswerDTO';
+
+export interface AiworkflowsInterfacesHttpAnswerListResponse {
+  items?: AiworkflowsInterfacesHttpAnswerDTO[];
+}
@@ -0,0 +1,16 @@
+/**
+ * Generated by orval v7.21.0 🍺
+ * Do not edit manually.
+ * Next-Go-PG API
+ * Go Clean Architecture API with Better Auth integration
+ * OpenAPI spec version: 1.0
+ */
+
+export interface AiworkflowsInterfacesHttpChangelogDTO {
+  added?: string[];
+  baseCommit?: string;
+  baseId?: number;
+  modified?: string[];
+  removed?: string[];
+  unchanged?: string[];
+}
@@ -0,0 +1,15 @@
+/**
+ * Generated by orval v7.21.0 🍺
+ * Do not edit manually.
+ * Next-Go-PG API
+ * Go Clean Architecture API with Better Auth integration
+ * OpenAPI spec version: 1.0
+ */
+
+export interface AiworkflowsInterfacesHttpDirectorySummaryDTO {
+  children?: AiworkflowsInterfacesHttpDirectorySummaryDTO[];
+  /** Files are the summarized files directly in the directory. */
+  files?: string[];
+  path?: string;
+  summary?: string;
+}
@@ -0,0 +1,18 @@
+/**
+ * Generated by orval v7.21.0 🍺
+ * Do not edit manually.
+ * Next-Go-PG API
+ * Go Clean Architecture API with Better Auth integration
+ * OpenAPI spec version: 1.0
+ */
+
+export interface AiworkflowsInterfacesHttpFileFilterDTO {
+  exclude?: string[];
+  include?: string[];
+  subdir?: string;
+  /**
+   * SummaryIgnore are the rules read from the repository's
+   * .summaryignore; known once the traverse step has run.
+   */
+  summaryIgnore?: string[];
+}
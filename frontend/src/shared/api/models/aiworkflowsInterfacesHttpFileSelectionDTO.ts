@@ -0,0 +1,25 @@
+/**
+ * Generated by orval v7.21.0 🍺
+ * Do not edit manually.
+ * Next-Go-PG API
+ * Go Clean Architecture API with Better Auth integration
+ * OpenAPI spec version: 1.0
+ */
+import type { AiworkflowsInterfacesHttpSelectedFileDTO } from './aiworkflowsInterfacesHttpSelectedFileDTO';
+
+export interface AiworkflowsInterfacesHttpFileSelectionDTO {
+  /** Files is in selection order, best candidate first. */
+  files?: AiworkflowsInterfacesHttpSelectedFileDTO[];
+  /**
+   * SkippedSecrets are files left out because they hold secrets
+   * (.env files, private keys, credential files).
+   */
+  skippedSecrets?: string[];
+  /**
+   * SkippedTooLarge are files left out for being over the per-file
+   * size ceiling (AI_MAX_FILE_BYTES).
+   */
+  skippedTooLarge?: string[];
+  /** Strategy is "ranked" or "alphabetical". */
+  strategy?: string;
+}
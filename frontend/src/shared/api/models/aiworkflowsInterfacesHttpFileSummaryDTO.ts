@@ -5,8 +5,38 @@
  * Go Clean Architecture API with Better Auth integration
  * OpenAPI spec version: 1.0
  */
+import type { AiworkflowsInterfacesHttpFileInsightsDTO } from './aiworkflowsInterfacesHttpFileInsightsDTO';
+import type { AiworkflowsInterfacesHttpTokenUsageDTO } from './aiworkflowsInterfacesHttpTokenUsageDTO';
 
 export interface AiworkflowsInterfacesHttpFileSummaryDTO {
+  /** Cached is true when the summary was served from the summary cache. */
+  cached?: boolean;
+  /**
+   * Chunks is how many chunks the file was summarized in because it
+   * was too large for one prompt; absent when it fit.
+   */
+  chunks?: number;
   filename?: string;
+  /**
+   * Insights is the structured breakdown of the file; absent for
+   * summaries generated in free-text mode.
+   */
+  insights?: AiworkflowsInterfacesHttpFileInsightsDTO;
+  /**
+   * Model is the LLM model that wrote the summary; with a provider
+   * fallback chain it may not be the first one configured. Absent for
+   * cached summaries.
+   */
+  model?: string;
+  /**
+   * Redactions is how many secrets were redacted from the file before
+   * it was sent to the LLM; absent when none.
+   */
+  redactions?: number;
   summary?: string;
+  /**
+   * Usage is what generating this summary cost; absent for cached
+   * and reused summaries.
+   */
+  usage?: AiworkflowsInterfacesHttpTokenUsageDTO;
 }
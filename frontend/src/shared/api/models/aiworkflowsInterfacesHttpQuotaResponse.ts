@@ -0,0 +1,16 @@
+/**
+ * Generated by orval v7.21.0 🍺
+ * Do not edit manually.
+ * Next-Go-PG API
+ * Go Clean Architecture API with Better Auth integration
+ * OpenAPI spec version: 1.0
+ */
+import type { AiworkflowsInterfacesHttpQuotaLimitsDTO } from './aiworkflowsInterfacesHttpQuotaLimitsDTO';
+import type { AiworkflowsInterfacesHttpQuotaUsageDTO } from './aiworkflowsInterfacesHttpQuotaUsageDTO';
+
+export interface AiworkflowsInterfacesHttpQuotaResponse {
+  dayResetsAt?: string;
+  limits?: AiworkflowsInterfacesHttpQuotaLimitsDTO;
+  monthResetsAt?: string;
+  usage?: AiworkflowsInterfacesHttpQuotaUsageDTO;
+}
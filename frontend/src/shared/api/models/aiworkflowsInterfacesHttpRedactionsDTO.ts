@@ -0,0 +1,17 @@
+/**
+ * Generated by orval v7.21.0 🍺
+ * Do not edit manually.
+ * Next-Go-PG API
+ * Go Clean Architecture API with Better Auth integration
+ * OpenAPI spec version: 1.0
+ */
+import type { AiworkflowsInterfacesHttpRedactionsDTOByRule } from './aiworkflowsInterfacesHttpRedactionsDTOByRule';
+
+export interface AiworkflowsInterfacesHttpRedactionsDTO {
+  /**
+   * ByRule maps the rule that matched (aws-access-key, private-key,
+   * env-assignment, …) to how often it did.
+   */
+  byRule?: AiworkflowsInterfacesHttpRedactionsDTOByRule;
+  total?: number;
+}
@@ -5,17 +5,91 @@
  * Go Clean Architecture API with Better Auth integration
  * OpenAPI spec version: 1.0
  */
+import type { AiworkflowsInterfacesHttpAttemptDTO } from './aiworkflowsInterfacesHttpAttemptDTO';
+import type { AiworkflowsInterfacesHttpChangelogDTO } from './aiworkflowsInterfacesHttpChangelogDTO';
+import type { AiworkflowsInterfacesHttpDirectorySummaryDTO } from './aiworkflowsInterfacesHttpDirectorySummaryDTO';
+import type { AiworkflowsInterfacesHttpFileFilterDTO } from './aiworkflowsInterfacesHttpFileFilterDTO';
+import type { AiworkflowsInterfacesHttpFileSelectionDTO } from './aiworkflowsInterfacesHttpFileSelectionDTO';
 import type { AiworkflowsInterfacesHttpFileSummaryDTO } from './aiworkflowsInterfacesHttpFileSummaryDTO';
+import type { AiworkflowsInterfacesHttpRedactionsDTO } from './aiworkflowsInterfacesHttpRedactionsDTO';
+import type { AiworkflowsInterfacesHttpRepoSummaryResponsePromptVersions } from './aiworkflowsInterfacesHttpRepoSummaryResponsePromptVersions';
 import type { AiworkflowsInterfacesHttpRepoSummaryResponseStepDurations } from './aiworkflowsInterfacesHttpRepoSummaryResponseStepDurations';
+import type { AiworkflowsInterfacesHttpTokenUsageDTO } from './aiworkflowsInterfacesHttpTokenUsageDTO';
 
 export interface AiworkflowsInterfacesHttpRepoSummaryResponse {
+  /**
+   * Attempts is the run's retry chain, oldest first, including the
+   * run itself. Only returned by GET /ai/summaries/{id}.
+   */
+  attempts?: AiworkflowsInterfacesHttpAttemptDTO[];
+  /**
+   * BaseID is the run an incremental run builds on; absent for a full
+   * run.
+   */
+  baseId?: number;
+  /**
+   * CacheHits and CacheMisses split Files by whether the summary was
+   * served from the summary cache or generated by the LLM.
+   */
+  cacheHits?: number;
+  cacheMisses?: number;
+  /**
+   * Changelog is how the files compare to the base run. Absent for
+   * full runs and until the summarize-files step has run.
+   */
+  changelog?: AiworkflowsInterfacesHttpChangelogDTO;
+  /**
+   * CommitSHA and CommitTime identify the commit that was actually
+   * summarized; known once the clone step has run.
+   */
+  commitSha?: string;
+  commitTime?: string;
   completedAt?: string;
+  /**
+   * Directories is the architecture outline: the root of the tree of
+   * directory summaries. Absent until the directory step has run.
+   */
+  directories?: AiworkflowsInterfacesHttpDirectorySummaryDTO;
   failReason?: string;
   files?: AiworkflowsInterfacesHttpFileSummaryDTO[];
+  /**
+   * Filter is the effective file filter: what the request asked for
+   * plus the repository's .summaryignore rules. Absent when neither
+   * restricts anything.
+   */
+  filter?: AiworkflowsInterfacesHttpFileFilterDTO;
   id?: number;
+  /**
+   * PromptVersions maps prompt name (file-summary, aggregate) to the
+   * template version the run used. Absent for runs that haven't
+   * started or predate prompt versioning.
+   */
+  promptVersions?: AiworkflowsInterfacesHttpRepoSummaryResponsePromptVersions;
+  /**
+   * Redactions counts the secrets kept from the LLM. Absent when
+   * nothing was redacted.
+   */
+  redactions?: AiworkflowsInterfacesHttpRedactionsDTO;
+  /**
+   * Ref is the branch, tag or commit the run asked for; absent for
+   * the default branch.
+   */
+  ref?: string;
   repoUrl?: string;
+  /** RetryOf is the failed run this one retried; 0 for a first attempt. */
+  retryOf?: number;
+  /**
+   * Selection is how the summarized files were chosen. Absent until
+   * the traverse step has run.
+   */
+  selection?: AiworkflowsInterfacesHttpFileSelectionDTO;
   startedAt?: string;
   status?: string;
   stepDurations?: AiworkflowsInterfacesHttpRepoSummaryResponseStepDurations;
   summary?: string;
+  /**
+   * Usage totals the run's LLM calls: generated file summaries plus
+   * the repo-level overview.
+   */
+  usage?: AiworkflowsInterfacesHttpTokenUsageDTO;
 }
@@ -7,5 +7,25 @@
  */
 
 export interface AiworkflowsInterfacesHttpSummarizeRepoRequest {
+  exclude?: string[];
+  /**
+   * Include and Exclude are optional .gitignore-style patterns matched
+   * against repo-relative paths. Include replaces the default file
+   * types; Exclude always wins.
+   */
+  include?: string[];
+  /**
+   * Incremental builds on the latest completed run of the same
+   * repository: only files added or modified since are summarized
+   * again. Without such a run it is a full run.
+   */
+  incremental?: boolean;
+  /**
+   * Ref is the branch, tag or full commit SHA to summarize; the
+   * default branch when empty.
+   */
+  ref?: string;
   repoUrl?: string;
+  /** Subdir restricts the run to one directory of the repository. */
+  subdir?: string;
 }
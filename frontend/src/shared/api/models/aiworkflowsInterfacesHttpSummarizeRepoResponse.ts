@@ -7,6 +7,11 @@
  */
 
 export interface AiworkflowsInterfacesHttpSummarizeRepoResponse {
+  /**
+   * BaseID is the run an incremental request builds on; absent for a
+   * full run.
+   */
+  baseId?: number;
   runId?: string;
   status?: string;
   summaryId?: number;
@@ -6,14 +6,31 @@
  * OpenAPI spec version: 1.0
  */
 
+export * from './aiworkflowsInterfacesHttpAnswerDTO';
+export * from './aiworkflowsInterfacesHttpAnswerListResponse';
+export * from './aiworkflowsInterfacesHttpAskRequest';
+export * from './aiworkflowsInterfacesHttpAttemptDTO';
+export * from './aiworkflowsInterfacesHttpChangelogDTO';
+export * from './aiworkflowsInterfacesHttpDirectorySummaryDTO';
 export * from './aiworkflowsInterfacesHttpErrorResponse';
+export * from './aiworkflowsInterfacesHttpFileFilterDTO';
+export * from './aiworkflowsInterfacesHttpFileInsightsDTO';
+export * from './aiworkflowsInterfacesHttpFileSelectionDTO';
 export * from './aiworkflowsInterfacesHttpFileSummaryDTO';
+export * from './aiworkflowsInterfacesHttpQuotaLimitsDTO';
+export * from './aiworkflowsInterfacesHttpQuotaResponse';
+export * from './aiworkflowsInterfacesHttpQuotaUsageDTO';
+export * from './aiworkflowsInterfacesHttpRedactionsDTO';
+export * from './aiworkflowsInterfacesHttpRedactionsDTOByRule';
 export * from './aiworkflowsInterfacesHttpRepoSummaryListItem';
 export * from './aiworkflowsInterfacesHttpRepoSummaryListResponse';
 export * from './aiworkflowsInterfacesHttpRepoSummaryResponse';
+export * from './aiworkflowsInterfacesHttpRepoSummaryResponsePromptVersions';
 export * from './aiworkflowsInterfacesHttpRepoSummaryResponseStepDurations';
+export * from './aiworkflowsInterfacesHttpSelectedFileDTO';
 export * from './aiworkflowsInterfacesHttpSummarizeRepoRequest';
 export * from './aiworkflowsInterfacesHttpSummarizeRepoResponse';
+export * from './aiworkflowsInterfacesHttpTokenUsageDTO';
 export * from './authInterfacesHttpErrorResponse';
 export * from './authInterfacesHttpMessageResponse';
 export * from './authInterfacesHttpUserResponse';